@@ -0,0 +1,49 @@
+package cfg
+
+import "runtime/debug"
+
+// BuildCommit and BuildTime are the commit and the time of the build of the
+// app, which are set by the linker when the app is built, e.g.
+//
+//	go build -ldflags "-X github.com/has-ghas/no-phi-ai/pkg/cfg.BuildCommit=$(git rev-parse HEAD)"
+//
+// When they are not set, the VCS information embedded by the Go toolchain (if
+// any) is used instead.
+var BuildCommit string
+var BuildTime string
+
+// BuildInfo struct contains the version of the app and the commit and time of
+// its build.
+type BuildInfo struct {
+	Commit  string `json:"commit"`
+	Time    string `json:"build_time"`
+	Version string `json:"version"`
+}
+
+// GetBuildInfo() function returns the BuildInfo of the running app, where
+// unknown values are set to BuildUnknown.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Commit:  BuildCommit,
+		Time:    BuildTime,
+		Version: AppVersion,
+	}
+	if build_info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build_info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Time == "":
+				info.Time = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = BuildUnknown
+	}
+	if info.Time == "" {
+		info.Time = BuildUnknown
+	}
+
+	return info
+}
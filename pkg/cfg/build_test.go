@@ -0,0 +1,27 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetBuildInfo() unit test function tests that the BuildInfo of the app
+// always contains its version, and that the commit and build time are never
+// empty.
+func TestGetBuildInfo(t *testing.T) {
+	info := GetBuildInfo()
+	assert.Equal(t, AppVersion, info.Version)
+	assert.NotEmpty(t, info.Commit)
+	assert.NotEmpty(t, info.Time)
+
+	BuildCommit = "abc123"
+	BuildTime = "2024-01-02T03:04:05Z"
+	defer func() {
+		BuildCommit = ""
+		BuildTime = ""
+	}()
+	info = GetBuildInfo()
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2024-01-02T03:04:05Z", info.Time)
+}
@@ -3,11 +3,15 @@ package cfg
 import (
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"gopkg.in/yaml.v2"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/glob"
 )
 
 // AppConfig struct contains the configuration items used by the running app.
@@ -51,13 +55,58 @@ type AzureAIConfig struct {
 	// AuthKey should be set to the value of a "key" associated with the
 	// AI Language service resource in Azure.
 	AuthKey string `yaml:"auth_key" json:"auth_key"`
+	// CategoryThresholds overrides the ConfidenceThreshold for detection
+	// results of specific entity categories (e.g. "Person": 0.8). Each
+	// threshold must be a value between 0 and 1.
+	CategoryThresholds map[string]float64 `yaml:"category_thresholds" json:"category_thresholds"`
 	// ConnfidenceThreshold is the minimum confidence score required for
 	// a detection result to be considered valid. This must be a value
 	// between 0 and 1.
 	ConfidenceThreshold float64 `yaml:"confidence_threshold" json:"confidence_threshold"`
+	// Domain is the "domain" parameter of PII entity recognition requests,
+	// which can be "none" or "phi", where "phi" should be used for accurate
+	// results from healthcare content.
+	//
+	// Domain default is defined in DefaultAzureAIDomain const.
+	Domain string `yaml:"domain" json:"domain"`
 	// DryRun prevents the actual sending of requests to the AI Language
 	// service API when set to true. Default is false.
 	DryRun bool `yaml:"dry_run" json:"dry_run"`
+	// IgnoreCategories is the list of entity categories (e.g. "DateTime" or
+	// "Organization") for which detection results are always discarded.
+	IgnoreCategories []string `yaml:"ignore_categories" json:"ignore_categories"`
+	// Language is the default language code (e.g. "en") of documents sent
+	// to the AI Language service, which is used whenever the language of a
+	// document is not (or cannot be) detected.
+	//
+	// Language default is defined in DefaultAzureAILanguage const.
+	Language string `yaml:"language" json:"language"`
+	// LanguageDetection controls how the language of each document is
+	// determined before requesting PII entity recognition:
+	//   - AzureAILanguageDetectionNone to always use the default Language;
+	//   - AzureAILanguageDetectionLocal to use a local heuristic;
+	//   - AzureAILanguageDetectionAzure to use the language detection API
+	//     of the AI Language service (billed separately).
+	//
+	// LanguageDetection default is defined in DefaultAzureAILanguageDetection const.
+	LanguageDetection string `yaml:"language_detection" json:"language_detection"`
+	// ModelVersion is the "modelVersion" parameter of PII entity recognition
+	// requests, such as "latest" or a dated version like "2023-09-01".
+	//
+	// ModelVersion default is defined in DefaultAzureAIModelVersion const.
+	ModelVersion string `yaml:"model_version" json:"model_version"`
+	// PiiCategories is the "piiCategories" parameter of PII entity recognition
+	// requests, which is the explicit list of entity categories to detect.
+	//
+	// PiiCategories default is defined in DefaultAzureAIPiiCategories var.
+	PiiCategories []string `yaml:"pii_categories" json:"pii_categories"`
+	// PricePer1kRecords is the price (e.g. in USD) of 1000 text records
+	// processed by the AI Language service, which is used to estimate the
+	// cost of each scan. Each document of up to 1000 characters is billed
+	// as a single text record.
+	//
+	// PricePer1kRecords default is defined in DefaultAzureAIPricePer1kRecords const.
+	PricePer1kRecords float64 `yaml:"price_per_1k_records" json:"price_per_1k_records"`
 	// Service should be set to the URL of the AI Language service deployment.
 	Service string `yaml:"service" json:"service"`
 	// ShowStats controls whether the "showStats=true" query parameter will
@@ -69,8 +118,10 @@ type AzureAIConfig struct {
 // Only used when AppConfig.Mode == AppModeCLI.
 type CommandConfig struct {
 	// available commands include:
+	//   - "estimate" to print the estimated cost of scanning a repository
 	//   - "help" to print help text
 	//   - "list-org-repos" to list repos in an org (for testing) // TODO
+	//   - "redact" to write sanitized copies of files containing PHI
 	//   - "scan-org" to scan an org for PHI // TODO
 	//   - "scan-repos" to scan a repo for PHI // TODO
 	//   - "version" to print the app version
@@ -158,11 +209,38 @@ func (c *GitHubConfig) GetGitHubAppConfig() *githubapp.Config {
 // GitScanConfig struct contains the configuration used to setup a PHI scan
 // for some organization and/or set of repositories.
 type GitScanConfig struct {
+	// Archives config
+	Archives GitScanArchivesConfig `yaml:"archives" json:"archives"`
+
+	// CacheDir is the directory where the results of every scanned file are
+	// saved, such that files that were already scanned by previous runs (with
+	// the same detector settings) are not sent to the detection service again.
+	// The cache is disabled when CacheDir is empty.
+	CacheDir string `yaml:"cache_dir" json:"cache_dir"`
+
+	// Checkpoint config
+	Checkpoint GitScanCheckpointConfig `yaml:"checkpoint" json:"checkpoint"`
+
+	// Encryption config
+	Encryption GitScanEncryptionConfig `yaml:"encryption" json:"encryption"`
+
+	// ExcludePaths is a list of doublestar glob patterns (e.g. "test/fixtures/**")
+	// of file paths to exclude/ignore from the scan. Values in this list take
+	// precedence over values in the IncludePaths list.
+	ExcludePaths []string `yaml:"exclude_paths" json:"exclude_paths"`
+
 	// Extensions is a list of file extensions to include in the scan, where
 	// each entry is a string in the format ".<ext>". If this list empty,
 	// then the DefaultScanFileExtensions list will be used.
 	Extensions []string `yaml:"extensions" json:"extensions"`
 
+	// ExtractText controls whether the text of supported documents (e.g. DOCX,
+	// XLSX, ODT, PDF and email messages) is extracted and scanned, based on
+	// the content type detected from the contents of each file. Documents
+	// with a supported content type are scanned regardless of the file
+	// extension, unless the path of the file is excluded.
+	ExtractText bool `yaml:"extract_text" json:"extract_text"`
+
 	// IgnoreExtensions is a list of file extensions to exclude/ignore from
 	// the scan, where each entry is a string in the format ".<ext>".
 	IgnoreExtensions []string `yaml:"ignore_extensions" json:"ignore_extensions"`
@@ -173,9 +251,16 @@ type GitScanConfig struct {
 	// the Repositories list.
 	IgnoreRepositories []string `yaml:"ignore_repositories" json:"ignore_repositories"`
 
+	// IncludePaths is a list of doublestar glob patterns (e.g. "docs/**/*.txt")
+	// of file paths to include in the scan, regardless of the file extension.
+	IncludePaths []string `yaml:"include_paths" json:"include_paths"`
+
 	// Limits config
 	Limits GitScanLimitsConfig `yaml:"limits" json:"limits"`
 
+	// Metadata config
+	Metadata GitScanMetadataConfig `yaml:"metadata" json:"metadata"`
+
 	// Organization is the URL of the GitHub organization to scan, where the
 	// app will query the GitHub API for a list of repositories to scan.
 	Organization string `yaml:"organization" json:"organization"`
@@ -188,29 +273,420 @@ type GitScanConfig struct {
 	// listed in Repositories, minus any duplicates and minus any repositories
 	// listed in the IgnoreRepositories list.
 	Repositories []string `yaml:"repositories" json:"repositories"`
+
+	// RespectGitignore controls whether file paths ignored by the .gitignore
+	// files of each scanned commit are also excluded from the scan.
+	RespectGitignore bool `yaml:"respect_gitignore" json:"respect_gitignore"`
+
+	// ResultText config
+	ResultText GitScanResultTextConfig `yaml:"result_text" json:"result_text"`
+
+	// Scope config
+	Scope GitScanScopeConfig `yaml:"scope" json:"scope"`
+
+	// SourceCode controls whether Jupyter notebooks (.ipynb) and the source
+	// code files of common languages (e.g. .go, .py, .sql) are scanned,
+	// regardless of the file extension, unless the path of the file is
+	// excluded. Only the cells of notebooks and the comments and string
+	// literals of source code are scanned, and each result is located by
+	// the cell and line or the line that contains the result.
+	SourceCode bool `yaml:"source_code" json:"source_code"`
+
+	// StructuredData controls whether CSV, JSON, XML and YAML files are
+	// scanned as structured data, where each value is scanned along with its
+	// column header or key path (e.g. "patient.dob: 1980-01-02") and each
+	// result is located by a JSON pointer, XPath or CSV row and column. Files
+	// that cannot be parsed are scanned as plain text.
+	StructuredData bool `yaml:"structured_data" json:"structured_data"`
+}
+
+// GitScanArchivesConfig struct contains the configuration used to scan the
+// members of archives (e.g. zip, tar and gzip files), where each member is
+// scanned with the same rules as the other files of the repository and the
+// path of each member includes the path of its archive (e.g.
+// "data.zip!/export/patients.csv").
+type GitScanArchivesConfig struct {
+	// Enabled controls whether the members of archives are scanned, since
+	// archives are otherwise ignored as binary files.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxDepth is the maximum depth of archives within archives, where the
+	// members of an archive that is nested deeper are not scanned.
+	//
+	// MaxDepth default is defined in DefaultGitScanArchivesMaxDepth const.
+	MaxDepth int `yaml:"max_depth" json:"max_depth"`
+	// MaxMembers is the maximum number of members of each archive.
+	//
+	// MaxMembers default is defined in DefaultGitScanArchivesMaxMembers const.
+	MaxMembers int `yaml:"max_members" json:"max_members"`
+	// MaxSize is the maximum total number of (uncompressed) bytes of the
+	// members of each archive, which protects the scan from zip bombs.
+	//
+	// MaxSize default is defined in DefaultGitScanArchivesMaxSize const.
+	MaxSize int64 `yaml:"max_size" json:"max_size"`
+}
+
+// GitScanCheckpointConfig struct contains the configuration used to save
+// checkpoints of a scan, which allow an interrupted scan to be resumed
+// without scanning the same commits, files and requests again.
+type GitScanCheckpointConfig struct {
+	// Dir is the directory where the checkpoints of each scan are saved.
+	// Checkpoints are disabled when Dir is empty.
+	Dir string `yaml:"dir" json:"dir"`
+	// ScanID is the ID of the scan, which is used (with the URL of the
+	// repository) to identify its checkpoint. Set ScanID to the ID of an
+	// interrupted scan to resume that scan. When ScanID is empty, the ID of
+	// the scan is derived from its repositories and scope, such that an
+	// interrupted scan is resumed when the same scan is run again.
+	ScanID string `yaml:"scan_id" json:"scan_id"`
+}
+
+// GitScanEncryptionConfig struct contains the configuration used to encrypt
+// the results of a scan that are stored at rest (i.e. checkpoints, the blob
+// cache and result records). Each key is a "<id>:<base64 key>" entry, where
+// the key is 32 random bytes (e.g. "openssl rand -base64 32") and the first
+// key is the primary key used to encrypt. The other keys are only used to
+// decrypt, such that a key is rotated by adding a new key before the old
+// key and running the "reencrypt" command. Encryption is disabled when both
+// KeyFile and Keys are empty.
+type GitScanEncryptionConfig struct {
+	// KeyFile is the path of a file that contains the keys, one entry per
+	// line, where empty lines and lines starting with "#" are ignored.
+	KeyFile string `yaml:"key_file" json:"key_file"`
+	// Keys contains the keys as a comma-separated list of entries, which is
+	// normally set by the NOPHI_GIT_SCAN_ENCRYPTION_KEYS env var.
+	Keys string `yaml:"keys" json:"keys"`
+}
+
+// Enabled() method returns true if the keys of the encryption are set.
+func (c GitScanEncryptionConfig) Enabled() bool {
+	return c.KeyFile != "" || c.Keys != ""
+}
+
+// GitScanMetadataConfig struct contains the configuration used to scan the
+// metadata of each scanned commit, such as the commit message, the messages
+// of the annotated tags of the commit and the git notes of the commit, in
+// addition to the files of the commit.
+type GitScanMetadataConfig struct {
+	// Authors controls whether the name and email of the author and the
+	// committer of each commit, and of the tagger of each annotated tag, are
+	// scanned. Since the author of every commit is a person, the names of
+	// authors are expected to be detected as such.
+	Authors bool `yaml:"authors" json:"authors"`
+	// Messages controls whether the message of each commit is scanned.
+	Messages bool `yaml:"messages" json:"messages"`
+	// Notes controls whether the git notes (refs/notes/commits) of each
+	// commit are scanned.
+	Notes bool `yaml:"notes" json:"notes"`
+	// Tags controls whether the messages of the annotated tags of each
+	// commit are scanned.
+	Tags bool `yaml:"tags" json:"tags"`
+}
+
+// Enabled() method returns true if any metadata of commits is scanned.
+func (c GitScanMetadataConfig) Enabled() bool {
+	return c.Authors || c.Messages || c.Notes || c.Tags
+}
+
+// GitScanResultTextConfig struct contains the configuration used to store
+// the text of the results of a scan, which is the detected PHI/PII itself.
+type GitScanResultTextConfig struct {
+	// Key is the secret key of the HMAC of the text of each result when Mode
+	// is GitScanResultTextModeHMAC, and of the fingerprints of the findings
+	// of the baseline with any Mode. The key must be kept the same for the
+	// findings of a baseline to match the results of later scans.
+	Key string `yaml:"key" json:"key"`
+	// Mode can be:
+	//   - GitScanResultTextModeHMAC to keep only a keyed HMAC of the text of
+	//     each result along with a masked preview of the text (e.g.
+	//     "J*** S****"), such that the results of a scan never contain the
+	//     detected PHI/PII;
+	//   - GitScanResultTextModeRaw to store the text of each result as it
+	//     was detected.
+	//
+	// Mode default is defined in DefaultGitScanResultTextMode const.
+	Mode string `yaml:"mode" json:"mode"`
+}
+
+// GitScanScopeConfig struct contains the configuration used to select the
+// commits of each repository that are scanned.
+type GitScanScopeConfig struct {
+	// MaxCommits is the maximum number of commits scanned from the history
+	// of each of the Refs, starting with the most recent commit. There is no
+	// limit when MaxCommits is 0. Only used when Mode is GitScanScopeHistory.
+	MaxCommits int `yaml:"max_commits" json:"max_commits"`
+	// Mode can be:
+	//   - GitScanScopeAll to scan every commit object in the repository,
+	//     including commits that are not reachable from any branch or tag;
+	//   - GitScanScopeHistory to scan the history of each of the Refs;
+	//   - GitScanScopeTip to scan only the commit at the tip of each of the
+	//     Refs (e.g. what is on "main" right now).
+	//
+	// Mode default is defined in DefaultGitScanScope const.
+	Mode string `yaml:"mode" json:"mode"`
+	// Refs is a list of branches, tags, or commit hashes to scan, where HEAD
+	// (i.e. the default branch) is scanned when Refs is empty. Branches that
+	// are not checked out are resolved from the "origin" remote. Not used when
+	// Mode is GitScanScopeAll.
+	Refs []string `yaml:"refs" json:"refs"`
+	// Since is a date ("2006-01-02") or time (RFC 3339) such that commits
+	// committed before Since are not scanned. Not used when Mode is
+	// GitScanScopeTip.
+	Since string `yaml:"since" json:"since"`
+	// SinceCommit is a commit hash (or ref) such that the commits in its
+	// history are not scanned, like "git log <SinceCommit>..<ref>". Only used
+	// when Mode is GitScanScopeHistory.
+	SinceCommit string `yaml:"since_commit" json:"since_commit"`
+}
+
+// SinceTime() method returns the time parsed from the Since value, or nil
+// if Since is empty.
+func (c *GitScanScopeConfig) SinceTime() (*time.Time, error) {
+	if c.Since == "" {
+		return nil, nil
+	}
+	for _, layout := range GitScanScopeSinceLayouts {
+		if since, err := time.Parse(layout, c.Since); err == nil {
+			return &since, nil
+		}
+	}
+
+	return nil, errors.New("invalid config value: git.scan.scope.since = " + c.Since)
+}
+
+// Verify() method verifies the values of the GitScanScopeConfig, where each
+// value is only allowed with the scope modes that use the value.
+func (c *GitScanScopeConfig) Verify() (e error) {
+	switch c.Mode {
+	case GitScanScopeAll:
+		if len(c.Refs) > 0 {
+			e = errors.New("invalid config value: git.scan.scope.refs cannot be used with scope mode " + c.Mode)
+			return
+		}
+	case GitScanScopeHistory:
+	case GitScanScopeTip:
+		if c.Since != "" {
+			e = errors.New("invalid config value: git.scan.scope.since cannot be used with scope mode " + c.Mode)
+			return
+		}
+	default:
+		e = errors.New("invalid config value: git.scan.scope.mode = " + c.Mode)
+		return
+	}
+	if c.Mode != GitScanScopeHistory {
+		if c.MaxCommits != 0 {
+			e = errors.New("invalid config value: git.scan.scope.max_commits cannot be used with scope mode " + c.Mode)
+			return
+		}
+		if c.SinceCommit != "" {
+			e = errors.New("invalid config value: git.scan.scope.since_commit cannot be used with scope mode " + c.Mode)
+			return
+		}
+	}
+	if c.MaxCommits < 0 {
+		e = errors.New("invalid config value: git.scan.scope.max_commits must not be negative")
+		return
+	}
+	_, e = c.SinceTime()
+
+	return
 }
 
 type GitScanLimitsConfig struct {
+	// ChunkOverlap is the number of characters of the neighbouring chunks of
+	// a file that are included at the start and at the end of each chunk,
+	// so that entities at the boundaries between chunks are detected with
+	// their context. Results within the overlap are only reported once.
+	//
+	// ChunkOverlap default is 0, which disables the overlap.
+	ChunkOverlap int `yaml:"chunk_overlap" json:"chunk_overlap"`
+	// MaxRequestChunkSize is the maximum number of characters (i.e. code
+	// points) of the text of each request, including any overlap.
 	MaxRequestChunkSize    int `yaml:"max_request_chunk_size" json:"max_request_chunk_size"`
 	MaxRequestsOutstanding int `yaml:"max_requests_outstanding" json:"max_requests_outstanding"`
 }
 
+// BaselineConfig struct contains the configuration of the baseline of the
+// known findings (i.e. false positives and accepted risks) of a repository,
+// which is created by the "baseline" command and used by the "scan-new"
+// command to report only the findings that are not in the baseline.
+type BaselineConfig struct {
+	// Author is the person who accepts the findings added to the baseline
+	// by the "baseline" command.
+	Author string `yaml:"author" json:"author"`
+	// Expires is the date (e.g. "2025-12-31") or time (RFC3339) at which the
+	// findings added to the baseline by the "baseline" command expire, after
+	// which they are reported as new findings again. The findings never
+	// expire when Expires is empty.
+	Expires string `yaml:"expires" json:"expires"`
+	// Path is the path of the baseline file.
+	//
+	// Path default is defined in DefaultBaselinePath const.
+	Path string `yaml:"path" json:"path"`
+	// Reason explains why the findings added to the baseline by the
+	// "baseline" command are accepted.
+	Reason string `yaml:"reason" json:"reason"`
+}
+
+// ExpiresTime() method returns the time parsed from the Expires value, or
+// nil if Expires is empty.
+func (c *BaselineConfig) ExpiresTime() (*time.Time, error) {
+	if c.Expires == "" {
+		return nil, nil
+	}
+	for _, layout := range BaselineExpiresLayouts {
+		if expires, err := time.Parse(layout, c.Expires); err == nil {
+			return &expires, nil
+		}
+	}
+
+	return nil, errors.New("invalid config value: baseline.expires = " + c.Expires)
+}
+
+// MetricsConfig struct contains the configuration used to export the metrics
+// of the app at the end of each run of the CLI. The metrics of the server are
+// always served by the /metrics route of the server.
+type MetricsConfig struct {
+	// DumpFile is the path of a file to which the metrics are written in the
+	// Prometheus text format (e.g. for the textfile collector of the node
+	// exporter), which is disabled when DumpFile is empty.
+	DumpFile string `yaml:"dump_file" json:"dump_file"`
+	// PushURL is the URL of the Prometheus push gateway to which the metrics
+	// are pushed, including the grouping key (e.g.
+	// "http://pushgateway:9091/metrics/job/no-phi-ai"), which is disabled
+	// when PushURL is empty.
+	PushURL string `yaml:"push_url" json:"push_url"`
+}
+
+// RedactConfig struct contains the configuration used by the "redact" command
+// to write sanitized copies of the files in which PHI/PII was detected.
+type RedactConfig struct {
+	// Detector controls how the PHI/PII to redact is detected:
+	//   - RedactDetectorAzure to scan the repository with the AI Language
+	//     service, which also returns the redacted text of each request;
+	//   - RedactDetectorCache to reuse the results of previous scans saved
+	//     in the blob cache (git.scan.cache_dir), without calling the AI
+	//     Language service, where the text is masked locally and files that
+	//     are not in the cache are not redacted.
+	//
+	// Detector default is defined in DefaultRedactDetector const.
+	Detector string `yaml:"detector" json:"detector"`
+	// OutputDir is the directory where sanitized copies of files are written,
+	// within a sub-directory named after each scanned repository.
+	//
+	// OutputDir default is defined in DefaultRedactOutputDir const.
+	OutputDir string `yaml:"output_dir" json:"output_dir"`
+}
+
 // ServerConfig struct contains the configuration used to start the HTTP server.
 // Only used when AppConfig.Mode == "server".
 type ServerConfig struct {
-	Address   string  `yaml:"address" json:"address"`
-	Port      int     `yaml:"port" json:"port"`
-	RateLimit float64 `yaml:"rate_limit" json:"rate_limit"`
+	Address string `yaml:"address" json:"address"`
+	// Deliveries contains the configuration of the store of the webhook
+	// deliveries received by the server.
+	Deliveries ServerDeliveriesConfig `yaml:"deliveries" json:"deliveries"`
+	// APIToken is the secret bearer token that authenticates the requests
+	// to the REST API of the server (e.g. to submit scans and retrieve their
+	// findings), which is disabled when APIToken is empty.
+	APIToken string `yaml:"api_token" json:"api_token"`
+	// Jobs contains the configuration of the scan jobs of the REST API.
+	Jobs ServerJobsConfig `yaml:"jobs" json:"jobs"`
+	Port int              `yaml:"port" json:"port"`
+	// Queue contains the configuration of the queue of webhook events.
+	Queue     ServerQueueConfig `yaml:"queue" json:"queue"`
+	RateLimit float64           `yaml:"rate_limit" json:"rate_limit"`
+	// ShutdownTimeoutSeconds is the time allowed for each phase of the
+	// graceful shutdown of the server (on SIGINT or SIGTERM), where open
+	// requests are finished first, then queued webhook events and running
+	// scan jobs are finished. Running scan jobs are then cancelled and their
+	// checkpoints saved.
+	//
+	// ShutdownTimeoutSeconds default is defined in DefaultServerShutdownTimeoutSeconds const.
+	ShutdownTimeoutSeconds int `yaml:"shutdown_timeout_seconds" json:"shutdown_timeout_seconds"`
+}
+
+// ServerDeliveriesConfig struct contains the configuration of the store of
+// the webhook deliveries received by the server, where the ID of each
+// delivery is kept for a time-to-live (TTL) such that any redelivery of the
+// same event is ignored, and the payload of each delivery is kept so that
+// it can be replayed by the REST API for debugging.
+type ServerDeliveriesConfig struct {
+	// Dir is the directory where deliveries are saved, such that deliveries
+	// are remembered when the server is restarted. Deliveries are only kept
+	// in memory when Dir is empty.
+	Dir string `yaml:"dir" json:"dir"`
+	// TTLHours is the number of hours that each delivery is kept.
+	//
+	// TTLHours default is defined in DefaultServerDeliveriesTTLHours const.
+	TTLHours int `yaml:"ttl_hours" json:"ttl_hours"`
+}
+
+// ServerJobsConfig struct contains the configuration of the scan jobs of the
+// REST API, which are run by a bounded pool of workers, where each finished
+// job (and the findings of its scan) is kept until it is evicted by either
+// its retention or the maximum number of finished jobs.
+type ServerJobsConfig struct {
+	// MaxFinished is the maximum number of finished jobs that are kept,
+	// where the oldest finished jobs are evicted first.
+	//
+	// MaxFinished default is defined in DefaultServerJobsMaxFinished const.
+	MaxFinished int `yaml:"max_finished" json:"max_finished"`
+	// RetentionHours is the number of hours that each finished job is kept.
+	//
+	// RetentionHours default is defined in DefaultServerJobsRetentionHours const.
+	RetentionHours int `yaml:"retention_hours" json:"retention_hours"`
+	// Workers is the number of jobs that can run concurrently, where other
+	// jobs stay queued until a worker is free.
+	//
+	// Workers default is defined in DefaultServerJobsWorkers const.
+	Workers int `yaml:"workers" json:"workers"`
+}
+
+// ServerQueueConfig struct contains the configuration of the queue of GitHub
+// webhook events, which are handled in the background by a bounded pool of
+// workers so that the server can respond to every webhook delivery without
+// waiting for its (slow) handler.
+type ServerQueueConfig struct {
+	// BackoffSeconds is the delay before the first retry of a failed event,
+	// which is doubled for each subsequent retry of the same event.
+	//
+	// BackoffSeconds default is defined in DefaultServerQueueBackoffSeconds const.
+	BackoffSeconds int `yaml:"backoff_seconds" json:"backoff_seconds"`
+	// Dir is the directory where queued events are saved, such that events
+	// that are queued when the server stops are handled once the server is
+	// restarted. Events are only kept in memory when Dir is empty.
+	Dir string `yaml:"dir" json:"dir"`
+	// MaxAttempts is the maximum number of times that the handler of each
+	// event is run before the event is dropped.
+	//
+	// MaxAttempts default is defined in DefaultServerQueueMaxAttempts const.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts"`
+	// MaxBackoffSeconds is the maximum delay before any retry of an event.
+	//
+	// MaxBackoffSeconds default is defined in DefaultServerQueueMaxBackoffSeconds const.
+	MaxBackoffSeconds int `yaml:"max_backoff_seconds" json:"max_backoff_seconds"`
+	// Size is the maximum number of events that can be queued, running or
+	// waiting to be retried, where new deliveries are rejected once the
+	// queue is full.
+	//
+	// Size default is defined in DefaultServerQueueSize const.
+	Size int `yaml:"size" json:"size"`
+	// Workers is the number of events that can be handled concurrently.
+	//
+	// Workers default is defined in DefaultServerQueueWorkers const.
+	Workers int `yaml:"workers" json:"workers"`
 }
 
 // Config struct is the top-level configuration object for the app.
 type Config struct {
-	App     AppConfig     `yaml:"app" json:"app"`
-	AzureAI AzureAIConfig `yaml:"azure_ai" json:"azure_ai"`
-	Command CommandConfig `yaml:"command" json:"command"`
-	Git     GitConfig     `yaml:"git" json:"git"`
-	GitHub  GitHubConfig  `yaml:"github" json:"github"`
-	Server  ServerConfig  `yaml:"server" json:"server"`
+	App      AppConfig      `yaml:"app" json:"app"`
+	AzureAI  AzureAIConfig  `yaml:"azure_ai" json:"azure_ai"`
+	Baseline BaselineConfig `yaml:"baseline" json:"baseline"`
+	Command  CommandConfig  `yaml:"command" json:"command"`
+	Git      GitConfig      `yaml:"git" json:"git"`
+	GitHub   GitHubConfig   `yaml:"github" json:"github"`
+	Metrics  MetricsConfig  `yaml:"metrics" json:"metrics"`
+	Redact   RedactConfig   `yaml:"redact" json:"redact"`
+	Server   ServerConfig   `yaml:"server" json:"server"`
 }
 
 // NewDefaultConfig() function returns a new Config object with default values
@@ -241,13 +717,44 @@ func (c *Config) defaultConfig() {
 	if c.App.UserAgent == "" {
 		c.App.UserAgent = DefaultAppUserAgent
 	}
+	// set defaults for optional c.AzureAI config values
+	if c.AzureAI.Domain == "" {
+		c.AzureAI.Domain = DefaultAzureAIDomain
+	}
+	if c.AzureAI.Language == "" {
+		c.AzureAI.Language = DefaultAzureAILanguage
+	}
+	if c.AzureAI.LanguageDetection == "" {
+		c.AzureAI.LanguageDetection = DefaultAzureAILanguageDetection
+	}
+	if c.AzureAI.ModelVersion == "" {
+		c.AzureAI.ModelVersion = DefaultAzureAIModelVersion
+	}
+	if len(c.AzureAI.PiiCategories) == 0 {
+		c.AzureAI.PiiCategories = DefaultAzureAIPiiCategories
+	}
+	if c.AzureAI.PricePer1kRecords == 0 {
+		c.AzureAI.PricePer1kRecords = DefaultAzureAIPricePer1kRecords
+	}
 	// golang boolean default is false, but we want to c.AzureAI.ShowStats
 	// default to true, so we set it here and force the user to override
 	// with env var NOPHI_AZURE_AI_SHOW_STATS=false
 	c.AzureAI.ShowStats = DefaultAzureAIShowStats
+	if c.Baseline.Path == "" {
+		c.Baseline.Path = DefaultBaselinePath
+	}
 	if c.Command.Run == "" {
 		c.Command.Run = DefaultCommandRun
 	}
+	if c.Git.Scan.Archives.MaxDepth <= 0 {
+		c.Git.Scan.Archives.MaxDepth = DefaultGitScanArchivesMaxDepth
+	}
+	if c.Git.Scan.Archives.MaxMembers <= 0 {
+		c.Git.Scan.Archives.MaxMembers = DefaultGitScanArchivesMaxMembers
+	}
+	if c.Git.Scan.Archives.MaxSize <= 0 {
+		c.Git.Scan.Archives.MaxSize = DefaultGitScanArchivesMaxSize
+	}
 	if len(c.Git.Scan.Extensions) == 0 {
 		c.Git.Scan.Extensions = DefaultScanFileExtensions
 	}
@@ -257,6 +764,12 @@ func (c *Config) defaultConfig() {
 	if c.Git.Scan.Limits.MaxRequestsOutstanding == 0 {
 		c.Git.Scan.Limits.MaxRequestsOutstanding = DefaultMaxRequestsOutstanding
 	}
+	if c.Git.Scan.ResultText.Mode == "" {
+		c.Git.Scan.ResultText.Mode = DefaultGitScanResultTextMode
+	}
+	if c.Git.Scan.Scope.Mode == "" {
+		c.Git.Scan.Scope.Mode = DefaultGitScanScope
+	}
 	if c.Git.WorkDir == "" {
 		c.Git.WorkDir = DefaultCommandWorkDir
 	}
@@ -264,16 +777,53 @@ func (c *Config) defaultConfig() {
 	if c.GitHub.V3APIURL == "" {
 		c.GitHub.V3APIURL = DefaultGitHubV3APIURL
 	}
+	// set defaults for optional c.Redact config values
+	if c.Redact.Detector == "" {
+		c.Redact.Detector = DefaultRedactDetector
+	}
+	if c.Redact.OutputDir == "" {
+		c.Redact.OutputDir = DefaultRedactOutputDir
+	}
 	// set defaults for optional c.Server config values
 	if c.Server.Address == "" {
 		c.Server.Address = DefaultServerAddress
 	}
+	if c.Server.Jobs.MaxFinished == 0 {
+		c.Server.Jobs.MaxFinished = DefaultServerJobsMaxFinished
+	}
+	if c.Server.Jobs.RetentionHours == 0 {
+		c.Server.Jobs.RetentionHours = DefaultServerJobsRetentionHours
+	}
+	if c.Server.Jobs.Workers == 0 {
+		c.Server.Jobs.Workers = DefaultServerJobsWorkers
+	}
 	if c.Server.Port == 0 {
 		c.Server.Port = DefaultServerPort
 	}
+	if c.Server.Deliveries.TTLHours == 0 {
+		c.Server.Deliveries.TTLHours = DefaultServerDeliveriesTTLHours
+	}
+	if c.Server.Queue.BackoffSeconds == 0 {
+		c.Server.Queue.BackoffSeconds = DefaultServerQueueBackoffSeconds
+	}
+	if c.Server.Queue.MaxAttempts == 0 {
+		c.Server.Queue.MaxAttempts = DefaultServerQueueMaxAttempts
+	}
+	if c.Server.Queue.MaxBackoffSeconds == 0 {
+		c.Server.Queue.MaxBackoffSeconds = DefaultServerQueueMaxBackoffSeconds
+	}
+	if c.Server.Queue.Size == 0 {
+		c.Server.Queue.Size = DefaultServerQueueSize
+	}
+	if c.Server.Queue.Workers == 0 {
+		c.Server.Queue.Workers = DefaultServerQueueWorkers
+	}
 	if c.Server.RateLimit == 0 {
 		c.Server.RateLimit = DefaultRateLimit
 	}
+	if c.Server.ShutdownTimeoutSeconds == 0 {
+		c.Server.ShutdownTimeoutSeconds = DefaultServerShutdownTimeoutSeconds
+	}
 }
 
 // verifyConfig() method returns an error if a required value has not
@@ -296,18 +846,45 @@ func (c *Config) verifyConfig() (e error) {
 // verifyConfigCLI() method verifies required config values when running the app
 // in "cli" mode.
 func (c *Config) verifyConfigCLI() (e error) {
-	// check the c.AzureAI config values
-	if c.AzureAI.Service == "" {
+	// check the c.AzureAI config values, where the service is not called
+	// when the "redact" command reuses the results of previous scans
+	uses_azure := c.Command.Run != CommandRunRedact || c.Redact.Detector != RedactDetectorCache
+	if uses_azure && c.AzureAI.Service == "" {
 		e = errors.New("missing required config value: azure_ai.service")
 		return
 	}
-	if c.AzureAI.AuthKey == "" {
+	if uses_azure && c.AzureAI.AuthKey == "" {
 		e = errors.New("missing required config value: azure_ai.auth_key")
 		return
 	}
 	if c.AzureAI.ConfidenceThreshold == 0 {
 		c.AzureAI.ConfidenceThreshold = DefaultConfidenceThreshold
 	}
+	if e = c.verifyConfigAzureAIParameters(); e != nil {
+		return
+	}
+	if e = c.verifyConfigBaseline(); e != nil {
+		return
+	}
+	if e = c.verifyConfigRedact(); e != nil {
+		return
+	}
+
+	if e = c.verifyConfigGitScanEncryption(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanPaths(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanLimits(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanResultText(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanScope(); e != nil {
+		return
+	}
 
 	// check the c.Git.Auth.Token config value
 	if c.Git.Auth.SSHKeyPath == "" && c.Git.Auth.Token == "" {
@@ -318,6 +895,183 @@ func (c *Config) verifyConfigCLI() (e error) {
 	return
 }
 
+// verifyConfigAzureAIParameters() method verifies the optional c.AzureAI
+// config values that are sent as parameters of requests to the AI Language
+// service, where the service would otherwise reject every request.
+func (c *Config) verifyConfigAzureAIParameters() (e error) {
+	if c.AzureAI.ConfidenceThreshold < 0 || c.AzureAI.ConfidenceThreshold > 1 {
+		e = errors.New("invalid config value: azure_ai.confidence_threshold must be between 0 and 1")
+		return
+	}
+	for category, threshold := range c.AzureAI.CategoryThresholds {
+		if threshold < 0 || threshold > 1 {
+			e = errors.New("invalid config value: azure_ai.category_thresholds." + category + " must be between 0 and 1")
+			return
+		}
+	}
+	switch c.AzureAI.Domain {
+	case AzureAIDomainNone, AzureAIDomainPHI:
+	default:
+		e = errors.New("invalid config value: azure_ai.domain = " + c.AzureAI.Domain)
+		return
+	}
+	switch c.AzureAI.LanguageDetection {
+	case AzureAILanguageDetectionAzure, AzureAILanguageDetectionLocal, AzureAILanguageDetectionNone:
+	default:
+		e = errors.New("invalid config value: azure_ai.language_detection = " + c.AzureAI.LanguageDetection)
+		return
+	}
+	if c.AzureAI.PricePer1kRecords < 0 {
+		e = errors.New("invalid config value: azure_ai.price_per_1k_records must not be negative")
+		return
+	}
+
+	return
+}
+
+// verifyConfigBaseline() method verifies the optional c.Baseline config
+// values, where the "baseline" and "scan-new" commands require the key of the
+// fingerprints of the findings of the baseline and the "baseline" command
+// requires the author and the reason of the findings that it adds to the
+// baseline.
+func (c *Config) verifyConfigBaseline() (e error) {
+	if _, e = c.Baseline.ExpiresTime(); e != nil {
+		return
+	}
+	if c.Command.Run != CommandRunBaseline && c.Command.Run != CommandRunScanNew {
+		return
+	}
+	if len(c.Git.Scan.ResultText.Key) < GitScanResultTextKeyMinLength {
+		e = errors.Errorf(
+			"invalid config value: git.scan.result_text.key must have at least %d characters with command %s",
+			GitScanResultTextKeyMinLength,
+			c.Command.Run,
+		)
+		return
+	}
+	if c.Command.Run != CommandRunBaseline {
+		return
+	}
+	if strings.TrimSpace(c.Baseline.Author) == "" {
+		e = errors.New("missing required config value: baseline.author")
+		return
+	}
+	if strings.TrimSpace(c.Baseline.Reason) == "" {
+		e = errors.New("missing required config value: baseline.reason")
+		return
+	}
+
+	return
+}
+
+// verifyConfigRedact() method verifies the optional c.Redact config values,
+// where the "redact" command can only reuse the results of previous scans
+// when the blob cache is configured.
+func (c *Config) verifyConfigRedact() (e error) {
+	switch c.Redact.Detector {
+	case RedactDetectorAzure:
+	case RedactDetectorCache:
+		if c.Command.Run == CommandRunRedact && c.Git.Scan.CacheDir == "" {
+			e = errors.New("missing required config value: git.scan.cache_dir must be set for redact.detector = " + RedactDetectorCache)
+			return
+		}
+	default:
+		e = errors.New("invalid config value: redact.detector = " + c.Redact.Detector)
+		return
+	}
+
+	return
+}
+
+// verifyConfigGitScanEncryption() method verifies the optional
+// c.Git.Scan.Encryption config values, where the keys must be set by only
+// one of the key file and the keys, and the "reencrypt" command requires the
+// keys to be set. The keys themselves are loaded (and so verified) by the
+// manager, which owns the encryption of the stored state of the app.
+func (c *Config) verifyConfigGitScanEncryption() (e error) {
+	encryption := &c.Git.Scan.Encryption
+	if encryption.KeyFile != "" && encryption.Keys != "" {
+		e = errors.New("invalid config: git.scan.encryption.key_file and git.scan.encryption.keys must not both be set")
+		return
+	}
+	if c.Command.Run == CommandRunReencrypt && !encryption.Enabled() {
+		e = errors.Errorf("missing required config value for command %s: git.scan.encryption.key_file or git.scan.encryption.keys", c.Command.Run)
+		return
+	}
+
+	return
+}
+
+// verifyConfigGitScanPaths() method verifies the optional glob patterns of
+// the c.Git.Scan.ExcludePaths and c.Git.Scan.IncludePaths config values.
+func (c *Config) verifyConfigGitScanPaths() (e error) {
+	for _, pattern := range c.Git.Scan.ExcludePaths {
+		if err := glob.Validate(pattern); err != nil {
+			e = errors.Wrap(err, "invalid config value: git.scan.exclude_paths")
+			return
+		}
+	}
+	for _, pattern := range c.Git.Scan.IncludePaths {
+		if err := glob.Validate(pattern); err != nil {
+			e = errors.Wrap(err, "invalid config value: git.scan.include_paths")
+			return
+		}
+	}
+
+	return
+}
+
+// verifyConfigGitScanLimits() method verifies the optional c.Git.Scan.Limits
+// config values, where the overlap of the chunks of a file must leave room
+// for the text of each chunk.
+func (c *Config) verifyConfigGitScanLimits() (e error) {
+	limits := &c.Git.Scan.Limits
+	if limits.MaxRequestChunkSize <= 0 {
+		e = errors.New("invalid config value: git.scan.limits.max_request_chunk_size must be positive")
+		return
+	}
+	if limits.ChunkOverlap < 0 {
+		e = errors.New("invalid config value: git.scan.limits.chunk_overlap must not be negative")
+		return
+	}
+	if limits.MaxRequestChunkSize-2*limits.ChunkOverlap <= 1 {
+		e = errors.New("invalid config value: git.scan.limits.chunk_overlap must be less than half of git.scan.limits.max_request_chunk_size")
+		return
+	}
+
+	return
+}
+
+// verifyConfigGitScanResultText() method verifies the optional
+// c.Git.Scan.ResultText config values, where the HMAC mode requires a key
+// that is long enough to keep the text of results secret.
+func (c *Config) verifyConfigGitScanResultText() (e error) {
+	result_text := &c.Git.Scan.ResultText
+	switch result_text.Mode {
+	case GitScanResultTextModeHMAC:
+		if len(result_text.Key) < GitScanResultTextKeyMinLength {
+			e = errors.Errorf(
+				"invalid config value: git.scan.result_text.key must have at least %d characters with mode %s",
+				GitScanResultTextKeyMinLength,
+				result_text.Mode,
+			)
+			return
+		}
+	case GitScanResultTextModeRaw:
+	default:
+		e = errors.New("invalid config value: git.scan.result_text.mode = " + result_text.Mode)
+		return
+	}
+
+	return
+}
+
+// verifyConfigGitScanScope() method verifies the optional c.Git.Scan.Scope
+// config values (see GitScanScopeConfig.Verify()).
+func (c *Config) verifyConfigGitScanScope() (e error) {
+	return c.Git.Scan.Scope.Verify()
+}
+
 // verifyConfigServer() method verifies required config values when running the app
 // in "server" mode.
 func (c *Config) verifyConfigServer() (e error) {
@@ -333,6 +1087,24 @@ func (c *Config) verifyConfigServer() (e error) {
 	if c.AzureAI.ConfidenceThreshold == 0 {
 		c.AzureAI.ConfidenceThreshold = DefaultConfidenceThreshold
 	}
+	if e = c.verifyConfigAzureAIParameters(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanEncryption(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanPaths(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanLimits(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanResultText(); e != nil {
+		return
+	}
+	if e = c.verifyConfigGitScanScope(); e != nil {
+		return
+	}
 
 	// check the c.GitHub config values
 	if c.GitHub.App.IntegrationID == 0 {
@@ -348,6 +1120,58 @@ func (c *Config) verifyConfigServer() (e error) {
 		return
 	}
 
+	// check the c.Server config values
+	if c.Server.Deliveries.TTLHours <= 0 {
+		e = errors.New("invalid config value: server.deliveries.ttl_hours must be positive")
+		return
+	}
+	if e = c.verifyConfigServerJobs(); e != nil {
+		return
+	}
+	if e = c.verifyConfigServerQueue(); e != nil {
+		return
+	}
+	if c.Server.ShutdownTimeoutSeconds <= 0 {
+		e = errors.New("invalid config value: server.shutdown_timeout_seconds must be positive")
+		return
+	}
+
+	return
+}
+
+// verifyConfigServerJobs() method verifies the optional c.Server.Jobs config
+// values, which must all be positive once defaults are set.
+func (c *Config) verifyConfigServerJobs() (e error) {
+	jobs := &c.Server.Jobs
+	switch {
+	case jobs.MaxFinished <= 0:
+		e = errors.New("invalid config value: server.jobs.max_finished must be positive")
+	case jobs.RetentionHours <= 0:
+		e = errors.New("invalid config value: server.jobs.retention_hours must be positive")
+	case jobs.Workers <= 0:
+		e = errors.New("invalid config value: server.jobs.workers must be positive")
+	}
+
+	return
+}
+
+// verifyConfigServerQueue() method verifies the optional c.Server.Queue
+// config values, which must all be positive once defaults are set.
+func (c *Config) verifyConfigServerQueue() (e error) {
+	queue := &c.Server.Queue
+	switch {
+	case queue.BackoffSeconds <= 0:
+		e = errors.New("invalid config value: server.queue.backoff_seconds must be positive")
+	case queue.MaxAttempts <= 0:
+		e = errors.New("invalid config value: server.queue.max_attempts must be positive")
+	case queue.MaxBackoffSeconds < queue.BackoffSeconds:
+		e = errors.New("invalid config value: server.queue.max_backoff_seconds must not be less than server.queue.backoff_seconds")
+	case queue.Size <= 0:
+		e = errors.New("invalid config value: server.queue.size must be positive")
+	case queue.Workers <= 0:
+		e = errors.New("invalid config value: server.queue.workers must be positive")
+	}
+
 	return
 }
 
@@ -1,6 +1,9 @@
 package cfg
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,16 +20,38 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.Equal(t, "", config.App.Log.File)
 	assert.Equal(t, DefaultAppLogLevel, config.App.Log.Level)
 	assert.Equal(t, DefaultAppUserAgent, config.App.UserAgent)
+	assert.Equal(t, DefaultAzureAIDomain, config.AzureAI.Domain)
+	assert.Equal(t, DefaultAzureAILanguage, config.AzureAI.Language)
+	assert.Equal(t, DefaultAzureAILanguageDetection, config.AzureAI.LanguageDetection)
+	assert.Equal(t, DefaultAzureAIModelVersion, config.AzureAI.ModelVersion)
+	assert.Equal(t, DefaultAzureAIPiiCategories, config.AzureAI.PiiCategories)
+	assert.Equal(t, DefaultAzureAIPricePer1kRecords, config.AzureAI.PricePer1kRecords)
 	assert.Equal(t, DefaultAzureAIShowStats, config.AzureAI.ShowStats)
 	assert.Equal(t, DefaultCommandRun, config.Command.Run)
 	assert.Equal(t, DefaultScanFileExtensions, config.Git.Scan.Extensions)
 	assert.Equal(t, DefaultMaxRequestChunkSize, config.Git.Scan.Limits.MaxRequestChunkSize)
 	assert.Equal(t, DefaultMaxRequestsOutstanding, config.Git.Scan.Limits.MaxRequestsOutstanding)
+	assert.Equal(t, DefaultGitScanScope, config.Git.Scan.Scope.Mode)
+	assert.Equal(t, DefaultGitScanArchivesMaxDepth, config.Git.Scan.Archives.MaxDepth)
+	assert.Equal(t, DefaultGitScanArchivesMaxMembers, config.Git.Scan.Archives.MaxMembers)
+	assert.Equal(t, DefaultGitScanArchivesMaxSize, config.Git.Scan.Archives.MaxSize)
 	assert.Equal(t, DefaultCommandWorkDir, config.Git.WorkDir)
 	assert.Equal(t, DefaultGitHubV3APIURL, config.GitHub.V3APIURL)
+	assert.Equal(t, DefaultRedactDetector, config.Redact.Detector)
+	assert.Equal(t, DefaultRedactOutputDir, config.Redact.OutputDir)
 	assert.Equal(t, DefaultServerAddress, config.Server.Address)
+	assert.Equal(t, DefaultServerDeliveriesTTLHours, config.Server.Deliveries.TTLHours)
+	assert.Equal(t, DefaultServerJobsMaxFinished, config.Server.Jobs.MaxFinished)
+	assert.Equal(t, DefaultServerJobsRetentionHours, config.Server.Jobs.RetentionHours)
+	assert.Equal(t, DefaultServerJobsWorkers, config.Server.Jobs.Workers)
 	assert.Equal(t, DefaultServerPort, config.Server.Port)
+	assert.Equal(t, DefaultServerQueueBackoffSeconds, config.Server.Queue.BackoffSeconds)
+	assert.Equal(t, DefaultServerQueueMaxAttempts, config.Server.Queue.MaxAttempts)
+	assert.Equal(t, DefaultServerQueueMaxBackoffSeconds, config.Server.Queue.MaxBackoffSeconds)
+	assert.Equal(t, DefaultServerQueueSize, config.Server.Queue.Size)
+	assert.Equal(t, DefaultServerQueueWorkers, config.Server.Queue.Workers)
 	assert.Equal(t, DefaultRateLimit, config.Server.RateLimit)
+	assert.Equal(t, DefaultServerShutdownTimeoutSeconds, config.Server.ShutdownTimeoutSeconds)
 	assert.Exactly(t, false, config.AzureAI.DryRun)
 
 	// assert that required values are not set by default
@@ -38,3 +63,621 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.Equal(t, "", config.GitHub.App.PrivateKey)
 	assert.Equal(t, "", config.GitHub.App.WebhookSecret)
 }
+
+// TestConfig_verifyConfigAzureAIParameters() unit test function tests the
+// verifyConfigAzureAIParameters() method.
+func TestConfig_verifyConfigAzureAIParameters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid_Values",
+			modify: func(c *Config) {
+				c.AzureAI.Domain = AzureAIDomainNone
+				c.AzureAI.LanguageDetection = AzureAILanguageDetectionAzure
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_CategoryThresholds",
+			modify:     func(c *Config) { c.AzureAI.CategoryThresholds = map[string]float64{"Person": 1.5} },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_ConfidenceThreshold",
+			modify:     func(c *Config) { c.AzureAI.ConfidenceThreshold = -0.1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Domain",
+			modify:     func(c *Config) { c.AzureAI.Domain = "healthcare" },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_LanguageDetection",
+			modify:     func(c *Config) { c.AzureAI.LanguageDetection = "auto" },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_PricePer1kRecords",
+			modify:     func(c *Config) { c.AzureAI.PricePer1kRecords = -1 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigAzureAIParameters()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigGitScanScope() unit test function tests the
+// verifyConfigGitScanScope() method.
+func TestConfig_verifyConfigGitScanScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid_All",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.Since = "2024-01-01"
+			},
+		},
+		{
+			name: "Valid_History",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.MaxCommits = 10
+				c.Git.Scan.Scope.Mode = GitScanScopeHistory
+				c.Git.Scan.Scope.Refs = []string{"main"}
+				c.Git.Scan.Scope.Since = "2024-01-01T12:00:00Z"
+				c.Git.Scan.Scope.SinceCommit = "v1.0.0"
+			},
+		},
+		{
+			name: "Valid_Tip",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.Mode = GitScanScopeTip
+				c.Git.Scan.Scope.Refs = []string{"main", "v1.0.0"}
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Mode",
+			modify:     func(c *Config) { c.Git.Scan.Scope.Mode = "head" },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_All_Refs",
+			modify:     func(c *Config) { c.Git.Scan.Scope.Refs = []string{"main"} },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_All_MaxCommits",
+			modify:     func(c *Config) { c.Git.Scan.Scope.MaxCommits = 10 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Tip_Since",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.Mode = GitScanScopeTip
+				c.Git.Scan.Scope.Since = "2024-01-01"
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Tip_SinceCommit",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.Mode = GitScanScopeTip
+				c.Git.Scan.Scope.SinceCommit = "v1.0.0"
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_History_MaxCommits",
+			modify: func(c *Config) {
+				c.Git.Scan.Scope.MaxCommits = -1
+				c.Git.Scan.Scope.Mode = GitScanScopeHistory
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Since",
+			modify:     func(c *Config) { c.Git.Scan.Scope.Since = "last week" },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigGitScanScope()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigGitScanPaths() unit test function tests the
+// verifyConfigGitScanPaths() method.
+func TestConfig_verifyConfigGitScanPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid",
+			modify: func(c *Config) {
+				c.Git.Scan.ExcludePaths = []string{"test/fixtures/**", "*.log"}
+				c.Git.Scan.IncludePaths = []string{"docs/**/*.txt"}
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Exclude",
+			modify:     func(c *Config) { c.Git.Scan.ExcludePaths = []string{"[a-"} },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Include_Empty",
+			modify:     func(c *Config) { c.Git.Scan.IncludePaths = []string{""} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigGitScanPaths()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigGitScanLimits() unit test function tests the
+// verifyConfigGitScanLimits() method.
+func TestConfig_verifyConfigGitScanLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "Valid_Overlap",
+			modify: func(c *Config) { c.Git.Scan.Limits.ChunkOverlap = 200 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Overlap_Negative",
+			modify:     func(c *Config) { c.Git.Scan.Limits.ChunkOverlap = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Overlap_Too_Large",
+			modify:     func(c *Config) { c.Git.Scan.Limits.ChunkOverlap = c.Git.Scan.Limits.MaxRequestChunkSize / 2 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Chunk_Size",
+			modify:     func(c *Config) { c.Git.Scan.Limits.MaxRequestChunkSize = -1 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigGitScanLimits()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigBaseline() unit test function tests the
+// verifyConfigBaseline() method.
+func TestConfig_verifyConfigBaseline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid_Baseline_Command",
+			modify: func(c *Config) {
+				c.Baseline.Author = "test"
+				c.Baseline.Expires = "2030-01-31"
+				c.Baseline.Reason = "legacy test data"
+				c.Command.Run = CommandRunBaseline
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+			},
+		},
+		{
+			name: "Valid_ScanNew_Command",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunScanNew
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Expires",
+			modify:     func(c *Config) { c.Baseline.Expires = "next year" },
+		},
+		{
+			expect_err: true,
+			name:       "Missing_Author",
+			modify: func(c *Config) {
+				c.Baseline.Reason = "legacy test data"
+				c.Command.Run = CommandRunBaseline
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Missing_Reason",
+			modify: func(c *Config) {
+				c.Baseline.Author = "test"
+				c.Command.Run = CommandRunBaseline
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Missing_Key",
+			modify: func(c *Config) {
+				c.Baseline.Author = "test"
+				c.Baseline.Reason = "legacy test data"
+				c.Command.Run = CommandRunBaseline
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Short_Key_ScanNew",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunScanNew
+				c.Git.Scan.ResultText.Key = "short"
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigBaseline()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigRedact() unit test function tests the
+// verifyConfigRedact() method.
+func TestConfig_verifyConfigRedact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid_Cache_Detector",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunRedact
+				c.Git.Scan.CacheDir = "cache"
+				c.Redact.Detector = RedactDetectorCache
+			},
+		},
+		{
+			name: "Valid_Cache_Detector_Other_Command",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunScanRepos
+				c.Redact.Detector = RedactDetectorCache
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Detector",
+			modify:     func(c *Config) { c.Redact.Detector = "local" },
+		},
+		{
+			expect_err: true,
+			name:       "Missing_CacheDir",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunRedact
+				c.Redact.Detector = RedactDetectorCache
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigRedact()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigGitScanResultText() unit test function tests the
+// verifyConfigGitScanResultText() method.
+func TestConfig_verifyConfigGitScanResultText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "Valid_HMAC",
+			modify: func(c *Config) {
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+				c.Git.Scan.ResultText.Mode = GitScanResultTextModeHMAC
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_HMAC_Short_Key",
+			modify: func(c *Config) {
+				c.Git.Scan.ResultText.Key = "secret"
+				c.Git.Scan.ResultText.Mode = GitScanResultTextModeHMAC
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Mode",
+			modify:     func(c *Config) { c.Git.Scan.ResultText.Mode = "plain" },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigGitScanResultText()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigGitScanEncryption() unit test function tests the
+// verifyConfigGitScanEncryption() method of the Config type.
+func TestConfig_verifyConfigGitScanEncryption(t *testing.T) {
+	t.Parallel()
+
+	const keys = "key-2:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=,key-1:ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8="
+	key_file := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(key_file, []byte("# rotated 2026-10-18\n"+strings.ReplaceAll(keys, ",", "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Disabled",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "Valid_Keys",
+			modify: func(c *Config) { c.Git.Scan.Encryption.Keys = keys },
+		},
+		{
+			name:   "Valid_KeyFile",
+			modify: func(c *Config) { c.Git.Scan.Encryption.KeyFile = key_file },
+		},
+		{
+			name: "Valid_Reencrypt",
+			modify: func(c *Config) {
+				c.Command.Run = CommandRunReencrypt
+				c.Git.Scan.Encryption.Keys = keys
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Both",
+			modify: func(c *Config) {
+				c.Git.Scan.Encryption.KeyFile = key_file
+				c.Git.Scan.Encryption.Keys = keys
+			},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Reencrypt_Disabled",
+			modify:     func(c *Config) { c.Command.Run = CommandRunReencrypt },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigGitScanEncryption()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigServerJobs() unit test function tests the
+// verifyConfigServerJobs() method.
+func TestConfig_verifyConfigServerJobs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Max_Finished",
+			modify:     func(c *Config) { c.Server.Jobs.MaxFinished = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Retention_Hours",
+			modify:     func(c *Config) { c.Server.Jobs.RetentionHours = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Workers",
+			modify:     func(c *Config) { c.Server.Jobs.Workers = -1 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigServerJobs()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_verifyConfigServerQueue() unit test function tests the
+// verifyConfigServerQueue() method.
+func TestConfig_verifyConfigServerQueue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect_err bool
+		modify     func(c *Config)
+		name       string
+	}{
+		{
+			name:   "Defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "Valid_Backoff",
+			modify: func(c *Config) { c.Server.Queue.BackoffSeconds = c.Server.Queue.MaxBackoffSeconds },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Backoff",
+			modify:     func(c *Config) { c.Server.Queue.BackoffSeconds = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Max_Attempts",
+			modify:     func(c *Config) { c.Server.Queue.MaxAttempts = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Max_Backoff",
+			modify:     func(c *Config) { c.Server.Queue.MaxBackoffSeconds = c.Server.Queue.BackoffSeconds - 1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Size",
+			modify:     func(c *Config) { c.Server.Queue.Size = -1 },
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Workers",
+			modify:     func(c *Config) { c.Server.Queue.Workers = -1 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			test.modify(config)
+			err := config.verifyConfigServerQueue()
+			if test.expect_err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
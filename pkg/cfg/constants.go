@@ -5,33 +5,138 @@ import "time"
 const AppModeCLI string = "cli"
 const AppModeServer string = "server"
 const AppVersion string = "1.0.0"
+const BuildUnknown string = "unknown"
 
+const AzureAIDomainNone string = "none"
+const AzureAIDomainPHI string = "phi"
+const AzureAILanguageDetectionAzure string = "azure"
+const AzureAILanguageDetectionLocal string = "local"
+const AzureAILanguageDetectionNone string = "none"
+
+const CommandRunBaseline string = "baseline"
+const CommandRunEstimate string = "estimate"
 const CommandRunHelp string = "help"
 const CommandRunListOrgRepos string = "list-org-repos"
+const CommandRunRedact string = "redact"
+const CommandRunReencrypt string = "reencrypt"
+const CommandRunScanNew string = "scan-new"
 const CommandRunScanOrg string = "scan-org"
 const CommandRunScanRepos string = "scan-repos"
 const CommandRunScanTest string = "scan-test"
 const CommandRunVersion string = "version"
 
+// LogRedactReplacement replaces the PHI/PII redacted from logs.
+const LogRedactReplacement string = "[REDACTED]"
+
+// Detectors of the PHI/PII redacted by the "redact" command.
+const RedactDetectorAzure string = "azure"
+const RedactDetectorCache string = "cache"
+
 const DefaultAppLogLevel string = "info"
 const DefaultAppMode string = AppModeServer
 const DefaultAppName string = "no-phi-ai"
 const DefaultAppUserAgent string = DefaultAppName + "/" + AppVersion
+const DefaultAzureAIDomain string = AzureAIDomainPHI
+const DefaultAzureAILanguage string = "en"
+const DefaultAzureAILanguageDetection string = AzureAILanguageDetectionNone
+const DefaultAzureAIModelVersion string = "latest"
+const DefaultBaselinePath string = "no-phi-baseline.json"
+
+// DefaultAzureAIPricePer1kRecords is the pay-as-you-go price in USD of 1000
+// text records for PII entity recognition in the standard tier.
+const DefaultAzureAIPricePer1kRecords float64 = 1.0
 const DefaultAzureAIShowStats bool = true
+
 const DefaultClientTimeout time.Duration = 3 * time.Second
 const DefaultCommandRun string = CommandRunHelp
 const DefaultCommandWorkDir string = "/tmp/" + DefaultAppName
 const DefaultConfidenceThreshold float64 = 0.6
+const DefaultGitScanArchivesMaxDepth int = 3
+const DefaultGitScanArchivesMaxMembers int = 10000
+const DefaultGitScanArchivesMaxSize int64 = 256 << 20
+const DefaultGitScanResultTextMode string = GitScanResultTextModeRaw
+const DefaultGitScanScope string = GitScanScopeAll
 const DefaultGitHubV3APIURL string = "https://api.github.com"
 const DefaultMaxRequestChunkSize int = 5000
 const DefaultMaxRequestsOutstanding int = 100
 const DefaultRateLimit float64 = 1000.0
+
+// DefaultReadinessCacheTTL is the time for which the results of the checks of
+// the external services (e.g. GitHub) of the /readyz route are cached.
+const DefaultReadinessCacheTTL time.Duration = 30 * time.Second
+
+// DefaultReadinessTimeout is the time allowed for all of the checks of the
+// /readyz route of the server.
+const DefaultReadinessTimeout time.Duration = 5 * time.Second
+const DefaultRedactDetector string = RedactDetectorAzure
+const DefaultRedactOutputDir string = "redacted"
 const DefaultServerAddress string = "127.0.0.1"
+const DefaultServerDeliveriesTTLHours int = 72
+const DefaultServerJobsMaxFinished int = 100
+const DefaultServerJobsRetentionHours int = 24
+const DefaultServerJobsWorkers int = 2
 const DefaultServerPort int = 8080
+const DefaultServerQueueBackoffSeconds int = 5
+const DefaultServerQueueMaxAttempts int = 5
+const DefaultServerQueueMaxBackoffSeconds int = 300
+const DefaultServerQueueSize int = 1000
+const DefaultServerQueueWorkers int = 4
+const DefaultServerShutdownTimeoutSeconds int = 30
+
+// Kinds of the metadata of repositories that can be enabled by the
+// NOPHI_GIT_SCAN_METADATA env var.
+const GitScanMetadataAuthors string = "authors"
+const GitScanMetadataMessages string = "messages"
+const GitScanMetadataNotes string = "notes"
+const GitScanMetadataTags string = "tags"
+
+// MetricsNamespace is the prefix of the name of every metric of the app.
+const MetricsNamespace string = "nophi"
 
+const GitScanScopeAll string = "all"
+const GitScanScopeHistory string = "history"
+const GitScanScopeTip string = "tip"
+
+const RouteDeliveryReplay string = "/deliveries/:id/replay"
+const RouteGroupAPIv1 string = "/api/v1"
 const RouteGroupGHv1 string = "/api/v1/github"
+const RouteHealthz string = "/healthz"
+const RouteMetrics string = "/metrics"
+const RouteReadyz string = "/readyz"
+const RouteScan string = "/scans/:id"
+const RouteScanFindings string = "/scans/:id/findings"
+const RouteScans string = "/scans"
+const RouteVersion string = "/version"
 const RouteWebhook string = "/hook"
 
+var DefaultAzureAIPiiCategories = []string{
+	"Default",
+}
+
+// Modes of storing the text of the results of a scan, where the raw text of
+// each result is either stored as detected or only kept as a keyed HMAC of
+// the text along with a masked preview of the text. The key of the HMAC must
+// have at least GitScanResultTextKeyMinLength characters.
+const (
+	GitScanResultTextKeyMinLength int    = 32
+	GitScanResultTextModeHMAC     string = "hmac"
+	GitScanResultTextModeRaw      string = "raw"
+)
+
+// BaselineExpiresLayouts are the layouts used to parse the Expires value of
+// BaselineConfig, in order of preference.
+var BaselineExpiresLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// GitScanScopeSinceLayouts are the layouts used to parse the Since value
+// of GitScanScopeConfig, in order of preference.
+var GitScanScopeSinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+}
+
 var DefaultScanFileExtensions = []string{
 	".csv",
 	".html",
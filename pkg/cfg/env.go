@@ -3,6 +3,7 @@ package cfg
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -12,9 +13,18 @@ const NOPHI_APP_LOG_LEVEL string = "NOPHI_APP_LOG_LEVEL"
 const NOPHI_APP_MODE string = "NOPHI_APP_MODE"
 const NOPHI_APP_NAME string = "NOPHI_APP_NAME"
 const NOPHI_AZURE_AI_AUTH_KEY string = "NOPHI_AZURE_AI_AUTH_KEY"
+const NOPHI_AZURE_AI_DOMAIN string = "NOPHI_AZURE_AI_DOMAIN"
 const NOPHI_AZURE_AI_DRY_RUN string = "NOPHI_AZURE_AI_DRY_RUN"
+const NOPHI_AZURE_AI_LANGUAGE string = "NOPHI_AZURE_AI_LANGUAGE"
+const NOPHI_AZURE_AI_LANGUAGE_DETECTION string = "NOPHI_AZURE_AI_LANGUAGE_DETECTION"
+const NOPHI_AZURE_AI_MODEL_VERSION string = "NOPHI_AZURE_AI_MODEL_VERSION"
+const NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS string = "NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS"
 const NOPHI_AZURE_AI_SERVICE string = "NOPHI_AZURE_AI_SERVICE"
 const NOPHI_AZURE_AI_SHOW_STATS string = "NOPHI_AZURE_AI_SHOW_STATS"
+const NOPHI_BASELINE_AUTHOR string = "NOPHI_BASELINE_AUTHOR"
+const NOPHI_BASELINE_EXPIRES string = "NOPHI_BASELINE_EXPIRES"
+const NOPHI_BASELINE_PATH string = "NOPHI_BASELINE_PATH"
+const NOPHI_BASELINE_REASON string = "NOPHI_BASELINE_REASON"
 const NOPHI_COMMAND_RUN = "NOPHI_COMMAND_RUN"
 const NOPHI_CONFIG_PATH string = "NOPHI_CONFIG_PATH"
 const NOPHI_GH_INTEGRATION_ID string = "NOPHI_GH_INTEGRATION_ID"
@@ -22,11 +32,40 @@ const NOPHI_GH_PRIVATE_KEY string = "NOPHI_GH_PRIVATE_KEY"
 const NOPHI_GH_V3APIURL string = "NOPHI_GH_V3APIURL"
 const NOPHI_GH_V4APIURL string = "NOPHI_GH_V4APIURL"
 const NOPHI_GH_WEBHOOK_SECRET = "NOPHI_GH_WEBHOOK_SECRET"
+const NOPHI_GIT_SCAN_ARCHIVES = "NOPHI_GIT_SCAN_ARCHIVES"
+const NOPHI_GIT_SCAN_CACHE_DIR = "NOPHI_GIT_SCAN_CACHE_DIR"
+const NOPHI_GIT_SCAN_CHECKPOINT_DIR = "NOPHI_GIT_SCAN_CHECKPOINT_DIR"
+const NOPHI_GIT_SCAN_CHUNK_OVERLAP = "NOPHI_GIT_SCAN_CHUNK_OVERLAP"
+const NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE = "NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE"
+const NOPHI_GIT_SCAN_ENCRYPTION_KEYS = "NOPHI_GIT_SCAN_ENCRYPTION_KEYS"
+const NOPHI_GIT_SCAN_EXCLUDE_PATHS = "NOPHI_GIT_SCAN_EXCLUDE_PATHS"
+const NOPHI_GIT_SCAN_EXTRACT_TEXT = "NOPHI_GIT_SCAN_EXTRACT_TEXT"
+const NOPHI_GIT_SCAN_ID = "NOPHI_GIT_SCAN_ID"
+const NOPHI_GIT_SCAN_INCLUDE_PATHS = "NOPHI_GIT_SCAN_INCLUDE_PATHS"
 const NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE = "NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE"
+const NOPHI_GIT_SCAN_METADATA = "NOPHI_GIT_SCAN_METADATA"
+const NOPHI_GIT_SCAN_RESPECT_GITIGNORE = "NOPHI_GIT_SCAN_RESPECT_GITIGNORE"
+const NOPHI_GIT_SCAN_RESULT_TEXT_KEY = "NOPHI_GIT_SCAN_RESULT_TEXT_KEY"
+const NOPHI_GIT_SCAN_RESULT_TEXT_MODE = "NOPHI_GIT_SCAN_RESULT_TEXT_MODE"
+const NOPHI_GIT_SCAN_SCOPE = "NOPHI_GIT_SCAN_SCOPE"
+const NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS = "NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS"
+const NOPHI_GIT_SCAN_SCOPE_REFS = "NOPHI_GIT_SCAN_SCOPE_REFS"
+const NOPHI_GIT_SCAN_SCOPE_SINCE = "NOPHI_GIT_SCAN_SCOPE_SINCE"
+const NOPHI_GIT_SCAN_SCOPE_SINCE_COMMIT = "NOPHI_GIT_SCAN_SCOPE_SINCE_COMMIT"
+const NOPHI_GIT_SCAN_SOURCE_CODE = "NOPHI_GIT_SCAN_SOURCE_CODE"
+const NOPHI_GIT_SCAN_STRUCTURED_DATA = "NOPHI_GIT_SCAN_STRUCTURED_DATA"
 const NOPHI_GIT_WORKDIR = "NOPHI_GIT_WORKDIR"
 const NOPHI_MAX_REQUESTS_OUTSTANDING = "NOPHI_MAX_REQUESTS_OUTSTANDING"
+const NOPHI_METRICS_DUMP_FILE string = "NOPHI_METRICS_DUMP_FILE"
+const NOPHI_METRICS_PUSH_URL string = "NOPHI_METRICS_PUSH_URL"
+const NOPHI_REDACT_DETECTOR string = "NOPHI_REDACT_DETECTOR"
+const NOPHI_REDACT_OUTPUT_DIR string = "NOPHI_REDACT_OUTPUT_DIR"
 const NOPHI_SERVER_ADDRESS string = "NOPHI_SERVER_ADDRESS"
+const NOPHI_SERVER_API_TOKEN string = "NOPHI_SERVER_API_TOKEN"
+const NOPHI_SERVER_DELIVERIES_DIR string = "NOPHI_SERVER_DELIVERIES_DIR"
 const NOPHI_SERVER_PORT string = "NOPHI_SERVER_PORT"
+const NOPHI_SERVER_QUEUE_DIR string = "NOPHI_SERVER_QUEUE_DIR"
+const NOPHI_SERVER_QUEUE_WORKERS string = "NOPHI_SERVER_QUEUE_WORKERS"
 
 // GetAppEnvVars() method returns a list of environment variables used by the app.
 func GetAppEnvVars() []string {
@@ -35,9 +74,18 @@ func GetAppEnvVars() []string {
 		NOPHI_APP_MODE,
 		NOPHI_APP_NAME,
 		NOPHI_AZURE_AI_AUTH_KEY,
+		NOPHI_AZURE_AI_DOMAIN,
 		NOPHI_AZURE_AI_DRY_RUN,
+		NOPHI_AZURE_AI_LANGUAGE,
+		NOPHI_AZURE_AI_LANGUAGE_DETECTION,
+		NOPHI_AZURE_AI_MODEL_VERSION,
+		NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS,
 		NOPHI_AZURE_AI_SERVICE,
 		NOPHI_AZURE_AI_SHOW_STATS,
+		NOPHI_BASELINE_AUTHOR,
+		NOPHI_BASELINE_EXPIRES,
+		NOPHI_BASELINE_PATH,
+		NOPHI_BASELINE_REASON,
 		NOPHI_COMMAND_RUN,
 		NOPHI_CONFIG_PATH,
 		NOPHI_GH_INTEGRATION_ID,
@@ -45,11 +93,40 @@ func GetAppEnvVars() []string {
 		NOPHI_GH_V3APIURL,
 		NOPHI_GH_V4APIURL,
 		NOPHI_GH_WEBHOOK_SECRET,
+		NOPHI_GIT_SCAN_ARCHIVES,
+		NOPHI_GIT_SCAN_CACHE_DIR,
+		NOPHI_GIT_SCAN_CHECKPOINT_DIR,
+		NOPHI_GIT_SCAN_CHUNK_OVERLAP,
+		NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE,
+		NOPHI_GIT_SCAN_ENCRYPTION_KEYS,
+		NOPHI_GIT_SCAN_EXCLUDE_PATHS,
+		NOPHI_GIT_SCAN_EXTRACT_TEXT,
+		NOPHI_GIT_SCAN_ID,
+		NOPHI_GIT_SCAN_INCLUDE_PATHS,
 		NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE,
+		NOPHI_GIT_SCAN_METADATA,
+		NOPHI_GIT_SCAN_RESPECT_GITIGNORE,
+		NOPHI_GIT_SCAN_RESULT_TEXT_KEY,
+		NOPHI_GIT_SCAN_RESULT_TEXT_MODE,
+		NOPHI_GIT_SCAN_SCOPE,
+		NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS,
+		NOPHI_GIT_SCAN_SCOPE_REFS,
+		NOPHI_GIT_SCAN_SCOPE_SINCE,
+		NOPHI_GIT_SCAN_SCOPE_SINCE_COMMIT,
+		NOPHI_GIT_SCAN_SOURCE_CODE,
+		NOPHI_GIT_SCAN_STRUCTURED_DATA,
 		NOPHI_GIT_WORKDIR,
 		NOPHI_MAX_REQUESTS_OUTSTANDING,
+		NOPHI_METRICS_DUMP_FILE,
+		NOPHI_METRICS_PUSH_URL,
+		NOPHI_REDACT_DETECTOR,
+		NOPHI_REDACT_OUTPUT_DIR,
 		NOPHI_SERVER_ADDRESS,
+		NOPHI_SERVER_API_TOKEN,
+		NOPHI_SERVER_DELIVERIES_DIR,
 		NOPHI_SERVER_PORT,
+		NOPHI_SERVER_QUEUE_DIR,
+		NOPHI_SERVER_QUEUE_WORKERS,
 	}
 }
 
@@ -67,6 +144,9 @@ func (c *Config) envOverride() error {
 	if azAuthKey := os.Getenv(NOPHI_AZURE_AI_AUTH_KEY); azAuthKey != "" {
 		c.AzureAI.AuthKey = azAuthKey
 	}
+	if azDomain := os.Getenv(NOPHI_AZURE_AI_DOMAIN); azDomain != "" {
+		c.AzureAI.Domain = azDomain
+	}
 	if azDryRun := os.Getenv(NOPHI_AZURE_AI_DRY_RUN); azDryRun != "" {
 		azDryRunBool, err := strconv.ParseBool(azDryRun)
 		if err != nil {
@@ -74,6 +154,22 @@ func (c *Config) envOverride() error {
 		}
 		c.AzureAI.DryRun = azDryRunBool
 	}
+	if azLanguage := os.Getenv(NOPHI_AZURE_AI_LANGUAGE); azLanguage != "" {
+		c.AzureAI.Language = azLanguage
+	}
+	if azLanguageDetection := os.Getenv(NOPHI_AZURE_AI_LANGUAGE_DETECTION); azLanguageDetection != "" {
+		c.AzureAI.LanguageDetection = azLanguageDetection
+	}
+	if azModelVersion := os.Getenv(NOPHI_AZURE_AI_MODEL_VERSION); azModelVersion != "" {
+		c.AzureAI.ModelVersion = azModelVersion
+	}
+	if azPrice := os.Getenv(NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS); azPrice != "" {
+		azPriceFloat, err := strconv.ParseFloat(azPrice, 64)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS env var")
+		}
+		c.AzureAI.PricePer1kRecords = azPriceFloat
+	}
 	if azService := os.Getenv(NOPHI_AZURE_AI_SERVICE); azService != "" {
 		c.AzureAI.Service = azService
 	}
@@ -84,6 +180,18 @@ func (c *Config) envOverride() error {
 		}
 		c.AzureAI.ShowStats = azShowStatsBool
 	}
+	if baselineAuthor := os.Getenv(NOPHI_BASELINE_AUTHOR); baselineAuthor != "" {
+		c.Baseline.Author = baselineAuthor
+	}
+	if baselineExpires := os.Getenv(NOPHI_BASELINE_EXPIRES); baselineExpires != "" {
+		c.Baseline.Expires = baselineExpires
+	}
+	if baselinePath := os.Getenv(NOPHI_BASELINE_PATH); baselinePath != "" {
+		c.Baseline.Path = baselinePath
+	}
+	if baselineReason := os.Getenv(NOPHI_BASELINE_REASON); baselineReason != "" {
+		c.Baseline.Reason = baselineReason
+	}
 	if commandRun := os.Getenv(NOPHI_COMMAND_RUN); commandRun != "" {
 		c.Command.Run = commandRun
 	}
@@ -93,6 +201,78 @@ func (c *Config) envOverride() error {
 			c.Git.Scan.Limits.MaxRequestsOutstanding = maxRequestsOutstandingInt
 		}
 	}
+	if archives := os.Getenv(NOPHI_GIT_SCAN_ARCHIVES); archives != "" {
+		archivesBool, err := strconv.ParseBool(archives)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_ARCHIVES env var")
+		}
+		c.Git.Scan.Archives.Enabled = archivesBool
+	}
+	if cacheDir := os.Getenv(NOPHI_GIT_SCAN_CACHE_DIR); cacheDir != "" {
+		c.Git.Scan.CacheDir = cacheDir
+	}
+	if checkpointDir := os.Getenv(NOPHI_GIT_SCAN_CHECKPOINT_DIR); checkpointDir != "" {
+		c.Git.Scan.Checkpoint.Dir = checkpointDir
+	}
+	// the keys of either env var replace both keys of the config file, where
+	// NOPHI_GIT_SCAN_ENCRYPTION_KEYS takes precedence if both are set
+	if encryptionKeyFile := os.Getenv(NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE); encryptionKeyFile != "" {
+		c.Git.Scan.Encryption.KeyFile = encryptionKeyFile
+		c.Git.Scan.Encryption.Keys = ""
+	}
+	if encryptionKeys := os.Getenv(NOPHI_GIT_SCAN_ENCRYPTION_KEYS); encryptionKeys != "" {
+		c.Git.Scan.Encryption.KeyFile = ""
+		c.Git.Scan.Encryption.Keys = encryptionKeys
+	}
+	if excludePaths := os.Getenv(NOPHI_GIT_SCAN_EXCLUDE_PATHS); excludePaths != "" {
+		// patterns are separated by commas, e.g. "test/fixtures/**,*.log"
+		c.Git.Scan.ExcludePaths = splitEnvList(excludePaths)
+	}
+	if extractText := os.Getenv(NOPHI_GIT_SCAN_EXTRACT_TEXT); extractText != "" {
+		extractTextBool, err := strconv.ParseBool(extractText)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_EXTRACT_TEXT env var")
+		}
+		c.Git.Scan.ExtractText = extractTextBool
+	}
+	if includePaths := os.Getenv(NOPHI_GIT_SCAN_INCLUDE_PATHS); includePaths != "" {
+		c.Git.Scan.IncludePaths = splitEnvList(includePaths)
+	}
+	if metadata := os.Getenv(NOPHI_GIT_SCAN_METADATA); metadata != "" {
+		// kinds are separated by commas, e.g. "messages,tags,notes"
+		c.Git.Scan.Metadata = GitScanMetadataConfig{}
+		for _, kind := range splitEnvList(metadata) {
+			switch kind {
+			case GitScanMetadataAuthors:
+				c.Git.Scan.Metadata.Authors = true
+			case GitScanMetadataMessages:
+				c.Git.Scan.Metadata.Messages = true
+			case GitScanMetadataNotes:
+				c.Git.Scan.Metadata.Notes = true
+			case GitScanMetadataTags:
+				c.Git.Scan.Metadata.Tags = true
+			default:
+				return errors.Errorf("failed parsing NOPHI_GIT_SCAN_METADATA env var : unknown metadata %s", kind)
+			}
+		}
+	}
+	if respectGitignore := os.Getenv(NOPHI_GIT_SCAN_RESPECT_GITIGNORE); respectGitignore != "" {
+		respectGitignoreBool, err := strconv.ParseBool(respectGitignore)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_RESPECT_GITIGNORE env var")
+		}
+		c.Git.Scan.RespectGitignore = respectGitignoreBool
+	}
+	if scanID := os.Getenv(NOPHI_GIT_SCAN_ID); scanID != "" {
+		c.Git.Scan.Checkpoint.ScanID = scanID
+	}
+	if chunkOverlap := os.Getenv(NOPHI_GIT_SCAN_CHUNK_OVERLAP); chunkOverlap != "" {
+		chunkOverlapInt, err := strconv.Atoi(chunkOverlap)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_CHUNK_OVERLAP env var")
+		}
+		c.Git.Scan.Limits.ChunkOverlap = chunkOverlapInt
+	}
 	if chunkSize := os.Getenv(NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE); chunkSize != "" {
 		chunkSizeInt, err := strconv.Atoi(chunkSize)
 		if err != nil {
@@ -100,6 +280,46 @@ func (c *Config) envOverride() error {
 		}
 		c.Git.Scan.Limits.MaxRequestChunkSize = chunkSizeInt
 	}
+	if resultTextKey := os.Getenv(NOPHI_GIT_SCAN_RESULT_TEXT_KEY); resultTextKey != "" {
+		c.Git.Scan.ResultText.Key = resultTextKey
+	}
+	if resultTextMode := os.Getenv(NOPHI_GIT_SCAN_RESULT_TEXT_MODE); resultTextMode != "" {
+		c.Git.Scan.ResultText.Mode = resultTextMode
+	}
+	if scope := os.Getenv(NOPHI_GIT_SCAN_SCOPE); scope != "" {
+		c.Git.Scan.Scope.Mode = scope
+	}
+	if maxCommits := os.Getenv(NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS); maxCommits != "" {
+		maxCommitsInt, err := strconv.Atoi(maxCommits)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS env var")
+		}
+		c.Git.Scan.Scope.MaxCommits = maxCommitsInt
+	}
+	if refs := os.Getenv(NOPHI_GIT_SCAN_SCOPE_REFS); refs != "" {
+		// refs are separated by commas, e.g. "main,release/1.0"
+		c.Git.Scan.Scope.Refs = splitEnvList(refs)
+	}
+	if since := os.Getenv(NOPHI_GIT_SCAN_SCOPE_SINCE); since != "" {
+		c.Git.Scan.Scope.Since = since
+	}
+	if sinceCommit := os.Getenv(NOPHI_GIT_SCAN_SCOPE_SINCE_COMMIT); sinceCommit != "" {
+		c.Git.Scan.Scope.SinceCommit = sinceCommit
+	}
+	if sourceCode := os.Getenv(NOPHI_GIT_SCAN_SOURCE_CODE); sourceCode != "" {
+		sourceCodeBool, err := strconv.ParseBool(sourceCode)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_SOURCE_CODE env var")
+		}
+		c.Git.Scan.SourceCode = sourceCodeBool
+	}
+	if structuredData := os.Getenv(NOPHI_GIT_SCAN_STRUCTURED_DATA); structuredData != "" {
+		structuredDataBool, err := strconv.ParseBool(structuredData)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing NOPHI_GIT_SCAN_STRUCTURED_DATA env var")
+		}
+		c.Git.Scan.StructuredData = structuredDataBool
+	}
 	if gitWorkDir := os.Getenv(NOPHI_GIT_WORKDIR); gitWorkDir != "" {
 		c.Git.WorkDir = gitWorkDir
 	}
@@ -122,9 +342,27 @@ func (c *Config) envOverride() error {
 	if V4APIURL := os.Getenv(NOPHI_GH_V4APIURL); V4APIURL != "" {
 		c.GitHub.V4APIURL = V4APIURL
 	}
+	if metricsDumpFile := os.Getenv(NOPHI_METRICS_DUMP_FILE); metricsDumpFile != "" {
+		c.Metrics.DumpFile = metricsDumpFile
+	}
+	if metricsPushURL := os.Getenv(NOPHI_METRICS_PUSH_URL); metricsPushURL != "" {
+		c.Metrics.PushURL = metricsPushURL
+	}
+	if redactDetector := os.Getenv(NOPHI_REDACT_DETECTOR); redactDetector != "" {
+		c.Redact.Detector = redactDetector
+	}
+	if redactOutputDir := os.Getenv(NOPHI_REDACT_OUTPUT_DIR); redactOutputDir != "" {
+		c.Redact.OutputDir = redactOutputDir
+	}
 	if serverAddress := os.Getenv(NOPHI_SERVER_ADDRESS); serverAddress != "" {
 		c.Server.Address = serverAddress
 	}
+	if serverAPIToken := os.Getenv(NOPHI_SERVER_API_TOKEN); serverAPIToken != "" {
+		c.Server.APIToken = serverAPIToken
+	}
+	if serverDeliveriesDir := os.Getenv(NOPHI_SERVER_DELIVERIES_DIR); serverDeliveriesDir != "" {
+		c.Server.Deliveries.Dir = serverDeliveriesDir
+	}
 	if serverPort := os.Getenv(NOPHI_SERVER_PORT); serverPort != "" {
 		serverPortInt, err := strconv.Atoi(serverPort)
 		if err != nil {
@@ -132,6 +370,29 @@ func (c *Config) envOverride() error {
 		}
 		c.Server.Port = serverPortInt
 	}
+	if serverQueueDir := os.Getenv(NOPHI_SERVER_QUEUE_DIR); serverQueueDir != "" {
+		c.Server.Queue.Dir = serverQueueDir
+	}
+	if serverQueueWorkers := os.Getenv(NOPHI_SERVER_QUEUE_WORKERS); serverQueueWorkers != "" {
+		serverQueueWorkersInt, err := strconv.Atoi(serverQueueWorkers)
+		if err != nil {
+			return errors.Wrap(err, "failed parsing SERVER_QUEUE_WORKERS env var")
+		}
+		c.Server.Queue.Workers = serverQueueWorkersInt
+	}
 
 	return nil
 }
+
+// splitEnvList() function returns the non-empty values of the comma-separated
+// list of an environment variable.
+func splitEnvList(value string) []string {
+	out := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
@@ -10,9 +10,18 @@ func TestGetAppEnvVars(t *testing.T) {
 		NOPHI_APP_MODE,
 		NOPHI_APP_NAME,
 		NOPHI_AZURE_AI_AUTH_KEY,
+		NOPHI_AZURE_AI_DOMAIN,
 		NOPHI_AZURE_AI_DRY_RUN,
+		NOPHI_AZURE_AI_LANGUAGE,
+		NOPHI_AZURE_AI_LANGUAGE_DETECTION,
+		NOPHI_AZURE_AI_MODEL_VERSION,
+		NOPHI_AZURE_AI_PRICE_PER_1K_RECORDS,
 		NOPHI_AZURE_AI_SERVICE,
 		NOPHI_AZURE_AI_SHOW_STATS,
+		NOPHI_BASELINE_AUTHOR,
+		NOPHI_BASELINE_EXPIRES,
+		NOPHI_BASELINE_PATH,
+		NOPHI_BASELINE_REASON,
 		NOPHI_COMMAND_RUN,
 		NOPHI_CONFIG_PATH,
 		NOPHI_GH_INTEGRATION_ID,
@@ -20,11 +29,40 @@ func TestGetAppEnvVars(t *testing.T) {
 		NOPHI_GH_V3APIURL,
 		NOPHI_GH_V4APIURL,
 		NOPHI_GH_WEBHOOK_SECRET,
+		NOPHI_GIT_SCAN_ARCHIVES,
+		NOPHI_GIT_SCAN_CACHE_DIR,
+		NOPHI_GIT_SCAN_CHECKPOINT_DIR,
+		NOPHI_GIT_SCAN_CHUNK_OVERLAP,
+		NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE,
+		NOPHI_GIT_SCAN_ENCRYPTION_KEYS,
+		NOPHI_GIT_SCAN_EXCLUDE_PATHS,
+		NOPHI_GIT_SCAN_EXTRACT_TEXT,
+		NOPHI_GIT_SCAN_ID,
+		NOPHI_GIT_SCAN_INCLUDE_PATHS,
 		NOPHI_GIT_SCAN_MAX_REQUEST_CHUNK_SIZE,
+		NOPHI_GIT_SCAN_METADATA,
+		NOPHI_GIT_SCAN_RESPECT_GITIGNORE,
+		NOPHI_GIT_SCAN_RESULT_TEXT_KEY,
+		NOPHI_GIT_SCAN_RESULT_TEXT_MODE,
+		NOPHI_GIT_SCAN_SCOPE,
+		NOPHI_GIT_SCAN_SCOPE_MAX_COMMITS,
+		NOPHI_GIT_SCAN_SCOPE_REFS,
+		NOPHI_GIT_SCAN_SCOPE_SINCE,
+		NOPHI_GIT_SCAN_SCOPE_SINCE_COMMIT,
+		NOPHI_GIT_SCAN_SOURCE_CODE,
+		NOPHI_GIT_SCAN_STRUCTURED_DATA,
 		NOPHI_GIT_WORKDIR,
 		NOPHI_MAX_REQUESTS_OUTSTANDING,
+		NOPHI_METRICS_DUMP_FILE,
+		NOPHI_METRICS_PUSH_URL,
+		NOPHI_REDACT_DETECTOR,
+		NOPHI_REDACT_OUTPUT_DIR,
 		NOPHI_SERVER_ADDRESS,
+		NOPHI_SERVER_API_TOKEN,
+		NOPHI_SERVER_DELIVERIES_DIR,
 		NOPHI_SERVER_PORT,
+		NOPHI_SERVER_QUEUE_DIR,
+		NOPHI_SERVER_QUEUE_WORKERS,
 	}
 
 	result := GetAppEnvVars()
@@ -39,3 +77,32 @@ func TestGetAppEnvVars(t *testing.T) {
 		}
 	}
 }
+
+// TestConfig_envOverride_encryption() unit test function tests that the keys
+// of either encryption env var replace both keys of the config file, so that
+// the keys of the env vars never conflict with the keys of the config file.
+func TestConfig_envOverride_encryption(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Git.Scan.Encryption.KeyFile = "/etc/no-phi-ai/keys"
+	t.Setenv(NOPHI_GIT_SCAN_ENCRYPTION_KEYS, "key-1:c2VjcmV0")
+	if err := config.envOverride(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Git.Scan.Encryption.KeyFile != "" || config.Git.Scan.Encryption.Keys != "key-1:c2VjcmV0" {
+		t.Errorf("Expected only the keys of the env var, but got %+v", config.Git.Scan.Encryption)
+	}
+	if err := config.verifyConfigGitScanEncryption(); err != nil {
+		t.Errorf("Expected the encryption config to be valid, but got %v", err)
+	}
+
+	config = NewDefaultConfig()
+	config.Git.Scan.Encryption.Keys = "key-1:c2VjcmV0"
+	t.Setenv(NOPHI_GIT_SCAN_ENCRYPTION_KEYS, "")
+	t.Setenv(NOPHI_GIT_SCAN_ENCRYPTION_KEY_FILE, "/etc/no-phi-ai/keys")
+	if err := config.envOverride(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Git.Scan.Encryption.KeyFile != "/etc/no-phi-ai/keys" || config.Git.Scan.Encryption.Keys != "" {
+		t.Errorf("Expected only the key file of the env var, but got %+v", config.Git.Scan.Encryption)
+	}
+}
@@ -0,0 +1,108 @@
+package cfg
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// LogRedactPatterns are the patterns of PHI/PII (e.g. email addresses) that
+// are replaced by LogRedactReplacement in every line written by the logger
+// of the app, regardless of which part of the app logged the line. Only the
+// first group of a pattern with a group is replaced, such that the label of
+// the PHI/PII (e.g. the key of a JSON field) is kept. Dates of birth and
+// medical record numbers (MRN) are only redacted after their label, since
+// dates and IDs are logged throughout the app (e.g. timestamps and commits).
+var LogRedactPatterns = []*regexp.Regexp{
+	// email addresses
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	// US social security numbers
+	regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	// US phone numbers, e.g. 555-123-4567, (555) 123-4567 or +1 555.123.4567
+	regexp.MustCompile(`(?:\+1[-. ]?)?(?:\(\d{3}\) ?|\b\d{3}[-. ])\d{3}[-. ]\d{4}\b`),
+	// dates of birth, e.g. "dob: 1980-01-02" or "date of birth 01/02/1980"
+	regexp.MustCompile(`(?i)\b(?:dob|date[ _-]?of[ _-]?birth|birth[ _-]?date|born(?: on)?)\b["':=\s]*(\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[/.-]\d{1,2}[/.-]\d{2,4})\b`),
+	// medical record numbers, e.g. "MRN: A1234567" or "medical record # 123456"
+	regexp.MustCompile(`(?i)\b(?:mrn|medical[ _-]?record(?:[ _-]?(?:number|no))?)\b["':=#\s]*([a-z0-9-]*\d[a-z0-9-]*)`),
+}
+
+// LogRedactKeep are the prefixes of matches of LogRedactPatterns that are not
+// PHI/PII, such as the user of the SSH URLs of git repositories.
+var LogRedactKeep = []string{
+	"git@",
+}
+
+// redactWriter struct wraps the writer of the logger, such that the
+// LogRedactPatterns are redacted from every line before it is written.
+type redactWriter struct {
+	writer io.Writer
+}
+
+// newRedactWriter() function returns a zerolog.LevelWriter that redacts the
+// lines written to the provided writer.
+func newRedactWriter(writer io.Writer) zerolog.LevelWriter {
+	return redactWriter{writer: writer}
+}
+
+// Write() method writes the redacted line to the writer. The length of the
+// line is returned instead of the length of the redacted line, since the
+// logger treats a shorter write as a failure.
+func (w redactWriter) Write(p []byte) (int, error) {
+	if _, err := w.writer.Write(redactLog(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteLevel() method writes the redacted line to the writer at the level,
+// if the writer is a zerolog.LevelWriter.
+func (w redactWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	level_writer, ok := w.writer.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := level_writer.WriteLevel(level, redactLog(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redactLog() function returns the line with every match of the
+// LogRedactPatterns (or the first group of the match) replaced by
+// LogRedactReplacement.
+func redactLog(p []byte) []byte {
+	for _, pattern := range LogRedactPatterns {
+		matches := pattern.FindAllSubmatchIndex(p, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		redacted := make([]byte, 0, len(p))
+		last := 0
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if len(match) > 2 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			redacted = append(redacted, p[last:start]...)
+			redacted = append(redacted, redactMatch(p[start:end])...)
+			last = end
+		}
+		p = append(redacted, p[last:]...)
+	}
+
+	return p
+}
+
+// redactMatch() function returns LogRedactReplacement for the match, unless
+// the match starts with any of the LogRedactKeep prefixes.
+func redactMatch(match []byte) []byte {
+	for _, keep := range LogRedactKeep {
+		if strings.HasPrefix(string(match), keep) {
+			return match
+		}
+	}
+
+	return []byte(LogRedactReplacement)
+}
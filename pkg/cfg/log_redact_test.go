@@ -0,0 +1,67 @@
+package cfg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_redactWriter() unit test function tests that the redactWriter redacts
+// PHI/PII from every line written by a logger.
+func Test_redactWriter(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	logger := zerolog.New(newRedactWriter(zerolog.LevelWriterAdapter{Writer: &buffer}))
+
+	logger.Info().Msgf("contact jane.doe@example.com about 555-01-2345")
+	logger.Info().Str("email", "john@example.org").Msg("scanning git@github.com:test-org/test-repo.git")
+	logger.Info().Msg("commit 1980-01-02 : 12345-67-8901")
+
+	lines := buffer.String()
+	assert.NotContains(t, lines, "jane.doe@example.com")
+	assert.NotContains(t, lines, "555-01-2345")
+	assert.NotContains(t, lines, "john@example.org")
+	assert.Contains(t, lines, `"message":"contact [REDACTED] about [REDACTED]"`)
+	assert.Contains(t, lines, "git@github.com:test-org/test-repo.git")
+	assert.Contains(t, lines, "1980-01-02 : 12345-67-8901")
+}
+
+// Test_redactLog() unit test function tests the redactLog() function for
+// each of the LogRedactPatterns.
+func Test_redactLog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expect string
+		line   string
+		name   string
+	}{
+		{name: "Email", line: "contact jane.doe@example.com now", expect: "contact [REDACTED] now"},
+		{name: "SSN", line: "ssn 555-01-2345", expect: "ssn [REDACTED]"},
+		{name: "Phone_Dashes", line: "call 555-123-4567 today", expect: "call [REDACTED] today"},
+		{name: "Phone_Parentheses", line: "call (555) 123-4567", expect: "call [REDACTED]"},
+		{name: "Phone_Country_Code", line: "call +1 555.123.4567", expect: "call [REDACTED]"},
+		{name: "DOB", line: "patient dob: 1980-01-02", expect: "patient dob: [REDACTED]"},
+		{name: "DOB_Slashes", line: "Date of Birth 01/02/1980", expect: "Date of Birth [REDACTED]"},
+		{name: "DOB_JSON", line: `{"dob":"1980-01-02"}`, expect: `{"dob":"[REDACTED]"}`},
+		{name: "Born", line: "born on 2/3/80 in Ohio", expect: "born on [REDACTED] in Ohio"},
+		{name: "MRN", line: "MRN: A1234567 admitted", expect: "MRN: [REDACTED] admitted"},
+		{name: "MRN_JSON", line: `{"mrn":"00123456"}`, expect: `{"mrn":"[REDACTED]"}`},
+		{name: "Medical_Record_Number", line: "medical record number #123456", expect: "medical record number #[REDACTED]"},
+		{
+			name:   "Not_PHI",
+			line:   `{"time":"2026-10-19T10:00:00Z","commit":"3f2a1b0","message":"scanned 1234 files in 5.123 s : 2026-10-19"}`,
+			expect: `{"time":"2026-10-19T10:00:00Z","commit":"3f2a1b0","message":"scanned 1234 files in 5.123 s : 2026-10-19"}`,
+		},
+		{name: "MRN_Without_ID", line: "mrn lookup failed", expect: "mrn lookup failed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, string(redactLog([]byte(test.line))))
+		})
+	}
+}
@@ -32,6 +32,9 @@ func (c *Config) setupLogger() *zerolog.Logger {
 		level_writer = zerolog.ConsoleWriter{Out: os.Stdout}
 	}
 
+	// redact PHI/PII from every line written to the console
+	level_writer = newRedactWriter(level_writer)
+
 	// create the zerolog.Logger instance that will be re-used throughout the app
 	logger := zerolog.New(level_writer).With().Caller().Timestamp().Logger()
 
@@ -41,10 +44,10 @@ func (c *Config) setupLogger() *zerolog.Logger {
 			logger.Error().Err(err).Msgf("failed to open log file=%s", c.App.Log.File)
 		} else if c.App.Log.ConsoleEnable {
 			// log to both console and file
-			logger = logger.Output(zerolog.MultiLevelWriter(level_writer, log_file))
+			logger = logger.Output(zerolog.MultiLevelWriter(level_writer, newRedactWriter(log_file)))
 		} else {
 			// just log to the file
-			logger = logger.Output(log_file)
+			logger = logger.Output(newRedactWriter(log_file))
 		}
 		logger.Info().Msgf("logger setup to log to file=%s", c.App.Log.File)
 	}
@@ -0,0 +1,14 @@
+package aztest
+
+const AnalyzeTextPath string = "/language/:analyze-text"
+const CharactersPerTransaction int = 1000
+const DefaultLanguage string = "en"
+const HeaderSubscriptionKey string = "Ocp-Apim-Subscription-Key"
+const KindLanguageDetection string = "LanguageDetection"
+const LanguageConfidenceScore float64 = 0.95
+const MalformedBody string = `{"kind": "PiiEntityRecognitionResults", "results": {"documents": [`
+const MaxDocumentCharacters int = 5120
+const MaxDocumentsPerRequest int = 5
+const ModelVersion string = "2021-01-15"
+const RedactionCharacter string = "*"
+const StringIndexTypeUtf16CodeUnit string = "Utf16CodeUnit"
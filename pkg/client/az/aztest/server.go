@@ -0,0 +1,390 @@
+package aztest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Server struct is a local stand-in for the "language/:analyze-text" endpoint
+// of the Azure AI Language service. Responses are driven by the Fixtures
+// loaded into the Server, which makes it possible to exercise the az client
+// (and anything built on top of it) without sending requests to Azure.
+type Server struct {
+	*httptest.Server
+
+	// Key is the expected value of the "Ocp-Apim-Subscription-Key" header.
+	// Requests with a different key are rejected with 401 when Key is set.
+	Key string
+
+	fixtures []Fixture
+	hits     []int
+	mutex    *sync.Mutex
+	requests []AnalyzeRequest
+}
+
+// NewServer() function starts and returns a new Server that responds to
+// requests using the provided fixtures. Call Close() when finished.
+func NewServer(fixtures ...Fixture) *Server {
+	s := &Server{
+		fixtures: fixtures,
+		hits:     make([]int, len(fixtures)),
+		mutex:    &sync.Mutex{},
+		requests: make([]AnalyzeRequest, 0),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// NewServerFromFile() function starts and returns a new Server using the
+// fixtures defined in the JSON file at the provided path.
+func NewServerFromFile(path string) (*Server, error) {
+	fixtures, err := LoadFixtures(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewServer(fixtures...), nil
+}
+
+// LoadFixtures() function reads a slice of Fixtures from the JSON file at
+// the provided path, where the file contains an object with a "fixtures"
+// array.
+func LoadFixtures(path string) ([]Fixture, error) {
+	file_bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading fixtures file %s", path)
+	}
+
+	var fixture_file struct {
+		Fixtures []Fixture `json:"fixtures"`
+	}
+	if err := json.Unmarshal(file_bytes, &fixture_file); err != nil {
+		return nil, errors.Wrapf(err, "failed parsing fixtures file %s", path)
+	}
+
+	return fixture_file.Fixtures, nil
+}
+
+// AddFixture() method adds a Fixture to the Server after it has started.
+func (s *Server) AddFixture(fixture Fixture) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fixtures = append(s.fixtures, fixture)
+	s.hits = append(s.hits, 0)
+}
+
+// Requests() method returns a copy of every request received by the Server
+// that could be decoded.
+func (s *Server) Requests() []AnalyzeRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	out := make([]AnalyzeRequest, len(s.requests))
+	copy(out, s.requests)
+
+	return out
+}
+
+// handle() method is the http.HandlerFunc for the Server.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != AnalyzeTextPath {
+		writeError(w, http.StatusNotFound, "404", "Resource not found")
+		return
+	}
+	if s.Key != "" && r.Header.Get(HeaderSubscriptionKey) != s.Key {
+		writeError(w, http.StatusUnauthorized, "401", "Access denied due to invalid subscription key")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	var request AnalyzeRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "Invalid Request Body")
+		return
+	}
+	if len(request.AnalysisInput.Documents) > MaxDocumentsPerRequest {
+		writeError(w, http.StatusBadRequest, "InvalidDocumentBatch", fmt.Sprintf(
+			"Batch request contains too many records. Max %d records are permitted.",
+			MaxDocumentsPerRequest,
+		))
+		return
+	}
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, request)
+	// request-level fixtures (status codes, malformed bodies) take
+	// precedence over any document-level fixtures
+	for _, doc := range request.AnalysisInput.Documents {
+		for i := range s.fixtures {
+			fixture := &s.fixtures[i]
+			if !fixture.isRequestLevel() || !s.matches(i, doc) {
+				continue
+			}
+			s.hits[i]++
+			s.mutex.Unlock()
+			fixture.writeRequestLevel(w)
+			return
+		}
+	}
+	response := s.respond(&request, r.URL.Query().Get("showStats") == "true")
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// matches() method checks if the fixture at index i applies to the document.
+// The caller must hold the mutex.
+func (s *Server) matches(i int, doc AnalyzeDocument) bool {
+	fixture := s.fixtures[i]
+	if fixture.Times > 0 && s.hits[i] >= fixture.Times {
+		return false
+	}
+	if fixture.ID != "" && fixture.ID != doc.ID {
+		return false
+	}
+	if fixture.Match != "" && !strings.Contains(doc.Text, fixture.Match) {
+		return false
+	}
+
+	return fixture.ID != "" || fixture.Match != ""
+}
+
+// respond() method builds the response for a request that was not failed by
+// a request-level fixture. The caller must hold the mutex.
+func (s *Server) respond(request *AnalyzeRequest, show_stats bool) AnalyzeResponse {
+	results := AnalyzeResults{
+		Documents:    make([]AnalyzeDocumentResult, 0),
+		Errors:       make([]AnalyzeDocumentError, 0),
+		ModelVersion: ModelVersion,
+	}
+	if request.Parameters.ModelVersion != "" && request.Parameters.ModelVersion != "latest" {
+		results.ModelVersion = request.Parameters.ModelVersion
+	}
+	index_type := request.Parameters.StringIndexType
+
+	var transactions int
+	for _, doc := range request.AnalysisInput.Documents {
+		length := textLength(doc.Text, index_type)
+		if length > MaxDocumentCharacters {
+			results.Errors = append(results.Errors, newDocumentError(doc.ID, "InvalidDocument", fmt.Sprintf(
+				"A document within the request was too large to be processed. Limit document size to: %d text elements.",
+				MaxDocumentCharacters,
+			)))
+			continue
+		}
+
+		if request.Kind == KindLanguageDetection {
+			results.Documents = append(results.Documents, s.detectLanguage(doc))
+			continue
+		}
+
+		doc_result := AnalyzeDocumentResult{
+			ID:       doc.ID,
+			Entities: make([]AnalyzeEntity, 0),
+			Warnings: make([]AnalyzeWarning, 0),
+		}
+		var doc_error *AnalyzeDocumentError
+		for i, fixture := range s.fixtures {
+			// fixtures for detected languages only apply to
+			// "LanguageDetection" requests
+			if fixture.isRequestLevel() || fixture.Language != "" || !s.matches(i, doc) {
+				continue
+			}
+			s.hits[i]++
+			if fixture.Error != nil {
+				doc_error = &AnalyzeDocumentError{ID: doc.ID}
+				doc_error.Error.Code = fixture.Error.Code
+				doc_error.Error.Message = fixture.Error.Message
+				break
+			}
+			for _, entity := range fixture.Entities {
+				doc_result.Entities = append(doc_result.Entities, locateEntity(doc.Text, index_type, entity)...)
+			}
+			doc_result.Warnings = append(doc_result.Warnings, fixture.Warnings...)
+		}
+		if doc_error != nil {
+			results.Errors = append(results.Errors, *doc_error)
+			continue
+		}
+		doc_result.RedactedText = redact(doc.Text, index_type, doc_result.Entities)
+		if show_stats {
+			doc_result.Statistics = &AnalyzeDocumentStatistics{
+				CharactersCount:   length,
+				TransactionsCount: transactionCount(length),
+			}
+			transactions += doc_result.Statistics.TransactionsCount
+		}
+		results.Documents = append(results.Documents, doc_result)
+	}
+
+	if show_stats {
+		results.Statistics = &AnalyzeRequestStatistics{
+			DocumentsCount:          len(request.AnalysisInput.Documents),
+			ErroneousDocumentsCount: len(results.Errors),
+			TransactionsCount:       transactions,
+			ValidDocumentsCount:     len(results.Documents),
+		}
+	}
+
+	return AnalyzeResponse{
+		Kind:    request.Kind + "Results",
+		Results: results,
+	}
+}
+
+// detectLanguage() method returns the result of a "LanguageDetection" request
+// for the document, using the Language of the first matching Fixture or the
+// DefaultLanguage. The caller must hold the mutex.
+func (s *Server) detectLanguage(doc AnalyzeDocument) AnalyzeDocumentResult {
+	language := DefaultLanguage
+	for i, fixture := range s.fixtures {
+		if fixture.Language == "" || !s.matches(i, doc) {
+			continue
+		}
+		s.hits[i]++
+		language = fixture.Language
+		break
+	}
+
+	return AnalyzeDocumentResult{
+		DetectedLanguage: &AnalyzeDetectedLanguage{
+			ConfidenceScore: LanguageConfidenceScore,
+			ISO6391Name:     language,
+			Name:            language,
+		},
+		ID:       doc.ID,
+		Warnings: make([]AnalyzeWarning, 0),
+	}
+}
+
+// locateEntity() function returns an AnalyzeEntity for every occurrence of
+// the fixture entity text within the document text, with offsets and lengths
+// measured in the units of the requested string index type.
+func locateEntity(text, index_type string, entity FixtureEntity) []AnalyzeEntity {
+	out := make([]AnalyzeEntity, 0)
+	if entity.Text == "" {
+		return out
+	}
+
+	search_from := 0
+	for {
+		i := strings.Index(text[search_from:], entity.Text)
+		if i < 0 {
+			break
+		}
+		byte_offset := search_from + i
+		out = append(out, AnalyzeEntity{
+			Category:        entity.Category,
+			ConfidenceScore: entity.ConfidenceScore,
+			Length:          textLength(entity.Text, index_type),
+			Offset:          textLength(text[:byte_offset], index_type),
+			Subcategory:     entity.Subcategory,
+			Text:            entity.Text,
+		})
+		search_from = byte_offset + len(entity.Text)
+	}
+
+	return out
+}
+
+// redact() function masks the text of each entity in the document text in
+// the same way as the default "CharacterMask" redaction policy of Azure,
+// where the text of each entity is located by its offset and length in the
+// units of the requested string index type.
+func redact(text, index_type string, entities []AnalyzeEntity) string {
+	runes := []rune(text)
+	masked := make([]bool, len(runes))
+	for _, entity := range entities {
+		start := runeIndex(runes, index_type, entity.Offset)
+		end := runeIndex(runes, index_type, entity.Offset+entity.Length)
+		for i := start; i < end; i++ {
+			masked[i] = true
+		}
+	}
+
+	var out strings.Builder
+	for i, r := range runes {
+		if masked[i] {
+			out.WriteString(RedactionCharacter)
+		} else {
+			out.WriteRune(r)
+		}
+	}
+
+	return out.String()
+}
+
+// runeIndex() function returns the index of the rune of the text at the
+// provided position in the units of the requested string index type, or the
+// number of runes if the position is beyond the end of the text.
+func runeIndex(runes []rune, index_type string, position int) int {
+	units := 0
+	for i, r := range runes {
+		if units >= position {
+			return i
+		}
+		units += textLength(string(r), index_type)
+	}
+
+	return len(runes)
+}
+
+// textLength() function returns the length of the text in the units of the
+// requested string index type, where the default ("TextElements_v8") is
+// approximated by counting runes.
+func textLength(text, index_type string) int {
+	if index_type == StringIndexTypeUtf16CodeUnit {
+		return len(utf16.Encode([]rune(text)))
+	}
+
+	return utf8.RuneCountInString(text)
+}
+
+// transactionCount() function returns the number of text records billed for
+// a document of the given length.
+func transactionCount(length int) int {
+	if length == 0 {
+		return 1
+	}
+
+	return (length + CharactersPerTransaction - 1) / CharactersPerTransaction
+}
+
+// writeError() function writes an error response in the format used by the
+// Azure AI Language service.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+func newDocumentError(id, code, message string) AnalyzeDocumentError {
+	doc_error := AnalyzeDocumentError{ID: id}
+	doc_error.Error.Code = code
+	doc_error.Error.Message = message
+
+	return doc_error
+}
@@ -0,0 +1,252 @@
+package aztest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const test_fixtures_path = "./testdata/fixtures.json"
+
+// testPost() helper function sends the documents to the Server and returns
+// the HTTP response.
+func testPost(t *testing.T, s *Server, query string, documents ...AnalyzeDocument) *http.Response {
+	request := AnalyzeRequest{Kind: "PiiEntityRecognition"}
+	request.AnalysisInput.Documents = documents
+	request.Parameters.StringIndexType = "UnicodeCodePoint"
+	body, err := json.Marshal(request)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	response, err := http.Post(s.URL+AnalyzeTextPath+query, "application/json", bytes.NewReader(body))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return response
+}
+
+// TestLoadFixtures() unit test function tests the LoadFixtures() function.
+func TestLoadFixtures(t *testing.T) {
+	t.Parallel()
+
+	fixtures, err := LoadFixtures(test_fixtures_path)
+	assert.NoError(t, err)
+	assert.Len(t, fixtures, 5)
+
+	_, err = LoadFixtures("./testdata/does-not-exist.json")
+	assert.Error(t, err)
+}
+
+// TestServer() unit test function tests the responses of the Server for
+// each kind of Fixture.
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewServerFromFile(test_fixtures_path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer s.Close()
+
+	t.Run("Entities", func(t *testing.T) {
+		response := testPost(t, s, "?showStats=true",
+			AnalyzeDocument{ID: "1", Language: "en", Text: "Patient: John Smith, seen by Dr. Ó John Smith"},
+			AnalyzeDocument{ID: "2", Language: "en", Text: "SSN 555-01-2345"},
+			AnalyzeDocument{ID: "3", Language: "en", Text: "nothing to see here"},
+		)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var body AnalyzeResponse
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, "PiiEntityRecognitionResults", body.Kind)
+		if !assert.Len(t, body.Results.Documents, 3) {
+			t.FailNow()
+		}
+
+		doc_1 := body.Results.Documents[0]
+		if assert.Len(t, doc_1.Entities, 2) {
+			assert.Equal(t, 9, doc_1.Entities[0].Offset)
+			assert.Equal(t, 10, doc_1.Entities[0].Length)
+			// offsets are measured in code points, not bytes
+			assert.Equal(t, 35, doc_1.Entities[1].Offset)
+		}
+		assert.Equal(t, "Patient: **********, seen by Dr. Ó **********", doc_1.RedactedText)
+		if assert.NotNil(t, doc_1.Statistics) {
+			assert.Equal(t, 45, doc_1.Statistics.CharactersCount)
+			assert.Equal(t, 1, doc_1.Statistics.TransactionsCount)
+		}
+
+		doc_2 := body.Results.Documents[1]
+		assert.Len(t, doc_2.Entities, 1)
+		assert.Len(t, doc_2.Warnings, 1)
+
+		assert.Empty(t, body.Results.Documents[2].Entities)
+		if assert.NotNil(t, body.Results.Statistics) {
+			assert.Equal(t, 3, body.Results.Statistics.DocumentsCount)
+			assert.Equal(t, 3, body.Results.Statistics.ValidDocumentsCount)
+		}
+	})
+
+	t.Run("DocumentError", func(t *testing.T) {
+		response := testPost(t, s, "",
+			AnalyzeDocument{ID: "1", Language: "en", Text: "invalid-document"},
+			AnalyzeDocument{ID: "2", Language: "en", Text: "John Smith"},
+		)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var body AnalyzeResponse
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Len(t, body.Results.Documents, 1)
+		if assert.Len(t, body.Results.Errors, 1) {
+			assert.Equal(t, "1", body.Results.Errors[0].ID)
+			assert.Equal(t, "InvalidDocument", body.Results.Errors[0].Error.Code)
+		}
+		assert.Nil(t, body.Results.Statistics)
+	})
+
+	t.Run("LanguageDetection", func(t *testing.T) {
+		request := AnalyzeRequest{Kind: KindLanguageDetection}
+		request.AnalysisInput.Documents = []AnalyzeDocument{
+			{ID: "1", Text: "Hola John Smith"},
+			{ID: "2", Text: "Hello John Smith"},
+		}
+		body, err := json.Marshal(request)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		language_server := NewServer(Fixture{Match: "Hola", Language: "es"})
+		defer language_server.Close()
+
+		response, err := http.Post(language_server.URL+AnalyzeTextPath, "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var results AnalyzeResponse
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&results))
+		assert.Equal(t, "LanguageDetectionResults", results.Kind)
+		if assert.Len(t, results.Results.Documents, 2) {
+			assert.Equal(t, "es", results.Results.Documents[0].DetectedLanguage.ISO6391Name)
+			assert.Equal(t, DefaultLanguage, results.Results.Documents[1].DetectedLanguage.ISO6391Name)
+			assert.Empty(t, results.Results.Documents[0].Entities)
+		}
+	})
+
+	t.Run("TooManyRequests", func(t *testing.T) {
+		response := testPost(t, s, "", AnalyzeDocument{ID: "1", Text: "rate-limit"})
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		assert.Equal(t, "1", response.Header.Get("Retry-After"))
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		response := testPost(t, s, "", AnalyzeDocument{ID: "1", Text: "malformed-body"})
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var body AnalyzeResponse
+		assert.Error(t, json.NewDecoder(response.Body).Decode(&body))
+	})
+
+	t.Run("TooManyDocuments", func(t *testing.T) {
+		documents := make([]AnalyzeDocument, MaxDocumentsPerRequest+1)
+		for i := range documents {
+			documents[i] = AnalyzeDocument{ID: string(rune('a' + i)), Text: "text"}
+		}
+		response := testPost(t, s, "", documents...)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
+
+// Test_redact() unit test function tests that the redact() function masks
+// the text of each entity by its offset and length in the units of the
+// requested string index type.
+func Test_redact(t *testing.T) {
+	t.Parallel()
+
+	// the emoji is 1 code point and 2 UTF-16 code units
+	text := "\U0001F600 John Smith and John Smith"
+	tests := []struct {
+		entities   []AnalyzeEntity
+		expected   string
+		index_type string
+		name       string
+	}{
+		{
+			entities:   []AnalyzeEntity{{Length: 10, Offset: 2, Text: "John Smith"}},
+			expected:   "\U0001F600 ********** and John Smith",
+			index_type: "UnicodeCodePoint",
+			name:       "UnicodeCodePoint",
+		},
+		{
+			entities:   []AnalyzeEntity{{Length: 10, Offset: 3, Text: "John Smith"}},
+			expected:   "\U0001F600 ********** and John Smith",
+			index_type: StringIndexTypeUtf16CodeUnit,
+			name:       "Utf16CodeUnit",
+		},
+		{
+			// code point offsets are wrong in UTF-16 code units
+			entities:   []AnalyzeEntity{{Length: 10, Offset: 2, Text: "John Smith"}},
+			expected:   "\U0001F600**********h and John Smith",
+			index_type: StringIndexTypeUtf16CodeUnit,
+			name:       "Utf16CodeUnit_Wrong_Offset",
+		},
+		{
+			entities:   []AnalyzeEntity{{Length: 10, Offset: 22, Text: "Smith"}},
+			expected:   "\U0001F600 John Smith and John *****",
+			index_type: "UnicodeCodePoint",
+			name:       "Beyond_End",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, redact(text, test.index_type, test.entities))
+		})
+	}
+}
+
+// TestServer_Key() unit test function tests that the Server rejects requests
+// without the expected subscription key.
+func TestServer_Key(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer()
+	defer s.Close()
+	s.Key = "test-key"
+
+	response := testPost(t, s, "", AnalyzeDocument{ID: "1", Text: "text"})
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Empty(t, s.Requests())
+}
+
+// TestServer_Times() unit test function tests that a Fixture with a Times
+// limit stops applying after the limit is reached.
+func TestServer_Times(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(Fixture{Match: "retry", Status: http.StatusTooManyRequests, Times: 1})
+	defer s.Close()
+
+	first := testPost(t, s, "", AnalyzeDocument{ID: "1", Text: "retry"})
+	first.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, first.StatusCode)
+
+	second := testPost(t, s, "", AnalyzeDocument{ID: "1", Text: "retry"})
+	second.Body.Close()
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Len(t, s.Requests(), 2)
+}
@@ -0,0 +1,159 @@
+package aztest
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Fixture struct defines how the Server responds to documents that match
+// the ID and/or Match fields of the Fixture. A Fixture either applies to a
+// single document (Entities, Error, Warnings) or fails the entire request
+// that contains a matching document (Status, Malformed).
+type Fixture struct {
+	// ID matches documents with exactly this ID.
+	ID string `json:"id"`
+	// Match matches documents whose text contains this substring.
+	Match string `json:"match"`
+	// Times limits the number of matches that the Fixture applies to,
+	// where 0 means the Fixture always applies.
+	Times int `json:"times"`
+
+	// Entities are returned for every occurrence of their text within a
+	// matching document, with offsets calculated by the Server.
+	Entities []FixtureEntity `json:"entities"`
+	// Error causes a matching document to be returned in the "errors"
+	// list of the results instead of the "documents" list.
+	Error *FixtureError `json:"error"`
+	// Warnings are added to the response for a matching document.
+	Warnings []AnalyzeWarning `json:"warnings"`
+	// Language is the ISO 639-1 code of the language detected for a
+	// matching document in "LanguageDetection" requests.
+	Language string `json:"language"`
+
+	// Status fails the whole request with the HTTP status code when set.
+	Status int `json:"status"`
+	// RetryAfter sets the "Retry-After" header (in seconds) sent with Status.
+	RetryAfter int `json:"retry_after"`
+	// Malformed responds to the whole request with an HTTP 200 status and
+	// a body that cannot be parsed as JSON.
+	Malformed bool `json:"malformed"`
+}
+
+// FixtureEntity struct defines an entity that the Server reports when its
+// Text is found within a document.
+type FixtureEntity struct {
+	Category        string  `json:"category"`
+	ConfidenceScore float64 `json:"confidenceScore"`
+	Subcategory     string  `json:"subcategory"`
+	Text            string  `json:"text"`
+}
+
+// FixtureError struct defines the document error reported by the Server.
+type FixtureError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// isRequestLevel() method returns true if the Fixture fails the entire request.
+func (f *Fixture) isRequestLevel() bool {
+	return f.Status != 0 || f.Malformed
+}
+
+// writeRequestLevel() method writes the response for a request-level Fixture.
+func (f *Fixture) writeRequestLevel(w http.ResponseWriter) {
+	if f.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(MalformedBody))
+		return
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+	}
+	writeError(w, f.Status, strconv.Itoa(f.Status), http.StatusText(f.Status))
+}
+
+// AnalyzeRequest struct is the body of a request sent to the Server.
+type AnalyzeRequest struct {
+	Kind          string `json:"kind"`
+	AnalysisInput struct {
+		Documents []AnalyzeDocument `json:"documents"`
+	} `json:"analysisInput"`
+	Parameters struct {
+		Domain          string   `json:"domain"`
+		LoggingOptOut   bool     `json:"loggingOptOut"`
+		ModelVersion    string   `json:"modelVersion"`
+		PiiCategories   []string `json:"piiCategories"`
+		StringIndexType string   `json:"stringIndexType"`
+	} `json:"parameters"`
+}
+
+// AnalyzeDocument struct is a single document within an AnalyzeRequest.
+type AnalyzeDocument struct {
+	ID       string `json:"id"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// AnalyzeResponse struct is the body of a successful response.
+type AnalyzeResponse struct {
+	Kind    string         `json:"kind"`
+	Results AnalyzeResults `json:"results"`
+}
+
+type AnalyzeResults struct {
+	Documents    []AnalyzeDocumentResult   `json:"documents"`
+	Errors       []AnalyzeDocumentError    `json:"errors"`
+	ModelVersion string                    `json:"modelVersion"`
+	Statistics   *AnalyzeRequestStatistics `json:"statistics,omitempty"`
+}
+
+type AnalyzeDocumentResult struct {
+	DetectedLanguage *AnalyzeDetectedLanguage   `json:"detectedLanguage,omitempty"`
+	Entities         []AnalyzeEntity            `json:"entities,omitempty"`
+	ID               string                     `json:"id"`
+	RedactedText     string                     `json:"redactedText,omitempty"`
+	Statistics       *AnalyzeDocumentStatistics `json:"statistics,omitempty"`
+	Warnings         []AnalyzeWarning           `json:"warnings"`
+}
+
+type AnalyzeDetectedLanguage struct {
+	ConfidenceScore float64 `json:"confidenceScore"`
+	ISO6391Name     string  `json:"iso6391Name"`
+	Name            string  `json:"name"`
+}
+
+type AnalyzeDocumentError struct {
+	ID    string `json:"id"`
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type AnalyzeDocumentStatistics struct {
+	CharactersCount   int `json:"charactersCount"`
+	TransactionsCount int `json:"transactionsCount"`
+}
+
+type AnalyzeEntity struct {
+	Category        string  `json:"category"`
+	ConfidenceScore float64 `json:"confidenceScore"`
+	Length          int     `json:"length"`
+	Offset          int     `json:"offset"`
+	Subcategory     string  `json:"subcategory,omitempty"`
+	Text            string  `json:"text"`
+}
+
+type AnalyzeRequestStatistics struct {
+	DocumentsCount          int `json:"documentsCount"`
+	ErroneousDocumentsCount int `json:"erroneousDocumentsCount"`
+	TransactionsCount       int `json:"transactionsCount"`
+	ValidDocumentsCount     int `json:"validDocumentsCount"`
+}
+
+type AnalyzeWarning struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	TargetRef string `json:"targetRef,omitempty"`
+}
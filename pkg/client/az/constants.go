@@ -3,8 +3,20 @@ package az
 import "time"
 
 const DefaultDetectionApi string = "language/:analyze-text?api-version=2022-05-01"
+const DefaultDomain string = "phi"
 const DefaultLanguage string = "en"
+const DefaultModelVersion string = "latest"
+const DefaultPiiCategory string = "Default"
+const DefaultStringIndexType string = "UnicodeCodePoint"
 const DocumentCharacterLimit int = 5000
+const ErrMsgStatusCode string = "Azure AI Language service returned status code %d : %s"
+const ErrMsgNoDocumentResponse string = "no response for document from Azure AI Language service"
+const ErrMsgPing string = "failed to reach Azure AI Language service"
+const KindLanguageDetection string = "LanguageDetection"
+const KindPiiEntityRecognition string = "PiiEntityRecognition"
+const LanguageUnknown string = "(Unknown)"
+const MetricRequestErrors string = "azure_ai.requests.errors"
+const MetricRequests string = "azure_ai.requests"
 const RequestDocumentLimit int = 5
 const RequestTimerDuration time.Duration = time.Second * 5
 const ShowStatsParam string = "&showStats=true"
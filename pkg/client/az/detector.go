@@ -6,6 +6,7 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/filter"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
 )
 
@@ -48,6 +49,17 @@ func (detector *AzAiLanguagePhiDetector) Run(
 
 	document_requests := make([]DocumentRequestWrapper, 0)
 
+	// sendResponse sends the response to the output channel and returns true,
+	// or returns false if the context is done before the response is received
+	sendResponse := func(response rrr.Response) bool {
+		select {
+		case chan_responses_out <- response:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
 	processDocumentRequests := func() {
 		if len(document_requests) == 0 {
 			return
@@ -64,36 +76,81 @@ func (detector *AzAiLanguagePhiDetector) Run(
 			documents = append(documents, *document_request.Document)
 		}
 		// create a new PiiEntityRecognitionRequest to send to AZ API
-		pii_request := NewPiiEntityRecognitionRequest(documents)
+		pii_request := detector.ai.NewPiiEntityRecognitionRequest(documents)
 		// send the request to AZ API and await the results
 		pii_results, err := detector.ai.requestAiResponse(ctx, pii_request)
 		if err != nil {
-			// send an error via the response channel
-			// TODO
+			// the requests of a cancelled scan are not answered, so that they
+			// are scanned again when the scan is resumed
+			if ctx.Err() != nil {
+				logger.Warn().Msgf("cancelled entity recognition request for %d documents", len(documents))
+				return
+			}
+			logger.Error().Err(err).Msgf("failed entity recognition request for %d documents", len(documents))
+			// send an error response for each request in the failed batch
+			for _, document_request := range document_requests_pending {
+				if !sendResponse(rrr.NewErrorResponse(document_request.Request, err.Error())) {
+					return
+				}
+			}
+			return
+		}
+		// track the usage of the service for the repository of each request
+		repository_ids := make(map[string]string, len(document_requests_pending))
+		for _, document_request := range document_requests_pending {
+			repository_ids[document_request.Request.ID] = document_request.Request.Repository.ID
 		}
+		detector.ai.trackUsage(pii_results, repository_ids)
+		// keep track of the requests that received a response
+		responded := make(map[string]bool)
 		// split the pii_results into individual responses
 		for _, document_response := range pii_results.Results.Documents {
 			// find the original request for the document response
-			var original_request *rrr.Request
 			for _, document_request := range document_requests_pending {
 				if document_request.Request.ID == document_response.ID {
-					original_request = document_request.Request
 					// convert and send the response to output channel
-					chan_responses_out <- convertDocumentResponseToResponse(
+					if !sendResponse(convertDocumentResponseToResponse(
+						ctx,
 						detector.ai.endpoint,
-						original_request,
+						detector.ai.filter,
+						document_request.Request,
 						&document_response,
-					)
+					)) {
+						return
+					}
+					responded[document_request.Request.ID] = true
+					break
+				}
+			}
+		}
+		// send an error response for each document rejected by the service
+		for _, document_error := range pii_results.Results.Errors {
+			for _, document_request := range document_requests_pending {
+				if document_request.Request.ID == document_error.ID {
+					if !sendResponse(rrr.NewErrorResponse(
+						document_request.Request,
+						document_error.Error.Code+" : "+document_error.Error.Message,
+					)) {
+						return
+					}
+					responded[document_request.Request.ID] = true
 					break
 				}
 			}
 		}
 		// handle any remnants from document_requests_pending
 		// by sending an error response for each (orphaned) request
-		// TODO
+		for _, document_request := range document_requests_pending {
+			if !responded[document_request.Request.ID] {
+				if !sendResponse(rrr.NewErrorResponse(document_request.Request, ErrMsgNoDocumentResponse)) {
+					return
+				}
+			}
+		}
 	}
 
 	timer := time.NewTimer(RequestTimerDuration)
+	defer timer.Stop()
 
 	for {
 		select {
@@ -102,7 +159,7 @@ func (detector *AzAiLanguagePhiDetector) Run(
 			// exit the function when the context is done
 			return
 		case request := <-chan_requests_in:
-			document_requests = append(document_requests, wrapDocumentRequest(&request))
+			document_requests = append(document_requests, detector.wrapDocumentRequest(&request))
 			if len(document_requests) >= RequestDocumentLimit {
 				processDocumentRequests()
 			}
@@ -120,9 +177,12 @@ func (detector *AzAiLanguagePhiDetector) Run(
 
 // convertDocumentResponseToResponse() function converts from a DocumentResponse
 // struct to a rrr.Response struct, using the original rrr.Request struct to
-// initialize the new rrr.Response struct.
+// initialize the new rrr.Response struct. Only the results that are kept by
+// the provided ResultFilter are included in the rrr.Response.
 func convertDocumentResponseToResponse(
+	ctx context.Context,
 	endpoint string,
+	result_filter *filter.ResultFilter,
 	request *rrr.Request,
 	doc_response *DocumentResponse,
 ) rrr.Response {
@@ -136,6 +196,16 @@ func convertDocumentResponseToResponse(
 		response.Results = append(response.Results, result)
 	}
 
+	var discarded int
+	response.Results, discarded = result_filter.FilterResults(response.Results)
+	if discarded > 0 {
+		zerolog.Ctx(ctx).Trace().Msgf("discarded %d results for request ID %s", discarded, request.ID)
+	} else if len(response.Results) > 0 {
+		// the redacted text from the service masks every detected entity,
+		// so it only matches the results when none have been discarded
+		response.RedactedText = doc_response.RedactedText
+	}
+
 	return response
 }
 
@@ -154,8 +224,10 @@ func convertEntitytToResult(endpoint string, entity Entity) rrr.Result {
 	}
 }
 
-func wrapDocumentRequest(request *rrr.Request) DocumentRequestWrapper {
-	document := NewDocument(request.ID, request.Text, "")
+// wrapDocumentRequest() method returns a new DocumentRequestWrapper for the
+// request, with a Document created by the EntityDetectionAI of the detector.
+func (detector *AzAiLanguagePhiDetector) wrapDocumentRequest(request *rrr.Request) DocumentRequestWrapper {
+	document := detector.ai.NewDocument(request.ID, request.Text)
 	return DocumentRequestWrapper{
 		Document: &document,
 		Request:  request,
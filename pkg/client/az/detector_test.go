@@ -0,0 +1,121 @@
+package az
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/filter"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestAzAiLanguagePhiDetector_Run() unit test function tests the Run()
+// method of the AzAiLanguagePhiDetector struct against a local aztest.Server.
+func TestAzAiLanguagePhiDetector_Run(t *testing.T) {
+	t.Parallel()
+
+	server, server_err := aztest.NewServerFromFile("./aztest/testdata/fixtures.json")
+	if !assert.NoError(t, server_err) {
+		t.FailNow()
+	}
+	defer server.Close()
+
+	tests := []struct {
+		expected_errors  int
+		expected_results int
+		name             string
+		texts            []string
+	}{
+		{
+			expected_errors:  1,
+			expected_results: 2,
+			name:             "Batch_Results_And_Document_Error",
+			texts: []string{
+				"Patient John Smith was discharged",
+				"invalid-document",
+				"SSN 555-01-2345",
+				"nothing to see here",
+				"still nothing",
+			},
+		},
+		{
+			expected_errors:  RequestDocumentLimit,
+			expected_results: 0,
+			name:             "Batch_Too_Many_Requests",
+			texts: []string{
+				"rate-limit",
+				"Patient John Smith was discharged",
+				"three",
+				"four",
+				"five",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			detector := NewAzAiLanguagePhiDetector(testNewEntityDetectionAI(t, server))
+			chan_requests := make(chan rrr.Request)
+			chan_responses := make(chan rrr.Response)
+			go detector.Run(ctx, chan_requests, chan_responses)
+
+			// send a full batch of requests so that the detector does not
+			// wait for the timer before sending the batch to the service
+			go func() {
+				for i, text := range test.texts {
+					request, err := rrr.NewRequest("test_repo", "test_commit", fmt.Sprintf("test_object_%d", i), text)
+					assert.NoError(t, err)
+					chan_requests <- request
+				}
+			}()
+
+			var errors, results int
+			for range test.texts {
+				response := <-chan_responses
+				if response.Error != "" {
+					errors++
+				}
+				results += len(response.Results)
+				for _, result := range response.Results {
+					assert.Equal(t, detector.ai.GetServiceEndpoint(), result.Service)
+				}
+			}
+			assert.Equal(t, test.expected_errors, errors)
+			assert.Equal(t, test.expected_results, results)
+		})
+	}
+}
+
+// Test_convertDocumentResponseToResponse() unit test function tests that the
+// convertDocumentResponseToResponse() function only keeps the results that
+// pass the ResultFilter.
+func Test_convertDocumentResponseToResponse(t *testing.T) {
+	t.Parallel()
+
+	request, err := rrr.NewRequest("test_repo", "test_commit", "test_object", "text")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	doc_response := &DocumentResponse{
+		ID: request.ID,
+		Entities: []Entity{
+			{Category: "Person", ConfidenceScore: 0.95, Text: "John Smith"},
+			{Category: "Person", ConfidenceScore: 0.4, Text: "Smith"},
+			{Category: "DateTime", ConfidenceScore: 0.99, Text: "today"},
+			{Category: "Address", ConfidenceScore: 0.7, Text: "Seattle"},
+		},
+	}
+	result_filter := filter.NewResultFilter(0.6, map[string]float64{"Address": 0.8}, []string{"DateTime"})
+
+	response := convertDocumentResponseToResponse(context.Background(), "test_endpoint", result_filter, &request, doc_response)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "John Smith", response.Results[0].Text)
+		assert.Equal(t, "test_endpoint", response.Results[0].Service)
+	}
+}
@@ -7,22 +7,34 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
+	metrics "github.com/rcrowley/go-metrics"
 	"github.com/rs/zerolog/log"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/filter"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/usage"
 )
 
 // EntityDetectionAI struct provides methods for (1) sending requests to
 // detect entities of interest in natural language documents and (2) processing
 // responses from the Azure AI Language service.
 type EntityDetectionAI struct {
-	client     *http.Client
-	confidence float64
-	dryRun     bool
-	endpoint   string
-	key        string
+	client            *http.Client
+	dryRun            bool
+	endpoint          string
+	filter            *filter.ResultFilter
+	key               string
+	language          string
+	languageDetection string
+	parameters        Parameters
+	registry          metrics.Registry
+	usage             *usage.Tracker
 }
 
 // NewEntityDetectionAI() function requires the Azure service host and
@@ -45,15 +57,66 @@ func NewEntityDetectionAI(c *cfg.Config) (*EntityDetectionAI, error) {
 		getDefaultDetectionApi(c.AzureAI.ShowStats),
 	)
 
+	// use the configured request parameters, falling back to the defaults
+	// for any parameters that are not configured
+	parameters := NewDefaultParameters()
+	if c.AzureAI.Domain != "" {
+		parameters.Domain = c.AzureAI.Domain
+	}
+	if c.AzureAI.ModelVersion != "" {
+		parameters.ModelVersion = c.AzureAI.ModelVersion
+	}
+	if len(c.AzureAI.PiiCategories) > 0 {
+		parameters.PiiCategories = c.AzureAI.PiiCategories
+	}
+	language := c.AzureAI.Language
+	if language == "" {
+		language = DefaultLanguage
+	}
+	language_detection := c.AzureAI.LanguageDetection
+	if language_detection == "" {
+		language_detection = cfg.AzureAILanguageDetectionNone
+	}
+
 	return &EntityDetectionAI{
-		client:     &http.Client{},
-		confidence: c.AzureAI.ConfidenceThreshold,
-		dryRun:     c.AzureAI.DryRun,
-		endpoint:   endpoint,
-		key:        c.AzureAI.AuthKey,
+		client:            &http.Client{},
+		dryRun:            c.AzureAI.DryRun,
+		endpoint:          endpoint,
+		filter:            filter.NewResultFilterFromConfig(c),
+		key:               c.AzureAI.AuthKey,
+		language:          language,
+		languageDetection: language_detection,
+		parameters:        parameters,
+		registry:          metrics.DefaultRegistry,
+		usage:             usage.NewTracker(c.AzureAI.PricePer1kRecords, metrics.DefaultRegistry),
 	}, nil
 }
 
+// NewDocument() method returns a new Document with the provided ID and text,
+// where the language of the Document depends upon the language detection
+// mode of the EntityDetectionAI:
+//   - "none" uses the default language;
+//   - "local" uses a local heuristic, falling back to the default language;
+//   - "azure" leaves the language empty, such that it is detected by the
+//     Azure AI Language service when the Document is sent in a request.
+func (ai *EntityDetectionAI) NewDocument(id, text string) Document {
+	switch ai.languageDetection {
+	case cfg.AzureAILanguageDetectionAzure:
+		return Document{ID: id, Text: text}
+	case cfg.AzureAILanguageDetectionLocal:
+		return NewDocument(id, text, detectLanguageLocal(text, ai.language))
+	default:
+		return NewDocument(id, text, ai.language)
+	}
+}
+
+// NewPiiEntityRecognitionRequest() method returns a new request to detect PII
+// entities in the provided documents, using the request parameters of the
+// EntityDetectionAI.
+func (ai *EntityDetectionAI) NewPiiEntityRecognitionRequest(documents []Document) *PiiEntityRecognitionRequest {
+	return NewPiiEntityRecognitionRequest(documents, ai.parameters)
+}
+
 // DetectPiiEntities() method accepts a PiiEntityRecognitionRequest as
 // input and returns an error (or nil) status depending upon the
 // PiiEntityRecognitionResponse from the Azure AI language service.
@@ -69,45 +132,95 @@ func (ai *EntityDetectionAI) DetectPiiEntities(
 		e = errors.Wrap(entity_recognition_err, "error requesting AI API response")
 		return
 	}
+	// the documents of the request are not associated with a repository
+	ai.trackUsage(entity_recognition_results, nil)
 
 	for _, doc := range entity_recognition_results.Results.Documents {
 		var entities []Entity
 		for _, entity := range doc.Entities {
-			if entity.ConfidenceScore >= ai.confidence {
+			if ai.filter.Allow(entity.Category, entity.ConfidenceScore) {
 				entities = append(entities, entity)
 			}
 		}
 		if len(entities) > 0 {
-			// set detected to true if any entities are found over the confidence threshold
+			// set detected to true if any entities are kept by the result filter
 			detected = true
 
-			// TODO : remove/replace processing of entities
-			result := struct {
-				ID       string   `json:"id"`
-				Entities []Entity `json:"entities"`
-			}{
-				ID:       doc.ID,
-				Entities: entities,
-			}
-			var resultBytes []byte
-			resultBytes, e = json.MarshalIndent(result, "", "  ")
-			if e != nil {
-				log.Ctx(ctx).Error().Msgf("error marshalling result within entity detection response: %s", e)
-				return
+			// log the categories of the entities, but never their text
+			categories := make([]string, 0, len(entities))
+			for _, entity := range entities {
+				categories = append(categories, entity.Category)
 			}
-			// TODO : remove debug logging of resultBytes
-			log.Ctx(ctx).Debug().Msgf("entity detection result:\n%s", string(resultBytes))
+			log.Ctx(ctx).Debug().Msgf(
+				"entity detection result : document ID=%s : %d entities : categories=%s",
+				doc.ID,
+				len(entities),
+				strings.Join(categories, ","),
+			)
 		}
 	}
 
 	return
 }
 
+// GetResultFilter() method returns the ResultFilter used to discard results
+// below the confidence thresholds or in ignored categories.
+func (ai *EntityDetectionAI) GetResultFilter() *filter.ResultFilter {
+	return ai.filter
+}
+
+// GetUsageTracker() method returns the Tracker of the usage of the Azure AI
+// Language service, including the usage estimated in dry run mode.
+func (ai *EntityDetectionAI) GetUsageTracker() *usage.Tracker {
+	return ai.usage
+}
+
 // GetServiceEndpoint() method return the full URL of the service API endpoint
 func (ai *EntityDetectionAI) GetServiceEndpoint() string {
 	return ai.endpoint
 }
 
+// Ping() method checks that the Azure AI Language service is reachable and
+// accepts the authentication key of the EntityDetectionAI, by sending a
+// request without any documents (i.e. that is not billed). Returns an error
+// if the request fails, the key is rejected or the service returns a server
+// error. Always succeeds in dry run mode, since no requests are sent.
+func (ai *EntityDetectionAI) Ping(ctx context.Context) error {
+	if ai.dryRun {
+		return nil
+	}
+	request_bytes, err := json.Marshal(ai.NewPiiEntityRecognitionRequest([]Document{}))
+	if err != nil {
+		return errors.Wrap(err, ErrMsgPing)
+	}
+	http_request, err := http.NewRequestWithContext(ctx, http.MethodPost, ai.endpoint, bytes.NewBuffer(request_bytes))
+	if err != nil {
+		return errors.Wrap(err, ErrMsgPing)
+	}
+	ai.setHttpRequestHeaders(http_request)
+
+	http_response, err := ai.client.Do(http_request)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgPing)
+	}
+	defer http_response.Body.Close()
+	http_response_body, _ := io.ReadAll(http_response.Body)
+
+	// any other response (e.g. 400 for the empty request) shows that the
+	// service is reachable and accepts the key
+	switch {
+	case http_response.StatusCode == http.StatusUnauthorized,
+		http_response.StatusCode == http.StatusForbidden,
+		http_response.StatusCode >= http.StatusInternalServerError:
+		return errors.Wrapf(
+			errors.Errorf(ErrMsgStatusCode, http_response.StatusCode, parseErrorMessage(http_response_body)),
+			ErrMsgPing,
+		)
+	}
+
+	return nil
+}
+
 func (ai *EntityDetectionAI) dryRunRespond(ctx context.Context, entity_request *PiiEntityRecognitionRequest) (*PiiEntityRecognitionResults, error) {
 	// create a fake response for dry run mode
 	fake_response := &PiiEntityRecognitionResults{
@@ -132,67 +245,175 @@ func (ai *EntityDetectionAI) dryRunRespond(ctx context.Context, entity_request *
 	return fake_response, nil
 }
 
-// requestAiResponse() method converts a PiiEntityRecognitionRequest to a
-// JSON byte array and sends the request to the Azure AI Language service API,
-// then converts the JSON response from the API to PiiEntityRecognitionResults.
-// This method returns an error if the request or response fails.
-func (ai *EntityDetectionAI) requestAiResponse(
-	ctx context.Context,
-	entity_request *PiiEntityRecognitionRequest,
-) (*PiiEntityRecognitionResults, error) {
-	var e error
+// detectLanguages() method sets the language of each of the provided
+// documents that has no language, using the language detection API of the
+// Azure AI Language service. The default language is used for any document
+// whose language is not detected.
+func (ai *EntityDetectionAI) detectLanguages(ctx context.Context, documents []Document) error {
+	undetected := make([]Document, 0)
+	for _, doc := range documents {
+		if doc.Language == "" {
+			undetected = append(undetected, doc)
+		}
+	}
+	if len(undetected) == 0 {
+		return nil
+	}
 
-	// check for dry run mode
-	if ai.dryRun {
-		return ai.dryRunRespond(ctx, entity_request)
+	detected := make(map[string]string)
+	if !ai.dryRun {
+		log.Ctx(ctx).Trace().Msgf("requesting language detection for %d documents", len(undetected))
+
+		var language_results LanguageDetectionResults
+		if err := ai.post(ctx, NewLanguageDetectionRequest(undetected), &language_results); err != nil {
+			return errors.Wrap(err, "failed language detection request")
+		}
+		for _, doc := range language_results.Results.Documents {
+			language := doc.DetectedLanguage.ISO6391Name
+			if language != "" && language != LanguageUnknown {
+				detected[doc.ID] = language
+			}
+		}
 	}
 
-	entity_request_bytes, err := json.Marshal(entity_request)
+	for i := range documents {
+		if documents[i].Language != "" {
+			continue
+		}
+		if language, ok := detected[documents[i].ID]; ok {
+			documents[i].Language = language
+		} else {
+			documents[i].Language = ai.language
+		}
+	}
+
+	return nil
+}
+
+// post() method converts the request_body to JSON and sends it to the Azure
+// AI Language service API, then converts the JSON response from the API into
+// the response_body. This method returns an error if the request or response
+// fails. The latency of each request is recorded by the MetricRequests timer,
+// and each failed request is counted by the MetricRequestErrors counter.
+func (ai *EntityDetectionAI) post(ctx context.Context, request_body, response_body interface{}) (e error) {
+	start := time.Now()
+	status := ""
+	defer func() {
+		ai.recordRequest(start, status, e)
+	}()
+
+	request_bytes, err := json.Marshal(request_body)
 	if err != nil {
-		e = errors.Wrap(err, "failed to marshal PiiEntityRecognitionRequest")
-		return nil, e
+		e = errors.Wrap(err, "failed to marshal request to Azure AI Language service")
+		return
 	}
 
-	http_request, err := http.NewRequestWithContext(ctx, "POST", ai.endpoint, bytes.NewBuffer(entity_request_bytes))
+	http_request, err := http.NewRequestWithContext(ctx, "POST", ai.endpoint, bytes.NewBuffer(request_bytes))
 	if err != nil {
 		e = errors.Wrap(err, "failed creating HTTP request to Azure AI Language service")
-		return nil, e
+		return
 	}
 
 	// set the required headers for the HTTP request before sending
 	ai.setHttpRequestHeaders(http_request)
 
-	log.Ctx(ctx).Trace().Msgf(
-		"requesting entity recognition for %d documents",
-		len(entity_request.AnalysisInput.Documents),
-	)
-
 	// send the HTTP request to the Azure AI Language service API
 	http_response, err := ai.client.Do(http_request)
 	if err != nil {
 		e = errors.Wrap(err, "failed sending HTTP request to Azure AI Language service")
-		return nil, e
+		return
 	}
 	defer http_response.Body.Close()
+	status = strconv.Itoa(http_response.StatusCode)
 
 	// read all bytes from the HTTP response body
 	http_response_body, err := io.ReadAll(http_response.Body)
 	if err != nil {
 		e = errors.Wrap(err, "failed reading HTTP response from Azure AI Language service")
-		return nil, e
+		return
 	}
 
-	var entity_recognition_results PiiEntityRecognitionResults
-	// unmarshal the bytes from the response body into a PiiEntityRecognitionResults
-	if e = json.Unmarshal(http_response_body, &entity_recognition_results); e != nil {
+	// check the HTTP status code before attempting to parse the results,
+	// since error responses (e.g. 401, 429) use a different structure
+	if http_response.StatusCode != http.StatusOK {
+		e = errors.Errorf(
+			ErrMsgStatusCode,
+			http_response.StatusCode,
+			parseErrorMessage(http_response_body),
+		)
+		return
+	}
+
+	// unmarshal the bytes from the response body into the response_body
+	if e = json.Unmarshal(http_response_body, response_body); e != nil {
 		e = errors.Wrap(e, "failed unmarshalling response from Azure AI Language service")
-		return nil, e
+		return
+	}
+
+	return
+}
+
+// recordRequest() method records the latency of a request to the Azure AI
+// Language service that started at the provided time, and counts the request
+// as failed if the provided error is not nil, tagged by the provided HTTP
+// status code (if any).
+func (ai *EntityDetectionAI) recordRequest(start time.Time, status string, err error) {
+	if ai.registry == nil {
+		return
+	}
+	metrics.GetOrRegisterTimer(MetricRequests, ai.registry).UpdateSince(start)
+	if err != nil {
+		if status == "" {
+			status = "none"
+		}
+		metrics.GetOrRegisterCounter(fmt.Sprintf("%s[status:%s]", MetricRequestErrors, status), ai.registry).Inc(1)
+	}
+}
+
+// requestAiResponse() method sends a PiiEntityRecognitionRequest to the Azure
+// AI Language service API and returns the PiiEntityRecognitionResults, after
+// first detecting the language of any documents in the request that have no
+// language. This method returns an error if the request or response fails.
+func (ai *EntityDetectionAI) requestAiResponse(
+	ctx context.Context,
+	entity_request *PiiEntityRecognitionRequest,
+) (*PiiEntityRecognitionResults, error) {
+	// language detection is billed separately for each document that is
+	// sent to the language detection API
+	language_transactions := make(map[string]int)
+	for _, doc := range entity_request.AnalysisInput.Documents {
+		if doc.Language == "" {
+			language_transactions[doc.ID] = usage.EstimateTransactions(utf8.RuneCountInString(doc.Text))
+		}
+	}
+	if err := ai.detectLanguages(ctx, entity_request.AnalysisInput.Documents); err != nil {
+		return nil, err
+	}
+
+	// check for dry run mode
+	if ai.dryRun {
+		results, err := ai.dryRunRespond(ctx, entity_request)
+		if err == nil {
+			setDocumentStatistics(entity_request, results, language_transactions)
+		}
+		return results, err
+	}
+
+	log.Ctx(ctx).Trace().Msgf(
+		"requesting entity recognition for %d documents",
+		len(entity_request.AnalysisInput.Documents),
+	)
+
+	var entity_recognition_results PiiEntityRecognitionResults
+	if err := ai.post(ctx, entity_request, &entity_recognition_results); err != nil {
+		return nil, err
 	}
 
 	log.Ctx(ctx).Trace().Msgf(
 		"received entity recognition results with %d document responses",
 		len(entity_recognition_results.Results.Documents),
 	)
+	setDocumentStatistics(entity_request, &entity_recognition_results, language_transactions)
 
 	return &entity_recognition_results, nil
 }
@@ -204,6 +425,59 @@ func (ai *EntityDetectionAI) setHttpRequestHeaders(req *http.Request) {
 	req.Header.Add("Content-Type", "application/json")
 }
 
+// trackUsage() method adds the usage of a single entity recognition request
+// to the usage Tracker, using the statistics of each document response. The
+// repository_ids map the ID of each document to the ID of its repository.
+func (ai *EntityDetectionAI) trackUsage(results *PiiEntityRecognitionResults, repository_ids map[string]string) {
+	usage_by_repository := make(map[string]usage.Usage)
+	for _, doc := range results.Results.Documents {
+		repository_id := repository_ids[doc.ID]
+		repository_usage := usage_by_repository[repository_id]
+		repository_usage.Characters += int64(doc.CountCharacters())
+		repository_usage.Documents++
+		repository_usage.Transactions += int64(doc.CountTransactions())
+		usage_by_repository[repository_id] = repository_usage
+	}
+	ai.usage.AddBatch(usage_by_repository)
+}
+
+// setDocumentStatistics() function estimates the statistics of each document
+// response that has no statistics (i.e. when the service was not asked to
+// show stats, or in dry run mode), then adds the transactions billed for the
+// language detection of each document to its statistics.
+func setDocumentStatistics(
+	entity_request *PiiEntityRecognitionRequest,
+	results *PiiEntityRecognitionResults,
+	language_transactions map[string]int,
+) {
+	texts := make(map[string]string, len(entity_request.AnalysisInput.Documents))
+	for _, doc := range entity_request.AnalysisInput.Documents {
+		texts[doc.ID] = doc.Text
+	}
+	for i := range results.Results.Documents {
+		doc := &results.Results.Documents[i]
+		if doc.Statistics.CharactersCount == 0 {
+			doc.Statistics.CharactersCount = utf8.RuneCountInString(texts[doc.ID])
+		}
+		if doc.Statistics.TransactionsCount == 0 {
+			doc.Statistics.TransactionsCount = usage.EstimateTransactions(doc.Statistics.CharactersCount)
+		}
+		doc.Statistics.TransactionsCount += language_transactions[doc.ID]
+	}
+}
+
+// parseErrorMessage() function returns the message from the body of an
+// error response sent by the Azure AI Language service, or the raw body
+// if it cannot be parsed.
+func parseErrorMessage(body []byte) string {
+	var error_response ErrorResponse
+	if err := json.Unmarshal(body, &error_response); err != nil || error_response.Error.Message == "" {
+		return string(body)
+	}
+
+	return error_response.Error.Code + " : " + error_response.Error.Message
+}
+
 func getDefaultDetectionApi(showStats bool) string {
 	if showStats {
 		return DefaultDetectionApi + ShowStatsParam
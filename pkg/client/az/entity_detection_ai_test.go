@@ -1,9 +1,15 @@
 package az
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
 )
 
 func TestNewEntityDetectionAI(t *testing.T) {
@@ -69,3 +75,372 @@ func Test_getDefaultDetectionApi(t *testing.T) {
 		t.Errorf("Expected result: %s, but got: %s", expectedResult, result)
 	}
 }
+
+// testNewEntityDetectionAI() helper function returns a new EntityDetectionAI
+// that sends requests to the provided aztest.Server.
+func testNewEntityDetectionAI(t *testing.T, server *aztest.Server) *EntityDetectionAI {
+	c := cfg.NewDefaultConfig()
+	c.AzureAI.AuthKey = "test-key"
+	c.AzureAI.ConfidenceThreshold = cfg.DefaultConfidenceThreshold
+	c.AzureAI.Service = server.URL
+	ai, err := NewEntityDetectionAI(c)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return ai
+}
+
+// TestEntityDetectionAI_DetectPiiEntities() unit test function tests the
+// DetectPiiEntities() method against a local aztest.Server.
+func TestEntityDetectionAI_DetectPiiEntities(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(
+		aztest.Fixture{
+			Match: "John Smith",
+			Entities: []aztest.FixtureEntity{
+				{Category: "Person", ConfidenceScore: 0.95, Text: "John Smith"},
+			},
+		},
+		aztest.Fixture{
+			Match: "Seattle",
+			Entities: []aztest.FixtureEntity{
+				{Category: "Address", ConfidenceScore: 0.2, Text: "Seattle"},
+			},
+		},
+	)
+	defer server.Close()
+	ai := testNewEntityDetectionAI(t, server)
+
+	tests := []struct {
+		expected bool
+		name     string
+		text     string
+	}{
+		{
+			expected: true,
+			name:     "Detected",
+			text:     "Patient John Smith was discharged",
+		},
+		{
+			expected: false,
+			name:     "BelowThreshold",
+			text:     "Patient lives in Seattle",
+		},
+		{
+			expected: false,
+			name:     "Clean",
+			text:     "Nothing to see here",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := ai.NewPiiEntityRecognitionRequest([]Document{ai.NewDocument("1", test.text)})
+			detected, err := ai.DetectPiiEntities(context.Background(), request)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, detected)
+		})
+	}
+}
+
+// TestEntityDetectionAI_requestAiResponse() unit test function tests the
+// requestAiResponse() method against a local aztest.Server.
+func TestEntityDetectionAI_requestAiResponse(t *testing.T) {
+	t.Parallel()
+
+	server, server_err := aztest.NewServerFromFile("./aztest/testdata/fixtures.json")
+	if !assert.NoError(t, server_err) {
+		t.FailNow()
+	}
+	defer server.Close()
+	server.Key = "test-key"
+	ai := testNewEntityDetectionAI(t, server)
+
+	tests := []struct {
+		err_contains       string
+		expected_documents int
+		expected_entities  int
+		expected_errors    int
+		expected_warnings  int
+		name               string
+		text               string
+	}{
+		{
+			expected_documents: 1,
+			expected_entities:  1,
+			name:               "Entities",
+			text:               "Patient John Smith was discharged",
+		},
+		{
+			expected_documents: 1,
+			expected_entities:  1,
+			expected_warnings:  1,
+			name:               "Warnings",
+			text:               "SSN 555-01-2345",
+		},
+		{
+			expected_errors: 1,
+			name:            "DocumentError",
+			text:            "invalid-document",
+		},
+		{
+			err_contains: "status code 429",
+			name:         "TooManyRequests",
+			text:         "rate-limit",
+		},
+		{
+			err_contains: "failed unmarshalling response",
+			name:         "Malformed",
+			text:         "malformed-body",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := ai.NewPiiEntityRecognitionRequest([]Document{ai.NewDocument("1", test.text)})
+			results, err := ai.requestAiResponse(context.Background(), request)
+			if test.err_contains != "" {
+				assert.ErrorContains(t, err, test.err_contains)
+				assert.Nil(t, results)
+				return
+			}
+			if !assert.NoError(t, err) || !assert.NotNil(t, results) {
+				t.FailNow()
+			}
+			assert.Len(t, results.Results.Documents, test.expected_documents)
+			assert.Len(t, results.Results.Errors, test.expected_errors)
+			if test.expected_documents > 0 {
+				document := results.Results.Documents[0]
+				assert.Len(t, document.Entities, test.expected_entities)
+				assert.Len(t, document.Warnings, test.expected_warnings)
+				assert.Equal(t, len(test.text), document.CountCharacters())
+				assert.Equal(t, 1, document.CountTransactions())
+			}
+		})
+	}
+
+	// the wrong key should be rejected by the server
+	ai.key = "wrong-key"
+	request := ai.NewPiiEntityRecognitionRequest([]Document{ai.NewDocument("1", "text")})
+	_, err := ai.requestAiResponse(context.Background(), request)
+	assert.ErrorContains(t, err, "status code 401")
+}
+
+// TestEntityDetectionAI_NewPiiEntityRecognitionRequest() unit test function
+// tests that the configured request parameters and document languages are
+// sent to the Azure AI Language service for each language detection mode.
+func TestEntityDetectionAI_NewPiiEntityRecognitionRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected_kinds     []string
+		expected_language  string
+		language_detection string
+		name               string
+	}{
+		{
+			expected_kinds:     []string{KindPiiEntityRecognition},
+			expected_language:  "fr",
+			language_detection: cfg.AzureAILanguageDetectionNone,
+			name:               "None",
+		},
+		{
+			expected_kinds:     []string{KindPiiEntityRecognition},
+			expected_language:  "es",
+			language_detection: cfg.AzureAILanguageDetectionLocal,
+			name:               "Local",
+		},
+		{
+			expected_kinds:     []string{KindLanguageDetection, KindPiiEntityRecognition},
+			expected_language:  "pt",
+			language_detection: cfg.AzureAILanguageDetectionAzure,
+			name:               "Azure",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := aztest.NewServer(aztest.Fixture{Match: "paciente", Language: "pt"})
+			defer server.Close()
+
+			c := cfg.NewDefaultConfig()
+			c.AzureAI.AuthKey = "test-key"
+			c.AzureAI.Domain = cfg.AzureAIDomainNone
+			c.AzureAI.Language = "fr"
+			c.AzureAI.LanguageDetection = test.language_detection
+			c.AzureAI.ModelVersion = "2023-04-15-preview"
+			c.AzureAI.PiiCategories = []string{"Person", "USSocialSecurityNumber"}
+			c.AzureAI.Service = server.URL
+			ai, err := NewEntityDetectionAI(c)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			request := ai.NewPiiEntityRecognitionRequest([]Document{
+				ai.NewDocument("1", "El paciente está en la sala de espera con su familia"),
+			})
+			_, err = ai.requestAiResponse(context.Background(), request)
+			assert.NoError(t, err)
+
+			requests := server.Requests()
+			if !assert.Len(t, requests, len(test.expected_kinds)) {
+				t.FailNow()
+			}
+			for i, kind := range test.expected_kinds {
+				assert.Equal(t, kind, requests[i].Kind)
+			}
+			pii_request := requests[len(requests)-1]
+			assert.Equal(t, test.expected_language, pii_request.AnalysisInput.Documents[0].Language)
+			assert.Equal(t, cfg.AzureAIDomainNone, pii_request.Parameters.Domain)
+			assert.Equal(t, "2023-04-15-preview", pii_request.Parameters.ModelVersion)
+			assert.Equal(t, []string{"Person", "USSocialSecurityNumber"}, pii_request.Parameters.PiiCategories)
+			assert.Equal(t, DefaultStringIndexType, pii_request.Parameters.StringIndexType)
+		})
+	}
+}
+
+// TestEntityDetectionAI_trackUsage() unit test function tests that the usage
+// of the Azure AI Language service is tracked for each repository, using the
+// statistics returned by the service or estimated when there are none.
+func TestEntityDetectionAI_trackUsage(t *testing.T) {
+	t.Parallel()
+
+	long_text := strings.Repeat("a", 1500)
+
+	tests := []struct {
+		dry_run               bool
+		expected_characters   int64
+		expected_transactions int64
+		language_detection    string
+		name                  string
+		show_stats            bool
+	}{
+		{
+			expected_characters:   1504,
+			expected_transactions: 3,
+			language_detection:    cfg.AzureAILanguageDetectionNone,
+			name:                  "ShowStats",
+			show_stats:            true,
+		},
+		{
+			expected_characters:   1504,
+			expected_transactions: 3,
+			language_detection:    cfg.AzureAILanguageDetectionNone,
+			name:                  "Estimated",
+		},
+		{
+			dry_run:               true,
+			expected_characters:   1504,
+			expected_transactions: 3,
+			language_detection:    cfg.AzureAILanguageDetectionNone,
+			name:                  "DryRun",
+		},
+		{
+			dry_run:               true,
+			expected_characters:   1504,
+			expected_transactions: 6,
+			language_detection:    cfg.AzureAILanguageDetectionAzure,
+			name:                  "DryRun_LanguageDetection",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := aztest.NewServer()
+			defer server.Close()
+
+			c := cfg.NewDefaultConfig()
+			c.AzureAI.AuthKey = "test-key"
+			c.AzureAI.DryRun = test.dry_run
+			c.AzureAI.LanguageDetection = test.language_detection
+			c.AzureAI.PricePer1kRecords = 2.0
+			c.AzureAI.Service = server.URL
+			c.AzureAI.ShowStats = test.show_stats
+			ai, err := NewEntityDetectionAI(c)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			request := ai.NewPiiEntityRecognitionRequest([]Document{
+				ai.NewDocument("1", "text"),
+				ai.NewDocument("2", long_text),
+			})
+			results, err := ai.requestAiResponse(context.Background(), request)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			ai.trackUsage(results, map[string]string{"1": "repo_a", "2": "repo_b"})
+
+			if test.dry_run {
+				assert.Empty(t, server.Requests())
+			}
+			total := ai.GetUsageTracker().Total()
+			assert.Equal(t, int64(1), total.Batches)
+			assert.Equal(t, int64(2), total.Documents)
+			assert.Equal(t, test.expected_characters, total.Characters)
+			assert.Equal(t, test.expected_transactions, total.Transactions)
+			assert.InDelta(t, float64(test.expected_transactions)*2.0/1000, total.EstimatedCost, 1e-9)
+			assert.Equal(t, []string{"repo_a", "repo_b"}, ai.GetUsageTracker().Repositories())
+			assert.Equal(t, int64(4), ai.GetUsageTracker().Get("repo_a").Characters)
+		})
+	}
+}
+
+// TestEntityDetectionAI_post_metrics() unit test function tests that the
+// latency of every request to the Azure AI Language service is recorded, and
+// that failed requests are counted by status code.
+func TestEntityDetectionAI_post_metrics(t *testing.T) {
+	t.Parallel()
+
+	server, server_err := aztest.NewServerFromFile("./aztest/testdata/fixtures.json")
+	if !assert.NoError(t, server_err) {
+		t.FailNow()
+	}
+	defer server.Close()
+	server.Key = "test-key"
+	ai := testNewEntityDetectionAI(t, server)
+	ai.registry = metrics.NewRegistry()
+
+	for _, text := range []string{"Patient John Smith was discharged", "rate-limit", "rate-limit"} {
+		request := ai.NewPiiEntityRecognitionRequest([]Document{ai.NewDocument("1", text)})
+		ai.requestAiResponse(context.Background(), request)
+	}
+	server.Close()
+	request := ai.NewPiiEntityRecognitionRequest([]Document{ai.NewDocument("1", "text")})
+	_, err := ai.requestAiResponse(context.Background(), request)
+	assert.Error(t, err)
+
+	assert.Equal(t, int64(4), metrics.GetOrRegisterTimer(MetricRequests, ai.registry).Count())
+	assert.Equal(t, int64(2), metrics.GetOrRegisterCounter(MetricRequestErrors+"[status:429]", ai.registry).Count())
+	assert.Equal(t, int64(1), metrics.GetOrRegisterCounter(MetricRequestErrors+"[status:none]", ai.registry).Count())
+}
+
+// TestEntityDetectionAI_Ping() unit test function tests checking that the
+// Azure AI Language service is reachable and accepts the authentication key.
+func TestEntityDetectionAI_Ping(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer()
+	defer server.Close()
+	server.Key = "test-key"
+	ai := testNewEntityDetectionAI(t, server)
+
+	assert.NoError(t, ai.Ping(context.Background()))
+	// the request of a ping contains no (billed) documents
+	if assert.Len(t, server.Requests(), 1) {
+		assert.Empty(t, server.Requests()[0].AnalysisInput.Documents)
+	}
+
+	ai.key = "wrong-key"
+	assert.ErrorContains(t, ai.Ping(context.Background()), "status code 401")
+
+	server.Close()
+	ai.key = "test-key"
+	assert.ErrorContains(t, ai.Ping(context.Background()), ErrMsgPing)
+
+	// no requests are sent in dry run mode
+	ai.dryRun = true
+	assert.NoError(t, ai.Ping(context.Background()))
+}
@@ -0,0 +1,113 @@
+package az
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageScripts maps the unicode scripts that (mostly) identify a single
+// language to the ISO 639-1 code of that language.
+var languageScripts = []struct {
+	language string
+	script   *unicode.RangeTable
+}{
+	{language: "ar", script: unicode.Arabic},
+	{language: "el", script: unicode.Greek},
+	{language: "he", script: unicode.Hebrew},
+	{language: "hi", script: unicode.Devanagari},
+	{language: "ja", script: unicode.Hiragana},
+	{language: "ja", script: unicode.Katakana},
+	{language: "ko", script: unicode.Hangul},
+	{language: "ru", script: unicode.Cyrillic},
+	{language: "th", script: unicode.Thai},
+	{language: "zh-hans", script: unicode.Han},
+}
+
+// languageStopwords maps the ISO 639-1 code of languages written in the Latin
+// script to a set of their most common (and most distinctive) words.
+var languageStopwords = map[string]map[string]bool{
+	"de": newWordSet("der", "die", "und", "nicht", "das", "ist", "mit", "ein", "eine", "für", "auf", "den", "ich", "sie"),
+	"en": newWordSet("the", "and", "is", "of", "to", "was", "with", "for", "that", "this", "are", "be", "on", "have"),
+	"es": newWordSet("el", "la", "los", "las", "y", "que", "es", "del", "por", "con", "una", "para", "su", "está"),
+	"fr": newWordSet("le", "la", "les", "et", "est", "des", "une", "dans", "pour", "que", "pas", "sur", "avec", "au"),
+	"it": newWordSet("il", "di", "che", "e", "gli", "della", "per", "una", "sono", "con", "non", "nel", "è", "lo"),
+	"nl": newWordSet("de", "het", "een", "en", "van", "is", "niet", "dat", "op", "met", "voor", "zijn", "ik", "te"),
+	"pt": newWordSet("o", "os", "as", "e", "que", "do", "da", "em", "não", "uma", "com", "para", "é", "dos"),
+}
+
+// detectLanguageLocal() function returns the ISO 639-1 code of the language
+// that the text is most likely written in, or the fallback language if the
+// language cannot be determined. Text written in a distinctive script is
+// identified by the script, while text written in the Latin script is
+// identified by counting common words of each supported language.
+func detectLanguageLocal(text, fallback string) string {
+	script_counts := make(map[string]int)
+	var letters, latin int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, language_script := range languageScripts {
+			if unicode.Is(language_script.script, r) {
+				script_counts[language_script.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return fallback
+	}
+
+	// Japanese text mixes kana with Han characters, so any kana at all
+	// identifies the text as Japanese rather than Chinese
+	if script_counts["ja"] > 0 && script_counts["zh-hans"] > 0 {
+		script_counts["ja"] += script_counts["zh-hans"]
+		delete(script_counts, "zh-hans")
+	}
+	if language, count := maxCount(script_counts); count > latin {
+		return language
+	}
+
+	word_counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for language, stopwords := range languageStopwords {
+			if stopwords[word] {
+				word_counts[language]++
+			}
+		}
+	}
+	if language, count := maxCount(word_counts); count > 0 && count > word_counts[fallback] {
+		return language
+	}
+
+	return fallback
+}
+
+// maxCount() function returns the key with the highest count, using the
+// lowest key to break ties so that the result is deterministic.
+func maxCount(counts map[string]int) (max_key string, max_count int) {
+	for key, count := range counts {
+		if count > max_count || (count == max_count && key < max_key) {
+			max_key, max_count = key, count
+		}
+	}
+
+	return
+}
+
+// newWordSet() function returns a set of the provided words.
+func newWordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+
+	return set
+}
@@ -0,0 +1,36 @@
+package az
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_detectLanguageLocal() unit test function tests the
+// detectLanguageLocal() function.
+func Test_detectLanguageLocal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected string
+		name     string
+		text     string
+	}{
+		{expected: "en", name: "English", text: "The patient was discharged with a prescription for the pain"},
+		{expected: "es", name: "Spanish", text: "El paciente está en la sala de espera con su familia"},
+		{expected: "fr", name: "French", text: "Le patient est dans la salle avec les médecins pour une visite"},
+		{expected: "de", name: "German", text: "Der Patient ist nicht mit der Familie und die Ärzte sind da"},
+		{expected: "ru", name: "Cyrillic", text: "Пациент был выписан из больницы"},
+		{expected: "ja", name: "Japanese", text: "患者は病院から退院しました"},
+		{expected: "zh-hans", name: "Chinese", text: "病人已出院"},
+		{expected: "ko", name: "Korean", text: "환자가 퇴원했습니다"},
+		{expected: "en", name: "Fallback_No_Letters", text: "555-01-2345"},
+		{expected: "en", name: "Fallback_No_Stopwords", text: "John Smith"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, detectLanguageLocal(test.text, "en"))
+		})
+	}
+}
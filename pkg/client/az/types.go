@@ -45,14 +45,27 @@ type DocumentError struct {
 	} `json:"error"`
 }
 
+// ErrorResponse struct represents the body of a response from the Azure AI
+// Language service API when the request as a whole has failed.
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#errorresponse
+type ErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 // DocumentResponse struct represents the document-specific response from the
 // Azure AI Language service API.
 // ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#documents
 type DocumentResponse struct {
-	Entities   []Entity           `json:"entities"`
-	ID         string             `json:"id"`
-	Statistics DocumentStatistics `json:"statistics"`
-	Warnings   []Warning          `json:"warnings"`
+	Entities []Entity `json:"entities"`
+	ID       string   `json:"id"`
+	// RedactedText is the text of the document with the text of every
+	// detected entity masked (regardless of confidence score).
+	RedactedText string             `json:"redactedText"`
+	Statistics   DocumentStatistics `json:"statistics"`
+	Warnings     []Warning          `json:"warnings"`
 }
 
 func (dr *DocumentResponse) CountCharacters() int {
@@ -118,6 +131,25 @@ type Parameters struct {
 	//
 	// (default = ["Default"])
 	PiiCategories []string `json:"piiCategories"`
+	// stringIndexType controls the units of the offset and length of each
+	// detected entity, which is always "UnicodeCodePoint" because the offsets
+	// of results are used as the indexes of the runes of the scanned text
+	// (e.g. to redact findings), so it is not configurable
+	//
+	// (default = "UnicodeCodePoint")
+	StringIndexType string `json:"stringIndexType"`
+}
+
+// NewDefaultParameters() function returns the Parameters used for requests
+// when no other values are configured.
+func NewDefaultParameters() Parameters {
+	return Parameters{
+		Domain:          DefaultDomain,
+		LoggingOptOut:   true,
+		ModelVersion:    DefaultModelVersion,
+		PiiCategories:   []string{DefaultPiiCategory},
+		StringIndexType: DefaultStringIndexType,
+	}
 }
 
 // ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#piitaskresult
@@ -133,19 +165,85 @@ type PiiEntityRecognitionRequest struct {
 	Parameters    Parameters    `json:"parameters"`
 }
 
-func NewPiiEntityRecognitionRequest(documents []Document) *PiiEntityRecognitionRequest {
+// NewPiiEntityRecognitionRequest() function returns a new request to detect
+// PII entities in the provided documents using the provided parameters.
+func NewPiiEntityRecognitionRequest(documents []Document, parameters Parameters) *PiiEntityRecognitionRequest {
 	return &PiiEntityRecognitionRequest{
-		Kind: "PiiEntityRecognition",
+		Kind: KindPiiEntityRecognition,
 		AnalysisInput: AnalysisInput{
 			Documents: documents,
 		},
-		Parameters: Parameters{
-			Domain:        "phi",
-			LoggingOptOut: true,
-			ModelVersion:  "latest",
-			PiiCategories: []string{"Default"},
+		Parameters: parameters,
+	}
+}
+
+// DetectedLanguage struct represents the language detected for a document
+// by the language detection API of the Azure AI Language service.
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#detectedlanguage
+type DetectedLanguage struct {
+	ConfidenceScore float64 `json:"confidenceScore"`
+	ISO6391Name     string  `json:"iso6391Name"`
+	Name            string  `json:"name"`
+}
+
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#languagedetectionanalysisinput
+type LanguageDetectionAnalysisInput struct {
+	Documents []LanguageInput `json:"documents"`
+}
+
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#languagedetectiondocumentresult
+type LanguageDetectionDocumentResponse struct {
+	DetectedLanguage DetectedLanguage `json:"detectedLanguage"`
+	ID               string           `json:"id"`
+	Warnings         []Warning        `json:"warnings"`
+}
+
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#analyzetextlanguagedetectioninput
+type LanguageDetectionRequest struct {
+	Kind          string                         `json:"kind"`
+	AnalysisInput LanguageDetectionAnalysisInput `json:"analysisInput"`
+	Parameters    struct {
+		LoggingOptOut bool   `json:"loggingOptOut"`
+		ModelVersion  string `json:"modelVersion"`
+	} `json:"parameters"`
+}
+
+// NewLanguageDetectionRequest() function returns a new request to detect the
+// language of the text of each of the provided documents.
+func NewLanguageDetectionRequest(documents []Document) *LanguageDetectionRequest {
+	request := &LanguageDetectionRequest{
+		Kind: KindLanguageDetection,
+		AnalysisInput: LanguageDetectionAnalysisInput{
+			Documents: make([]LanguageInput, 0, len(documents)),
 		},
 	}
+	request.Parameters.LoggingOptOut = true
+	request.Parameters.ModelVersion = DefaultModelVersion
+	for _, doc := range documents {
+		request.AnalysisInput.Documents = append(request.AnalysisInput.Documents, LanguageInput{
+			ID:   doc.ID,
+			Text: doc.Text,
+		})
+	}
+
+	return request
+}
+
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#languagedetectiontaskresult
+type LanguageDetectionResults struct {
+	Kind    string `json:"kind"`
+	Results struct {
+		Documents    []LanguageDetectionDocumentResponse `json:"documents"`
+		Errors       []DocumentError                     `json:"errors"`
+		ModelVersion string                              `json:"modelVersion"`
+	} `json:"results"`
+}
+
+// ref: https://learn.microsoft.com/en-us/rest/api/language/text-analysis-runtime/analyze-text?view=rest-language-2023-04-01&tabs=HTTP#languageinput
+type LanguageInput struct {
+	CountryHint string `json:"countryHint,omitempty"`
+	ID          string `json:"id"`
+	Text        string `json:"text"`
 }
 
 // RequestStatistics will only be returned in the response from the API
@@ -1,8 +1,11 @@
 package gh
 
 import (
+	"context"
+
 	"github.com/gregjones/httpcache"
 	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
 	metrics "github.com/rcrowley/go-metrics"
 	"github.com/rs/zerolog"
 
@@ -39,3 +42,19 @@ func NewClientManager(config *cfg.Config) (*ClientManager, error) {
 
 	return &ClientManager{cc}, nil
 }
+
+// CheckAppCredentials() method checks that the credentials of the GitHub App
+// (i.e. its integration ID and private key) are accepted by the GitHub API,
+// by getting the GitHub App that is authenticated by the credentials.
+func (cms *ClientManager) CheckAppCredentials(ctx context.Context) error {
+	client, err := cms.NewAppClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create GitHub App client")
+	}
+	_, response, err := client.Apps.Get(ctx, "")
+	if err := checkResponse(response, err); err != nil {
+		return errors.Wrap(err, "failed to authenticate as GitHub App")
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/jobs"
+)
+
+// registerAPIRoutes() method registers the routes of the REST API of the
+// server with the provided gin.IRouter, where every route is authenticated
+// by the configured API token and wrapped by the provided handlers (e.g. the
+// rate limiter). The REST API is used to submit, poll and cancel scan jobs
+// and to list the findings of each job, and to replay webhook deliveries if
+// the event dispatcher of the server is setup.
+func (m *Manager) registerAPIRoutes(router gin.IRouter, handlers ...gin.HandlerFunc) (e error) {
+	// the scan jobs share the blob cache, since concurrent jobs with a blob
+	// cache each would overwrite the blobs saved by the other jobs
+	m.blobs, e = newBlobCache(m.ctx, m.config)
+	if e != nil {
+		return
+	}
+	m.jobs, e = jobs.NewRunner(
+		m.ctx,
+		jobs.Options{
+			MaxFinished: m.config.Server.Jobs.MaxFinished,
+			Retention:   time.Duration(m.config.Server.Jobs.RetentionHours) * time.Hour,
+			Workers:     m.config.Server.Jobs.Workers,
+		},
+		m.runScanJob,
+	)
+	if e != nil {
+		return
+	}
+
+	api := router.Group(cfg.RouteGroupAPIv1, handlers...)
+	api.GET(cfg.RouteScans, m.handleListScans)
+	api.POST(cfg.RouteScans, m.handleSubmitScan)
+	api.GET(cfg.RouteScan, m.handleGetScan)
+	api.DELETE(cfg.RouteScan, m.handleCancelScan)
+	api.GET(cfg.RouteScanFindings, m.handleListScanFindings)
+	if m.deliveries != nil && m.dispatcher != nil {
+		api.POST(cfg.RouteDeliveryReplay, m.handleReplayDelivery)
+	}
+
+	return
+}
+
+// handleCancelScan() method handles requests to cancel the scan job with the
+// ID in the path of the request.
+func (m *Manager) handleCancelScan(c *gin.Context) {
+	job, err := m.jobs.Cancel(c.Param("id"))
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job.Status())
+}
+
+// handleGetScan() method handles requests for the status of the scan job with
+// the ID in the path of the request.
+func (m *Manager) handleGetScan(c *gin.Context) {
+	job, err := m.jobs.Get(c.Param("id"))
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.Status())
+}
+
+// handleListScanFindings() method handles requests for a page of the findings
+// of the scan job with the ID in the path of the request, where the page is
+// selected by the "offset" and "limit" query parameters.
+func (m *Manager) handleListScanFindings(c *gin.Context) {
+	job, err := m.jobs.Get(c.Param("id"))
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	offset, offset_err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, limit_err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if offset_err != nil || limit_err != nil {
+		abortWithError(c, http.StatusBadRequest, jobs.ErrPageInvalid)
+		return
+	}
+	page, err := job.Findings(offset, limit)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// handleListScans() method handles requests for the status of every scan job.
+func (m *Manager) handleListScans(c *gin.Context) {
+	statuses := make([]jobs.JobStatus, 0)
+	for _, job := range m.jobs.List() {
+		statuses = append(statuses, job.Status())
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": statuses})
+}
+
+// handleReplayDelivery() method handles requests to replay the webhook
+// delivery with the ID in the path of the request, which is sent through the
+// event dispatcher of the server (i.e. as if GitHub had delivered it again)
+// such that the response of the dispatcher is the response to the request.
+func (m *Manager) handleReplayDelivery(c *gin.Context) {
+	delivery, err := m.deliveries.Get(c.Param("id"))
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	request, err := delivery.Request(
+		m.logger.WithContext(c.Request.Context()),
+		cfg.RouteGroupGHv1+cfg.RouteWebhook,
+		m.config.GitHub.App.WebhookSecret,
+	)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	m.logger.Info().Msgf("replaying webhook delivery type=%s : deliveryID=%s", delivery.EventType, delivery.DeliveryID)
+	m.dispatcher.ServeHTTP(c.Writer, request)
+}
+
+// handleSubmitScan() method handles requests to submit a new scan job with the
+// jobs.ScanRequest in the (JSON) body of the request.
+func (m *Manager) handleSubmitScan(c *gin.Context) {
+	var request jobs.ScanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, http.StatusBadRequest, errors.Wrap(err, "invalid scan request"))
+		return
+	}
+	if _, err := request.ScopeConfig(m.config.Git.Scan.Scope); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	job, err := m.jobs.Submit(request)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job.Status())
+}
+
+// runScanJob() method is the jobs.ScanFunc of the REST API, which scans each
+// repository of the provided job in turn with the configured git.scan values,
+// replaced by the values of the request of the job. The repositories of the
+// job are cloned to a working directory of the job, which is removed once the
+// job is finished, and the scan ID of the job (see jobs.ScanRequest) is used
+// as the ID of the scan (e.g. of its checkpoints). Every job uses the blob
+// cache shared by the jobs (if configured).
+func (m *Manager) runScanJob(ctx context.Context, job *jobs.Job) error {
+	scope, err := job.Request.ScopeConfig(m.config.Git.Scan.Scope)
+	if err != nil {
+		return err
+	}
+	work_dir := filepath.Join(m.config.Git.WorkDir, job.ID)
+	defer os.RemoveAll(work_dir)
+
+	for _, repository := range job.Request.Repositories {
+		config := *m.config
+		config.Git.WorkDir = work_dir
+		config.Git.Scan.Checkpoint.ScanID = job.ScanID()
+		config.Git.Scan.Repositories = []string{repository}
+		config.Git.Scan.Scope = scope
+
+		s, err := newScanner(ctx, &config, m.blobs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to initialize new Scanner for repository %s", repository)
+		}
+		ai, err := az.NewEntityDetectionAI(&config)
+		if err != nil {
+			return errors.Wrapf(err, "failed to initialize new EntityDetectionAI for repository %s", repository)
+		}
+		job.AddScanner(repository, s)
+		if err := runScanner(ctx, s, az.NewAzAiLanguagePhiDetector(ai), nil); err != nil {
+			return errors.Wrapf(err, "failed to scan repository %s", repository)
+		}
+	}
+
+	return nil
+}
+
+// abortWithError() function aborts the request with a JSON response for the
+// provided error, where the provided status code is replaced by the status
+// code of the known causes of the error (e.g. jobs.ErrJobNotFound).
+func abortWithError(c *gin.Context, code int, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound), errors.Is(err, deliveries.ErrDeliveryNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, jobs.ErrJobFinished), errors.Is(err, jobs.ErrJobScanIDInUse):
+		code = http.StatusConflict
+	case errors.Is(err, jobs.ErrRunnerClosed):
+		code = http.StatusServiceUnavailable
+	case errors.Is(err, jobs.ErrPageInvalid), errors.Is(err, jobs.ErrJobRepositoriesEmpty), errors.Is(err, jobs.ErrJobScanIDInvalid),
+		errors.Is(err, deliveries.ErrDeliveryIDInvalid):
+		code = http.StatusBadRequest
+	}
+	c.AbortWithStatusJSON(code, gin.H{"code": code, "response": err.Error()})
+}
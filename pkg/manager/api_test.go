@@ -0,0 +1,244 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/handlers"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/jobs"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+)
+
+// testAPIRequest() helper function sends a request with the provided method,
+// path and (JSON) body to the provided router, authenticated by the provided
+// bearer token (if any), and decodes the JSON response into out (if not nil).
+func testAPIRequest(t *testing.T, router http.Handler, method, path, body, token string, out interface{}) int {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if out != nil {
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), out))
+	}
+	return recorder.Code
+}
+
+// TestManager_registerAPIRoutes() unit test function submits a scan job to
+// the REST API, polls the job until it is complete and lists its findings,
+// where the job scans a local repository against a local aztest.Server.
+func TestManager_registerAPIRoutes(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\nJohn Smith,1975-03-04\n",
+		"README.md":    "# test repository\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	router := gin.New()
+	if !assert.NoError(t, m.registerAPIRoutes(router, handlers.TokenAuthHandler("api-token"))) {
+		t.FailNow()
+	}
+
+	// every route requires the API token
+	assert.Equal(t, http.StatusUnauthorized, testAPIRequest(t, router, http.MethodGet, "/api/v1/scans", "", "", nil))
+	assert.Equal(t, http.StatusUnauthorized, testAPIRequest(t, router, http.MethodGet, "/api/v1/scans", "", "wrong-token", nil))
+
+	// invalid requests are rejected
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans", `{"repositories":[]}`, "api-token", nil))
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans", `{"repositories":`, "api-token", nil))
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans",
+		`{"repositories":["test-org/test-repo"],"scope":{"mode":"unknown"}}`, "api-token", nil))
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans",
+		`{"repositories":["test-org/test-repo"],"scan_id":"../test_scan"}`, "api-token", nil))
+	assert.Equal(t, http.StatusNotFound, testAPIRequest(t, router, http.MethodGet, "/api/v1/scans/missing", "", "api-token", nil))
+
+	var submitted jobs.JobStatus
+	body := `{"repositories":["` + repo_url + `"]}`
+	if !assert.Equal(t, http.StatusAccepted, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans", body, "api-token", &submitted)) {
+		t.FailNow()
+	}
+	assert.NotEmpty(t, submitted.ID)
+	assert.Equal(t, []string{repo_url}, submitted.Request.Repositories)
+
+	var status jobs.JobStatus
+	assert.Eventually(t, func() bool {
+		testAPIRequest(t, router, http.MethodGet, "/api/v1/scans/"+submitted.ID, "", "api-token", &status)
+		return status.Status != jobs.StatusQueued && status.Status != jobs.StatusRunning
+	}, 10*time.Second, 20*time.Millisecond)
+	if !assert.Equal(t, jobs.StatusComplete, status.Status, status.Error) {
+		t.FailNow()
+	}
+	if assert.Len(t, status.Repositories, 1) {
+		assert.Equal(t, map[string]int{"Person": 2}, status.Repositories[0].Results)
+		assert.Equal(t, submitted.ID, status.Repositories[0].ScanID)
+	}
+
+	var listed struct {
+		Jobs []jobs.JobStatus `json:"jobs"`
+	}
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, "/api/v1/scans", "", "api-token", &listed))
+	if assert.Len(t, listed.Jobs, 1) {
+		assert.Equal(t, submitted.ID, listed.Jobs[0].ID)
+	}
+
+	var page jobs.FindingsPage
+	path := "/api/v1/scans/" + submitted.ID + "/findings?offset=1&limit=1"
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, path, "", "api-token", &page))
+	assert.Equal(t, 2, page.Total)
+	if assert.Len(t, page.Findings, 1) {
+		assert.Equal(t, "patients.csv", page.Findings[0].Object.Path)
+		assert.Equal(t, repo_url, page.Findings[0].Repository.ID)
+	}
+	path = "/api/v1/scans/" + submitted.ID + "/findings?offset=-1"
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodGet, path, "", "api-token", nil))
+	path = "/api/v1/scans/" + submitted.ID + "/findings?limit=all"
+	assert.Equal(t, http.StatusBadRequest, testAPIRequest(t, router, http.MethodGet, path, "", "api-token", nil))
+
+	// finished jobs cannot be cancelled
+	assert.Equal(t, http.StatusConflict, testAPIRequest(t, router, http.MethodDelete, "/api/v1/scans/"+submitted.ID, "", "api-token", nil))
+}
+
+// TestManager_runScanJob_blobCache() unit test function tests that concurrent
+// scan jobs share the blob cache, such that the blobs scanned by every job
+// are saved to the file of the blob cache.
+func TestManager_runScanJob_blobCache(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	contents := []string{"first patient John Smith\n", "second patient John Smith\n"}
+	repo_urls := []string{
+		testCreateRepository(t, map[string]string{"first.md": contents[0]}),
+		testCreateRepository(t, map[string]string{"second.md": contents[1]}),
+	}
+	m := testNewManager(t, server, repo_urls[0])
+	m.config.Git.Scan.CacheDir = t.TempDir()
+	m.config.Server.Jobs.Workers = len(repo_urls)
+	router := gin.New()
+	if !assert.NoError(t, m.registerAPIRoutes(router)) {
+		t.FailNow()
+	}
+
+	submitted := make([]jobs.JobStatus, len(repo_urls))
+	for i, repo_url := range repo_urls {
+		body := `{"repositories":["` + repo_url + `"]}`
+		if !assert.Equal(t, http.StatusAccepted, testAPIRequest(t, router, http.MethodPost, "/api/v1/scans", body, "", &submitted[i])) {
+			t.FailNow()
+		}
+	}
+	for _, job := range submitted {
+		var status jobs.JobStatus
+		assert.Eventually(t, func() bool {
+			testAPIRequest(t, router, http.MethodGet, "/api/v1/scans/"+job.ID, "", "", &status)
+			return status.Status != jobs.StatusQueued && status.Status != jobs.StatusRunning
+		}, 10*time.Second, 20*time.Millisecond)
+		assert.Equal(t, jobs.StatusComplete, status.Status, status.Error)
+	}
+
+	blob_cache, err := cache.NewBlobCache(m.config.Git.Scan.CacheDir, cache.FingerprintFromConfig(m.config), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, content := range contents {
+		_, cached := blob_cache.Get(plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String())
+		assert.True(t, cached, content)
+	}
+}
+
+// testPushHandler struct is a githubapp.EventHandler that counts the push
+// events that it handles.
+type testPushHandler struct {
+	handled atomic.Int32
+}
+
+func (h *testPushHandler) Handles() []string {
+	return []string{handlers.EventTypePush}
+}
+
+func (h *testPushHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	h.handled.Add(1)
+	return nil
+}
+
+// TestManager_handleReplayDelivery() unit test function tests that duplicate
+// webhook deliveries are ignored by the event dispatcher, and that stored
+// deliveries are replayed through the dispatcher by the REST API.
+func TestManager_handleReplayDelivery(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	server := aztest.NewServer()
+	defer server.Close()
+	m := testNewManager(t, server, "test-org/test-repo")
+	m.config.GitHub.App.WebhookSecret = "webhook-secret"
+	handler := &testPushHandler{}
+	var err error
+	m.deliveries, err = deliveries.NewScheduler(m.ctx, githubapp.DefaultScheduler(), deliveries.NewMemoryStore(), time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m.dispatcher = githubapp.NewEventDispatcher(
+		[]githubapp.EventHandler{handler},
+		m.config.GitHub.App.WebhookSecret,
+		githubapp.WithScheduler(m.deliveries),
+		githubapp.WithResponseCallback(handlers.AcceptedResponseCallback),
+	)
+	router := gin.New()
+	if !assert.NoError(t, m.registerAPIRoutes(router, handlers.TokenAuthHandler("api-token"))) {
+		t.FailNow()
+	}
+
+	// deliver the same event twice from "GitHub"
+	payload := `{"after":"abc123","repository":{"full_name":"test-org/test-repo"}}`
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/github/hook", strings.NewReader(payload))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(deliveries.HeaderDelivery, "delivery-1")
+		request.Header.Set(deliveries.HeaderEvent, handlers.EventTypePush)
+		request.Header.Set(deliveries.HeaderSignature, deliveries.Signature("webhook-secret", []byte(payload)))
+		recorder := httptest.NewRecorder()
+		m.dispatcher.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+	}
+	assert.Equal(t, int32(1), handler.handled.Load())
+
+	// replay the stored delivery with the REST API
+	assert.Equal(t, http.StatusUnauthorized, testAPIRequest(t, router, http.MethodPost, "/api/v1/deliveries/delivery-1/replay", "", "", nil))
+	assert.Equal(t, http.StatusAccepted, testAPIRequest(t, router, http.MethodPost, "/api/v1/deliveries/delivery-1/replay", "", "api-token", nil))
+	assert.Equal(t, int32(2), handler.handled.Load())
+	assert.Equal(t, http.StatusNotFound, testAPIRequest(t, router, http.MethodPost, "/api/v1/deliveries/delivery-2/replay", "", "api-token", nil))
+}
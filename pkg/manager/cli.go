@@ -5,12 +5,27 @@ import "github.com/has-ghas/no-phi-ai/pkg/cfg"
 // runCLI() method is used to run the command specified in m.config.Command.Run var.
 func (m *Manager) runCLI() (e error) {
 	switch m.config.Command.Run {
+	case cfg.CommandRunBaseline:
+		e = m.commandBaseline()
+		return
+	case cfg.CommandRunEstimate:
+		e = m.commandEstimate()
+		return
 	case cfg.CommandRunHelp:
 		e = m.commandHelp()
 		return
 	case cfg.CommandRunListOrgRepos:
 		e = m.commandListOrgRepos()
 		return
+	case cfg.CommandRunRedact:
+		e = m.commandRedact()
+		return
+	case cfg.CommandRunReencrypt:
+		e = m.commandReencrypt()
+		return
+	case cfg.CommandRunScanNew:
+		e = m.commandScanNew()
+		return
 	case cfg.CommandRunScanOrg:
 		e = m.commandScanOrg()
 		return
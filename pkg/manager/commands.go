@@ -1,22 +1,38 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 	"github.com/has-ghas/no-phi-ai/pkg/client/az"
-	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/queue"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/baseline"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/checkpoint"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/dryrun"
-	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/redact"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/usage"
 )
 
 // commandHelp() method is used to run the "help" (default) command.
 func (m *Manager) commandHelp() (e error) {
 	fmt.Printf("CLI Help Information for %s app:\n", m.config.App.Name)
 	fmt.Println("\tAvailable Commands:")
+	printNameAndDescription(
+		cfg.CommandRunBaseline,
+		"Scans a repository and adds its current findings to the baseline of known findings.",
+	)
+	printNameAndDescription(
+		cfg.CommandRunEstimate,
+		"Scans a repository in dry run mode and prints the estimated cost of the Azure AI Language service.",
+	)
 	printNameAndDescription(
 		cfg.CommandRunHelp,
 		"Prints (this) help information for the app.",
@@ -25,6 +41,18 @@ func (m *Manager) commandHelp() (e error) {
 		cfg.CommandRunListOrgRepos,
 		"... not yet implemented ...",
 	)
+	printNameAndDescription(
+		cfg.CommandRunRedact,
+		"Scans a repository and writes sanitized copies of files containing PHI/PII.",
+	)
+	printNameAndDescription(
+		cfg.CommandRunReencrypt,
+		"Encrypts the saved checkpoints and blob cache with the primary encryption key.",
+	)
+	printNameAndDescription(
+		cfg.CommandRunScanNew,
+		"Scans a repository and fails if it has any findings that are not in the baseline.",
+	)
 	printNameAndDescription(
 		cfg.CommandRunScanOrg,
 		"... not yet implemented ...",
@@ -48,6 +76,100 @@ func (m *Manager) commandHelp() (e error) {
 	return
 }
 
+// commandBaseline() method is used to run the "baseline" command, which scans
+// the contents of a single git repository for PHI/PII and then adds each
+// finding that is not already in the baseline file to the baseline, with the
+// configured author, reason and expiry. Expired suppressions are removed
+// from the baseline, so findings that still exist are added again.
+func (m *Manager) commandBaseline() (e error) {
+	var known *baseline.Baseline
+	known, e = baseline.Load(m.config.Baseline.Path, []byte(m.config.Git.Scan.ResultText.Key))
+	if e != nil {
+		e = errors.Wrapf(e, "failed to load baseline for command %s", m.config.Command.Run)
+		return
+	}
+	expires, err := m.config.Baseline.ExpiresTime()
+	if err != nil {
+		e = errors.Wrapf(err, "failed to parse baseline expiry for command %s", m.config.Command.Run)
+		return
+	}
+
+	var ai *az.EntityDetectionAI
+	ai, e = az.NewEntityDetectionAI(m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize new EntityDetectionAI for command %s", m.config.Command.Run)
+		return
+	}
+
+	if e = m.runScan(az.NewAzAiLanguagePhiDetector(ai), nil); e != nil {
+		return
+	}
+
+	// the Scanner only scans the first of the configured repositories
+	records, _, err := m.scanner.NewResultRecords(m.config.Git.Scan.Repositories[0])
+	if err != nil {
+		e = errors.Wrapf(err, "failed to list findings for command %s", m.config.Command.Run)
+		return
+	}
+	now := time.Now()
+	dropped := known.DropExpired(now)
+	added, err := known.Suppress(records, m.config.Baseline.Author, m.config.Baseline.Reason, expires, now)
+	if err != nil {
+		e = errors.Wrapf(err, "failed to add findings to baseline for command %s", m.config.Command.Run)
+		return
+	}
+	if e = known.Save(m.config.Baseline.Path); e != nil {
+		e = errors.Wrapf(e, "failed to save baseline for command %s", m.config.Command.Run)
+		return
+	}
+	m.logger.Info().Msgf(
+		"baseline %s : added %d findings : removed %d expired findings : %d findings in baseline",
+		m.config.Baseline.Path,
+		added,
+		dropped,
+		known.Len(),
+	)
+
+	m.scanner.SetBaseline(known)
+
+	return m.reportScan(ai.GetUsageTracker())
+}
+
+// commandEstimate() method is used to run the "estimate" command, which scans
+// the contents of a single git repository in dry run mode (i.e. without
+// sending any requests to the Azure AI Language service), then prints the
+// estimated usage and cost of scanning the repository with the service.
+func (m *Manager) commandEstimate() (e error) {
+	// force dry run mode for the scan, regardless of the config
+	m.config.AzureAI.DryRun = true
+
+	var ai *az.EntityDetectionAI
+	ai, e = az.NewEntityDetectionAI(m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize new EntityDetectionAI for command %s", m.config.Command.Run)
+		return
+	}
+
+	if e = m.runScan(az.NewAzAiLanguagePhiDetector(ai), nil); e != nil {
+		return
+	}
+	if e = m.reportScan(ai.GetUsageTracker()); e != nil {
+		return
+	}
+
+	estimate := m.report.Usage
+	fmt.Printf(
+		"Estimated cost of scanning %s : %.2f (%d text records in %d documents at %.2f per 1000 text records)\n",
+		m.report.Repository,
+		estimate.EstimatedCost,
+		estimate.Transactions,
+		estimate.Documents,
+		ai.GetUsageTracker().GetPricePer1k(),
+	)
+
+	return
+}
+
 // commandListOrgRepos() method is used to run the "list-org-repos" command.
 func (m *Manager) commandListOrgRepos() (e error) {
 	m.logger.Warn().Msgf("%s commmand is TODO\n", cfg.CommandRunListOrgRepos)
@@ -61,17 +183,193 @@ func (m *Manager) commandScanOrg() (e error) {
 	return
 }
 
+// commandRedact() method is used to run the "redact" command, which scans
+// the contents of a single git repository for PHI/PII and then writes a
+// sanitized copy of each file (in the HEAD commit) in which PHI/PII was
+// detected, with the text of every detected entity masked. The PHI/PII is
+// detected by the detector configured in m.config.Redact.Detector.
+func (m *Manager) commandRedact() (e error) {
+	var redactor *redact.Redactor
+	redactor, e = redact.NewRedactor(m.config.Redact.OutputDir)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize new Redactor for command %s", m.config.Command.Run)
+		return
+	}
+
+	detector, usage_tracker, err := m.newRedactDetector()
+	if err != nil {
+		e = errors.Wrapf(err, "failed to initialize detector for command %s", m.config.Command.Run)
+		return
+	}
+
+	if e = m.runScan(detector, redactor.Add); e != nil {
+		return
+	}
+	if e = m.reportScan(usage_tracker); e != nil {
+		return
+	}
+
+	// the Scanner only scans the first of the configured repositories
+	scan_repo, err := m.scanner.GetScanRepository(m.config.Git.Scan.Repositories[0])
+	if err != nil {
+		e = errors.Wrapf(err, "failed to get scanned repository for command %s", m.config.Command.Run)
+		return
+	}
+	written, err := redactor.Write(scan_repo.ID, scan_repo.Name, scan_repo.GetRepository())
+	if err != nil {
+		e = errors.Wrapf(err, "failed to write redacted files for command %s", m.config.Command.Run)
+		return
+	}
+	m.logger.Info().Msgf(
+		"repository %s : wrote %d redacted files to %s : %d flagged objects",
+		scan_repo.URL,
+		len(written),
+		m.config.Redact.OutputDir,
+		redactor.CountObjects(scan_repo.ID),
+	)
+
+	return
+}
+
+// newRedactDetector() method returns the detector of the "redact" command,
+// along with the usage tracker of the detector (if any), which either scans
+// the repository with the AI Language service or reuses the results of
+// previous scans saved in the blob cache, where the text of the results is
+// masked locally.
+func (m *Manager) newRedactDetector() (rrr.RequestResponsePhiDetector, *usage.Tracker, error) {
+	switch m.config.Redact.Detector {
+	case cfg.RedactDetectorCache:
+		blob_cache, err := newBlobCache(m.ctx, m.config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if blob_cache.Len() == 0 {
+			m.logger.Warn().Msgf("no files will be redacted : blob cache in %s is empty", m.config.Git.Scan.CacheDir)
+		}
+		return cache.NewCachedPhiDetector(blob_cache), nil, nil
+	default:
+		ai, err := az.NewEntityDetectionAI(m.config)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to initialize new EntityDetectionAI")
+		}
+		return az.NewAzAiLanguagePhiDetector(ai), ai.GetUsageTracker(), nil
+	}
+}
+
+// commandReencrypt() method is used to run the "reencrypt" command, which
+// encrypts the saved checkpoints, blob cache, webhook deliveries and queued
+// webhook events (if configured) with the primary encryption key, such that a
+// rotated key can be removed once every file that was encrypted with it (or
+// stored before encryption was enabled) has been re-encrypted.
+func (m *Manager) commandReencrypt() (e error) {
+	var keyring *crypt.Keyring
+	keyring, e = loadKeyring(m.config)
+	if e != nil {
+		e = errors.Wrapf(e, "failed to load encryption keys for command %s", m.config.Command.Run)
+		return
+	}
+
+	if m.config.Git.Scan.Checkpoint.Dir != "" {
+		var file_store *checkpoint.FileStore
+		file_store, e = checkpoint.NewFileStore(m.config.Git.Scan.Checkpoint.Dir, keyring)
+		if e != nil {
+			e = errors.Wrapf(e, "failed to initialize checkpoint store for command %s", m.config.Command.Run)
+			return
+		}
+		count, err := file_store.Reencrypt()
+		if err != nil {
+			e = errors.Wrapf(err, "failed to run command %s", m.config.Command.Run)
+			return
+		}
+		m.logger.Info().Msgf(
+			"re-encrypted %d checkpoints in %s with key %s",
+			count,
+			m.config.Git.Scan.Checkpoint.Dir,
+			keyring.Primary(),
+		)
+	}
+
+	if m.config.Git.Scan.CacheDir != "" {
+		changed, err := cache.Reencrypt(m.config.Git.Scan.CacheDir, keyring)
+		if err != nil {
+			e = errors.Wrapf(err, "failed to run command %s", m.config.Command.Run)
+			return
+		}
+		if changed {
+			m.logger.Info().Msgf("re-encrypted blob cache in %s with key %s", m.config.Git.Scan.CacheDir, keyring.Primary())
+		}
+	}
+
+	if m.config.Server.Deliveries.Dir != "" {
+		var deliveries_store *deliveries.FileStore
+		deliveries_store, e = deliveries.NewFileStore(m.config.Server.Deliveries.Dir, keyring)
+		if e != nil {
+			e = errors.Wrapf(e, "failed to initialize deliveries store for command %s", m.config.Command.Run)
+			return
+		}
+		count, err := deliveries_store.Reencrypt()
+		if err != nil {
+			e = errors.Wrapf(err, "failed to run command %s", m.config.Command.Run)
+			return
+		}
+		m.logger.Info().Msgf(
+			"re-encrypted %d webhook deliveries in %s with key %s",
+			count,
+			m.config.Server.Deliveries.Dir,
+			keyring.Primary(),
+		)
+	}
+
+	if m.config.Server.Queue.Dir != "" {
+		var queue_store *queue.FileStore
+		queue_store, e = queue.NewFileStore(m.config.Server.Queue.Dir, keyring)
+		if e != nil {
+			e = errors.Wrapf(e, "failed to initialize queue store for command %s", m.config.Command.Run)
+			return
+		}
+		count, err := queue_store.Reencrypt()
+		if err != nil {
+			e = errors.Wrapf(err, "failed to run command %s", m.config.Command.Run)
+			return
+		}
+		m.logger.Info().Msgf(
+			"re-encrypted %d queued webhook events in %s with key %s",
+			count,
+			m.config.Server.Queue.Dir,
+			keyring.Primary(),
+		)
+	}
+
+	return
+}
+
 // commandScanRepos() method is used to run the "scan-repos" command, which
 // is used to scan the contents of a single git repository for PHI/PII.
 func (m *Manager) commandScanRepos() (e error) {
-	m.scanner, e = scanner.NewScanner(m.ctx, &m.config.Git, memory.NewMemoryResultRecordIO(m.ctx))
+	var ai *az.EntityDetectionAI
+	ai, e = az.NewEntityDetectionAI(m.config)
 	if e != nil {
-		e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+		e = errors.Wrapf(e, "failed to initialize new EntityDetectionAI for command %s", m.config.Command.Run)
 		return
 	}
 
-	if len(m.config.Git.Scan.Repositories) == 0 {
-		e = errors.New("no repositories specified for scan")
+	if e = m.runScan(az.NewAzAiLanguagePhiDetector(ai), nil); e != nil {
+		return
+	}
+
+	return m.reportScan(ai.GetUsageTracker())
+}
+
+// commandScanNew() method is used to run the "scan-new" command, which scans
+// the contents of a single git repository for PHI/PII and reports only the
+// findings that are not in the baseline file, such that the command can be
+// used as a gate of a CI pipeline. Returns an error if the repository has
+// any new findings, which are logged without their text.
+func (m *Manager) commandScanNew() (e error) {
+	var known *baseline.Baseline
+	known, e = baseline.Load(m.config.Baseline.Path, []byte(m.config.Git.Scan.ResultText.Key))
+	if e != nil {
+		e = errors.Wrapf(e, "failed to load baseline for command %s", m.config.Command.Run)
 		return
 	}
 
@@ -81,21 +379,36 @@ func (m *Manager) commandScanRepos() (e error) {
 		e = errors.Wrapf(e, "failed to initialize new EntityDetectionAI for command %s", m.config.Command.Run)
 		return
 	}
-	az_ai_detector := az.NewAzAiLanguagePhiDetector(ai)
-	chan_scan_errors := make(chan error)
-	chan_requests := make(chan rrr.Request)
-	chan_responses := make(chan rrr.Response)
 
-	go m.scanner.Scan(chan_scan_errors, chan_requests, chan_responses)
-	go az_ai_detector.Run(m.ctx, chan_requests, chan_responses)
+	if e = m.runScan(az.NewAzAiLanguagePhiDetector(ai), nil); e != nil {
+		return
+	}
+	m.scanner.SetBaseline(known)
+	if e = m.reportScan(ai.GetUsageTracker()); e != nil {
+		return
+	}
 
-	// wait for an error to be returned from the scanner
-	e = <-chan_scan_errors
-	if e != nil {
-		e = errors.Wrapf(e, "failed to run command '%s' ", m.config.Command.Run)
+	// the Scanner only scans the first of the configured repositories
+	new_records, _, err := m.scanner.NewResultRecords(m.config.Git.Scan.Repositories[0])
+	if err != nil {
+		e = errors.Wrapf(err, "failed to list new findings for command %s", m.config.Command.Run)
+		return
+	}
+	for _, record := range new_records {
+		m.logger.Warn().Msgf(
+			"new finding : commit %s : %s %s : category %s : offset %d : fingerprint %s",
+			record.Commit.ID,
+			record.Object.Type,
+			record.Object.Path,
+			record.Category,
+			record.Offset,
+			known.Fingerprint(record),
+		)
+	}
+	if len(new_records) > 0 {
+		e = errors.Errorf("found %d findings that are not in baseline %s", len(new_records), m.config.Baseline.Path)
 		return
 	}
-	m.logger.Info().Msgf("command '%s' completed successfully", m.config.Command.Run)
 
 	return
 }
@@ -103,28 +416,61 @@ func (m *Manager) commandScanRepos() (e error) {
 // commandScanTest() method is used to run the "scan-test" command, which is
 // for development use only.
 func (m *Manager) commandScanTest() (e error) {
-	m.scanner, e = scanner.NewScanner(m.ctx, &m.config.Git, memory.NewMemoryResultRecordIO(m.ctx))
-	if e != nil {
-		e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+	if e = m.runScan(dryrun.NewDryRunPhiDetector(), nil); e != nil {
 		return
 	}
 
-	if len(m.config.Git.Scan.Repositories) == 0 {
-		e = errors.New("no repositories specified for scan")
+	return m.reportScan(nil)
+}
+
+// reportScan() method creates the Report of the scan of the configured
+// repository, including the usage from the (optional) usage_tracker, then
+// prints the Report to stdout as JSON.
+func (m *Manager) reportScan(usage_tracker *usage.Tracker) (e error) {
+	// the Scanner only scans the first of the configured repositories
+	m.report, e = m.scanner.Report(m.config.Git.Scan.Repositories[0])
+	if e != nil {
+		e = errors.Wrapf(e, "failed to report scan for command %s", m.config.Command.Run)
 		return
 	}
+	if usage_tracker != nil {
+		repository_usage := usage_tracker.Get(m.report.Repository)
+		m.report.Usage = &repository_usage
+	}
 
-	chan_scan_errors := make(chan error)
-	chan_requests := make(chan rrr.Request)
-	chan_responses := make(chan rrr.Response)
-	dry_run_detector := dryrun.NewDryRunPhiDetector()
+	var report_bytes []byte
+	report_bytes, e = json.MarshalIndent(m.report, "", "  ")
+	if e != nil {
+		e = errors.Wrapf(e, "failed to marshal scan report for command %s", m.config.Command.Run)
+		return
+	}
+	fmt.Println(string(report_bytes))
 
-	go m.scanner.Scan(chan_scan_errors, chan_requests, chan_responses)
-	go dry_run_detector.Run(m.ctx, chan_requests, chan_responses)
+	return
+}
 
-	// wait for an error to be returned from the scanner
-	e = <-chan_scan_errors
+// runScan() method runs a scan of the configured repository, where requests
+// generated by the scan are processed by the provided detector. Every
+// response from the detector is passed to the (optional) on_response func
+// before it is processed by the Scanner. The blob cache (if configured) is
+// only used without on_response, which must receive a response for every
+// scanned file.
+func (m *Manager) runScan(detector rrr.RequestResponsePhiDetector, on_response func(rrr.Response)) (e error) {
+	var blob_cache *cache.BlobCache
+	if on_response == nil {
+		blob_cache, e = newBlobCache(m.ctx, m.config)
+		if e != nil {
+			e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+			return
+		}
+	}
+	m.scanner, e = newScanner(m.ctx, m.config, blob_cache)
 	if e != nil {
+		e = errors.Wrapf(e, "failed to initialize new Scanner for command %s", m.config.Command.Run)
+		return
+	}
+
+	if e = runScanner(m.ctx, m.scanner, detector, on_response); e != nil {
 		e = errors.Wrapf(e, "failed to run command '%s' ", m.config.Command.Run)
 		return
 	}
@@ -136,7 +482,8 @@ func (m *Manager) commandScanTest() (e error) {
 // commandVersion() method is used to run the "version" command, which prints
 // the version information for the app and then exits.
 func (m *Manager) commandVersion() (e error) {
-	fmt.Printf("%s %s\n", m.config.App.Name, cfg.AppVersion)
+	build_info := cfg.GetBuildInfo()
+	fmt.Printf("%s %s (commit %s, built %s)\n", m.config.App.Name, build_info.Version, build_info.Commit, build_info.Time)
 	return
 }
 
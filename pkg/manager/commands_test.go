@@ -0,0 +1,502 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	nogit "github.com/has-ghas/no-phi-ai/pkg/client/no-git"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/queue"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testCreateRepository() helper function creates a local git repository
+// with a single commit containing the provided files, and returns the path
+// of the repository in a form that can be used as a repository "URL".
+func testCreateRepository(t *testing.T, files map[string]string) string {
+	repo_path := filepath.Join(t.TempDir(), "test-org", "test-repo")
+	repository, err := git.PlainInit(repo_path, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	worktree, err := repository.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for name, content := range files {
+		file_path := filepath.Join(repo_path, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file_path), 0755))
+		assert.NoError(t, os.WriteFile(file_path, []byte(content), 0644))
+		_, err = worktree.Add(name)
+		assert.NoError(t, err)
+	}
+	_, err = worktree.Commit("test commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return repo_path
+}
+
+// testNewManager() helper function returns a new Manager for running CLI
+// commands against the provided aztest.Server and repository, where the
+// server is nil for commands that do not call the AI Language service.
+func testNewManager(t *testing.T, server *aztest.Server, repo_url string) *Manager {
+	config := cfg.NewDefaultConfig()
+	config.App.Mode = cfg.AppModeCLI
+	config.AzureAI.AuthKey = "test-key"
+	config.AzureAI.ConfidenceThreshold = cfg.DefaultConfidenceThreshold
+	if server != nil {
+		config.AzureAI.Service = server.URL
+	}
+	config.Command.Run = cfg.CommandRunScanRepos
+	config.Git.Auth.Token = "test-token"
+	config.Git.Scan.Repositories = []string{repo_url}
+	config.Git.WorkDir = t.TempDir()
+
+	logger := zerolog.Nop()
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background()))
+	t.Cleanup(cancel)
+
+	return &Manager{
+		cancel: cancel,
+		checks: newReadinessCache(cfg.DefaultReadinessCacheTTL),
+		config: config,
+		ctx:    ctx,
+		logger: &logger,
+	}
+}
+
+// TestManager_commandScanRepos() unit test function runs the "scan-repos"
+// command end-to-end against a local repository and a local aztest.Server.
+func TestManager_commandScanRepos(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv":  "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":     "# test repository\n",
+		"image.png":     "not really an image",
+		"docs/notes.md": "nothing to see here\n",
+	})
+	m := testNewManager(t, server, repo_url)
+
+	err := m.commandScanRepos()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	records, list_err := m.scanner.GetResultRecordIO().List()
+	assert.NoError(t, list_err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "Person", records[0].Category)
+		assert.Equal(t, "John Smith", records[0].Text)
+		assert.Equal(t, repo_url, records[0].Repository.ID)
+	}
+	// only the three files with supported extensions should be sent
+	documents := 0
+	for _, request := range server.Requests() {
+		documents += len(request.AnalysisInput.Documents)
+	}
+	assert.Equal(t, 3, documents)
+	// the scan report should include the usage of the service
+	if assert.NotNil(t, m.report) && assert.NotNil(t, m.report.Usage) {
+		assert.Equal(t, map[string]int{"Person": 1}, m.report.Results)
+		assert.Equal(t, int64(3), m.report.Usage.Documents)
+		assert.Equal(t, int64(3), m.report.Usage.Transactions)
+	}
+}
+
+// TestManager_commandEstimate() unit test function runs the "estimate"
+// command end-to-end against a local repository, and checks that no
+// requests are sent to the local aztest.Server.
+func TestManager_commandEstimate(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer()
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":    strings.Repeat("lorem ipsum ", 100),
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.AzureAI.PricePer1kRecords = 10
+	m.config.Command.Run = cfg.CommandRunEstimate
+
+	err := m.commandEstimate()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Empty(t, server.Requests())
+	if assert.NotNil(t, m.report) && assert.NotNil(t, m.report.Usage) {
+		assert.Equal(t, int64(2), m.report.Usage.Documents)
+		// the README.md file of 1200 characters is billed as 2 text records
+		assert.Equal(t, int64(3), m.report.Usage.Transactions)
+		assert.InDelta(t, 0.03, m.report.Usage.EstimatedCost, 1e-9)
+	}
+}
+
+// TestManager_commandRedact() unit test function runs the "redact" command
+// end-to-end against a local repository and a local aztest.Server.
+func TestManager_commandRedact(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":    "# test repository\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.Command.Run = cfg.CommandRunRedact
+	m.config.Redact.OutputDir = t.TempDir()
+
+	err := m.commandRedact()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	repo_name, name_err := nogit.ParseRepoNameFromURL(repo_url)
+	assert.NoError(t, name_err)
+	patients, read_err := os.ReadFile(filepath.Join(m.config.Redact.OutputDir, repo_name, "patients.csv"))
+	assert.NoError(t, read_err)
+	assert.Equal(t, "name,dob\n**********,1980-01-02\n", string(patients))
+	// files without detected entities should not be written
+	_, stat_err := os.Stat(filepath.Join(m.config.Redact.OutputDir, repo_name, "README.md"))
+	assert.True(t, os.IsNotExist(stat_err))
+}
+
+// TestManager_commandRedact_cache() unit test function runs the "redact"
+// command end-to-end against a local repository without the AI Language
+// service, where the results of a previous scan are reused from the blob
+// cache and the text of the results is masked locally.
+func TestManager_commandRedact_cache(t *testing.T) {
+	t.Parallel()
+
+	notes := "Patient John Smith was seen today.\n"
+	repo_url := testCreateRepository(t, map[string]string{
+		"notes.md":  notes,
+		"README.md": "# test repository\n",
+	})
+	m := testNewManager(t, nil, repo_url)
+	m.config.Command.Run = cfg.CommandRunRedact
+	m.config.Git.Scan.CacheDir = t.TempDir()
+	m.config.Redact.Detector = cfg.RedactDetectorCache
+	m.config.Redact.OutputDir = t.TempDir()
+
+	// save the results of a previous scan of notes.md in the blob cache,
+	// where README.md was never scanned
+	blob_cache, err := cache.NewBlobCache(m.config.Git.Scan.CacheDir, cache.FingerprintFromConfig(m.config), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	blob := plumbing.ComputeHash(plumbing.BlobObject, []byte(notes)).String()
+	response := rrr.Response{Results: []rrr.Result{
+		{Category: "Person", ConfidenceScore: 0.97, Length: 10, Offset: 8, Text: "John Smith"},
+	}}
+	response.ID = "request_1"
+	response.Object.ID = blob
+	response.Object.Length = len(notes)
+	blob_cache.Expect(blob, []string{response.ID})
+	blob_cache.Add(response)
+	if !assert.NoError(t, blob_cache.Save()) {
+		t.FailNow()
+	}
+
+	err = m.commandRedact()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	repo_name, name_err := nogit.ParseRepoNameFromURL(repo_url)
+	assert.NoError(t, name_err)
+	redacted, read_err := os.ReadFile(filepath.Join(m.config.Redact.OutputDir, repo_name, "notes.md"))
+	assert.NoError(t, read_err)
+	assert.Equal(t, "Patient ********** was seen today.\n", string(redacted))
+	// files that are not in the blob cache should not be written
+	_, stat_err := os.Stat(filepath.Join(m.config.Redact.OutputDir, repo_name, "README.md"))
+	assert.True(t, os.IsNotExist(stat_err))
+	if assert.NotNil(t, m.report) {
+		assert.Nil(t, m.report.Usage)
+	}
+}
+
+// TestManager_commandBaseline() unit test function runs the "baseline" and
+// "scan-new" commands end-to-end against a local repository and a local
+// aztest.Server, where the findings of the repository are only reported as
+// new findings until they are added to the baseline.
+func TestManager_commandBaseline(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":    "# test repository\n",
+	})
+	baseline_path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline_key := "0123456789abcdef0123456789abcdef"
+
+	// the finding is new without a baseline
+	m := testNewManager(t, server, repo_url)
+	m.config.Baseline.Path = baseline_path
+	m.config.Git.Scan.ResultText.Key = baseline_key
+	m.config.Command.Run = cfg.CommandRunScanNew
+	err := m.commandScanNew()
+	assert.ErrorContains(t, err, "found 1 findings that are not in baseline")
+	if assert.NotNil(t, m.report) {
+		assert.Equal(t, map[string]int{"Person": 1}, m.report.Results)
+		assert.Equal(t, 0, m.report.Suppressed)
+	}
+
+	// the finding is added to the baseline
+	m = testNewManager(t, server, repo_url)
+	m.config.Baseline.Author = "test"
+	m.config.Baseline.Path = baseline_path
+	m.config.Git.Scan.ResultText.Key = baseline_key
+	m.config.Baseline.Reason = "test data"
+	m.config.Command.Run = cfg.CommandRunBaseline
+	if !assert.NoError(t, m.commandBaseline()) {
+		t.FailNow()
+	}
+	contents, read_err := os.ReadFile(baseline_path)
+	assert.NoError(t, read_err)
+	assert.Contains(t, string(contents), `"reason": "test data"`)
+	// the text of the finding is not written to the baseline
+	assert.NotContains(t, string(contents), "John Smith")
+
+	// the finding is suppressed by the baseline, even when the scan only
+	// keeps the HMAC of the text of its results
+	m = testNewManager(t, server, repo_url)
+	m.config.Baseline.Path = baseline_path
+	m.config.Git.Scan.ResultText.Key = baseline_key
+	m.config.Git.Scan.ResultText.Mode = cfg.GitScanResultTextModeHMAC
+	m.config.Command.Run = cfg.CommandRunScanNew
+	assert.NoError(t, m.commandScanNew())
+	if assert.NotNil(t, m.report) {
+		assert.Empty(t, m.report.Results)
+		assert.Equal(t, 1, m.report.Suppressed)
+	}
+}
+
+// TestManager_commandScanRepos_ignore() unit test function runs the
+// "scan-repos" command end-to-end against a local repository with inline
+// annotations, and checks that the ignored results are counted instead of
+// being stored.
+func TestManager_commandScanRepos_ignore(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"fixtures.md": "<!-- no-phi-ai:ignore-next-line category=Person -->\nJohn Smith is a synthetic patient\n",
+	})
+	m := testNewManager(t, server, repo_url)
+
+	err := m.commandScanRepos()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	records, list_err := m.scanner.GetResultRecordIO().List()
+	assert.NoError(t, list_err)
+	assert.Empty(t, records)
+	if assert.NotNil(t, m.report) {
+		assert.Empty(t, m.report.Results)
+		assert.Equal(t, int64(1), m.report.ResultsIgnored)
+	}
+}
+
+// TestManager_commandScanRepos_resultTextHMAC() unit test function runs the
+// "scan-repos" command end-to-end with the HMAC mode of the text of results,
+// and checks that the detected text is not stored.
+func TestManager_commandScanRepos_resultTextHMAC(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+	m.config.Git.Scan.ResultText.Mode = cfg.GitScanResultTextModeHMAC
+
+	err := m.commandScanRepos()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	records, list_err := m.scanner.GetResultRecordIO().List()
+	assert.NoError(t, list_err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "Person", records[0].Category)
+		assert.Equal(t, "J*** S****", records[0].Text)
+		assert.NotEmpty(t, records[0].TextHMAC)
+	}
+}
+
+// TestManager_commandReencrypt() unit test function runs the "scan-repos"
+// command with encryption enabled and saves and queues a webhook event, then
+// runs the "reencrypt" command with a rotated key.
+func TestManager_commandReencrypt(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.Git.Scan.CacheDir = t.TempDir()
+	m.config.Git.Scan.Encryption.Keys = "key-1:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+
+	err := m.commandScanRepos()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the encryption of the result records is transparent to the scanner
+	records, list_err := m.scanner.GetResultRecordIO().List()
+	assert.NoError(t, list_err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "John Smith", records[0].Text)
+	}
+	cache_path := filepath.Join(m.config.Git.Scan.CacheDir, cache.FileName)
+	contents, read_err := os.ReadFile(cache_path)
+	if assert.NoError(t, read_err) {
+		key_id, _ := crypt.EnvelopeKeyID(contents)
+		assert.Equal(t, "key-1", key_id)
+		assert.NotContains(t, string(contents), "John Smith")
+	}
+
+	// queue a webhook event of the server with the same key
+	m.config.Server.Queue.Dir = t.TempDir()
+	keyring, keyring_err := loadKeyring(m.config)
+	if !assert.NoError(t, keyring_err) {
+		t.FailNow()
+	}
+	queue_store, store_err := queue.NewFileStore(m.config.Server.Queue.Dir, keyring)
+	if !assert.NoError(t, store_err) {
+		t.FailNow()
+	}
+	task, task_err := queue.NewTask(githubapp.Dispatch{
+		DeliveryID: "delivery-1",
+		EventType:  "push",
+		Payload:    []byte(`{"after":"abc123","ref":"refs/heads/main","repository":{"full_name":"test-org/test-repo"}}`),
+	})
+	if !assert.NoError(t, task_err) {
+		t.FailNow()
+	}
+	assert.NoError(t, queue_store.Save(task))
+	task_path := filepath.Join(m.config.Server.Queue.Dir, "delivery-1"+queue.FileExtension)
+	// keep the delivery of the webhook event with the same key
+	m.config.Server.Deliveries.Dir = t.TempDir()
+	deliveries_store, store_err := deliveries.NewFileStore(m.config.Server.Deliveries.Dir, keyring)
+	if !assert.NoError(t, store_err) {
+		t.FailNow()
+	}
+	assert.NoError(t, deliveries_store.Save(&deliveries.Delivery{DeliveryID: "delivery-1", Payload: task.Payload}))
+	delivery_path := filepath.Join(m.config.Server.Deliveries.Dir, "delivery-1"+deliveries.FileExtension)
+
+	m.config.Command.Run = cfg.CommandRunReencrypt
+	m.config.Git.Scan.Encryption.Keys = "key-2:ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8=," + m.config.Git.Scan.Encryption.Keys
+	assert.NoError(t, m.commandReencrypt())
+	for _, path := range []string{cache_path, delivery_path, task_path} {
+		contents, read_err = os.ReadFile(path)
+		if assert.NoError(t, read_err) {
+			key_id, _ := crypt.EnvelopeKeyID(contents)
+			assert.Equal(t, "key-2", key_id, path)
+		}
+	}
+}
+
+// TestLoadKeyring() unit test function tests that the configured encryption
+// keys are verified when they are loaded.
+func TestLoadKeyring(t *testing.T) {
+	t.Parallel()
+
+	config := cfg.NewDefaultConfig()
+	keyring, err := loadKeyring(config)
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+
+	config.Git.Scan.Encryption.Keys = "key-1:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	keyring, err = loadKeyring(config)
+	if assert.NoError(t, err) && assert.NotNil(t, keyring) {
+		assert.Equal(t, "key-1", keyring.Primary())
+	}
+
+	config.Git.Scan.Encryption.Keys = "key-1:c2VjcmV0"
+	_, err = loadKeyring(config)
+	assert.ErrorContains(t, err, "invalid config value: git.scan.encryption")
+
+	config.Git.Scan.Encryption.Keys = ""
+	config.Git.Scan.Encryption.KeyFile = filepath.Join(t.TempDir(), "missing")
+	_, err = loadKeyring(config)
+	assert.ErrorContains(t, err, "invalid config value: git.scan.encryption")
+}
@@ -0,0 +1,20 @@
+package deliveries
+
+import (
+	"os"
+	"time"
+)
+
+const DirMode os.FileMode = 0700
+const FileExtension string = ".json"
+const FileMode os.FileMode = 0600
+
+// PruneInterval is the interval at which expired deliveries are deleted from
+// the Store of a Scheduler.
+const PruneInterval time.Duration = 10 * time.Minute
+
+// Headers of the webhook deliveries of GitHub, which are set on the requests
+// that replay a Delivery.
+const HeaderDelivery string = "X-GitHub-Delivery"
+const HeaderEvent string = "X-GitHub-Event"
+const HeaderSignature string = "X-Hub-Signature-256"
@@ -0,0 +1,84 @@
+package deliveries
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Delivery struct contains a webhook delivery of GitHub that was scheduled
+// by a Scheduler, which is kept until it expires in order to ignore any
+// redelivery of the same event, and to replay the event for debugging.
+type Delivery struct {
+	// DeliveryID is the unique ID of the delivery (i.e. the
+	// X-GitHub-Delivery header).
+	DeliveryID string `json:"delivery_id"`
+	// EventType is the type of the event (i.e. the X-GitHub-Event header).
+	EventType string `json:"event_type"`
+	// Payload is the (JSON) payload of the event.
+	Payload json.RawMessage `json:"payload"`
+	// Received is the time at which the delivery was first scheduled.
+	Received time.Time `json:"received"`
+}
+
+// Expired() method returns true if the Delivery was received longer than the
+// provided TTL before the provided time.
+func (d *Delivery) Expired(now time.Time, ttl time.Duration) bool {
+	return !now.Before(d.Received.Add(ttl))
+}
+
+// Request() method returns a new (POST) request to the provided URL that
+// replays the Delivery with the context of a replay (see WithReplay()), where
+// the payload of the request is signed with the provided webhook secret such
+// that the request is accepted by a githubapp event dispatcher.
+func (d *Delivery) Request(ctx context.Context, url, secret string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(WithReplay(ctx), http.MethodPost, url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderDelivery, d.DeliveryID)
+	request.Header.Set(HeaderEvent, d.EventType)
+	request.Header.Set(HeaderSignature, Signature(secret, d.Payload))
+
+	return request, nil
+}
+
+// Signature() function returns the value of the X-Hub-Signature-256 header
+// of a webhook delivery with the provided payload, which is the hex-encoded
+// HMAC-SHA256 of the payload keyed by the webhook secret.
+func Signature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// replayKey struct is the key of the value of a context that marks the
+// context as the context of a replayed Delivery.
+type replayKey struct{}
+
+// WithReplay() function returns a copy of the provided context that marks
+// the webhook delivery of the context as a replay, which is never ignored
+// by a Scheduler.
+func WithReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+// IsReplay() function returns true if the provided context is marked as the
+// context of a replayed webhook delivery.
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// validDeliveryID() function returns true if the provided delivery ID can be
+// used as the name of a file.
+func validDeliveryID(delivery_id string) bool {
+	return delivery_id != "" && delivery_id != "." && delivery_id != ".." && !strings.ContainsAny(delivery_id, `/\`)
+}
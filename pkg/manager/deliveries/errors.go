@@ -0,0 +1,21 @@
+package deliveries
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgDeliveryDelete    = "failed to delete webhook delivery"
+	ErrMsgDeliveryGet       = "failed to get webhook delivery"
+	ErrMsgDeliveryList      = "failed to list webhook deliveries"
+	ErrMsgDeliveryReencrypt = "failed to re-encrypt webhook deliveries"
+	ErrMsgDeliverySave      = "failed to save webhook delivery"
+)
+
+var (
+	ErrDeliveryIDInvalid   = errors.New("webhook delivery ID is invalid")
+	ErrDeliveryNil         = errors.New("webhook delivery is nil")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrFileStoreDirEmpty   = errors.New("delivery file store requires a directory")
+	ErrSchedulerNextNil    = errors.New("delivery scheduler requires a next scheduler")
+	ErrSchedulerStoreNil   = errors.New("delivery scheduler requires a store")
+	ErrSchedulerTTLInvalid = errors.New("delivery scheduler TTL must be positive")
+)
@@ -0,0 +1,197 @@
+package deliveries
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Scheduler struct provides an implementation of the githubapp.Scheduler
+// interface that makes the webhook deliveries of GitHub idempotent, where
+// each delivery is passed to the next githubapp.Scheduler (e.g. a queue) and
+// then saved to the Store of the Scheduler until its TTL expires, such that
+// any redelivery of the same event (i.e. with the same delivery ID) is
+// ignored. Replayed deliveries (see WithReplay()) are never ignored. Safe for
+// concurrent use.
+type Scheduler struct {
+	mutex *sync.Mutex
+	next  githubapp.Scheduler
+	// pending contains the IDs of the deliveries that are being scheduled,
+	// which are reserved while the mutex is held, such that the mutex is not
+	// held while the deliveries are passed to next and saved to the store
+	pending map[string]struct{}
+	store   Store
+	ttl     time.Duration
+}
+
+// NewScheduler() function returns a new Scheduler that passes deliveries to
+// the provided next githubapp.Scheduler and saves them to the provided Store
+// for the provided TTL, where expired deliveries are deleted from the Store
+// every PruneInterval until the provided context is done.
+func NewScheduler(ctx context.Context, next githubapp.Scheduler, store Store, ttl time.Duration) (*Scheduler, error) {
+	if next == nil {
+		return nil, ErrSchedulerNextNil
+	}
+	if store == nil {
+		return nil, ErrSchedulerStoreNil
+	}
+	if ttl <= 0 {
+		return nil, ErrSchedulerTTLInvalid
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s := &Scheduler{
+		mutex:   &sync.Mutex{},
+		next:    next,
+		pending: make(map[string]struct{}),
+		store:   store,
+		ttl:     ttl,
+	}
+	go s.prune(ctx)
+
+	return s, nil
+}
+
+// Get() method returns the (unexpired) Delivery with the provided delivery
+// ID, or an error wrapping ErrDeliveryNotFound if it does not exist.
+func (s *Scheduler) Get(delivery_id string) (*Delivery, error) {
+	delivery, err := s.store.Get(delivery_id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Expired(time.Now(), s.ttl) {
+		return nil, errors.Wrapf(ErrDeliveryNotFound, "delivery ID = %q", delivery_id)
+	}
+
+	return delivery, nil
+}
+
+// Prune() method deletes every expired Delivery from the Store of the
+// Scheduler, and returns the number of deleted deliveries.
+func (s *Scheduler) Prune() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deliveries, err := s.store.List()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	now := time.Now()
+	for _, delivery := range deliveries {
+		if !delivery.Expired(now, s.ttl) {
+			continue
+		}
+		if err := s.store.Delete(delivery.DeliveryID); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Schedule() method implements the githubapp.Scheduler interface by passing
+// the provided githubapp.Dispatch to the next githubapp.Scheduler, unless
+// the delivery of the Dispatch was already scheduled within the TTL of the
+// Scheduler or that is still being scheduled. Deliveries that are rejected
+// by the next githubapp.Scheduler are not saved, such that GitHub can
+// deliver them again.
+func (s *Scheduler) Schedule(ctx context.Context, dispatch githubapp.Dispatch) error {
+	logger := zerolog.Ctx(ctx)
+	replay := IsReplay(ctx)
+
+	if !replay {
+		if !s.reserve(dispatch.DeliveryID) {
+			logger.Info().Msgf("ignoring duplicate webhook delivery type=%s : deliveryID=%s : still being scheduled",
+				dispatch.EventType, dispatch.DeliveryID)
+			return nil
+		}
+		// the reservation is released once the delivery is saved or
+		// rejected, such that GitHub can deliver rejected events again
+		defer s.release(dispatch.DeliveryID)
+
+		delivery, err := s.store.Get(dispatch.DeliveryID)
+		switch {
+		case err == nil && !delivery.Expired(time.Now(), s.ttl):
+			logger.Info().Msgf("ignoring duplicate webhook delivery type=%s : deliveryID=%s : first received %s",
+				dispatch.EventType, dispatch.DeliveryID, delivery.Received.Format(time.RFC3339))
+			return nil
+		case err != nil && !errors.Is(err, ErrDeliveryNotFound):
+			// the event is still scheduled, so that a failure of the Store
+			// does not drop the delivery
+			logger.Error().Err(err).Msgf("failed to check webhook delivery deliveryID=%s", dispatch.DeliveryID)
+		}
+	}
+
+	if err := s.next.Schedule(ctx, dispatch); err != nil {
+		return err
+	}
+	if replay {
+		logger.Info().Msgf("replayed webhook delivery type=%s : deliveryID=%s", dispatch.EventType, dispatch.DeliveryID)
+		return nil
+	}
+
+	err := s.store.Save(&Delivery{
+		DeliveryID: dispatch.DeliveryID,
+		EventType:  dispatch.EventType,
+		Payload:    dispatch.Payload,
+		Received:   time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Error().Err(err).Msgf("failed to save webhook delivery deliveryID=%s", dispatch.DeliveryID)
+	}
+
+	return nil
+}
+
+// release() method releases the reservation of the provided delivery ID
+// (see reserve()).
+func (s *Scheduler) release(delivery_id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.pending, delivery_id)
+}
+
+// reserve() method reserves the provided delivery ID for the caller, and
+// returns false if the delivery ID is already reserved by another call of
+// Schedule() (i.e. the delivery is a duplicate that is being scheduled).
+func (s *Scheduler) reserve(delivery_id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.pending[delivery_id]; ok {
+		return false
+	}
+	s.pending[delivery_id] = struct{}{}
+
+	return true
+}
+
+// prune() method deletes expired deliveries from the Store of the Scheduler
+// every PruneInterval until the provided context is done.
+func (s *Scheduler) prune(ctx context.Context) {
+	ticker := time.NewTicker(PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.Prune()
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msg("failed to prune expired webhook deliveries")
+			} else if count > 0 {
+				zerolog.Ctx(ctx).Debug().Msgf("pruned %d expired webhook deliveries", count)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package deliveries
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testScheduler struct is a githubapp.Scheduler that records the delivery ID
+// of every scheduled githubapp.Dispatch, and rejects every dispatch while
+// its err field is set.
+type testScheduler struct {
+	err       error
+	mutex     *sync.Mutex
+	scheduled []string
+}
+
+func (ts *testScheduler) Schedule(ctx context.Context, dispatch githubapp.Dispatch) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.err != nil {
+		return ts.err
+	}
+	ts.scheduled = append(ts.scheduled, dispatch.DeliveryID)
+	return nil
+}
+
+// testDispatch() helper function returns a githubapp.Dispatch of a push event
+// with the provided delivery ID.
+func testDispatch(delivery_id string) githubapp.Dispatch {
+	return githubapp.Dispatch{
+		DeliveryID: delivery_id,
+		EventType:  "push",
+		Payload:    []byte(`{"after":"abc123"}`),
+	}
+}
+
+// TestScheduler() unit test function tests ignoring duplicate deliveries and
+// replaying deliveries with the Scheduler type.
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	next := &testScheduler{mutex: &sync.Mutex{}}
+	store := NewMemoryStore()
+
+	_, err := NewScheduler(context.Background(), nil, store, time.Hour)
+	assert.ErrorIs(t, err, ErrSchedulerNextNil)
+	_, err = NewScheduler(context.Background(), next, nil, time.Hour)
+	assert.ErrorIs(t, err, ErrSchedulerStoreNil)
+	_, err = NewScheduler(context.Background(), next, store, 0)
+	assert.ErrorIs(t, err, ErrSchedulerTTLInvalid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := NewScheduler(ctx, next, store, time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// redeliveries of the same event are ignored
+	assert.NoError(t, s.Schedule(ctx, testDispatch("delivery-1")))
+	assert.NoError(t, s.Schedule(ctx, testDispatch("delivery-1")))
+	assert.Equal(t, []string{"delivery-1"}, next.scheduled)
+
+	delivery, err := s.Get("delivery-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "push", delivery.EventType)
+		assert.JSONEq(t, `{"after":"abc123"}`, string(delivery.Payload))
+	}
+	_, err = s.Get("delivery-missing")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+
+	// replayed deliveries are never ignored
+	assert.NoError(t, s.Schedule(WithReplay(ctx), testDispatch("delivery-1")))
+	assert.Equal(t, []string{"delivery-1", "delivery-1"}, next.scheduled)
+
+	// rejected deliveries are not saved, so that they can be delivered again
+	next.err = githubapp.ErrCapacityExceeded
+	assert.ErrorIs(t, s.Schedule(ctx, testDispatch("delivery-2")), githubapp.ErrCapacityExceeded)
+	next.err = nil
+	assert.NoError(t, s.Schedule(ctx, testDispatch("delivery-2")))
+	assert.Equal(t, []string{"delivery-1", "delivery-1", "delivery-2"}, next.scheduled)
+
+	// expired deliveries are no longer ignored, and are pruned
+	delivery, _ = store.Get("delivery-1")
+	delivery.Received = time.Now().Add(-time.Hour)
+	_, err = s.Get("delivery-1")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	count, err := s.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	deliveries, _ := store.List()
+	assert.Len(t, deliveries, 1)
+	assert.NoError(t, s.Schedule(ctx, testDispatch("delivery-1")))
+	assert.Equal(t, []string{"delivery-1", "delivery-1", "delivery-2", "delivery-1"}, next.scheduled)
+}
+
+// testBlockingScheduler struct is a testScheduler that blocks the dispatch
+// of the delivery with the ID of its block field until its release channel
+// is closed, after sending the delivery ID to its started channel.
+type testBlockingScheduler struct {
+	*testScheduler
+	block   string
+	release chan struct{}
+	started chan string
+}
+
+func (ts *testBlockingScheduler) Schedule(ctx context.Context, dispatch githubapp.Dispatch) error {
+	if dispatch.DeliveryID == ts.block {
+		ts.started <- dispatch.DeliveryID
+		<-ts.release
+	}
+	return ts.testScheduler.Schedule(ctx, dispatch)
+}
+
+// TestScheduler_concurrent() unit test function tests that a delivery that
+// is blocked by the next githubapp.Scheduler does not block the scheduling
+// of other deliveries, and that its redeliveries are ignored meanwhile.
+func TestScheduler_concurrent(t *testing.T) {
+	t.Parallel()
+
+	next := &testBlockingScheduler{
+		testScheduler: &testScheduler{mutex: &sync.Mutex{}},
+		block:         "delivery-1",
+		release:       make(chan struct{}),
+		started:       make(chan string, 1),
+	}
+	s, err := NewScheduler(context.Background(), next, NewMemoryStore(), time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- s.Schedule(context.Background(), testDispatch("delivery-1"))
+	}()
+	assert.Equal(t, "delivery-1", <-next.started)
+
+	scheduled := make(chan error, 1)
+	go func() {
+		// the redelivery is ignored while the first delivery is scheduled
+		if err := s.Schedule(context.Background(), testDispatch("delivery-1")); err != nil {
+			scheduled <- err
+			return
+		}
+		scheduled <- s.Schedule(context.Background(), testDispatch("delivery-2"))
+	}()
+	select {
+	case err := <-scheduled:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduling was blocked by another delivery")
+	}
+
+	close(next.release)
+	assert.NoError(t, <-blocked)
+	assert.Equal(t, []string{"delivery-2", "delivery-1"}, next.scheduled)
+	_, err = s.Get("delivery-1")
+	assert.NoError(t, err)
+}
+
+// TestScheduler_storeError() unit test function tests that deliveries are
+// still scheduled when the Store of the Scheduler fails.
+func TestScheduler_storeError(t *testing.T) {
+	t.Parallel()
+
+	next := &testScheduler{mutex: &sync.Mutex{}}
+	s, err := NewScheduler(context.Background(), next, testFailingStore{}, time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, s.Schedule(context.Background(), testDispatch("delivery-1")))
+	assert.NoError(t, s.Schedule(context.Background(), testDispatch("delivery-1")))
+	assert.Equal(t, []string{"delivery-1", "delivery-1"}, next.scheduled)
+}
+
+// testFailingStore struct is a Store whose methods always fail.
+type testFailingStore struct{}
+
+func (testFailingStore) Delete(string) error           { return errors.New("test failure") }
+func (testFailingStore) Get(string) (*Delivery, error) { return nil, errors.New("test failure") }
+func (testFailingStore) List() ([]*Delivery, error)    { return nil, errors.New("test failure") }
+func (testFailingStore) Save(*Delivery) error          { return errors.New("test failure") }
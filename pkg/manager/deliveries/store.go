@@ -0,0 +1,267 @@
+package deliveries
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+)
+
+// Store interface defines the methods for saving the webhook deliveries of a
+// Scheduler to some store (e.g. memory, files, database), where each Delivery
+// is identified by its delivery ID.
+type Store interface {
+	Delete(delivery_id string) error
+	Get(delivery_id string) (*Delivery, error)
+	List() ([]*Delivery, error)
+	Save(delivery *Delivery) error
+}
+
+// MemoryStore struct provides an implementation of the Store interface that
+// keeps every Delivery in memory, such that deliveries are forgotten when
+// the server is restarted.
+type MemoryStore struct {
+	deliveries map[string]*Delivery
+	mutex      *sync.RWMutex
+}
+
+// NewMemoryStore() function returns a new (empty) MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		deliveries: make(map[string]*Delivery),
+		mutex:      &sync.RWMutex{},
+	}
+}
+
+// Delete() method deletes the Delivery with the provided delivery ID, if it
+// exists.
+func (ms *MemoryStore) Delete(delivery_id string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	delete(ms.deliveries, delivery_id)
+	return nil
+}
+
+// Get() method returns the Delivery with the provided delivery ID, or an
+// error wrapping ErrDeliveryNotFound if it does not exist.
+func (ms *MemoryStore) Get(delivery_id string) (*Delivery, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	delivery, exists := ms.deliveries[delivery_id]
+	if !exists {
+		return nil, errors.Wrapf(ErrDeliveryNotFound, "delivery ID = %q", delivery_id)
+	}
+	return delivery, nil
+}
+
+// List() method returns every Delivery of the MemoryStore.
+func (ms *MemoryStore) List() ([]*Delivery, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	deliveries := make([]*Delivery, 0, len(ms.deliveries))
+	for _, delivery := range ms.deliveries {
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Save() method saves the provided Delivery, replacing any Delivery with the
+// same delivery ID.
+func (ms *MemoryStore) Save(delivery *Delivery) error {
+	if delivery == nil {
+		return errors.Wrap(ErrDeliveryNil, ErrMsgDeliverySave)
+	}
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.deliveries[delivery.DeliveryID] = delivery
+	return nil
+}
+
+// FileStore struct provides an implementation of the Store interface that
+// saves each Delivery as a JSON file in a directory, which is encrypted when
+// the FileStore has a keyring (i.e. because payloads may contain PHI).
+type FileStore struct {
+	dir     string
+	keyring *crypt.Keyring
+	mutex   *sync.Mutex
+}
+
+// NewFileStore() function returns a new FileStore that saves deliveries in
+// the provided directory, which is created if it does not exist. The
+// deliveries are encrypted with the (optional) keyring.
+func NewFileStore(dir string, keyring *crypt.Keyring) (*FileStore, error) {
+	if dir == "" {
+		return nil, ErrFileStoreDirEmpty
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return nil, errors.Wrapf(err, "failed to create deliveries directory %s", dir)
+	}
+
+	return &FileStore{
+		dir:     dir,
+		keyring: keyring,
+		mutex:   &sync.Mutex{},
+	}, nil
+}
+
+// Delete() method deletes the Delivery with the provided delivery ID, if it
+// exists.
+func (fs *FileStore) Delete(delivery_id string) error {
+	path, err := fs.path(delivery_id)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgDeliveryDelete)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, ErrMsgDeliveryDelete)
+	}
+
+	return nil
+}
+
+// Get() method returns the Delivery with the provided delivery ID, or an
+// error wrapping ErrDeliveryNotFound if it has not been saved.
+func (fs *FileStore) Get(delivery_id string) (*Delivery, error) {
+	path, err := fs.path(delivery_id)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgDeliveryGet)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	delivery, err := fs.read(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrDeliveryNotFound, "delivery ID = %q", delivery_id)
+		}
+		return nil, errors.Wrap(err, ErrMsgDeliveryGet)
+	}
+
+	return delivery, nil
+}
+
+// List() method returns every Delivery saved in the FileStore.
+func (fs *FileStore) List() ([]*Delivery, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgDeliveryList)
+	}
+
+	deliveries := make([]*Delivery, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExtension {
+			continue
+		}
+		delivery, err := fs.read(filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s : %s", ErrMsgDeliveryList, entry.Name())
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Save() method writes the Delivery to its file, replacing any Delivery with
+// the same delivery ID. The file is replaced atomically.
+func (fs *FileStore) Save(delivery *Delivery) error {
+	if delivery == nil {
+		return errors.Wrap(ErrDeliveryNil, ErrMsgDeliverySave)
+	}
+	path, err := fs.path(delivery.DeliveryID)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgDeliverySave)
+	}
+
+	contents, err := json.Marshal(delivery)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgDeliverySave)
+	}
+	contents, err = crypt.Encode(fs.keyring, contents)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgDeliverySave)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := fileio.WriteFileAtomic(path, contents, FileMode); err != nil {
+		return errors.Wrap(err, ErrMsgDeliverySave)
+	}
+
+	return nil
+}
+
+// Reencrypt() method encrypts every Delivery file of the FileStore with the
+// primary key of its keyring (see crypt.ReencryptFile()), and returns the
+// number of files that were rewritten.
+func (fs *FileStore) Reencrypt() (int, error) {
+	if fs.keyring == nil {
+		return 0, errors.Wrap(crypt.ErrKeyringEmpty, ErrMsgDeliveryReencrypt)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrMsgDeliveryReencrypt)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExtension {
+			continue
+		}
+		changed, err := crypt.ReencryptFile(fs.keyring, filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return count, errors.Wrap(err, ErrMsgDeliveryReencrypt)
+		}
+		if changed {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// path() method returns the path of the file of the Delivery with the
+// provided delivery ID.
+func (fs *FileStore) path(delivery_id string) (string, error) {
+	if !validDeliveryID(delivery_id) {
+		return "", errors.Wrapf(ErrDeliveryIDInvalid, "delivery ID = %q", delivery_id)
+	}
+
+	return filepath.Join(fs.dir, delivery_id+FileExtension), nil
+}
+
+// read() method reads the Delivery from the file with the provided path,
+// which must be locked by the caller.
+func (fs *FileStore) read(path string) (*Delivery, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	contents, err = crypt.Decode(fs.keyring, contents)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &Delivery{}
+	if err := json.Unmarshal(contents, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,161 @@
+package deliveries
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+)
+
+// TestStore() unit test function tests saving, getting, listing and deleting
+// deliveries with each implementation of the Store interface.
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFileStore("", nil)
+	assert.ErrorIs(t, err, ErrFileStoreDirEmpty)
+
+	keyring, err := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	plaintext_dir := t.TempDir()
+	plaintext_store, err := NewFileStore(plaintext_dir, nil)
+	assert.NoError(t, err)
+	encrypted_dir := t.TempDir()
+	encrypted_store, err := NewFileStore(encrypted_dir, keyring)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		dir   string
+		name  string
+		store Store
+	}{
+		{name: "Memory", store: NewMemoryStore()},
+		{dir: plaintext_dir, name: "File_Plaintext", store: plaintext_store},
+		{dir: encrypted_dir, name: "File_Encrypted", store: encrypted_store},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			delivery := &Delivery{
+				DeliveryID: "delivery-1",
+				EventType:  "issue_comment",
+				Payload:    []byte(`{"comment":{"body":"John Smith"}}`),
+				Received:   time.Now().UTC().Truncate(time.Second),
+			}
+			assert.NoError(t, test.store.Save(delivery))
+			assert.NoError(t, test.store.Save(&Delivery{DeliveryID: "delivery-2", Payload: []byte(`{}`)}))
+
+			got, err := test.store.Get("delivery-1")
+			if assert.NoError(t, err) {
+				assert.Equal(t, delivery.EventType, got.EventType)
+				assert.JSONEq(t, string(delivery.Payload), string(got.Payload))
+				assert.True(t, delivery.Received.Equal(got.Received))
+			}
+			listed, err := test.store.List()
+			assert.NoError(t, err)
+			assert.Len(t, listed, 2)
+
+			if test.dir != "" {
+				contents, err := os.ReadFile(filepath.Join(test.dir, "delivery-1"+FileExtension))
+				assert.NoError(t, err)
+				assert.Equal(t, test.name == "File_Plaintext", bytes.Contains(contents, []byte("John Smith")))
+				_, err = test.store.Get("../delivery-1")
+				assert.ErrorIs(t, err, ErrDeliveryIDInvalid)
+			}
+
+			assert.NoError(t, test.store.Delete("delivery-1"))
+			assert.NoError(t, test.store.Delete("delivery-1"))
+			_, err = test.store.Get("delivery-1")
+			assert.ErrorIs(t, err, ErrDeliveryNotFound)
+			assert.ErrorIs(t, test.store.Save(nil), ErrDeliveryNil)
+		})
+	}
+}
+
+// TestFileStore_Reencrypt() unit test function tests that the deliveries of
+// a FileStore are re-encrypted with the primary key of a rotated keyring by
+// the Reencrypt() method.
+func TestFileStore_Reencrypt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	plain_store, err := NewFileStore(dir, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = plain_store.Reencrypt()
+	assert.ErrorIs(t, err, crypt.ErrKeyringEmpty)
+
+	// save a delivery before encryption is enabled and a delivery with the
+	// old key
+	assert.NoError(t, plain_store.Save(&Delivery{DeliveryID: "delivery-1", Payload: []byte(`{}`)}))
+	old_keyring, _ := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	old_store, err := NewFileStore(dir, old_keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, old_store.Save(&Delivery{DeliveryID: "delivery-2", Payload: []byte(`{}`)}))
+
+	keyring, _ := crypt.NewKeyring([]crypt.Key{
+		{ID: "key-2", Secret: bytes.Repeat([]byte{2}, crypt.KeySize)},
+		{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)},
+	})
+	rotated_store, err := NewFileStore(dir, keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	count, err := rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// the old key can no longer read the re-encrypted deliveries
+	_, err = old_store.Get("delivery-1")
+	assert.ErrorIs(t, err, crypt.ErrKeyNotFound)
+	deliveries, err := rotated_store.List()
+	if assert.NoError(t, err) {
+		assert.Len(t, deliveries, 2)
+	}
+}
+
+// TestDelivery_Request() unit test function tests that the requests that
+// replay a Delivery are signed with the webhook secret.
+func TestDelivery_Request(t *testing.T) {
+	t.Parallel()
+
+	delivery := &Delivery{
+		DeliveryID: "delivery-1",
+		EventType:  "push",
+		Payload:    []byte(`{"after":"abc123"}`),
+	}
+	request, err := delivery.Request(context.Background(), "/api/v1/github/hook", "test-secret")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, IsReplay(request.Context()))
+	assert.False(t, IsReplay(context.Background()))
+	assert.Equal(t, "delivery-1", request.Header.Get(HeaderDelivery))
+	assert.Equal(t, "push", request.Header.Get(HeaderEvent))
+
+	payload, err := github.ValidatePayload(request, []byte("test-secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(delivery.Payload), payload)
+
+	request, _ = delivery.Request(context.Background(), "/api/v1/github/hook", "other-secret")
+	_, err = github.ValidatePayload(request, []byte("test-secret"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,14 @@
+package exporter
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType string = "text/plain; version=0.0.4; charset=utf-8"
+
+// Types of the metric families of the Prometheus text exposition format.
+const TypeCounter string = "counter"
+const TypeGauge string = "gauge"
+const TypeSummary string = "summary"
+const TypeUntyped string = "untyped"
+
+// Quantiles are the quantiles of the summaries of go-metrics timers and
+// histograms.
+var Quantiles = []float64{0.5, 0.9, 0.99}
@@ -0,0 +1,13 @@
+package exporter
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgMetricsPush  = "failed to push metrics"
+	ErrMsgMetricsWrite = "failed to write metrics"
+)
+
+var (
+	ErrFamilyTypeMismatch = errors.New("metric family has samples of different types")
+	ErrPushStatus         = errors.New("unexpected status code from push gateway")
+)
@@ -0,0 +1,265 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+)
+
+// Sample struct contains a single sample of a metric Family.
+type Sample struct {
+	// Labels are the labels of the Sample (e.g. {"category": "Person"}).
+	Labels map[string]string
+	// Suffix is appended to the name of the Family for the Sample, which is
+	// used for the "_sum" and "_count" samples of a summary.
+	Suffix string
+	// Value is the value of the Sample.
+	Value float64
+}
+
+// Family struct contains the samples of a metric, where Type is one of the
+// types of the Prometheus text exposition format (e.g. TypeGauge).
+type Family struct {
+	Help    string
+	Name    string
+	Samples []Sample
+	Type    string
+}
+
+// Collector func type returns the current metric families of some state of
+// the app (e.g. the depth of a queue) each time that metrics are exported.
+type Collector func() []Family
+
+// Exporter struct exports the metrics of a go-metrics registry, along with
+// the metric families of its collectors, in the Prometheus text exposition
+// format, where the name of every metric is prefixed by the namespace of the
+// Exporter. Safe for concurrent use.
+type Exporter struct {
+	collectors []Collector
+	mutex      *sync.RWMutex
+	namespace  string
+	registry   metrics.Registry
+}
+
+// NewExporter() function returns a new Exporter for the metrics of the
+// provided (optional) go-metrics registry.
+func NewExporter(namespace string, registry metrics.Registry) *Exporter {
+	return &Exporter{
+		collectors: make([]Collector, 0),
+		mutex:      &sync.RWMutex{},
+		namespace:  namespace,
+		registry:   registry,
+	}
+}
+
+// Families() method returns every metric Family of the Exporter, sorted by
+// name, where the families with the same name are merged.
+func (e *Exporter) Families() ([]Family, error) {
+	e.mutex.RLock()
+	families := make([]Family, 0)
+	if e.registry != nil {
+		families = append(families, RegistryFamilies(e.registry)...)
+	}
+	for _, collector := range e.collectors {
+		families = append(families, collector()...)
+	}
+	e.mutex.RUnlock()
+
+	merged := make(map[string]*Family)
+	for i := range families {
+		family := families[i]
+		name := metricName(e.namespace, family.Name)
+		existing, exists := merged[name]
+		if !exists {
+			family.Name = name
+			merged[name] = &family
+			continue
+		}
+		if existing.Type != family.Type {
+			return nil, errors.Wrapf(ErrFamilyTypeMismatch, "%s : %s != %s", name, existing.Type, family.Type)
+		}
+		existing.Samples = append(existing.Samples, family.Samples...)
+		if existing.Help == "" {
+			existing.Help = family.Help
+		}
+	}
+
+	out := make([]Family, 0, len(merged))
+	for _, family := range merged {
+		out = append(out, *family)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].Name < out[b].Name
+	})
+
+	return out, nil
+}
+
+// Push() method pushes the metrics of the Exporter to the Prometheus push
+// gateway at the provided URL (e.g. "http://pushgateway:9091/metrics/job/x"),
+// replacing any metrics previously pushed to the same URL.
+func (e *Exporter) Push(ctx context.Context, url string) error {
+	var buffer bytes.Buffer
+	if err := e.Write(&buffer); err != nil {
+		return errors.Wrap(err, ErrMsgMetricsPush)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, url, &buffer)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgMetricsPush)
+	}
+	request.Header.Set("Content-Type", ContentType)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgMetricsPush)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return errors.Wrapf(ErrPushStatus, "%s : status code %d : %s", ErrMsgMetricsPush, response.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// Register() method adds the provided Collector to the Exporter.
+func (e *Exporter) Register(collector Collector) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.collectors = append(e.collectors, collector)
+}
+
+// ServeHTTP() method implements the http.Handler interface by responding
+// with the metrics of the Exporter.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buffer bytes.Buffer
+	if err := e.Write(&buffer); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buffer.Bytes())
+}
+
+// Write() method writes the metrics of the Exporter to the provided writer
+// in the Prometheus text exposition format.
+func (e *Exporter) Write(w io.Writer) error {
+	families, err := e.Families()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgMetricsWrite)
+	}
+
+	buffer := bufio.NewWriter(w)
+	for _, family := range families {
+		if family.Help != "" {
+			buffer.WriteString("# HELP " + family.Name + " " + escapeHelp(family.Help) + "\n")
+		}
+		family_type := family.Type
+		if family_type == "" {
+			family_type = TypeUntyped
+		}
+		buffer.WriteString("# TYPE " + family.Name + " " + family_type + "\n")
+		for _, sample := range family.Samples {
+			buffer.WriteString(family.Name + sample.Suffix + formatLabels(sample.Labels) + " " + formatValue(sample.Value) + "\n")
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return errors.Wrap(err, ErrMsgMetricsWrite)
+	}
+
+	return nil
+}
+
+// WriteFile() method writes the metrics of the Exporter to the file with the
+// provided path (e.g. for the textfile collector of the Prometheus node
+// exporter), where the file is replaced atomically.
+func (e *Exporter) WriteFile(path string) error {
+	var buffer bytes.Buffer
+	if err := e.Write(&buffer); err != nil {
+		return err
+	}
+	if err := fileio.WriteFileAtomic(path, buffer.Bytes(), 0644); err != nil {
+		return errors.Wrap(err, ErrMsgMetricsWrite)
+	}
+
+	return nil
+}
+
+// escapeHelp() function escapes the backslashes and line feeds of the help
+// text of a metric Family.
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// formatLabels() function returns the labels of a Sample in the Prometheus
+// text exposition format, sorted by name.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, sanitizeName(name)+`="`+replacer.Replace(labels[name])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue() function returns the value of a Sample in the Prometheus
+// text exposition format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// metricName() function returns the provided name of a metric, prefixed by
+// the provided namespace (if any), with every character that is not valid
+// in the name of a Prometheus metric replaced by an underscore.
+func metricName(namespace, name string) string {
+	if namespace != "" {
+		name = namespace + "_" + name
+	}
+	return sanitizeName(name)
+}
+
+// sanitizeName() function replaces every character of the provided name that
+// is not valid in the name of a Prometheus metric or label by an underscore.
+func sanitizeName(name string) string {
+	out := []rune(name)
+	for i, r := range out {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':' && i > 0:
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
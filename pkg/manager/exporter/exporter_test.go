@@ -0,0 +1,194 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExporter_Write() unit test function tests the text exposition format
+// of the metrics of a go-metrics registry and of registered collectors.
+func TestExporter_Write(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("github.requests", registry).Inc(3)
+	metrics.GetOrRegisterGauge("github.rate.limit[installation:1]", registry).Update(5000)
+	metrics.GetOrRegisterGauge("github.rate.limit[installation:2]", registry).Update(60)
+	metrics.GetOrRegisterGaugeFloat64("azure_ai.usage.estimated_cost", registry).Update(0.25)
+	timer := metrics.GetOrRegisterTimer("azure_ai.requests", registry)
+	timer.Update(time.Second)
+	timer.Update(3 * time.Second)
+
+	e := NewExporter("nophi", registry)
+	e.Register(func() []Family {
+		return []Family{{
+			Help: "Number of findings.",
+			Name: "findings",
+			Samples: []Sample{
+				{Labels: map[string]string{"category": `Quote"d\n`}, Value: 2},
+			},
+			Type: TypeGauge,
+		}}
+	})
+
+	var buffer bytes.Buffer
+	if !assert.NoError(t, e.Write(&buffer)) {
+		t.FailNow()
+	}
+	expected := `# TYPE nophi_azure_ai_requests_seconds summary
+nophi_azure_ai_requests_seconds{quantile="0.5"} 2
+nophi_azure_ai_requests_seconds{quantile="0.9"} 3
+nophi_azure_ai_requests_seconds{quantile="0.99"} 3
+nophi_azure_ai_requests_seconds_sum 4
+nophi_azure_ai_requests_seconds_count 2
+# TYPE nophi_azure_ai_usage_estimated_cost gauge
+nophi_azure_ai_usage_estimated_cost 0.25
+# HELP nophi_findings Number of findings.
+# TYPE nophi_findings gauge
+nophi_findings{category="Quote\"d\\n"} 2
+# TYPE nophi_github_rate_limit gauge
+nophi_github_rate_limit{installation="1"} 5000
+nophi_github_rate_limit{installation="2"} 60
+# TYPE nophi_github_requests_total counter
+nophi_github_requests_total 3
+`
+	assert.Equal(t, expected, buffer.String())
+
+	// families with the same name must have the same type
+	e.Register(func() []Family {
+		return []Family{{Name: "findings", Type: TypeCounter}}
+	})
+	assert.ErrorIs(t, e.Write(io.Discard), ErrFamilyTypeMismatch)
+}
+
+// TestExporter_Write_nameSort() unit test function tests that the samples of
+// tagged go-metrics names are sorted deterministically, independent of the
+// iteration order of the registry.
+func TestExporter_Write_nameSort(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	for _, name := range []string{"a[k:3]", "a[k:1]", "a[k:2]"} {
+		metrics.GetOrRegisterGauge(name, registry).Update(1)
+	}
+	e := NewExporter("", registry)
+	var buffer bytes.Buffer
+	assert.NoError(t, e.Write(&buffer))
+	assert.Contains(t, buffer.String(), "# TYPE a gauge\n")
+	assert.Equal(t, 3, bytes.Count(buffer.Bytes(), []byte("\na{k=")))
+}
+
+// TestExporter_ServeHTTP() unit test function tests serving the metrics of
+// an Exporter over HTTP.
+func TestExporter_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("github.requests", registry).Inc(1)
+	recorder := httptest.NewRecorder()
+	NewExporter("nophi", registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "nophi_github_requests_total 1\n")
+}
+
+// TestExporter_Push() unit test function tests pushing the metrics of an
+// Exporter to a (test) push gateway.
+func TestExporter_Push(t *testing.T) {
+	t.Parallel()
+
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(contents)
+		if r.URL.Path == "/metrics/job/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("github.requests", registry).Inc(2)
+	e := NewExporter("nophi", registry)
+
+	assert.NoError(t, e.Push(context.Background(), server.URL+"/metrics/job/no-phi-ai"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/no-phi-ai", path)
+	assert.Contains(t, body, "nophi_github_requests_total 2\n")
+
+	assert.ErrorIs(t, e.Push(context.Background(), server.URL+"/metrics/job/fail"), ErrPushStatus)
+}
+
+// TestExporter_WriteFile() unit test function tests dumping the metrics of an
+// Exporter to a file.
+func TestExporter_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("github.requests", registry).Inc(4)
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	e := NewExporter("nophi", registry)
+	assert.NoError(t, e.WriteFile(path))
+	contents, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "nophi_github_requests_total 4\n")
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, e.WriteFile(filepath.Join(t.TempDir(), "missing", "metrics.prom")))
+}
+
+// Test_formatValue() unit test function tests formatting sample values.
+func Test_formatValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected string
+		value    float64
+	}{
+		{expected: "0", value: 0},
+		{expected: "1.5", value: 1.5},
+		{expected: "1e+21", value: 1e21},
+		{expected: "+Inf", value: math.Inf(1)},
+		{expected: "-Inf", value: math.Inf(-1)},
+		{expected: "NaN", value: math.NaN()},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, formatValue(test.value))
+	}
+}
+
+// Test_parseName() unit test function tests splitting tagged go-metrics names
+// into names and labels.
+func Test_parseName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		labels map[string]string
+		name   string
+		input  string
+	}{
+		{input: "github.requests", name: "github.requests"},
+		{input: "github.rate.limit[installation:1]", labels: map[string]string{"installation": "1"}, name: "github.rate.limit"},
+		{input: "x[a:1,b:two,invalid]", labels: map[string]string{"a": "1", "b": "two"}, name: "x"},
+		{input: "x[a:1", name: "x[a:1"},
+	}
+	for _, test := range tests {
+		name, labels := parseName(test.input)
+		assert.Equal(t, test.name, name, test.input)
+		assert.Equal(t, test.labels, labels, test.input)
+	}
+}
@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// RegistryFamilies() function returns a metric Family for each metric of the
+// provided go-metrics registry, where the tags of tagged metric names (e.g.
+// "github.rate.limit[installation:123]") are converted to labels. Counters
+// and meters are exported as counters with the "_total" suffix, gauges as
+// gauges, and timers (in seconds) and histograms as summaries. The families
+// are sorted by the registered names of their metrics.
+func RegistryFamilies(registry metrics.Registry) []Family {
+	registered := make(map[string]interface{})
+	registry.Each(func(registered_name string, metric interface{}) {
+		registered[registered_name] = metric
+	})
+	registered_names := make([]string, 0, len(registered))
+	for registered_name := range registered {
+		registered_names = append(registered_names, registered_name)
+	}
+	sort.Strings(registered_names)
+
+	families := make([]Family, 0, len(registered_names))
+	for _, registered_name := range registered_names {
+		name, labels := parseName(registered_name)
+		switch m := registered[registered_name].(type) {
+		case metrics.Counter:
+			families = append(families, Family{
+				Name:    name + "_total",
+				Samples: []Sample{{Labels: labels, Value: float64(m.Count())}},
+				Type:    TypeCounter,
+			})
+		case metrics.Gauge:
+			families = append(families, Family{
+				Name:    name,
+				Samples: []Sample{{Labels: labels, Value: float64(m.Value())}},
+				Type:    TypeGauge,
+			})
+		case metrics.GaugeFloat64:
+			families = append(families, Family{
+				Name:    name,
+				Samples: []Sample{{Labels: labels, Value: m.Value()}},
+				Type:    TypeGauge,
+			})
+		case metrics.Meter:
+			families = append(families, Family{
+				Name:    name + "_total",
+				Samples: []Sample{{Labels: labels, Value: float64(m.Snapshot().Count())}},
+				Type:    TypeCounter,
+			})
+		case metrics.Timer:
+			snapshot := m.Snapshot()
+			families = append(families, Family{
+				Name:    name + "_seconds",
+				Samples: summarySamples(labels, snapshot.Percentiles(Quantiles), float64(snapshot.Sum()), snapshot.Count(), float64(time.Second)),
+				Type:    TypeSummary,
+			})
+		case metrics.Histogram:
+			snapshot := m.Snapshot()
+			families = append(families, Family{
+				Name:    name,
+				Samples: summarySamples(labels, snapshot.Percentiles(Quantiles), float64(snapshot.Sum()), snapshot.Count(), 1),
+				Type:    TypeSummary,
+			})
+		}
+	}
+
+	return families
+}
+
+// parseName() function splits the provided (tagged) go-metrics name (e.g.
+// "github.rate.limit[installation:123]") into its name and labels.
+func parseName(registered_name string) (string, map[string]string) {
+	start := strings.IndexByte(registered_name, '[')
+	if start < 0 || !strings.HasSuffix(registered_name, "]") {
+		return registered_name, nil
+	}
+
+	labels := make(map[string]string)
+	for _, tag := range strings.Split(registered_name[start+1:len(registered_name)-1], ",") {
+		key, value, found := strings.Cut(tag, ":")
+		if !found || key == "" {
+			continue
+		}
+		labels[key] = value
+	}
+
+	return registered_name[:start], labels
+}
+
+// summarySamples() function returns the samples of a summary with the
+// provided labels, quantile values, sum and count, where the quantile values
+// and the sum are divided by the provided unit (e.g. to convert nanoseconds
+// to seconds).
+func summarySamples(labels map[string]string, values []float64, sum float64, count int64, unit float64) []Sample {
+	samples := make([]Sample, 0, len(Quantiles)+2)
+	for i, quantile := range Quantiles {
+		quantile_labels := map[string]string{"quantile": strconv.FormatFloat(quantile, 'g', -1, 64)}
+		for key, value := range labels {
+			quantile_labels[key] = value
+		}
+		samples = append(samples, Sample{Labels: quantile_labels, Value: values[i] / unit})
+	}
+	samples = append(samples,
+		Sample{Labels: labels, Suffix: "_sum", Value: sum / unit},
+		Sample{Labels: labels, Suffix: "_count", Value: float64(count)},
+	)
+
+	return samples
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenAuthHandler() function returns a gin.HandlerFunc that aborts every
+// request that does not have the provided bearer token in its Authorization
+// header, where the token is compared in constant time.
+func TokenAuthHandler(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":     http.StatusUnauthorized,
+				"response": "missing or invalid bearer token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 const EventTypeInstallation string = "installation"
+const EventTypePing string = "ping"
 const EventTypePullRequest string = "pull_request"
 const EventTypePush string = "push"
 const EventTypeIssueComment string = "issue_comment"
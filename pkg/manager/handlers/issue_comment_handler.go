@@ -29,9 +29,7 @@ func (h *IssueCommentHandler) Handle(ctx context.Context, eventType, deliveryID
 	if err := json.Unmarshal(payload, &event); err != nil {
 		return errors.Wrap(err, "failed to parse payload for eventType="+EventTypeIssueComment)
 	}
-	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook eventType=%s", h.name(), eventType)
-	// TODO : remove vulnerable use of payload as unfiltered input to logging function
-	//zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event:\n%s", h.name(), string(payload))
+	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook eventType=%s : deliveryID=%s", h.name(), eventType, deliveryID)
 
 	if event.GetIssue().IsPullRequest() {
 		return h.handlePullRequestIssueComments(ctx, eventType, deliveryID, event)
@@ -69,7 +67,7 @@ func (h *IssueCommentHandler) handlePullRequestIssueComments(ctx context.Context
 	documents := []az.Document{}
 	// add documents to the slice using data from text fields in the webhook event
 	if event_comment := event.GetComment().GetBody(); event_comment != "" {
-		document := az.NewDocument(event.GetComment().GetURL(), event_comment, "en")
+		document := h.AI.NewDocument(event.GetComment().GetURL(), event_comment)
 		documents = append(documents, document)
 	}
 	// TODO : pull data from other text fields as potential sources of PHI/PII
@@ -78,7 +76,7 @@ func (h *IssueCommentHandler) handlePullRequestIssueComments(ctx context.Context
 	var issue_label = gh.LabelCleanPHI
 	if len(documents) > 0 {
 		// create a new request to detect PII entities in the documents
-		req := az.NewPiiEntityRecognitionRequest(documents)
+		req := h.AI.NewPiiEntityRecognitionRequest(documents)
 
 		zerolog.Ctx(ctx).Debug().Msgf("sending PII entity detection request for %d documents", len(documents))
 		var found bool
@@ -26,9 +26,7 @@ func (h *PullRequestHandler) Handle(ctx context.Context, eventType, deliveryID s
 	if err := json.Unmarshal(payload, &event); err != nil {
 		return errors.Wrap(err, "failed to parse payload for event type="+EventTypePullRequest)
 	}
-	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event type=%s", h.name(), eventType)
-	// TODO : remove vulnerable use of payload as unfiltered input to logging function
-	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event:\n%s", h.name(), string(payload))
+	zerolog.Ctx(ctx).Debug().Msgf("%s received webhook event type=%s : deliveryID=%s", h.name(), eventType, deliveryID)
 
 	// TODO
 
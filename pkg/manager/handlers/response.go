@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+// AcceptedResponseCallback() function is a githubapp.ResponseCallback that
+// responds with 202 Accepted to every webhook event, except for "ping"
+// events, because every other event is handled in the background once it
+// has been queued.
+func AcceptedResponseCallback(w http.ResponseWriter, r *http.Request, event string, handled bool) {
+	if event == EventTypePing {
+		githubapp.DefaultResponseCallback(w, r, event, handled)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
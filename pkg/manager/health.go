@@ -0,0 +1,215 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+)
+
+// Statuses of the readiness checks of the server.
+const (
+	readinessStatusOK       string = "ok"
+	readinessStatusReady    string = "ready"
+	readinessStatusNotReady string = "not_ready"
+)
+
+// readinessCheck func type checks that some dependency of the server (e.g. the
+// Azure AI Language service) is ready, and returns an error if it is not.
+type readinessCheck func(ctx context.Context) error
+
+// readinessCache struct caches the results of the readiness checks of the
+// external services of the server (e.g. GitHub) for a time-to-live (TTL), so
+// that frequent readiness probes do not call the services on every probe.
+// Safe for concurrent use.
+type readinessCache struct {
+	mutex   *sync.Mutex
+	results map[string]readinessResult
+	ttl     time.Duration
+}
+
+// readinessResult struct is the cached result of a readiness check.
+type readinessResult struct {
+	err     error
+	expires time.Time
+}
+
+// newReadinessCache() function returns a new readinessCache that caches the
+// result of each readiness check for the provided TTL.
+func newReadinessCache(ttl time.Duration) *readinessCache {
+	return &readinessCache{
+		mutex:   &sync.Mutex{},
+		results: make(map[string]readinessResult),
+		ttl:     ttl,
+	}
+}
+
+// cached() method returns a readinessCheck that runs the provided check with
+// the provided name at most once per TTL, and otherwise returns the cached
+// result of the check. The result of a check that is cut short by its context
+// (e.g. by the DefaultReadinessTimeout) is not cached.
+func (rc *readinessCache) cached(name string, check readinessCheck) readinessCheck {
+	return func(ctx context.Context) error {
+		rc.mutex.Lock()
+		result, exists := rc.results[name]
+		rc.mutex.Unlock()
+		if exists && time.Now().Before(result.expires) {
+			return result.err
+		}
+
+		err := check(ctx)
+		if ctx.Err() == nil {
+			rc.mutex.Lock()
+			rc.results[name] = readinessResult{err: err, expires: time.Now().Add(rc.ttl)}
+			rc.mutex.Unlock()
+		}
+
+		return err
+	}
+}
+
+// ReadinessStatus struct is the response of the /readyz route of the server,
+// which contains the result of each readiness check.
+type ReadinessStatus struct {
+	// Checks maps the name of each readiness check to "ok" or to the error
+	// message of the failed check.
+	Checks map[string]string `json:"checks"`
+	// Status is "ready" when every check is ok, or "not_ready" otherwise.
+	Status string `json:"status"`
+}
+
+// checkStorage() method checks that every configured directory in which the
+// server stores data (e.g. the queued webhook events) is writable, by writing
+// and then removing a temporary file in each directory. Missing directories
+// are created, as they would be when the data is first stored.
+func (m *Manager) checkStorage(ctx context.Context) error {
+	dirs := []string{
+		m.config.Git.WorkDir,
+		m.config.Git.Scan.CacheDir,
+		m.config.Git.Scan.Checkpoint.Dir,
+		m.config.Server.Deliveries.Dir,
+		m.config.Server.Queue.Dir,
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := checkDirWritable(dir); err != nil {
+			return errors.Wrapf(err, "directory %s is not writable", dir)
+		}
+	}
+
+	return nil
+}
+
+// handleHealthz() method handles the liveness probes of the server, which
+// succeed whenever the server is able to respond.
+func (m *Manager) handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": readinessStatusOK})
+}
+
+// handleReadyz() method handles the readiness probes of the server, which
+// run every readiness check concurrently (within the DefaultReadinessTimeout)
+// and respond with 503 Service Unavailable if any check fails.
+func (m *Manager) handleReadyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.DefaultReadinessTimeout)
+	defer cancel()
+
+	status := m.readiness(ctx)
+	if status.Status != readinessStatusReady {
+		m.logger.Warn().Interface("checks", status.Checks).Msg("server is not ready")
+		c.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// handleVersion() method handles requests for the version of the app and the
+// commit and time of its build.
+func (m *Manager) handleVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, cfg.GetBuildInfo())
+}
+
+// readiness() method runs every readiness check of the server concurrently
+// and returns the ReadinessStatus of the checks.
+func (m *Manager) readiness(ctx context.Context) *ReadinessStatus {
+	checks := m.readinessChecks()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			results[i] = readinessStatusOK
+			if err := check(ctx); err != nil {
+				results[i] = err.Error()
+			}
+		}(i, checks[name])
+	}
+	wg.Wait()
+
+	status := &ReadinessStatus{
+		Checks: make(map[string]string, len(names)),
+		Status: readinessStatusReady,
+	}
+	for i, name := range names {
+		status.Checks[name] = results[i]
+		if results[i] != readinessStatusOK {
+			status.Status = readinessStatusNotReady
+		}
+	}
+
+	return status
+}
+
+// readinessChecks() method returns the readiness checks of the server by name,
+// which check that the Azure AI Language service is reachable, that the
+// credentials of the GitHub App are accepted by GitHub (where the results of
+// both checks are cached for the DefaultReadinessCacheTTL), and that the
+// storage of the server is writable.
+func (m *Manager) readinessChecks() map[string]readinessCheck {
+	checks := map[string]readinessCheck{
+		"storage": m.checkStorage,
+	}
+	if m.ai != nil {
+		checks["azure_ai"] = m.checks.cached("azure_ai", m.ai.Ping)
+	}
+	if m.ghcm != nil {
+		checks["github_app"] = m.checks.cached("github_app", m.ghcm.CheckAppCredentials)
+	}
+
+	return checks
+}
+
+// checkDirWritable() function checks that the provided directory is writable,
+// by writing and then removing a temporary file in the directory, where the
+// directory is created if it does not exist.
+func checkDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	temp_file, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp_file.Name())
+	if _, err := temp_file.Write([]byte(readinessStatusOK)); err != nil {
+		temp_file.Close()
+		return err
+	}
+
+	return temp_file.Close()
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	"github.com/has-ghas/no-phi-ai/pkg/client/gh"
+)
+
+// testGitHubServer() helper function returns a (test) GitHub API server that
+// responds to requests for the authenticated GitHub App, which rejects every
+// request while the returned flag is set.
+func testGitHubServer(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	reject := &atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if reject.Load() || !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"slug":"no-phi-ai"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, reject
+}
+
+// TestManager_handleReadyz() unit test function tests the liveness, readiness
+// and build info routes of the server, where the readiness checks use a local
+// aztest.Server, a (test) GitHub API server and temporary directories.
+func TestManager_handleReadyz(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	azure := aztest.NewServer()
+	defer azure.Close()
+	azure.Key = "test-key"
+	github, reject := testGitHubServer(t)
+
+	private_key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m := testNewManager(t, azure, "test-org/test-repo")
+	m.config.GitHub.App.IntegrationID = 1
+	m.config.GitHub.App.PrivateKey = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private_key),
+	}))
+	m.config.GitHub.V3APIURL = github.URL + "/"
+	m.config.Server.Queue.Dir = filepath.Join(t.TempDir(), "queue")
+	m.ai, err = az.NewEntityDetectionAI(m.config)
+	assert.NoError(t, err)
+	m.ghcm, err = gh.NewClientManager(m.config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.GET(cfg.RouteHealthz, m.handleHealthz)
+	router.GET(cfg.RouteReadyz, m.handleReadyz)
+	router.GET(cfg.RouteVersion, m.handleVersion)
+
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, "/healthz", "", "", nil))
+
+	var build_info cfg.BuildInfo
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, "/version", "", "", &build_info))
+	assert.Equal(t, cfg.AppVersion, build_info.Version)
+	assert.NotEmpty(t, build_info.Commit)
+	assert.NotEmpty(t, build_info.Time)
+
+	var status ReadinessStatus
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, "/readyz", "", "", &status))
+	assert.Equal(t, "ready", status.Status)
+	assert.Equal(t, map[string]string{"azure_ai": "ok", "github_app": "ok", "storage": "ok"}, status.Checks)
+	assert.DirExists(t, m.config.Server.Queue.Dir)
+
+	// the results of the checks of the external services are cached
+	reject.Store(true)
+	azure.Key = "other-key"
+	status = ReadinessStatus{}
+	assert.Equal(t, http.StatusOK, testAPIRequest(t, router, http.MethodGet, "/readyz", "", "", &status))
+	assert.Equal(t, "ready", status.Status)
+
+	// every failed check is reported once the cached results expire
+	m.checks = newReadinessCache(cfg.DefaultReadinessCacheTTL)
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	assert.NoError(t, os.WriteFile(blocked, []byte{}, 0600))
+	m.config.Server.Deliveries.Dir = blocked
+	status = ReadinessStatus{}
+	assert.Equal(t, http.StatusServiceUnavailable, testAPIRequest(t, router, http.MethodGet, "/readyz", "", "", &status))
+	assert.Equal(t, "not_ready", status.Status)
+	assert.Contains(t, status.Checks["azure_ai"], "status code 401")
+	assert.Contains(t, status.Checks["github_app"], "failed to authenticate as GitHub App")
+	assert.Contains(t, status.Checks["storage"], blocked)
+}
@@ -0,0 +1,18 @@
+package jobs
+
+// Statuses of a Job, where a Job is queued until it starts running, then
+// runs until it is complete, fails or is cancelled.
+const (
+	StatusCancelled string = "cancelled"
+	StatusComplete  string = "complete"
+	StatusFailed    string = "failed"
+	StatusQueued    string = "queued"
+	StatusRunning   string = "running"
+)
+
+// DefaultPageLimit is the number of findings of a page when the limit of
+// the page is not set, and MaxPageLimit is the maximum limit of a page.
+const (
+	DefaultPageLimit int = 100
+	MaxPageLimit     int = 1000
+)
@@ -0,0 +1,21 @@
+package jobs
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgJobFindings    = "failed to list findings of job"
+	ErrMsgJobSubmit      = "failed to submit job"
+	ErrMsgRunnerShutdown = "failed to finish running jobs before shutdown"
+)
+
+var (
+	ErrJobFinished          = errors.New("job is already finished")
+	ErrJobNotFound          = errors.New("job not found")
+	ErrJobRepositoriesEmpty = errors.New("job requires at least one repository")
+	ErrJobScanIDInUse       = errors.New("scan ID is in use by a running job")
+	ErrJobScanIDInvalid     = errors.New("scan ID must not be a path")
+	ErrOptionsInvalid       = errors.New("job runner options must be positive")
+	ErrPageInvalid          = errors.New("page offset and limit must not be negative")
+	ErrRunnerClosed         = errors.New("job runner is shut down")
+	ErrRunnerScanFuncNil    = errors.New("job runner requires a scan function")
+)
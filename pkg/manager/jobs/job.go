@@ -0,0 +1,292 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// ScanRequest struct contains the parameters of a scan job, which override
+// the configured git.scan values for the scan of each of the Repositories.
+type ScanRequest struct {
+	// Refs is the list of branches, tags, or commit hashes to scan, which
+	// replaces the Refs of the Scope (if any).
+	Refs []string `json:"refs,omitempty"`
+	// Repositories is the list of repositories to scan, where each entry is
+	// a string in the format "<org>/<repo>", or the URL of the repository.
+	Repositories []string `json:"repositories"`
+	// ScanID is the (optional) ID of the scan, which identifies the
+	// checkpoints of the scan of each repository. A cancelled (e.g. by a
+	// restart of the server) or failed Job is resumed from its checkpoints
+	// by submitting its request again with the ScanID of the Job, which is
+	// the ID of the Job when ScanID is not set.
+	ScanID string `json:"scan_id,omitempty"`
+	// Scope is the (optional) scope of the scan of each repository, which
+	// replaces the configured scope.
+	Scope *cfg.GitScanScopeConfig `json:"scope,omitempty"`
+}
+
+// ScopeConfig() method returns the scope of the scan of each repository of
+// the ScanRequest, which is the provided (i.e. configured) scope replaced by
+// the Scope and Refs of the ScanRequest (if set), or an error if the scope
+// is invalid.
+func (r ScanRequest) ScopeConfig(defaults cfg.GitScanScopeConfig) (cfg.GitScanScopeConfig, error) {
+	scope := defaults
+	if r.Scope != nil {
+		scope = *r.Scope
+		if scope.Mode == "" {
+			scope.Mode = defaults.Mode
+		}
+	}
+	if len(r.Refs) > 0 {
+		scope.Refs = r.Refs
+	}
+
+	return scope, scope.Verify()
+}
+
+// Job struct contains the state of a scan job, which scans each repository of
+// its ScanRequest with a separate scanner.Scanner. The status of the scan of
+// each repository is reported from the trackers of its Scanner, and the
+// findings of the Job are read from the rrr.ResultRecordIO of each Scanner.
+// The reports of a finished Job are kept, so that they are only read once.
+// Safe for concurrent use.
+type Job struct {
+	// ID is the unique identifier of the Job.
+	ID string
+	// Request contains the parameters of the Job.
+	Request ScanRequest
+
+	cancel   context.CancelFunc
+	created  time.Time
+	err      string
+	finished time.Time
+	mutex    *sync.RWMutex
+	reports  []*scanner.Report
+	scanners map[string]*scanner.Scanner
+	started  time.Time
+	status   string
+}
+
+// JobStatus struct is a snapshot of the state of a Job.
+type JobStatus struct {
+	// Created is the time at which the Job was submitted.
+	Created time.Time `json:"created"`
+	// Error is the error message of a failed Job.
+	Error string `json:"error,omitempty"`
+	// Finished is the time at which the Job finished, if it is finished.
+	Finished *time.Time `json:"finished,omitempty"`
+	// ID is the unique identifier of the Job.
+	ID string `json:"id"`
+	// Repositories contains the report of the scan of each repository that
+	// the Job has started to scan, including the counts of the commits,
+	// files and requests of the scan in each state.
+	Repositories []*scanner.Report `json:"repositories"`
+	// Request contains the parameters of the Job.
+	Request ScanRequest `json:"request"`
+	// ScanID is the ID of the scan of the Job, which is used to resume the
+	// scan of the Job from its checkpoints (see ScanRequest.ScanID).
+	ScanID string `json:"scan_id"`
+	// Started is the time at which the Job started running, if it started.
+	Started *time.Time `json:"started,omitempty"`
+	// Status is the status of the Job (e.g. StatusRunning).
+	Status string `json:"status"`
+}
+
+// FindingsPage struct contains a page of the findings of a Job, along with
+// the total number of findings of the Job.
+type FindingsPage struct {
+	Findings []rrr.ResultRecord `json:"findings"`
+	Limit    int                `json:"limit"`
+	Offset   int                `json:"offset"`
+	Total    int                `json:"total"`
+}
+
+// newJob() function returns a new (queued) Job with the provided ID and
+// request.
+func newJob(id string, request ScanRequest) *Job {
+	return &Job{
+		ID:       id,
+		Request:  request,
+		created:  time.Now().UTC(),
+		mutex:    &sync.RWMutex{},
+		scanners: make(map[string]*scanner.Scanner),
+		status:   StatusQueued,
+	}
+}
+
+// AddScanner() method adds the Scanner of the scan of the repository with the
+// provided ID (i.e. URL) to the Job, which is used to report the status and
+// findings of the scan of the repository.
+func (j *Job) AddScanner(repository_id string, s *scanner.Scanner) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.scanners[repository_id] = s
+}
+
+// ScanID() method returns the ID of the scan of the Job, which is the ScanID
+// of the request of the Job, or else the ID of the Job.
+func (j *Job) ScanID() string {
+	if j.Request.ScanID != "" {
+		return j.Request.ScanID
+	}
+	return j.ID
+}
+
+// Findings() method returns the page of the findings of every repository
+// scanned by the Job that starts at the provided offset, with at most limit
+// findings, where the findings are sorted by repository, path, offset and
+// hash. The DefaultPageLimit is used when limit is 0.
+func (j *Job) Findings(offset, limit int) (*FindingsPage, error) {
+	if offset < 0 || limit < 0 {
+		return nil, errors.Wrap(ErrPageInvalid, ErrMsgJobFindings)
+	}
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	j.mutex.RLock()
+	scanners := make([]*scanner.Scanner, 0, len(j.scanners))
+	for _, s := range j.scanners {
+		scanners = append(scanners, s)
+	}
+	j.mutex.RUnlock()
+
+	findings := make([]rrr.ResultRecord, 0)
+	for _, s := range scanners {
+		records, err := s.GetResultRecordIO().List()
+		if err != nil {
+			return nil, errors.Wrap(err, ErrMsgJobFindings)
+		}
+		findings = append(findings, records...)
+	}
+	sort.Slice(findings, func(a, b int) bool {
+		if findings[a].Repository.ID != findings[b].Repository.ID {
+			return findings[a].Repository.ID < findings[b].Repository.ID
+		}
+		if findings[a].Object.Path != findings[b].Object.Path {
+			return findings[a].Object.Path < findings[b].Object.Path
+		}
+		if findings[a].Offset != findings[b].Offset {
+			return findings[a].Offset < findings[b].Offset
+		}
+		return findings[a].Hash < findings[b].Hash
+	})
+
+	page := &FindingsPage{
+		Findings: make([]rrr.ResultRecord, 0),
+		Limit:    limit,
+		Offset:   offset,
+		Total:    len(findings),
+	}
+	if offset < len(findings) {
+		page.Findings = findings[offset:min(offset+limit, len(findings))]
+	}
+
+	return page, nil
+}
+
+// Status() method returns a snapshot of the state of the Job.
+func (j *Job) Status() JobStatus {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	status := JobStatus{
+		Created:      j.created,
+		Error:        j.err,
+		ID:           j.ID,
+		Repositories: j.reports,
+		Request:      j.Request,
+		ScanID:       j.ScanID(),
+		Status:       j.status,
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		status.Started = &started
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		status.Finished = &finished
+	}
+	if j.finished.IsZero() {
+		status.Repositories = j.newReports((*scanner.Scanner).Report)
+	}
+
+	return status
+}
+
+// Reports() method returns the reports of the scan of each repository of the
+// Job, which is cheap enough to be called for every job (e.g. for metrics):
+// the reports of a finished Job are kept, and the reports of a running Job
+// only contain the counts of its trackers (see scanner.Scanner.Counts()).
+func (j *Job) Reports() []*scanner.Report {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if !j.finished.IsZero() {
+		return j.reports
+	}
+	return j.newReports((*scanner.Scanner).Counts)
+}
+
+// finish() method sets the final status of the Job from the error returned
+// by its scan, where the Job is cancelled if its context is done.
+func (j *Job) finish(ctx context.Context, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.finished = time.Now().UTC()
+	switch {
+	case ctx.Err() != nil:
+		j.status = StatusCancelled
+	case err != nil:
+		j.err = err.Error()
+		j.status = StatusFailed
+	default:
+		j.status = StatusComplete
+	}
+	j.reports = j.newReports((*scanner.Scanner).Report)
+}
+
+// isFinished() method returns true if the Job is no longer queued or running.
+func (j *Job) isFinished() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.status != StatusQueued && j.status != StatusRunning
+}
+
+// newReports() method returns the reports of the scan of each repository of
+// the Job by the provided method of its Scanner (e.g. Report), where the mutex
+// of the Job must be held.
+func (j *Job) newReports(report_func func(*scanner.Scanner, string) (*scanner.Report, error)) []*scanner.Report {
+	reports := make([]*scanner.Report, 0, len(j.scanners))
+	for _, repository_id := range j.Request.Repositories {
+		s, exists := j.scanners[repository_id]
+		if !exists {
+			continue
+		}
+		// the Scanner cannot report the scan of the repository until the
+		// repository is cloned
+		if report, err := report_func(s, repository_id); err == nil {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports
+}
+
+// start() method sets the status of the Job to running.
+func (j *Job) start() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.started = time.Now().UTC()
+	j.status = StatusRunning
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// ScanFunc func type runs the scan of every repository of the provided Job,
+// adding the Scanner of each repository to the Job (see Job.AddScanner()),
+// and must return once the provided context is done.
+type ScanFunc func(ctx context.Context, job *Job) error
+
+// Options struct contains the options of a Runner.
+type Options struct {
+	// MaxFinished is the maximum number of finished jobs that are kept,
+	// where the jobs that finished first are evicted first.
+	MaxFinished int
+	// Retention is the time that each finished Job is kept.
+	Retention time.Duration
+	// Workers is the number of jobs that can run concurrently, where other
+	// jobs stay queued until a worker is free.
+	Workers int
+}
+
+// Runner struct submits, tracks and cancels scan jobs, where each Job is run
+// in the background by the ScanFunc of the Runner, and finished jobs are
+// evicted once they exceed the retention or the MaxFinished of the Options
+// of the Runner. Safe for concurrent use.
+type Runner struct {
+	closed  bool
+	ctx     context.Context
+	jobs    map[string]*Job
+	mutex   *sync.RWMutex
+	options Options
+	running *sync.WaitGroup
+	scan    ScanFunc
+	workers chan struct{}
+}
+
+// NewRunner() function returns a new Runner that runs jobs with the provided
+// ScanFunc and Options, where every Job is cancelled once the provided
+// context is done.
+func NewRunner(ctx context.Context, options Options, scan ScanFunc) (*Runner, error) {
+	if scan == nil {
+		return nil, ErrRunnerScanFuncNil
+	}
+	if options.MaxFinished <= 0 || options.Retention <= 0 || options.Workers <= 0 {
+		return nil, errors.Wrapf(ErrOptionsInvalid, "%+v", options)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &Runner{
+		ctx:     ctx,
+		jobs:    make(map[string]*Job),
+		mutex:   &sync.RWMutex{},
+		options: options,
+		running: &sync.WaitGroup{},
+		scan:    scan,
+		workers: make(chan struct{}, options.Workers),
+	}, nil
+}
+
+// Cancel() method cancels the Job with the provided ID, returning an error
+// if the Job does not exist or is already finished.
+func (r *Runner) Cancel(id string) (*Job, error) {
+	job, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.isFinished() {
+		return job, errors.Wrapf(ErrJobFinished, "job %s", id)
+	}
+	job.cancel()
+
+	return job, nil
+}
+
+// Counts() method returns the number of jobs of the Runner by status, which
+// (unlike the Status() of each Job) does not read the reports of the jobs.
+func (r *Runner) Counts() map[string]int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, job := range r.jobs {
+		job.mutex.RLock()
+		counts[job.status]++
+		job.mutex.RUnlock()
+	}
+
+	return counts
+}
+
+// Get() method returns the Job with the provided ID.
+func (r *Runner) Get(id string) (*Job, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, errors.Wrapf(ErrJobNotFound, "job %s", id)
+	}
+
+	return job, nil
+}
+
+// List() method returns every Job of the Runner, sorted by the time at which
+// each Job was submitted.
+func (r *Runner) List() []*Job {
+	r.evict()
+
+	r.mutex.RLock()
+	jobs := make([]*Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		if !jobs[a].created.Equal(jobs[b].created) {
+			return jobs[a].created.Before(jobs[b].created)
+		}
+		return jobs[a].ID < jobs[b].ID
+	})
+
+	return jobs
+}
+
+// Shutdown() method stops the Runner from accepting new jobs, then waits for
+// the running jobs to finish. If the provided context is done before the jobs
+// finish, the running jobs are cancelled and the error of the context is
+// returned once every cancelled job has returned (i.e. once the state of each
+// cancelled scan has been saved, so that it can be resumed).
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mutex.Lock()
+	r.closed = true
+	r.mutex.Unlock()
+
+	chan_done := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(chan_done)
+	}()
+
+	select {
+	case <-chan_done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	cancelled := 0
+	for _, job := range r.List() {
+		if !job.isFinished() {
+			job.cancel()
+			cancelled++
+		}
+	}
+	<-chan_done
+
+	return errors.Wrapf(ctx.Err(), "%s : cancelled %d running jobs", ErrMsgRunnerShutdown, cancelled)
+}
+
+// Submit() method submits a new Job for the provided ScanRequest, which is
+// run in the background. Returns an error wrapping ErrRunnerClosed once the
+// Runner is shut down.
+func (r *Runner) Submit(request ScanRequest) (*Job, error) {
+	if len(request.Repositories) == 0 {
+		return nil, errors.Wrap(ErrJobRepositoriesEmpty, ErrMsgJobSubmit)
+	}
+	if !validScanID(request.ScanID) {
+		return nil, errors.Wrapf(ErrJobScanIDInvalid, "%s : scan ID = %q", ErrMsgJobSubmit, request.ScanID)
+	}
+
+	r.evict()
+
+	job := newJob(uuid.NewString(), request)
+	ctx, cancel := context.WithCancel(r.ctx)
+	job.cancel = cancel
+
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		cancel()
+		return nil, errors.Wrap(ErrRunnerClosed, ErrMsgJobSubmit)
+	}
+	// the checkpoints of a scan must not be shared by concurrent jobs
+	for _, other := range r.jobs {
+		if other.ScanID() == job.ScanID() && !other.isFinished() {
+			r.mutex.Unlock()
+			cancel()
+			return nil, errors.Wrapf(ErrJobScanIDInUse, "%s : job %s : scan ID = %s", ErrMsgJobSubmit, other.ID, job.ScanID())
+		}
+	}
+	r.jobs[job.ID] = job
+	r.running.Add(1)
+	r.mutex.Unlock()
+
+	go r.run(ctx, job)
+
+	return job, nil
+}
+
+// evict() method removes the finished jobs that exceed the retention of the
+// Runner, and then the jobs that finished first while the number of finished
+// jobs exceeds the MaxFinished of the Runner.
+func (r *Runner) evict() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	expired := time.Now().UTC().Add(-r.options.Retention)
+	finished := make([]*Job, 0)
+	for id, job := range r.jobs {
+		job.mutex.RLock()
+		finished_at := job.finished
+		job.mutex.RUnlock()
+		switch {
+		case finished_at.IsZero():
+		case finished_at.Before(expired):
+			delete(r.jobs, id)
+		default:
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= r.options.MaxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].finished.Before(finished[b].finished)
+	})
+	for _, job := range finished[:len(finished)-r.options.MaxFinished] {
+		delete(r.jobs, job.ID)
+	}
+}
+
+// run() method runs the provided Job with the ScanFunc of the Runner once a
+// worker of the Runner is free, where a Job that is cancelled while it is
+// queued is finished without running.
+func (r *Runner) run(ctx context.Context, job *Job) {
+	defer r.running.Done()
+	defer job.cancel()
+
+	logger := zerolog.Ctx(r.ctx)
+	select {
+	case r.workers <- struct{}{}:
+		defer func() { <-r.workers }()
+	case <-ctx.Done():
+		job.finish(ctx, nil)
+		logger.Warn().Msgf("job %s : scan cancelled before it started", job.ID)
+		return
+	}
+
+	logger.Info().Msgf("job %s : started scan of %d repositories", job.ID, len(job.Request.Repositories))
+	job.start()
+
+	err := r.scan(ctx, job)
+	job.finish(ctx, err)
+	switch {
+	case ctx.Err() != nil:
+		logger.Warn().Msgf("job %s : scan cancelled", job.ID)
+	case err != nil:
+		logger.Error().Err(err).Msgf("job %s : scan failed", job.ID)
+	default:
+		logger.Info().Msgf("job %s : scan complete", job.ID)
+	}
+}
+
+// validScanID() function returns true if the provided (optional) scan ID can
+// be used as the name of the file of a checkpoint.
+func validScanID(scan_id string) bool {
+	return scan_id != "." && scan_id != ".." && !strings.ContainsAny(scan_id, `/\`)
+}
@@ -0,0 +1,381 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// test_options are the Options of the Runner of each test, which neither
+// limit the running jobs nor evict the finished jobs of the test.
+var test_options = Options{MaxFinished: 10, Retention: time.Hour, Workers: 10}
+
+// testWaitFinished() helper function waits for the provided Job to finish.
+func testWaitFinished(t *testing.T, job *Job) JobStatus {
+	assert.Eventually(t, job.isFinished, 5*time.Second, 10*time.Millisecond)
+	return job.Status()
+}
+
+// TestRunner() unit test function tests submitting, listing and cancelling
+// jobs with the Runner type.
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRunner(context.Background(), test_options, nil)
+	assert.ErrorIs(t, err, ErrRunnerScanFuncNil)
+	_, err = NewRunner(context.Background(), Options{}, func(ctx context.Context, job *Job) error { return nil })
+	assert.ErrorIs(t, err, ErrOptionsInvalid)
+
+	chan_release := make(chan struct{})
+	runner, err := NewRunner(context.Background(), test_options, func(ctx context.Context, job *Job) error {
+		switch job.Request.Repositories[0] {
+		case "test-org/fail":
+			return errors.New("clone failed")
+		case "test-org/block":
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-chan_release:
+				return nil
+			}
+		}
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = runner.Submit(ScanRequest{})
+	assert.ErrorIs(t, err, ErrJobRepositoriesEmpty)
+
+	complete, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/ok"}})
+	if assert.NoError(t, err) {
+		status := testWaitFinished(t, complete)
+		assert.Equal(t, StatusComplete, status.Status)
+		assert.NotNil(t, status.Started)
+		assert.NotNil(t, status.Finished)
+		assert.Empty(t, status.Error)
+	}
+
+	failed, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/fail"}})
+	if assert.NoError(t, err) {
+		status := testWaitFinished(t, failed)
+		assert.Equal(t, StatusFailed, status.Status)
+		assert.Equal(t, "clone failed", status.Error)
+	}
+
+	blocked, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/block"}})
+	if assert.NoError(t, err) {
+		assert.Eventually(t, func() bool { return blocked.Status().Status == StatusRunning }, 5*time.Second, 10*time.Millisecond)
+		_, err = runner.Cancel(blocked.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusCancelled, testWaitFinished(t, blocked).Status)
+	}
+	close(chan_release)
+
+	// finished jobs cannot be cancelled
+	_, err = runner.Cancel(complete.ID)
+	assert.ErrorIs(t, err, ErrJobFinished)
+	_, err = runner.Cancel("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	_, err = runner.Get("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	got, err := runner.Get(failed.ID)
+	assert.NoError(t, err)
+	assert.Same(t, failed, got)
+
+	listed := runner.List()
+	if assert.Len(t, listed, 3) {
+		assert.Equal(t, []string{complete.ID, failed.ID, blocked.ID}, []string{listed[0].ID, listed[1].ID, listed[2].ID})
+	}
+}
+
+// TestRunner_ScanID() unit test function tests that the scan ID of a job is
+// its ID unless set by its request, and that the scan ID of a job that is not
+// finished cannot be used by another job.
+func TestRunner_ScanID(t *testing.T) {
+	t.Parallel()
+
+	chan_release := make(chan struct{})
+	runner, err := NewRunner(context.Background(), test_options, func(ctx context.Context, job *Job) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-chan_release:
+			return nil
+		}
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for _, scan_id := range []string{".", "..", "a/b", `a\b`} {
+		_, err = runner.Submit(ScanRequest{Repositories: []string{"test-org/test-repo"}, ScanID: scan_id})
+		assert.ErrorIs(t, err, ErrJobScanIDInvalid, scan_id)
+	}
+
+	generated, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/test-repo"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, generated.ID, generated.ScanID())
+	assert.Equal(t, generated.ID, generated.Status().ScanID)
+
+	request := ScanRequest{Repositories: []string{"test-org/test-repo"}, ScanID: "test_scan"}
+	running, err := runner.Submit(request)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "test_scan", running.Status().ScanID)
+	_, err = runner.Submit(request)
+	assert.ErrorIs(t, err, ErrJobScanIDInUse)
+	_, err = runner.Submit(ScanRequest{Repositories: []string{"test-org/test-repo"}, ScanID: generated.ID})
+	assert.ErrorIs(t, err, ErrJobScanIDInUse)
+
+	// a cancelled job is resumed by submitting its scan ID again
+	_, err = runner.Cancel(running.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCancelled, testWaitFinished(t, running).Status)
+	resumed, err := runner.Submit(request)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, running.ID, resumed.ID)
+		assert.Equal(t, "test_scan", resumed.ScanID())
+	}
+	close(chan_release)
+}
+
+// TestRunner_Shutdown() unit test function tests that a Runner that is shut
+// down waits for its running jobs, and cancels the jobs that are still
+// running once the deadline of the shutdown is exceeded.
+func TestRunner_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	chan_release := make(chan struct{})
+	runner, err := NewRunner(context.Background(), test_options, func(ctx context.Context, job *Job) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-chan_release:
+			return nil
+		}
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the running job is cancelled once the deadline is exceeded
+	blocked, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/block"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = runner.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "cancelled 1 running jobs")
+	// the cancelled job has returned once the Runner is shut down
+	assert.True(t, blocked.isFinished())
+	assert.Equal(t, StatusCancelled, blocked.Status().Status)
+
+	// new jobs are rejected once the Runner is shut down
+	_, err = runner.Submit(ScanRequest{Repositories: []string{"test-org/ok"}})
+	assert.ErrorIs(t, err, ErrRunnerClosed)
+	assert.Len(t, runner.List(), 1)
+
+	// a Runner without running jobs is shut down immediately
+	close(chan_release)
+	assert.NoError(t, runner.Shutdown(context.Background()))
+}
+
+// TestRunner_Workers() unit test function tests that the jobs of a Runner
+// stay queued until a worker is free, and that a queued job that is cancelled
+// is finished without running.
+func TestRunner_Workers(t *testing.T) {
+	t.Parallel()
+
+	chan_release := make(chan struct{})
+	runner, err := NewRunner(context.Background(), Options{MaxFinished: 10, Retention: time.Hour, Workers: 1}, func(ctx context.Context, job *Job) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-chan_release:
+			return nil
+		}
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	first, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/first"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Eventually(t, func() bool { return first.Status().Status == StatusRunning }, 5*time.Second, 10*time.Millisecond)
+	second, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/second"}})
+	assert.NoError(t, err)
+	third, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/third"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{StatusQueued: 2, StatusRunning: 1}, runner.Counts())
+
+	// the cancelled job never starts
+	_, err = runner.Cancel(third.ID)
+	assert.NoError(t, err)
+	status := testWaitFinished(t, third)
+	assert.Equal(t, StatusCancelled, status.Status)
+	assert.Nil(t, status.Started)
+	assert.Equal(t, StatusQueued, second.Status().Status)
+
+	close(chan_release)
+	assert.Equal(t, StatusComplete, testWaitFinished(t, first).Status)
+	assert.Equal(t, StatusComplete, testWaitFinished(t, second).Status)
+	assert.Equal(t, map[string]int{StatusCancelled: 1, StatusComplete: 2}, runner.Counts())
+}
+
+// TestRunner_evict() unit test function tests that the finished jobs of a
+// Runner are evicted once they exceed either the retention or the maximum
+// number of finished jobs of the Runner.
+func TestRunner_evict(t *testing.T) {
+	t.Parallel()
+
+	runner, err := NewRunner(context.Background(), Options{MaxFinished: 2, Retention: time.Hour, Workers: 1}, func(ctx context.Context, job *Job) error {
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	submitted := make([]*Job, 0, 3)
+	for i := 0; i < 3; i++ {
+		job, err := runner.Submit(ScanRequest{Repositories: []string{"test-org/test-repo"}})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		testWaitFinished(t, job)
+		submitted = append(submitted, job)
+	}
+	// the job that finished first is evicted
+	listed := runner.List()
+	if assert.Len(t, listed, 2) {
+		assert.Equal(t, []string{submitted[1].ID, submitted[2].ID}, []string{listed[0].ID, listed[1].ID})
+	}
+	_, err = runner.Get(submitted[0].ID)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// every job is evicted once it exceeds the retention
+	runner.options.Retention = time.Nanosecond
+	assert.Empty(t, runner.List())
+}
+
+// TestJob_Findings() unit test function tests paginating the findings of the
+// scanners of a Job.
+func TestJob_Findings(t *testing.T) {
+	t.Parallel()
+
+	job := newJob("test_job", ScanRequest{Repositories: []string{"repo_a", "repo_b"}})
+	for _, repository_id := range job.Request.Repositories {
+		result_io := memory.NewMemoryResultRecordIO(context.Background())
+		records := make([]rrr.ResultRecord, 0)
+		for offset := 0; offset < 3; offset++ {
+			record := rrr.ResultRecord{
+				Hash:   repository_id + "_" + string(rune('0'+offset)),
+				Result: rrr.Result{Category: "Person", Offset: 10 * (2 - offset)},
+			}
+			record.Object.Path = "patients.csv"
+			record.Repository.ID = repository_id
+			records = append(records, record)
+		}
+		assert.NoError(t, result_io.Write(records))
+		s, err := scanner.NewScanner(context.Background(), &cfg.GitConfig{}, result_io)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		job.AddScanner(repository_id, s)
+	}
+
+	page, err := job.Findings(0, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultPageLimit, page.Limit)
+		assert.Equal(t, 6, page.Total)
+		assert.Len(t, page.Findings, 6)
+	}
+
+	page, err = job.Findings(2, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 6, page.Total)
+		if assert.Len(t, page.Findings, 2) {
+			// findings are sorted by repository, path and offset
+			assert.Equal(t, "repo_a_0", page.Findings[0].Hash)
+			assert.Equal(t, "repo_b_2", page.Findings[1].Hash)
+		}
+	}
+
+	page, err = job.Findings(6, MaxPageLimit+1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, MaxPageLimit, page.Limit)
+		assert.Empty(t, page.Findings)
+	}
+
+	_, err = job.Findings(-1, 0)
+	assert.ErrorIs(t, err, ErrPageInvalid)
+}
+
+// TestScanRequest_ScopeConfig() unit test function tests replacing the
+// configured scope with the scope and refs of a ScanRequest.
+func TestScanRequest_ScopeConfig(t *testing.T) {
+	t.Parallel()
+
+	defaults := cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, Refs: []string{"main"}}
+
+	tests := []struct {
+		expect_err bool
+		expected   cfg.GitScanScopeConfig
+		name       string
+		request    ScanRequest
+	}{
+		{
+			expected: defaults,
+			name:     "Defaults",
+		},
+		{
+			expected: cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, Refs: []string{"develop", "v1.0.0"}},
+			name:     "Refs",
+			request:  ScanRequest{Refs: []string{"develop", "v1.0.0"}},
+		},
+		{
+			expected: cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory, MaxCommits: 10},
+			name:     "Scope",
+			request:  ScanRequest{Scope: &cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory, MaxCommits: 10}},
+		},
+		{
+			expected: cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, Refs: []string{"develop"}},
+			name:     "Scope_Default_Mode",
+			request:  ScanRequest{Refs: []string{"develop"}, Scope: &cfg.GitScanScopeConfig{}},
+		},
+		{
+			expect_err: true,
+			name:       "Invalid_Scope",
+			request:    ScanRequest{Scope: &cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, MaxCommits: 10}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope, err := test.request.ScopeConfig(defaults)
+			if test.expect_err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, scope)
+		})
+	}
+}
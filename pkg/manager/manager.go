@@ -3,26 +3,48 @@ package manager
 import (
 	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az"
+	"github.com/has-ghas/no-phi-ai/pkg/client/gh"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/jobs"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/queue"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
 )
 
 // Manager struct holds the configuration and state for app.
 type Manager struct {
-	config  *cfg.Config
-	ctx     context.Context
-	logger  *zerolog.Logger
-	scanner *scanner.Scanner
-	server  *http.Server
+	ai         *az.EntityDetectionAI
+	blobs      *cache.BlobCache
+	cancel     context.CancelFunc
+	checks     *readinessCache
+	config     *cfg.Config
+	ctx        context.Context
+	deliveries *deliveries.Scheduler
+	dispatcher http.Handler
+	ghcm       *gh.ClientManager
+	jobs       *jobs.Runner
+	logger     *zerolog.Logger
+	queue      *queue.Queue
+	report     *scanner.Report
+	scanner    *scanner.Scanner
+	server     *http.Server
 }
 
 // New() function returns a new Manager instance for the app.
 // Generates a fatal error if unable to:
 //   - parse the configuration from file and env vars, or...
+//   - load the configured encryption keys, or...
 //   - setup the HTTP server, or...
 //   - register HTTP handlers for GitHub webhook events.
 func New() *Manager {
@@ -43,15 +65,35 @@ func New() *Manager {
 			logger.Fatal().Err(err).Msg(msg)
 		}
 	}
+	// verify the encryption keys before they are needed by any command
+	if _, err = loadKeyring(config); err != nil {
+		logger.Fatal().Err(err).Msg("failed to load encryption keys for new Manager")
+	}
 
-	// populate the Manager struct
+	// populate the Manager struct, where the logger is added to the context
+	// of the Manager for the background jobs and tasks of the server, which
+	// are stopped once the context is cancelled
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background()))
 	return &Manager{
+		cancel: cancel,
+		checks: newReadinessCache(cfg.DefaultReadinessCacheTTL),
 		config: config,
-		ctx:    context.Background(),
+		ctx:    ctx,
 		logger: logger,
 	}
 }
 
+// loadKeyring() function returns the crypt.Keyring of the configured
+// encryption keys, or nil if encryption is disabled.
+func loadKeyring(config *cfg.Config) (*crypt.Keyring, error) {
+	keyring, err := crypt.LoadKeyring(config.Git.Scan.Encryption.KeyFile, config.Git.Scan.Encryption.Keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid config value: git.scan.encryption")
+	}
+
+	return keyring, nil
+}
+
 // GetAppMode() method returns the configured app mode for the Manager.
 func (m *Manager) GetAppMode() string {
 	return m.config.App.Mode
@@ -72,17 +114,33 @@ func (m *Manager) Init() {
 	}
 }
 
-// Run() method runs the Manager in the configured mode.
+// Run() method runs the Manager in the configured mode, until the run is
+// complete or until the Manager receives a SIGINT or SIGTERM signal, upon
+// which the Manager is shut down gracefully.
 func (m *Manager) Run() {
 	m.logger.Trace().Msg("running Manager")
+	defer m.cancel()
+	signals, stop := signal.NotifyContext(m.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	switch m.GetAppMode() {
 	case cfg.AppModeCLI:
-		if err := m.runCLI(); err != nil {
+		// cancel the run on SIGINT or SIGTERM, where the scan of the run
+		// saves its checkpoint before it stops, so that it can be resumed
+		stop_cancel := context.AfterFunc(signals, func() {
+			m.logger.Warn().Msg("received shutdown signal : cancelling run")
+			m.cancel()
+		})
+		defer stop_cancel()
+		err := m.runCLI()
+		// export the metrics of the run, even if the run failed
+		m.exportMetrics()
+		if err != nil {
 			m.logger.Fatal().Err(err).Msgf("error running in '%s' mode", m.GetAppMode())
 		}
 		return
 	case cfg.AppModeServer:
-		if err := m.runServer(); err != nil {
+		if err := m.runServer(signals); err != nil {
 			m.logger.Fatal().Err(err).Msgf("error running in '%s' mode", m.GetAppMode())
 		}
 		return
@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"context"
+	"sort"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/exporter"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// collectMetrics() method implements the exporter.Collector func type by
+// returning the metric families of the state of the Manager, which are the
+// depth of the queue of webhook events, the number of scan jobs by status,
+// and the counts of the scanned objects by state and of the findings by
+// category of the last CLI scan and of every scan job, where the findings of
+// a running scan job are only counted once the job is finished.
+func (m *Manager) collectMetrics() []exporter.Family {
+	reports := make([]*scanner.Report, 0)
+	if m.report != nil {
+		reports = append(reports, m.report)
+	}
+
+	families := make([]exporter.Family, 0)
+	if m.jobs != nil {
+		for _, job := range m.jobs.List() {
+			reports = append(reports, job.Reports()...)
+		}
+		statuses := m.jobs.Counts()
+		samples := make([]exporter.Sample, 0, len(statuses))
+		for status, count := range statuses {
+			samples = append(samples, exporter.Sample{
+				Labels: map[string]string{"status": status},
+				Value:  float64(count),
+			})
+		}
+		families = append(families, exporter.Family{
+			Help:    "Number of scan jobs of the REST API by status.",
+			Name:    "scan_jobs",
+			Samples: sortSamples(samples, "status"),
+			Type:    exporter.TypeGauge,
+		})
+	}
+	if m.queue != nil {
+		families = append(families, exporter.Family{
+			Help:    "Number of webhook events that are queued, running or waiting to be retried.",
+			Name:    "webhook_queue_depth",
+			Samples: []exporter.Sample{{Value: float64(m.queue.Len())}},
+			Type:    exporter.TypeGauge,
+		})
+	}
+
+	return append(families, reportFamilies(reports)...)
+}
+
+// exportMetrics() method dumps the metrics of the app to the configured file
+// and / or pushes them to the configured push gateway at the end of a run of
+// the CLI, where a failure to export the metrics is logged but does not fail
+// the run.
+func (m *Manager) exportMetrics() {
+	if m.config.Metrics.DumpFile == "" && m.config.Metrics.PushURL == "" {
+		return
+	}
+
+	e := m.newExporter(metrics.DefaultRegistry)
+	if m.config.Metrics.DumpFile != "" {
+		if err := e.WriteFile(m.config.Metrics.DumpFile); err != nil {
+			m.logger.Error().Err(err).Msgf("failed to dump metrics to file %s", m.config.Metrics.DumpFile)
+		} else {
+			m.logger.Info().Msgf("dumped metrics to file %s", m.config.Metrics.DumpFile)
+		}
+	}
+	if m.config.Metrics.PushURL != "" {
+		// the metrics of a cancelled run are pushed too
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(m.ctx), cfg.DefaultClientTimeout)
+		defer cancel()
+		if err := e.Push(ctx, m.config.Metrics.PushURL); err != nil {
+			m.logger.Error().Err(err).Msg("failed to push metrics")
+		} else {
+			m.logger.Info().Msg("pushed metrics to push gateway")
+		}
+	}
+}
+
+// newExporter() method returns a new exporter.Exporter of the metrics of the
+// provided go-metrics registry (e.g. of the GitHub and Azure AI clients) and
+// of the state of the Manager.
+func (m *Manager) newExporter(registry metrics.Registry) *exporter.Exporter {
+	e := exporter.NewExporter(cfg.MetricsNamespace, registry)
+	e.Register(m.collectMetrics)
+	return e
+}
+
+// reportFamilies() function returns the metric families of the provided scan
+// reports, where the counts of the reports are summed.
+func reportFamilies(reports []*scanner.Report) []exporter.Family {
+	objects := map[string]*tracker.KeyDataCounts{
+		"commits":  {},
+		"files":    {},
+		"requests": {},
+	}
+	findings := make(map[string]int)
+	for _, report := range reports {
+		for kind, counts := range map[string]tracker.KeyDataCounts{
+			"commits":  report.Commits,
+			"files":    report.Files,
+			"requests": report.Requests,
+		} {
+			objects[kind].Complete += counts.Complete
+			objects[kind].Error += counts.Error
+			objects[kind].Ignore += counts.Ignore
+			objects[kind].Init += counts.Init
+			objects[kind].Pending += counts.Pending
+		}
+		for category, count := range report.Results {
+			findings[category] += count
+		}
+	}
+
+	object_samples := make([]exporter.Sample, 0, len(objects)*5)
+	for kind, counts := range objects {
+		for state, count := range map[string]int{
+			tracker.KeyStateComplete: counts.Complete,
+			tracker.KeyStateError:    counts.Error,
+			tracker.KeyStateIgnore:   counts.Ignore,
+			tracker.KeyStateInit:     counts.Init,
+			tracker.KeyStatePending:  counts.Pending,
+		} {
+			object_samples = append(object_samples, exporter.Sample{
+				Labels: map[string]string{"kind": kind, "state": state},
+				Value:  float64(count),
+			})
+		}
+	}
+	finding_samples := make([]exporter.Sample, 0, len(findings))
+	for category, count := range findings {
+		finding_samples = append(finding_samples, exporter.Sample{
+			Labels: map[string]string{"category": category},
+			Value:  float64(count),
+		})
+	}
+
+	return []exporter.Family{
+		{
+			Help:    "Number of findings of the scanned repositories by category.",
+			Name:    "findings",
+			Samples: sortSamples(finding_samples, "category"),
+			Type:    exporter.TypeGauge,
+		},
+		{
+			Help:    "Number of scanned objects (commits, files and requests) by state.",
+			Name:    "scanner_objects",
+			Samples: sortSamples(object_samples, "kind", "state"),
+			Type:    exporter.TypeGauge,
+		},
+	}
+}
+
+// sortSamples() function sorts the provided samples by the values of the
+// provided labels, in order, and returns the sorted samples.
+func sortSamples(samples []exporter.Sample, labels ...string) []exporter.Sample {
+	sort.Slice(samples, func(a, b int) bool {
+		for _, label := range labels {
+			if samples[a].Labels[label] != samples[b].Labels[label] {
+				return samples[a].Labels[label] < samples[b].Labels[label]
+			}
+		}
+		return false
+	})
+	return samples
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+)
+
+// TestManager_exportMetrics() unit test function runs the "scan-repos"
+// command against a local repository and a local aztest.Server, then tests
+// that the metrics of the run are dumped to a file and pushed to a (test)
+// push gateway.
+func TestManager_exportMetrics(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	var pushed string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPut && r.URL.Path == "/metrics/job/no-phi-ai" {
+			pushed = string(body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":    "# test repository\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.Metrics.DumpFile = filepath.Join(t.TempDir(), "metrics.prom")
+	m.config.Metrics.PushURL = gateway.URL + "/metrics/job/no-phi-ai"
+
+	if !assert.NoError(t, m.commandScanRepos()) {
+		t.FailNow()
+	}
+	m.exportMetrics()
+
+	dumped, err := os.ReadFile(m.config.Metrics.DumpFile)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, expected := range []string{
+		"# TYPE nophi_findings gauge\n",
+		"nophi_findings{category=\"Person\"} 1\n",
+		"nophi_scanner_objects{kind=\"files\",state=\"complete\"} 2\n",
+		"nophi_scanner_objects{kind=\"files\",state=\"error\"} 0\n",
+		"# TYPE nophi_azure_ai_requests_seconds summary\n",
+	} {
+		assert.Contains(t, string(dumped), expected)
+	}
+	assert.NotContains(t, string(dumped), "nophi_webhook_queue_depth")
+	// the default registry is shared by parallel tests, so the pushed metrics
+	// are not necessarily equal to the dumped metrics
+	assert.Contains(t, pushed, "nophi_findings{category=\"Person\"} 1\n")
+}
@@ -0,0 +1,7 @@
+package queue
+
+import "os"
+
+const DirMode os.FileMode = 0700
+const FileExtension string = ".json"
+const FileMode os.FileMode = 0600
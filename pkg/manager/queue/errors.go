@@ -0,0 +1,21 @@
+package queue
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgQueueDrain     = "failed to drain queued webhook events"
+	ErrMsgQueueEnqueue   = "failed to enqueue webhook event"
+	ErrMsgQueueLoad      = "failed to load queued webhook events"
+	ErrMsgQueueReencrypt = "failed to re-encrypt queued webhook events"
+	ErrMsgTaskDelete     = "failed to delete queued webhook event"
+	ErrMsgTaskSave       = "failed to save queued webhook event"
+)
+
+var (
+	ErrFileStoreDirEmpty     = errors.New("queue file store requires a directory")
+	ErrOptionsInvalid        = errors.New("queue options are invalid")
+	ErrQueueClosed           = errors.New("queue is closed to new webhook events")
+	ErrTaskDeliveryIDInvalid = errors.New("webhook delivery ID is invalid")
+	ErrTaskHandlerNotFound   = errors.New("no handler registered for webhook event type")
+	ErrTaskNil               = errors.New("queued webhook event is nil")
+)
@@ -0,0 +1,356 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Options struct contains the options of a Queue.
+type Options struct {
+	// Backoff is the delay before the first retry of a failed Task, which is
+	// doubled for each subsequent retry of the same Task.
+	Backoff time.Duration
+	// MaxAttempts is the maximum number of times that each Task is handled
+	// before it is dropped.
+	MaxAttempts int
+	// MaxBackoff is the maximum delay before any retry of a Task.
+	MaxBackoff time.Duration
+	// Size is the maximum number of tasks that can be queued, running or
+	// waiting to be retried.
+	Size int
+	// Workers is the number of tasks that can be handled concurrently.
+	Workers int
+}
+
+// Queue struct provides an implementation of the githubapp.Scheduler
+// interface, which queues every webhook event dispatched by a githubapp
+// event dispatcher as a Task that is handled in the background by a bounded
+// pool of workers, such that the dispatcher can respond to each delivery
+// without waiting for its handler. Tasks are deduplicated by their delivery
+// ID and head commit, failed tasks are retried with exponential backoff, and
+// tasks are saved to the (optional) Store until they are finished, such that
+// queued tasks survive a restart of the server. Safe for concurrent use.
+type Queue struct {
+	cancel     context.CancelFunc
+	chan_drain chan struct{}
+	chan_tasks chan *Task
+	closed     bool
+	ctx        context.Context
+	handlers   map[string]githubapp.EventHandler
+	keys       map[string]string
+	mutex      *sync.Mutex
+	options    Options
+	store      Store
+	tasks      map[string]*Task
+	workers    *sync.WaitGroup
+}
+
+// NewQueue() function returns a new Queue that handles each Task with the
+// provided handler for its event type, where the first of the handlers has
+// priority (as with githubapp.NewEventDispatcher()). The tasks saved in the
+// (optional) store are queued again, and the workers of the Queue run until
+// the provided context is done, or until the Queue is drained.
+func NewQueue(ctx context.Context, store Store, options Options, handlers ...githubapp.EventHandler) (*Queue, error) {
+	if options.Backoff <= 0 || options.MaxAttempts <= 0 || options.MaxBackoff < options.Backoff || options.Size <= 0 || options.Workers <= 0 {
+		return nil, errors.Wrapf(ErrOptionsInvalid, "%+v", options)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	handler_map := make(map[string]githubapp.EventHandler)
+	for i := len(handlers) - 1; i >= 0; i-- {
+		for _, event_type := range handlers[i].Handles() {
+			handler_map[event_type] = handlers[i]
+		}
+	}
+
+	saved := make([]*Task, 0)
+	if store != nil {
+		var err error
+		if saved, err = store.List(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q := &Queue{
+		cancel:     cancel,
+		chan_drain: make(chan struct{}),
+		// the channel can hold every Task of the Queue, so sending a Task
+		// to the channel never blocks
+		chan_tasks: make(chan *Task, max(options.Size, len(saved))),
+		ctx:        ctx,
+		handlers:   handler_map,
+		keys:       make(map[string]string),
+		mutex:      &sync.Mutex{},
+		options:    options,
+		store:      store,
+		tasks:      make(map[string]*Task),
+		workers:    &sync.WaitGroup{},
+	}
+
+	logger := zerolog.Ctx(ctx)
+	q.mutex.Lock()
+	for _, task := range saved {
+		if _, exists := q.handlers[task.EventType]; !exists {
+			logger.Warn().Msgf("dropping queued webhook event type=%s : deliveryID=%s : no handler", task.EventType, task.DeliveryID)
+			q.remove(task)
+			continue
+		}
+		q.add(task)
+		q.schedule(task, time.Until(task.NextAttempt))
+	}
+	q.mutex.Unlock()
+	if len(saved) > 0 {
+		logger.Info().Msgf("loaded %d queued webhook events", q.Len())
+	}
+
+	q.workers.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go q.work()
+	}
+
+	return q, nil
+}
+
+// Drain() method stops the Queue from accepting new tasks, then waits for
+// the workers of the Queue to handle the tasks that are queued or running
+// before they stop. Tasks that are waiting to be retried are not handled, and
+// remain in the Store to be handled once the server is restarted. If the
+// provided context is done before the workers stop, the running tasks are
+// cancelled (and also remain in the Store) and the error of the context is
+// returned.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.chan_drain)
+	}
+	q.mutex.Unlock()
+
+	chan_done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(chan_done)
+	}()
+
+	select {
+	case <-chan_done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return errors.Wrapf(ctx.Err(), "%s : %d webhook events remain queued", ErrMsgQueueDrain, q.Len())
+	}
+}
+
+// Enqueue() method adds the provided Task to the Queue, returning false if
+// the Task is a duplicate of a Task in the Queue, which has the same delivery
+// ID or the same event type and head commit of the same repository. Returns
+// an error wrapping githubapp.ErrCapacityExceeded if the Queue is full, or
+// wrapping ErrQueueClosed if the Queue is drained.
+func (q *Queue) Enqueue(task *Task) (bool, error) {
+	if task == nil {
+		return false, errors.Wrap(ErrTaskNil, ErrMsgQueueEnqueue)
+	}
+	if _, exists := q.handlers[task.EventType]; !exists {
+		return false, errors.Wrapf(ErrTaskHandlerNotFound, "%s : event type = %s", ErrMsgQueueEnqueue, task.EventType)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false, errors.Wrap(ErrQueueClosed, ErrMsgQueueEnqueue)
+	}
+	if _, exists := q.tasks[task.DeliveryID]; exists {
+		return false, nil
+	}
+	if key := task.key(); key != "" {
+		if _, exists := q.keys[key]; exists {
+			return false, nil
+		}
+	}
+	if len(q.tasks) >= q.options.Size {
+		return false, errors.Wrap(githubapp.ErrCapacityExceeded, ErrMsgQueueEnqueue)
+	}
+	// the Task is saved before it is accepted, such that the delivery of
+	// an accepted event is never lost
+	if q.store != nil {
+		if err := q.store.Save(task); err != nil {
+			return false, errors.Wrap(err, ErrMsgQueueEnqueue)
+		}
+	}
+	q.add(task)
+	q.chan_tasks <- task
+
+	return true, nil
+}
+
+// Len() method returns the number of tasks that are queued, running or
+// waiting to be retried.
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.tasks)
+}
+
+// Schedule() method implements the githubapp.Scheduler interface by adding a
+// new Task for the provided githubapp.Dispatch to the Queue, where duplicate
+// deliveries are ignored.
+func (q *Queue) Schedule(ctx context.Context, dispatch githubapp.Dispatch) error {
+	task, err := NewTask(dispatch)
+	if err != nil {
+		return githubapp.ValidationError{
+			Cause:      err,
+			DeliveryID: dispatch.DeliveryID,
+			EventType:  dispatch.EventType,
+		}
+	}
+	queued, err := q.Enqueue(task)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		zerolog.Ctx(ctx).Info().Msgf("ignoring duplicate webhook event type=%s : deliveryID=%s", task.EventType, task.DeliveryID)
+	}
+
+	return nil
+}
+
+// add() method adds the provided Task to the maps of the Queue, which must
+// be locked by the caller.
+func (q *Queue) add(task *Task) {
+	q.tasks[task.DeliveryID] = task
+	if key := task.key(); key != "" {
+		q.keys[key] = task.DeliveryID
+	}
+}
+
+// backoff() method returns the delay before the retry of a Task that failed
+// the provided number of attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.options.Backoff
+	for i := 1; i < attempts && delay < q.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.options.MaxBackoff)
+}
+
+// handle() method runs the handler of the provided Task, where a panic of
+// the handler is returned as an error.
+func (q *Queue) handle(ctx context.Context, task *Task) (e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = errors.Errorf("panic in handler of webhook event : %v", r)
+		}
+	}()
+
+	return q.handlers[task.EventType].Handle(ctx, task.EventType, task.DeliveryID, task.Payload)
+}
+
+// remove() method removes the provided Task from the Queue and its Store,
+// which must be locked by the caller.
+func (q *Queue) remove(task *Task) {
+	delete(q.tasks, task.DeliveryID)
+	if key := task.key(); key != "" && q.keys[key] == task.DeliveryID {
+		delete(q.keys, key)
+	}
+	if q.store != nil {
+		if err := q.store.Delete(task.DeliveryID); err != nil {
+			zerolog.Ctx(q.ctx).Error().Err(err).Msgf("failed to delete webhook event deliveryID=%s", task.DeliveryID)
+		}
+	}
+}
+
+// run() method handles the provided Task, which is removed from the Queue
+// once it is handled, or once it has failed the maximum number of attempts.
+// Otherwise, the Task is retried after a delay.
+func (q *Queue) run(task *Task) {
+	logger := zerolog.Ctx(q.ctx).With().
+		Str(githubapp.LogKeyEventType, task.EventType).
+		Str(githubapp.LogKeyDeliveryID, task.DeliveryID).
+		Logger()
+	started := time.Now()
+	err := q.handle(logger.WithContext(q.ctx), task)
+
+	// the Task remains in the Store when the Queue is stopped, such that it
+	// is handled once the server is restarted
+	if q.ctx.Err() != nil {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	task.Attempts++
+	if err == nil {
+		logger.Debug().Msgf("handled webhook event in %s", time.Since(started))
+		q.remove(task)
+		return
+	}
+	task.Error = err.Error()
+	if task.Attempts >= q.options.MaxAttempts {
+		logger.Error().Err(err).Msgf("dropping webhook event after %d failed attempts", task.Attempts)
+		q.remove(task)
+		return
+	}
+
+	delay := q.backoff(task.Attempts)
+	task.NextAttempt = time.Now().UTC().Add(delay)
+	if q.store != nil {
+		if save_err := q.store.Save(task); save_err != nil {
+			logger.Error().Err(save_err).Msg("failed to save webhook event for retry")
+		}
+	}
+	logger.Warn().Err(err).Msgf("retrying webhook event in %s : attempt %d of %d failed", delay, task.Attempts, q.options.MaxAttempts)
+	q.schedule(task, delay)
+}
+
+// schedule() method sends the provided Task to the workers of the Queue once
+// the provided delay has elapsed.
+func (q *Queue) schedule(task *Task, delay time.Duration) {
+	if delay <= 0 {
+		q.chan_tasks <- task
+		return
+	}
+	time.AfterFunc(delay, func() {
+		select {
+		case q.chan_tasks <- task:
+		case <-q.ctx.Done():
+		}
+	})
+}
+
+// work() method runs a worker of the Queue, which handles tasks until the
+// context of the Queue is done, or until no tasks are ready to be handled
+// once the Queue is drained.
+func (q *Queue) work() {
+	defer q.workers.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case task := <-q.chan_tasks:
+			q.run(task)
+		case <-q.chan_drain:
+			for {
+				select {
+				case <-q.ctx.Done():
+					return
+				case task := <-q.chan_tasks:
+					q.run(task)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
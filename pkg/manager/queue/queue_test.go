@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHandler struct is a githubapp.EventHandler that records the delivery
+// ID of every event that it handles, and fails each event the number of
+// times in its failures map.
+type testHandler struct {
+	chan_release chan struct{}
+	failures     map[string]int
+	handled      []string
+	mutex        *sync.Mutex
+}
+
+// newTestHandler() helper function returns a new testHandler, which blocks
+// until its chan_release channel is closed when block is true.
+func newTestHandler(block bool) *testHandler {
+	h := &testHandler{
+		chan_release: make(chan struct{}),
+		failures:     make(map[string]int),
+		handled:      make([]string, 0),
+		mutex:        &sync.Mutex{},
+	}
+	if !block {
+		close(h.chan_release)
+	}
+	return h
+}
+
+func (h *testHandler) Handles() []string {
+	return []string{"pull_request", "push"}
+}
+
+func (h *testHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.chan_release:
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.handled = append(h.handled, deliveryID)
+	if h.failures[deliveryID] > 0 {
+		h.failures[deliveryID]--
+		return errors.New("test failure")
+	}
+	return nil
+}
+
+// count() method returns the number of times that the event with the
+// provided delivery ID was handled.
+func (h *testHandler) count(delivery_id string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	count := 0
+	for _, handled := range h.handled {
+		if handled == delivery_id {
+			count++
+		}
+	}
+	return count
+}
+
+// testOptions() helper function returns the Options used by the tests.
+func testOptions() Options {
+	return Options{
+		Backoff:     time.Millisecond,
+		MaxAttempts: 3,
+		MaxBackoff:  4 * time.Millisecond,
+		Size:        3,
+		Workers:     2,
+	}
+}
+
+// testDispatch() helper function returns a githubapp.Dispatch for a push
+// event to the main branch with the provided delivery ID and head commit.
+func testDispatch(delivery_id, head_sha string) githubapp.Dispatch {
+	return githubapp.Dispatch{
+		DeliveryID: delivery_id,
+		EventType:  "push",
+		Payload:    []byte(`{"after":"` + head_sha + `","ref":"refs/heads/main","repository":{"full_name":"test-org/test-repo"}}`),
+	}
+}
+
+// testPullRequestDispatch() helper function returns a githubapp.Dispatch for
+// a pull_request event with the provided delivery ID, action and head commit.
+func testPullRequestDispatch(delivery_id, action, head_sha string) githubapp.Dispatch {
+	return githubapp.Dispatch{
+		DeliveryID: delivery_id,
+		EventType:  "pull_request",
+		Payload:    []byte(`{"action":"` + action + `","pull_request":{"head":{"sha":"` + head_sha + `"}},"repository":{"full_name":"test-org/test-repo"}}`),
+	}
+}
+
+// TestQueue() unit test function tests queueing, deduplicating and retrying
+// webhook events with the Queue type.
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewQueue(context.Background(), nil, Options{})
+	assert.ErrorIs(t, err, ErrOptionsInvalid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := newTestHandler(true)
+	handler.failures["delivery-retry"] = 2
+	handler.failures["delivery-drop"] = 3
+	q, err := NewQueue(ctx, nil, testOptions(), handler)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// events are deduplicated by delivery ID and head commit
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-retry", "sha-1")))
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-retry", "sha-2")))
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-other", "sha-1")))
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-drop", "sha-2")))
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-ok", "")))
+	assert.Equal(t, 3, q.Len())
+
+	// the queue is full
+	err = q.Schedule(ctx, testDispatch("delivery-full", ""))
+	assert.ErrorIs(t, err, githubapp.ErrCapacityExceeded)
+	// invalid events are rejected
+	err = q.Schedule(ctx, githubapp.Dispatch{DeliveryID: "delivery-invalid", EventType: "push", Payload: []byte(`{`)})
+	assert.ErrorAs(t, err, &githubapp.ValidationError{})
+	_, err = q.Enqueue(&Task{DeliveryID: "delivery-unknown", EventType: "issues"})
+	assert.ErrorIs(t, err, ErrTaskHandlerNotFound)
+
+	close(handler.chan_release)
+	assert.Eventually(t, func() bool { return q.Len() == 0 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, handler.count("delivery-retry"))
+	assert.Equal(t, 3, handler.count("delivery-drop"))
+	assert.Equal(t, 1, handler.count("delivery-ok"))
+	assert.Equal(t, 0, handler.count("delivery-other"))
+
+	// finished events are no longer deduplicated
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-other", "sha-1")))
+	assert.Eventually(t, func() bool { return handler.count("delivery-other") == 1 }, 5*time.Second, 5*time.Millisecond)
+}
+
+// TestQueue_dedupActions() unit test function tests that events for the
+// same head commit are only deduplicated if they have the same action, and
+// (for push events) the same ref.
+func TestQueue_dedupActions(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	options := testOptions()
+	options.Size = 10
+	q, err := NewQueue(ctx, nil, options, newTestHandler(true))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, q.Schedule(ctx, testPullRequestDispatch("delivery-opened", "opened", "sha-1")))
+	assert.NoError(t, q.Schedule(ctx, testPullRequestDispatch("delivery-closed", "closed", "sha-1")))
+	assert.NoError(t, q.Schedule(ctx, testPullRequestDispatch("delivery-labeled", "labeled", "sha-1")))
+	// the same action for the same head commit is a duplicate
+	assert.NoError(t, q.Schedule(ctx, testPullRequestDispatch("delivery-reopened", "opened", "sha-1")))
+	assert.Equal(t, 3, q.Len())
+
+	// a push of the same commit to a new branch is not a duplicate
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-main", "sha-1")))
+	branch := testDispatch("delivery-branch", "sha-1")
+	branch.Payload = []byte(`{"after":"sha-1","ref":"refs/heads/feature","repository":{"full_name":"test-org/test-repo"}}`)
+	assert.NoError(t, q.Schedule(ctx, branch))
+	assert.Equal(t, 5, q.Len())
+}
+
+// TestQueue_restart() unit test function tests that the events saved by the
+// Store of a stopped Queue are handled by a new Queue.
+func TestQueue_restart(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewFileStore(t.TempDir(), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q, err := NewQueue(ctx, store, testOptions(), newTestHandler(true))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-1", "sha-1")))
+	assert.NoError(t, q.Schedule(ctx, testDispatch("delivery-2", "sha-2")))
+	// stop the queue before the (blocked) events are handled
+	cancel()
+
+	saved, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+
+	handler := newTestHandler(false)
+	restarted, err := NewQueue(context.Background(), store, testOptions(), handler)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Eventually(t, func() bool { return restarted.Len() == 0 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, handler.count("delivery-1"))
+	assert.Equal(t, 1, handler.count("delivery-2"))
+
+	saved, err = store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+}
+
+// TestQueue_backoff() unit test function tests the exponential backoff of
+// the retries of failed events.
+func TestQueue_backoff(t *testing.T) {
+	t.Parallel()
+
+	q := &Queue{options: Options{Backoff: time.Second, MaxBackoff: 5 * time.Second}}
+	assert.Equal(t, time.Second, q.backoff(1))
+	assert.Equal(t, 2*time.Second, q.backoff(2))
+	assert.Equal(t, 4*time.Second, q.backoff(3))
+	assert.Equal(t, 5*time.Second, q.backoff(4))
+	assert.Equal(t, 5*time.Second, q.backoff(100))
+}
+
+// TestQueue_Drain() unit test function tests that a drained Queue handles
+// the queued events before its workers stop, and that the events of a Queue
+// that is not drained within the deadline remain in its Store.
+func TestQueue_Drain(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewFileStore(t.TempDir(), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the (blocked) events are not handled within the deadline
+	q, err := NewQueue(context.Background(), store, testOptions(), newTestHandler(true))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, q.Schedule(context.Background(), testDispatch("delivery-1", "sha-1")))
+	assert.NoError(t, q.Schedule(context.Background(), testDispatch("delivery-2", "sha-2")))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = q.Drain(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "2 webhook events remain queued")
+	// a drained queue does not accept new events
+	err = q.Schedule(context.Background(), testDispatch("delivery-3", "sha-3"))
+	assert.ErrorIs(t, err, ErrQueueClosed)
+	saved, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+
+	// the saved events are handled before the restarted queue is drained
+	handler := newTestHandler(true)
+	restarted, err := NewQueue(context.Background(), store, testOptions(), handler)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, restarted.Schedule(context.Background(), testDispatch("delivery-3", "sha-3")))
+	time.AfterFunc(20*time.Millisecond, func() { close(handler.chan_release) })
+	assert.NoError(t, restarted.Drain(context.Background()))
+	assert.Equal(t, 0, restarted.Len())
+	for _, delivery_id := range []string{"delivery-1", "delivery-2", "delivery-3"} {
+		assert.Equal(t, 1, handler.count(delivery_id))
+	}
+	saved, err = store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+}
@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+)
+
+// Store interface defines the methods for saving queued tasks to some
+// persistent store (e.g. files, database), such that the tasks that are
+// queued when the server stops are handled once the server is restarted.
+type Store interface {
+	Delete(delivery_id string) error
+	List() ([]*Task, error)
+	Save(task *Task) error
+}
+
+// FileStore struct provides an implementation of the Store interface that
+// saves each Task as a JSON file in a directory, which is encrypted when the
+// FileStore has a keyring (i.e. because payloads may contain PHI).
+type FileStore struct {
+	dir     string
+	keyring *crypt.Keyring
+	mutex   *sync.Mutex
+}
+
+// NewFileStore() function returns a new FileStore that saves tasks in the
+// provided directory, which is created if it does not exist. The tasks are
+// encrypted with the (optional) keyring.
+func NewFileStore(dir string, keyring *crypt.Keyring) (*FileStore, error) {
+	if dir == "" {
+		return nil, ErrFileStoreDirEmpty
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return nil, errors.Wrapf(err, "failed to create queue directory %s", dir)
+	}
+
+	return &FileStore{
+		dir:     dir,
+		keyring: keyring,
+		mutex:   &sync.Mutex{},
+	}, nil
+}
+
+// Delete() method deletes the Task with the provided delivery ID, if it
+// exists.
+func (fs *FileStore) Delete(delivery_id string) error {
+	path, err := fs.path(delivery_id)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgTaskDelete)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, ErrMsgTaskDelete)
+	}
+
+	return nil
+}
+
+// List() method returns every Task saved in the FileStore, sorted by the
+// time at which each Task was queued.
+func (fs *FileStore) List() ([]*Task, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgQueueLoad)
+	}
+
+	tasks := make([]*Task, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExtension {
+			continue
+		}
+		contents, err := os.ReadFile(filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, ErrMsgQueueLoad)
+		}
+		contents, err = crypt.Decode(fs.keyring, contents)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s : %s", ErrMsgQueueLoad, entry.Name())
+		}
+		task := &Task{}
+		if err := json.Unmarshal(contents, task); err != nil {
+			return nil, errors.Wrapf(err, "%s : %s", ErrMsgQueueLoad, entry.Name())
+		}
+		tasks = append(tasks, task)
+	}
+	sort.SliceStable(tasks, func(a, b int) bool {
+		return tasks[a].Created.Before(tasks[b].Created)
+	})
+
+	return tasks, nil
+}
+
+// Save() method writes the Task to its file, replacing any previous state of
+// the Task. The file is replaced atomically, such that an interrupted save
+// never corrupts the previous state of the Task.
+func (fs *FileStore) Save(task *Task) error {
+	if task == nil {
+		return errors.Wrap(ErrTaskNil, ErrMsgTaskSave)
+	}
+	path, err := fs.path(task.DeliveryID)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgTaskSave)
+	}
+
+	contents, err := json.Marshal(task)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgTaskSave)
+	}
+	contents, err = crypt.Encode(fs.keyring, contents)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgTaskSave)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := fileio.WriteFileAtomic(path, contents, FileMode); err != nil {
+		return errors.Wrap(err, ErrMsgTaskSave)
+	}
+
+	return nil
+}
+
+// Reencrypt() method encrypts every Task file of the FileStore with the
+// primary key of its keyring (see crypt.ReencryptFile()), and returns the
+// number of files that were rewritten.
+func (fs *FileStore) Reencrypt() (int, error) {
+	if fs.keyring == nil {
+		return 0, errors.Wrap(crypt.ErrKeyringEmpty, ErrMsgQueueReencrypt)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrMsgQueueReencrypt)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExtension {
+			continue
+		}
+		changed, err := crypt.ReencryptFile(fs.keyring, filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return count, errors.Wrap(err, ErrMsgQueueReencrypt)
+		}
+		if changed {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// path() method returns the path of the file of the Task with the provided
+// delivery ID.
+func (fs *FileStore) path(delivery_id string) (string, error) {
+	if !validDeliveryID(delivery_id) {
+		return "", errors.Wrapf(ErrTaskDeliveryIDInvalid, "delivery ID = %q", delivery_id)
+	}
+
+	return filepath.Join(fs.dir, delivery_id+FileExtension), nil
+}
@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+)
+
+// TestNewTask() unit test function tests reading the head commit and the
+// repository of webhook events with the NewTask() function.
+func TestNewTask(t *testing.T) {
+	t.Parallel()
+
+	task, err := NewTask(githubapp.Dispatch{
+		DeliveryID: "delivery-1",
+		EventType:  "pull_request",
+		Payload:    []byte(`{"action":"opened","pull_request":{"head":{"sha":"abc123"}},"repository":{"full_name":"test-org/test-repo"}}`),
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "opened", task.Action)
+		assert.Equal(t, "abc123", task.HeadSHA)
+		assert.Equal(t, "test-org/test-repo", task.Repository)
+		assert.Equal(t, "pull_request.opened/test-org/test-repo@abc123", task.key())
+	}
+
+	task, err = NewTask(testDispatch("delivery-2", "def456"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "def456", task.HeadSHA)
+		assert.Equal(t, "refs/heads/main", task.Ref)
+		assert.Equal(t, "push/test-org/test-repo:refs/heads/main@def456", task.key())
+	}
+
+	// events without a head commit are only deduplicated by delivery ID
+	task, err = NewTask(githubapp.Dispatch{DeliveryID: "delivery-3", EventType: "issue_comment", Payload: []byte(`{}`)})
+	if assert.NoError(t, err) {
+		assert.Empty(t, task.key())
+	}
+
+	_, err = NewTask(githubapp.Dispatch{DeliveryID: "../delivery", EventType: "push", Payload: []byte(`{}`)})
+	assert.ErrorIs(t, err, ErrTaskDeliveryIDInvalid)
+	_, err = NewTask(githubapp.Dispatch{DeliveryID: "delivery-4", EventType: "push", Payload: []byte(`[]`)})
+	assert.Error(t, err)
+}
+
+// TestFileStore() unit test function tests saving, listing and deleting
+// tasks with the FileStore type, with and without encryption.
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFileStore("", nil)
+	assert.ErrorIs(t, err, ErrFileStoreDirEmpty)
+
+	keyring, err := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for name, keyring := range map[string]*crypt.Keyring{"Plaintext": nil, "Encrypted": keyring} {
+		keyring := keyring
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			store, err := NewFileStore(dir, keyring)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			first, _ := NewTask(testDispatch("delivery-1", "John Smith"))
+			second, _ := NewTask(testDispatch("delivery-2", ""))
+			second.Created = first.Created.Add(1)
+			assert.NoError(t, store.Save(second))
+			assert.NoError(t, store.Save(first))
+			first.Attempts = 1
+			assert.NoError(t, store.Save(first))
+
+			tasks, err := store.List()
+			if assert.NoError(t, err) && assert.Len(t, tasks, 2) {
+				assert.Equal(t, "delivery-1", tasks[0].DeliveryID)
+				assert.Equal(t, 1, tasks[0].Attempts)
+				assert.JSONEq(t, string(first.Payload), string(tasks[0].Payload))
+				assert.Equal(t, "delivery-2", tasks[1].DeliveryID)
+			}
+
+			contents, err := os.ReadFile(filepath.Join(dir, "delivery-1"+FileExtension))
+			assert.NoError(t, err)
+			assert.Equal(t, keyring == nil, bytes.Contains(contents, []byte("John Smith")))
+
+			assert.NoError(t, store.Delete("delivery-1"))
+			assert.NoError(t, store.Delete("delivery-1"))
+			tasks, err = store.List()
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 1)
+
+			assert.ErrorIs(t, store.Save(&Task{DeliveryID: ".."}), ErrTaskDeliveryIDInvalid)
+			assert.ErrorIs(t, store.Save(nil), ErrTaskNil)
+		})
+	}
+}
+
+// TestFileStore_Reencrypt() unit test function tests that the tasks of a
+// FileStore are re-encrypted with the primary key of a rotated keyring by
+// the Reencrypt() method.
+func TestFileStore_Reencrypt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	plain_store, err := NewFileStore(dir, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = plain_store.Reencrypt()
+	assert.ErrorIs(t, err, crypt.ErrKeyringEmpty)
+
+	// save a task before encryption is enabled and a task with the old key
+	plain_task, _ := NewTask(testDispatch("delivery-1", "John Smith"))
+	assert.NoError(t, plain_store.Save(plain_task))
+	old_keyring, _ := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	old_store, err := NewFileStore(dir, old_keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	task, _ := NewTask(testDispatch("delivery-2", "John Smith"))
+	assert.NoError(t, old_store.Save(task))
+
+	keyring, _ := crypt.NewKeyring([]crypt.Key{
+		{ID: "key-2", Secret: bytes.Repeat([]byte{2}, crypt.KeySize)},
+		{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)},
+	})
+	rotated_store, err := NewFileStore(dir, keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	count, err := rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// the old key can no longer load the re-encrypted tasks
+	_, err = old_store.List()
+	assert.ErrorIs(t, err, crypt.ErrKeyNotFound)
+	tasks, err := rotated_store.List()
+	if assert.NoError(t, err) {
+		assert.Len(t, tasks, 2)
+	}
+}
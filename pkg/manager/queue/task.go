@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+)
+
+// Task struct contains a GitHub webhook event that is queued to be handled
+// in the background, along with the state of its (failed) attempts.
+type Task struct {
+	// Action is the action of the event (e.g. "opened" for a pull_request
+	// event), which is empty for events without an action.
+	Action string `json:"action,omitempty"`
+	// Attempts is the number of times that the handler of the event has run.
+	Attempts int `json:"attempts"`
+	// Created is the time at which the event was queued.
+	Created time.Time `json:"created"`
+	// DeliveryID is the unique ID of the webhook delivery of the event
+	// (i.e. the X-GitHub-Delivery header), which identifies the Task.
+	DeliveryID string `json:"delivery_id"`
+	// Error is the error returned by the last attempt to handle the event.
+	Error string `json:"error,omitempty"`
+	// EventType is the type of the event (i.e. the X-GitHub-Event header).
+	EventType string `json:"event_type"`
+	// HeadSHA is the commit at the head of the pull request or push of the
+	// event, which is empty for events without a head commit.
+	HeadSHA string `json:"head_sha,omitempty"`
+	// NextAttempt is the time of the next retry of a failed event.
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	// Payload is the (JSON) payload of the event.
+	Payload json.RawMessage `json:"payload"`
+	// Ref is the (full) name of the ref of a push event, which is empty for
+	// other events.
+	Ref string `json:"ref,omitempty"`
+	// Repository is the full name (i.e. "<org>/<repo>") of the repository of
+	// the event, if any.
+	Repository string `json:"repository,omitempty"`
+}
+
+// NewTask() function returns a new Task for the provided githubapp.Dispatch,
+// where the action, ref, head commit and repository of the event are read
+// from its payload.
+func NewTask(dispatch githubapp.Dispatch) (*Task, error) {
+	if !validDeliveryID(dispatch.DeliveryID) {
+		return nil, errors.Wrapf(ErrTaskDeliveryIDInvalid, "delivery ID = %q", dispatch.DeliveryID)
+	}
+
+	// only the fields used to deduplicate events are read from the payload
+	var fields struct {
+		Action      string `json:"action"`
+		After       string `json:"after"`
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+		Ref        string `json:"ref"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(dispatch.Payload, &fields); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse payload of delivery ID %s", dispatch.DeliveryID)
+	}
+
+	task := &Task{
+		Action:     fields.Action,
+		Created:    time.Now().UTC(),
+		DeliveryID: dispatch.DeliveryID,
+		EventType:  dispatch.EventType,
+		Payload:    dispatch.Payload,
+		Ref:        fields.Ref,
+		Repository: fields.Repository.FullName,
+	}
+	switch {
+	case fields.PullRequest.Head.SHA != "":
+		task.HeadSHA = fields.PullRequest.Head.SHA
+	case fields.After != "":
+		task.HeadSHA = fields.After
+	}
+
+	return task, nil
+}
+
+// key() method returns the key used to deduplicate events of the same type
+// and action for the same head commit (and ref, for push events) of a
+// repository, or an empty string if the event does not have a head commit.
+// Events with different actions (e.g. a pull request that is "opened" then
+// "closed") or pushes of the same commit to different refs are never
+// duplicates of each other.
+func (t *Task) key() string {
+	if t.HeadSHA == "" {
+		return ""
+	}
+	key := t.EventType
+	if t.Action != "" {
+		key += "." + t.Action
+	}
+	key += "/" + t.Repository
+	if t.Ref != "" {
+		key += ":" + t.Ref
+	}
+	return key + "@" + t.HeadSHA
+}
+
+// validDeliveryID() function returns true if the provided delivery ID can be
+// used as the name of a file.
+func validDeliveryID(delivery_id string) bool {
+	return delivery_id != "" && delivery_id != "." && delivery_id != ".." && !strings.ContainsAny(delivery_id, `/\`)
+}
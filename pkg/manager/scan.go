@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// newBlobCache() function returns the cache.BlobCache saved in the configured
+// directory (encrypted, if encryption is enabled), or nil if the blob cache
+// is not configured. A BlobCache is safe for concurrent use, such that
+// concurrent scans (e.g. the scan jobs of the REST API) must share a single
+// BlobCache, since each scan saves every blob of its BlobCache to the file.
+func newBlobCache(ctx context.Context, config *cfg.Config) (*cache.BlobCache, error) {
+	if config.Git.Scan.CacheDir == "" {
+		return nil, nil
+	}
+
+	keyring, err := loadKeyring(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load encryption keys")
+	}
+	blob_cache, err := cache.NewBlobCache(config.Git.Scan.CacheDir, cache.FingerprintFromConfig(config), keyring)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize blob cache")
+	}
+	zerolog.Ctx(ctx).Info().Msgf("loaded blob cache with %d blobs from %s", blob_cache.Len(), config.Git.Scan.CacheDir)
+
+	return blob_cache, nil
+}
+
+// newScanner() function returns a new scanner.Scanner for the scan of the
+// first repository configured in the provided config, which stores its
+// results in memory (encrypted, if encryption is enabled) and uses the
+// provided (optional) blob cache.
+func newScanner(ctx context.Context, config *cfg.Config, blob_cache *cache.BlobCache) (*scanner.Scanner, error) {
+	if len(config.Git.Scan.Repositories) == 0 {
+		return nil, errors.New("no repositories specified for scan")
+	}
+
+	// the text of the result records is encrypted at rest if encryption is
+	// enabled
+	keyring, err := loadKeyring(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load encryption keys")
+	}
+	var result_io rrr.ResultRecordIO = memory.NewMemoryResultRecordIO(ctx)
+	if keyring != nil {
+		result_io, err = crypt.NewEncryptedResultRecordIO(result_io, keyring)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize encrypted result store")
+		}
+	}
+
+	s, err := scanner.NewScanner(ctx, &config.Git, result_io)
+	if err != nil {
+		return nil, err
+	}
+	if blob_cache != nil {
+		s.SetBlobCache(blob_cache)
+	}
+
+	return s, nil
+}
+
+// runScanner() function runs the scan of the provided scanner.Scanner, where
+// requests generated by the scan are processed by the provided detector.
+// Every response from the detector is passed to the (optional) on_response
+// func before it is processed by the Scanner, which must be nil if the
+// Scanner uses a blob cache. Returns once the scan is complete, or once the
+// provided context (i.e. the context of the Scanner) is done and the Scanner
+// has saved the state of the cancelled scan.
+func runScanner(
+	ctx context.Context,
+	s *scanner.Scanner,
+	detector rrr.RequestResponsePhiDetector,
+	on_response func(rrr.Response),
+) error {
+	chan_scan_done := make(chan struct{})
+	chan_scan_errors := make(chan error)
+	chan_requests := make(chan rrr.Request)
+	chan_responses := make(chan rrr.Response)
+
+	// the detector sends responses directly to the Scanner, unless the
+	// responses must first be passed to on_response
+	chan_detector_responses := chan_responses
+	if on_response != nil {
+		chan_detector_responses = make(chan rrr.Response)
+		go func() {
+			for response := range chan_detector_responses {
+				on_response(response)
+				select {
+				case chan_responses <- response:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(chan_scan_done)
+		s.Scan(chan_scan_errors, chan_requests, chan_responses)
+	}()
+	go detector.Run(ctx, chan_requests, chan_detector_responses)
+
+	// wait for an error to be returned from the scanner
+	select {
+	case err := <-chan_scan_errors:
+		if ctx.Err() == nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+	// wait for the scanner to save the state of the cancelled scan
+	<-chan_scan_done
+
+	return errors.Wrap(ctx.Err(), "scan cancelled")
+}
@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/client/az"
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/checkpoint"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testBlockingDetector struct is a rrr.RequestResponsePhiDetector that never
+// responds to requests, which closes its chan_received channel once it has
+// received the first request.
+type testBlockingDetector struct {
+	chan_received chan struct{}
+	once          *sync.Once
+}
+
+func (d *testBlockingDetector) Run(ctx context.Context, chan_requests_in <-chan rrr.Request, chan_responses_out chan<- rrr.Response) {
+	defer close(chan_responses_out)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-chan_requests_in:
+			d.once.Do(func() { close(d.chan_received) })
+		}
+	}
+}
+
+// TestRunScanner_cancel() unit test function tests that a scan that is
+// cancelled while its requests are pending returns the error of its context
+// once the checkpoint of the scan is saved, and that the scan is completed
+// when it is resumed from the checkpoint.
+func TestRunScanner_cancel(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer(aztest.Fixture{
+		Match: "John Smith",
+		Entities: []aztest.FixtureEntity{
+			{Category: "Person", ConfidenceScore: 0.97, Text: "John Smith"},
+		},
+	})
+	defer server.Close()
+	server.Key = "test-key"
+
+	repo_url := testCreateRepository(t, map[string]string{
+		"patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+		"README.md":    "# test repository\n",
+	})
+	m := testNewManager(t, server, repo_url)
+	m.config.Git.Scan.Checkpoint.Dir = t.TempDir()
+	m.config.Git.Scan.Checkpoint.ScanID = "test_scan"
+
+	// cancel the scan once the detector has received a request
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+	s, err := newScanner(ctx, m.config, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	detector := &testBlockingDetector{chan_received: make(chan struct{}), once: &sync.Once{}}
+	chan_scan_errors := make(chan error, 1)
+	go func() {
+		chan_scan_errors <- runScanner(ctx, s, detector, nil)
+	}()
+	select {
+	case <-detector.chan_received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("detector did not receive any request")
+	}
+	cancel()
+	select {
+	case err = <-chan_scan_errors:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(10 * time.Second):
+		t.Fatal("cancelled scan did not return")
+	}
+
+	store, err := checkpoint.NewFileStore(m.config.Git.Scan.Checkpoint.Dir, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	saved, err := store.Load(repo_url, "test_scan")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotEmpty(t, saved.Files)
+	assert.NotEmpty(t, saved.Requests)
+
+	// resume the scan, which scans the pending files again
+	resumed, err := newScanner(m.ctx, m.config, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ai, err := az.NewEntityDetectionAI(m.config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, runScanner(m.ctx, resumed, az.NewAzAiLanguagePhiDetector(ai), nil))
+	records, err := resumed.GetResultRecordIO().List()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "John Smith", records[0].Text)
+	}
+	_, err = store.Load(repo_url, "test_scan")
+	assert.ErrorIs(t, err, checkpoint.ErrCheckpointNotFound)
+}
@@ -1,8 +1,10 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	tollbooth "github.com/didip/tollbooth/v6"
@@ -11,11 +13,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/pkg/errors"
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 	"github.com/has-ghas/no-phi-ai/pkg/client/az"
 	"github.com/has-ghas/no-phi-ai/pkg/client/gh"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/deliveries"
 	"github.com/has-ghas/no-phi-ai/pkg/manager/handlers"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/queue"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
 )
 
 // initServer() method initializes the HTTP server and registers handlers.
@@ -42,14 +50,31 @@ func (m *Manager) initServer() {
 	router.Use(gin.Recovery())
 
 	// setup an http.Handler as the event dispatcher for GitHub webhook events
-	eventDispatcher, err := setupEventDispatcher(m.config)
+	eventDispatcher, err := m.setupEventDispatcher()
 	if err != nil {
 		m.logger.Fatal().Err(err).Msg("failed to setup event handler for new Manager")
 	}
+	m.dispatcher = eventDispatcher
 
 	// setup routes for the HTTP server
 	v1 := router.Group(cfg.RouteGroupGHv1)
 	v1.POST(cfg.RouteWebhook, handlers.LimitHandler(lmt), gin.WrapH(eventDispatcher))
+	// serve the metrics of the server in the Prometheus text format
+	router.GET(cfg.RouteMetrics, handlers.LimitHandler(lmt), gin.WrapH(m.newExporter(metrics.DefaultRegistry)))
+	// serve the liveness and readiness probes and the build info of the server,
+	// which are not rate limited so that a busy server is not reported as not
+	// ready
+	router.GET(cfg.RouteHealthz, m.handleHealthz)
+	router.GET(cfg.RouteReadyz, m.handleReadyz)
+	router.GET(cfg.RouteVersion, m.handleVersion)
+	// the REST API is only served when the API token is configured
+	if m.config.Server.APIToken != "" {
+		if err := m.registerAPIRoutes(router, handlers.LimitHandler(lmt), handlers.TokenAuthHandler(m.config.Server.APIToken)); err != nil {
+			m.logger.Fatal().Err(err).Msg("failed to setup REST API for new Manager")
+		}
+	} else {
+		m.logger.Warn().Msg("REST API is disabled : server.api_token is empty")
+	}
 
 	// set the m.server field to a new http.Server instance
 	m.server = &http.Server{
@@ -65,26 +90,91 @@ func (m *Manager) logRoutes() {
 	}
 }
 
-// runServer() method auto-loads configuration from file and env vars,
-// then uses this configuration to setup clients for Azure AI Language
-// service APIs and GitHub APIs before registering HTTP handlers for
-// GitHub webhook events.
-func (m *Manager) runServer() error {
+// runServer() method runs the HTTP server until the provided context is done
+// (e.g. on SIGINT or SIGTERM), then shuts down the server gracefully.
+func (m *Manager) runServer(ctx context.Context) error {
 	m.logRoutes()
 	// run the HTTP server
-	return m.server.ListenAndServe()
+	chan_serve := make(chan error, 1)
+	go func() {
+		chan_serve <- m.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-chan_serve:
+		return err
+	case <-ctx.Done():
+		m.logger.Warn().Msg("received shutdown signal : shutting down server")
+	}
+
+	m.shutdownServer()
+
+	return nil
+}
+
+// shutdownServer() method shuts down the HTTP server gracefully in two
+// phases, each within the configured timeout. The server first stops
+// accepting connections and waits for open requests, then the queued webhook
+// events are drained while the running scan jobs are finished, where the
+// events and jobs that are not finished within the timeout are cancelled (and
+// remain queued or saved as checkpoints, respectively). The drain phase has a
+// timeout of its own, such that open requests that are not finished within
+// their timeout (which is only logged) do not cancel the events and jobs at
+// once. Finally, the context of the Manager is cancelled to stop any other
+// background tasks.
+func (m *Manager) shutdownServer() {
+	defer m.cancel()
+
+	timeout := time.Duration(m.config.Server.ShutdownTimeoutSeconds) * time.Second
+	requests_ctx, requests_cancel := context.WithTimeout(m.ctx, timeout)
+	defer requests_cancel()
+
+	if err := m.server.Shutdown(requests_ctx); err != nil {
+		m.logger.Error().Err(err).Msg("failed to finish open requests before shutdown")
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	if m.queue != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.queue.Drain(ctx); err != nil {
+				m.logger.Warn().Err(err).Msg("queued webhook events will be handled after restart")
+			}
+		}()
+	}
+	if m.jobs != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.jobs.Shutdown(ctx); err != nil {
+				m.logger.Warn().Err(err).Msg("cancelled scan jobs can be resumed by submitting them again with their scan_id")
+			}
+		}()
+	}
+	wg.Wait()
+	m.logger.Info().Msg("server shut down")
 }
 
-// setupEventDispatcher() function returns an http.Handler that can be used
+// setupEventDispatcher() method returns an http.Handler that can be used
 // as the event dispatcher for GitHub webhook events sent to the HTTP server;
 // returns a non-nil error if unable to setup the event dispatcher handler.
-func setupEventDispatcher(config *cfg.Config) (http.Handler, error) {
+// Every event is queued by the m.queue field, which runs the event handlers
+// in the background, such that the dispatcher responds to every delivery
+// with 202 Accepted without waiting for its handler, and duplicate deliveries
+// are ignored by the m.deliveries field.
+func (m *Manager) setupEventDispatcher() (http.Handler, error) {
+	config := m.config
 	// create a common *gh.ClientManager, which can be used for interacting
 	// with the GitHub API via the go-github libarary
 	ghcm, err := gh.NewClientManager(config)
 	if err != nil {
 		return nil, err
 	}
+	m.ghcm = ghcm
 
 	// create a common *az.EntityDetectionAI, which can be used for detecting
 	// "entities" of interest within text documents submitted to the the API
@@ -93,6 +183,7 @@ func setupEventDispatcher(config *cfg.Config) (http.Handler, error) {
 	if ai_err != nil {
 		return nil, ai_err
 	}
+	m.ai = ai
 
 	// define the event handlers
 	installationHandler := &handlers.InstallationHandler{
@@ -110,15 +201,95 @@ func setupEventDispatcher(config *cfg.Config) (http.Handler, error) {
 		AI:   ai,
 		GHCM: ghcm,
 	}
-
-	// register the event handlers with a new/default event dispatcher
-	eventDispatcher := githubapp.NewDefaultEventDispatcher(
-		*config.GitHub.GetGitHubAppConfig(),
+	event_handlers := []githubapp.EventHandler{
 		installationHandler,
 		issueCommentHandler,
 		pullRequestHandler,
 		pushHandler,
+	}
+
+	// the queued events and the deliveries are encrypted if encryption is
+	// enabled, because their payloads may contain PHI
+	keyring, err := loadKeyring(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load encryption keys")
+	}
+	// setup the queue that runs the event handlers in the background
+	m.queue, err = newQueue(m.ctx, config, keyring, event_handlers...)
+	if err != nil {
+		return nil, err
+	}
+	// setup the scheduler that ignores duplicate deliveries of events
+	m.deliveries, err = newDeliveryScheduler(m.ctx, config, keyring, m.queue)
+	if err != nil {
+		return nil, err
+	}
+
+	// register the event handlers with a new event dispatcher
+	eventDispatcher := githubapp.NewEventDispatcher(
+		event_handlers,
+		config.GitHub.App.WebhookSecret,
+		githubapp.WithScheduler(m.deliveries),
+		githubapp.WithResponseCallback(handlers.AcceptedResponseCallback),
 	)
 
 	return eventDispatcher, nil
 }
+
+// newDeliveryScheduler() function returns a new deliveries.Scheduler that
+// passes deliveries to the provided next githubapp.Scheduler, which saves
+// the deliveries to the configured directory (if any), encrypted with the
+// provided (optional) keyring.
+func newDeliveryScheduler(
+	ctx context.Context,
+	config *cfg.Config,
+	keyring *crypt.Keyring,
+	next githubapp.Scheduler,
+) (*deliveries.Scheduler, error) {
+	var store deliveries.Store = deliveries.NewMemoryStore()
+	if config.Server.Deliveries.Dir != "" {
+		file_store, err := deliveries.NewFileStore(config.Server.Deliveries.Dir, keyring)
+		if err != nil {
+			return nil, err
+		}
+		store = file_store
+	} else {
+		zerolog.Ctx(ctx).Warn().Msg("webhook deliveries will not survive a restart : server.deliveries.dir is empty")
+	}
+
+	return deliveries.NewScheduler(ctx, next, store, time.Duration(config.Server.Deliveries.TTLHours)*time.Hour)
+}
+
+// newQueue() function returns a new queue.Queue for the provided event
+// handlers, which saves the queued events to the configured directory (if
+// any), encrypted with the provided (optional) keyring.
+func newQueue(
+	ctx context.Context,
+	config *cfg.Config,
+	keyring *crypt.Keyring,
+	event_handlers ...githubapp.EventHandler,
+) (*queue.Queue, error) {
+	var store queue.Store
+	if config.Server.Queue.Dir != "" {
+		file_store, err := queue.NewFileStore(config.Server.Queue.Dir, keyring)
+		if err != nil {
+			return nil, err
+		}
+		store = file_store
+	} else {
+		zerolog.Ctx(ctx).Warn().Msg("queued webhook events will not survive a restart : server.queue.dir is empty")
+	}
+
+	return queue.NewQueue(
+		ctx,
+		store,
+		queue.Options{
+			Backoff:     time.Duration(config.Server.Queue.BackoffSeconds) * time.Second,
+			MaxAttempts: config.Server.Queue.MaxAttempts,
+			MaxBackoff:  time.Duration(config.Server.Queue.MaxBackoffSeconds) * time.Second,
+			Size:        config.Server.Queue.Size,
+			Workers:     config.Server.Queue.Workers,
+		},
+		event_handlers...,
+	)
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/client/az/aztest"
+	"github.com/has-ghas/no-phi-ai/pkg/manager/jobs"
+)
+
+// TestManager_runServer() unit test function tests that the server is shut
+// down once its context is done, where a running scan job that is not
+// finished within the shutdown timeout is cancelled, even though an open
+// request is not finished within the timeout either. A running scan job that
+// is finished after the timeout of the open request, but within the timeout
+// of the drain phase, is not cancelled.
+func TestManager_runServer(t *testing.T) {
+	t.Parallel()
+
+	server := aztest.NewServer()
+	defer server.Close()
+	m := testNewManager(t, server, "test-org/test-repo")
+	m.config.Server.ShutdownTimeoutSeconds = 1
+	// find a free port for the server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	chan_entered := make(chan struct{})
+	chan_release := make(chan struct{})
+	router := gin.New()
+	router.GET("/block", func(c *gin.Context) {
+		close(chan_entered)
+		<-chan_release
+		c.Status(http.StatusOK)
+	})
+	m.server = &http.Server{
+		Addr:    address,
+		Handler: router,
+	}
+
+	chan_job_release := make(chan struct{})
+	m.jobs, err = jobs.NewRunner(m.ctx, jobs.Options{MaxFinished: 2, Retention: time.Hour, Workers: 2}, func(ctx context.Context, job *jobs.Job) error {
+		if job.ScanID() == "finishing" {
+			select {
+			case <-chan_job_release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	job, err := m.jobs.Submit(jobs.ScanRequest{Repositories: []string{"test-org/test-repo"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	finishing_job, err := m.jobs.Submit(jobs.ScanRequest{Repositories: []string{"test-org/test-repo"}, ScanID: "finishing"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	signals, stop := context.WithCancel(context.Background())
+	chan_server_errors := make(chan error, 1)
+	go func() {
+		chan_server_errors <- m.runServer(signals)
+	}()
+	// open a request that blocks until the server is shut down
+	chan_request_done := make(chan struct{})
+	go func() {
+		defer close(chan_request_done)
+		for i := 0; i < 100; i++ {
+			response, err := http.Get("http://" + address + "/block")
+			if err == nil {
+				response.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	select {
+	case <-chan_entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("request was not received")
+	}
+	stop()
+	// finish the job after the timeout of the open request
+	time.AfterFunc(1500*time.Millisecond, func() { close(chan_job_release) })
+	select {
+	case err = <-chan_server_errors:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	close(chan_release)
+	<-chan_request_done
+	assert.Equal(t, jobs.StatusCancelled, job.Status().Status)
+	assert.Equal(t, jobs.StatusComplete, finishing_job.Status().Status)
+	assert.ErrorIs(t, m.ctx.Err(), context.Canceled)
+	_, err = m.jobs.Submit(jobs.ScanRequest{Repositories: []string{"test-org/test-repo"}})
+	assert.ErrorIs(t, err, jobs.ErrRunnerClosed)
+}
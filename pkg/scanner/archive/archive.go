@@ -0,0 +1,223 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Limits struct contains the limits that protect the scanner from malicious
+// (e.g. zip bomb) archives.
+type Limits struct {
+	// MaxMembers is the maximum number of members of an archive.
+	MaxMembers int
+	// MaxSize is the maximum total number of (uncompressed) bytes of the
+	// members of an archive.
+	MaxSize int64
+}
+
+// Budget struct is the number of members and (uncompressed) bytes that
+// remain to be read from an archive and from every archive nested within it,
+// such that the Limits apply to the archive as a whole instead of to each of
+// its nested archives. Not safe for concurrent use.
+type Budget struct {
+	members int
+	size    int64
+}
+
+// NewBudget() function returns a new Budget of the provided Limits.
+func NewBudget(limits Limits) *Budget {
+	return &Budget{
+		members: limits.MaxMembers,
+		size:    limits.MaxSize,
+	}
+}
+
+// Members() method returns the number of members that remain to be read.
+func (b *Budget) Members() int {
+	return b.members
+}
+
+// Size() method returns the number of (uncompressed) bytes that remain to
+// be read.
+func (b *Budget) Size() int64 {
+	return b.size
+}
+
+// Member struct is a single file within an archive.
+type Member struct {
+	// Contents are the (uncompressed) contents of the member.
+	Contents []byte
+	// Name is the path of the member within the archive.
+	Name string
+}
+
+// Detect() function returns the format of the archive with the provided
+// name and the first bytes of its contents (at least 512 bytes for tar), or
+// an empty string if the file is not a supported archive. Documents that
+// are zip files (e.g. DOCX) are not archives.
+func Detect(name string, head []byte) string {
+	if DocumentExtensions[strings.ToLower(filepath.Ext(name))] {
+		return ""
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return FormatZip
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		return FormatGzip
+	case isTar(head):
+		return FormatTar
+	}
+
+	return ""
+}
+
+// isTar() function returns true if the contents start with the header of a
+// POSIX (ustar) tar archive.
+func isTar(head []byte) bool {
+	return len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar"))
+}
+
+// Open() function returns the regular files of the archive with the
+// provided format, name and contents, in the order in which they appear in
+// the archive. A gzip file is a single member named after the archive
+// without its extension, unless it is a compressed tar archive (e.g. .tgz).
+// Encrypted members of zip archives are skipped. The members that are read
+// are deducted from the provided Budget, which is shared by the archives
+// nested within the same (top-level) archive. Returns an error if the
+// archive exceeds the remaining Budget.
+func Open(format, name string, contents []byte, budget *Budget) (members []Member, e error) {
+	reader := &memberReader{budget: budget}
+	switch format {
+	case FormatGzip:
+		e = reader.readGzip(name, contents)
+	case FormatTar:
+		e = reader.readTar(bytes.NewReader(contents))
+	case FormatZip:
+		e = reader.readZip(contents)
+	default:
+		e = ErrUnsupportedFormat
+	}
+	if e != nil {
+		return nil, errors.Wrapf(e, ErrMsgOpenArchive, format)
+	}
+
+	return reader.members, nil
+}
+
+// memberReader struct reads the members of an archive, keeping the number
+// and total size of the members within the Budget.
+type memberReader struct {
+	budget  *Budget
+	members []Member
+}
+
+// add() method reads the contents of a member and adds the member, unless
+// the member exceeds the Budget.
+func (r *memberReader) add(name string, contents io.Reader) error {
+	if r.budget.members <= 0 {
+		return ErrArchiveTooManyMembers
+	}
+	remaining := r.budget.size
+	data, err := io.ReadAll(io.LimitReader(contents, remaining+1))
+	if err != nil {
+		return errors.Wrap(err, name)
+	}
+	if int64(len(data)) > remaining {
+		return ErrArchiveTooLarge
+	}
+	r.budget.members--
+	r.budget.size -= int64(len(data))
+	r.members = append(r.members, Member{
+		Contents: data,
+		Name:     cleanName(name),
+	})
+
+	return nil
+}
+
+// readGzip() method reads the member of a gzip file, or the members of the
+// tar archive compressed by the gzip file.
+func (r *memberReader) readGzip(name string, contents []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	member_name := gz.Name
+	if member_name == "" {
+		member_name = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+	// buffer the start of the decompressed contents to check for a tar
+	// archive, without decompressing more than the limits allow
+	buffered := bufio.NewReaderSize(gz, 512)
+	head, _ := buffered.Peek(512)
+	if isTar(head) {
+		return r.readTar(buffered)
+	}
+
+	return r.add(member_name, buffered)
+}
+
+// readTar() method reads the regular files of a tar archive.
+func (r *memberReader) readTar(contents io.Reader) error {
+	reader := tar.NewReader(contents)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := r.add(header.Name, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// readZip() method reads the files of a zip archive.
+func (r *memberReader) readZip(contents []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		// skip directories and encrypted files
+		if file.FileInfo().IsDir() || file.Flags&0x1 != 0 {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return errors.Wrap(err, file.Name)
+		}
+		err = r.add(file.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanName() function returns the path of a member relative to the root of
+// the archive.
+func cleanName(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+
+	return strings.TrimPrefix(name, "/")
+}
@@ -0,0 +1,219 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testZip() helper function returns the contents of a zip archive with the
+// provided entries, where entries with a name ending with "/" are
+// directories.
+func testZip(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for _, entry := range entries {
+		w, err := writer.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// testTar() helper function returns the contents of a tar archive with the
+// provided regular files and a directory.
+func testTar(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := tar.NewWriter(buffer)
+	if err := writer.WriteHeader(&tar.Header{Name: "export/", Mode: 0755, Typeflag: tar.TypeDir}); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry[0], Mode: 0644, Size: int64(len(entry[1])), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// testGzip() helper function returns the gzip-compressed contents, with the
+// provided (optional) name in the gzip header.
+func testGzip(t *testing.T, name string, contents []byte) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	writer.Name = name
+	if _, err := writer.Write(contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// TestDetect() unit test function tests that Detect() returns the format of
+// archives from their contents, except for documents that are zip files.
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	zip_contents := testZip(t, [2]string{"a.txt", "a"})
+	tests := []struct {
+		name     string
+		file     string
+		contents []byte
+		expected string
+	}{
+		{name: "zip", file: "data.zip", contents: zip_contents, expected: FormatZip},
+		{name: "zip without extension", file: "data", contents: zip_contents, expected: FormatZip},
+		{name: "docx", file: "report.DOCX", contents: zip_contents, expected: ""},
+		{name: "gzip", file: "dump.sql.gz", contents: testGzip(t, "", []byte("x")), expected: FormatGzip},
+		{name: "tar", file: "data.tar", contents: testTar(t), expected: FormatTar},
+		{name: "text", file: "notes.txt", contents: []byte("John Smith"), expected: ""},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, Detect(test.file, test.contents))
+		})
+	}
+}
+
+// TestOpen() unit test function tests that Open() returns the regular files
+// of each supported archive format with their paths.
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	limits := Limits{MaxMembers: 10, MaxSize: 1 << 20}
+	tar_contents := testTar(t, [2]string{"export/patients.csv", "John Smith"}, [2]string{"./notes.txt", "Jane Doe"})
+	tests := []struct {
+		name     string
+		file     string
+		format   string
+		contents []byte
+		expected []Member
+	}{
+		{
+			name:     "zip",
+			file:     "data.zip",
+			format:   FormatZip,
+			contents: testZip(t, [2]string{"export/", ""}, [2]string{"export/patients.csv", "John Smith"}, [2]string{"../notes.txt", "Jane Doe"}),
+			expected: []Member{
+				{Contents: []byte("John Smith"), Name: "export/patients.csv"},
+				{Contents: []byte("Jane Doe"), Name: "notes.txt"},
+			},
+		},
+		{
+			name:     "tar",
+			file:     "data.tar",
+			format:   FormatTar,
+			contents: tar_contents,
+			expected: []Member{
+				{Contents: []byte("John Smith"), Name: "export/patients.csv"},
+				{Contents: []byte("Jane Doe"), Name: "notes.txt"},
+			},
+		},
+		{
+			name:     "tgz",
+			file:     "data.tgz",
+			format:   FormatGzip,
+			contents: testGzip(t, "", tar_contents),
+			expected: []Member{
+				{Contents: []byte("John Smith"), Name: "export/patients.csv"},
+				{Contents: []byte("Jane Doe"), Name: "notes.txt"},
+			},
+		},
+		{
+			name:     "gzip",
+			file:     "backup/dump.sql.gz",
+			format:   FormatGzip,
+			contents: testGzip(t, "", []byte("INSERT 'John Smith'")),
+			expected: []Member{{Contents: []byte("INSERT 'John Smith'"), Name: "dump.sql"}},
+		},
+		{
+			name:     "gzip with name",
+			file:     "dump.gz",
+			format:   FormatGzip,
+			contents: testGzip(t, "patients.csv", []byte("John Smith")),
+			expected: []Member{{Contents: []byte("John Smith"), Name: "patients.csv"}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			members, err := Open(test.format, test.file, test.contents, NewBudget(limits))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, members)
+		})
+	}
+}
+
+// TestOpen_limits() unit test function tests that Open() returns an error
+// for archives that exceed the Limits, such as a zip bomb.
+func TestOpen_limits(t *testing.T) {
+	t.Parallel()
+
+	bomb := testZip(t, [2]string{"zeros.txt", string(make([]byte, 1<<16))})
+	_, err := Open(FormatZip, "bomb.zip", bomb, NewBudget(Limits{MaxMembers: 10, MaxSize: 1 << 10}))
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+
+	gzip_bomb := testGzip(t, "", make([]byte, 1<<16))
+	_, err = Open(FormatGzip, "bomb.gz", gzip_bomb, NewBudget(Limits{MaxMembers: 10, MaxSize: 1 << 10}))
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+
+	many := testZip(t, [2]string{"a.txt", "a"}, [2]string{"b.txt", "b"})
+	_, err = Open(FormatZip, "many.zip", many, NewBudget(Limits{MaxMembers: 1, MaxSize: 1 << 10}))
+	assert.ErrorIs(t, err, ErrArchiveTooManyMembers)
+
+	_, err = Open(FormatZip, "invalid.zip", []byte("PK\x03\x04 truncated"), NewBudget(Limits{MaxMembers: 1, MaxSize: 1 << 10}))
+	assert.Error(t, err)
+
+	_, err = Open("rar", "data.rar", []byte("Rar!"), NewBudget(Limits{MaxMembers: 1, MaxSize: 1 << 10}))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// the budget is shared by the archives opened with it (e.g. nested
+	// archives), where each archive is within the limits on its own
+	budget := NewBudget(Limits{MaxMembers: 2, MaxSize: 1 << 10})
+	half := testZip(t, [2]string{"zeros.txt", string(make([]byte, 600))})
+	members, err := Open(FormatZip, "first.zip", half, budget)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, 1, budget.Members())
+	assert.Equal(t, int64(1<<10-600), budget.Size())
+	_, err = Open(FormatZip, "second.zip", half, budget)
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+	_, err = Open(FormatZip, "many.zip", many, budget)
+	assert.ErrorIs(t, err, ErrArchiveTooManyMembers)
+}
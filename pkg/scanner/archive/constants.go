@@ -0,0 +1,23 @@
+package archive
+
+// Formats of the archives supported by Open().
+const (
+	FormatGzip string = "gzip"
+	FormatTar  string = "tar"
+	FormatZip  string = "zip"
+)
+
+// DocumentExtensions is a map of the file extensions of documents that are
+// zip files (e.g. DOCX), which are not opened as archives.
+var DocumentExtensions = map[string]bool{
+	".docx": true,
+	".odp":  true,
+	".ods":  true,
+	".odt":  true,
+	".pptx": true,
+	".xlsx": true,
+}
+
+// PathSeparator separates the path of an archive from the path of a member
+// of the archive (e.g. "data.zip!/export/patients.csv").
+const PathSeparator string = "!/"
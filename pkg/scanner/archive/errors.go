@@ -0,0 +1,11 @@
+package archive
+
+import "github.com/pkg/errors"
+
+const ErrMsgOpenArchive = "failed to open %s archive"
+
+var (
+	ErrArchiveTooLarge       = errors.New("archive exceeds the maximum size of its members")
+	ErrArchiveTooManyMembers = errors.New("archive exceeds the maximum number of members")
+	ErrUnsupportedFormat     = errors.New("format is not a supported archive format")
+)
@@ -0,0 +1,292 @@
+package baseline
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Suppression struct marks the findings that match its Fingerprint or its
+// Hash as a known false positive or an accepted risk, which are not
+// reported as new findings until the suppression expires.
+type Suppression struct {
+	// Author is the person who accepted the finding.
+	Author string `json:"author"`
+	// Category is the category of the finding, which is kept for the
+	// readers of the baseline file.
+	Category string `json:"category,omitempty"`
+	// Created is the time at which the suppression was created.
+	Created time.Time `json:"created"`
+	// Expires is the time at which the suppression expires, or nil if the
+	// suppression never expires.
+	Expires *time.Time `json:"expires,omitempty"`
+	// Fingerprint is the content fingerprint of the finding, as returned by
+	// the Fingerprint() function with the key of the Baseline, which matches
+	// the finding in every commit regardless of the position of its text
+	// within the file.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Hash is the ResultRecord.Hash of the finding, which only matches the
+	// finding at the same position within the same commit.
+	Hash string `json:"hash,omitempty"`
+	// Path is the path of the file (or object) of the finding, which is kept
+	// for the readers of the baseline file.
+	Path string `json:"path,omitempty"`
+	// Reason explains why the finding is suppressed.
+	Reason string `json:"reason"`
+}
+
+// Expired() method returns true if the suppression has expired at the
+// provided time.
+func (s Suppression) Expired(now time.Time) bool {
+	return s.Expires != nil && !now.Before(*s.Expires)
+}
+
+// key() method returns the key of the suppression within a Baseline.
+func (s Suppression) key() string {
+	if s.Fingerprint != "" {
+		return "fingerprint:" + s.Fingerprint
+	}
+	return "hash:" + s.Hash
+}
+
+// baselineFile struct is the format of the baseline file.
+type baselineFile struct {
+	Suppressions []Suppression `json:"suppressions"`
+	Version      int           `json:"version"`
+}
+
+// Baseline struct contains the suppressions of the findings of previous
+// scans, which are used to report only the new findings of a scan, where the
+// fingerprints of the findings are keyed by the key of the Baseline. The
+// Baseline is not safe for concurrent use.
+type Baseline struct {
+	key          []byte
+	suppressions map[string]Suppression
+}
+
+// NewBaseline() function returns a new empty Baseline with the provided key
+// of the fingerprints of its findings (see Fingerprint()).
+func NewBaseline(key []byte) (*Baseline, error) {
+	if len(key) == 0 {
+		return nil, ErrBaselineKeyEmpty
+	}
+
+	return &Baseline{
+		key:          key,
+		suppressions: make(map[string]Suppression),
+	}, nil
+}
+
+// Load() function returns the Baseline saved in the file at the provided
+// path, or a new empty Baseline if the file does not exist, with the
+// provided key of the fingerprints of its findings.
+func Load(path string, key []byte) (*Baseline, error) {
+	if path == "" {
+		return nil, ErrBaselinePathEmpty
+	}
+
+	b, err := NewBaseline(key)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, errors.Wrap(err, ErrMsgBaselineLoad)
+	}
+	saved := baselineFile{}
+	if err := json.Unmarshal(contents, &saved); err != nil {
+		return nil, errors.Wrap(err, ErrMsgBaselineLoad)
+	}
+	if saved.Version != Version {
+		return nil, errors.Wrap(ErrBaselineVersion, ErrMsgBaselineLoad)
+	}
+	for _, suppression := range saved.Suppressions {
+		if err := b.Add(suppression); err != nil {
+			return nil, errors.Wrap(err, ErrMsgBaselineLoad)
+		}
+	}
+
+	return b, nil
+}
+
+// Add() method adds the suppression to the Baseline, replacing any
+// suppression with the same Fingerprint (or Hash, if the suppression has no
+// Fingerprint).
+func (b *Baseline) Add(suppression Suppression) error {
+	if suppression.Fingerprint == "" && suppression.Hash == "" {
+		return ErrSuppressionKeyEmpty
+	}
+	if strings.TrimSpace(suppression.Reason) == "" {
+		return ErrSuppressionReasonEmpty
+	}
+	b.suppressions[suppression.key()] = suppression
+
+	return nil
+}
+
+// DropExpired() method removes the suppressions that have expired at the
+// provided time, and returns the number of removed suppressions.
+func (b *Baseline) DropExpired(now time.Time) int {
+	dropped := 0
+	for key, suppression := range b.suppressions {
+		if suppression.Expired(now) {
+			delete(b.suppressions, key)
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// Filter() method splits the records into the new findings, which are not
+// matched by any suppression of the Baseline, and the suppressed findings.
+func (b *Baseline) Filter(records []rrr.ResultRecord, now time.Time) (new_records, suppressed []rrr.ResultRecord) {
+	new_records = make([]rrr.ResultRecord, 0)
+	suppressed = make([]rrr.ResultRecord, 0)
+	for _, record := range records {
+		if _, ok := b.Match(record, now); ok {
+			suppressed = append(suppressed, record)
+		} else {
+			new_records = append(new_records, record)
+		}
+	}
+
+	return
+}
+
+// Len() method returns the number of suppressions in the Baseline.
+func (b *Baseline) Len() int {
+	return len(b.suppressions)
+}
+
+// Match() method returns the suppression of the Baseline that matches the
+// record by its Fingerprint or by its Hash, ignoring expired suppressions.
+func (b *Baseline) Match(record rrr.ResultRecord, now time.Time) (Suppression, bool) {
+	keys := []string{
+		Suppression{Fingerprint: b.Fingerprint(record)}.key(),
+		Suppression{Hash: record.Hash}.key(),
+	}
+	for _, key := range keys {
+		if suppression, ok := b.suppressions[key]; ok && !suppression.Expired(now) {
+			return suppression, true
+		}
+	}
+
+	return Suppression{}, false
+}
+
+// Save() method writes the suppressions of the Baseline to the file at the
+// provided path, sorted by path, category and key such that the file can be
+// reviewed and diffed. The file is replaced atomically, such that an
+// interrupted save never corrupts the previous file.
+func (b *Baseline) Save(path string) error {
+	if path == "" {
+		return ErrBaselinePathEmpty
+	}
+
+	saved := baselineFile{
+		Suppressions: make([]Suppression, 0, len(b.suppressions)),
+		Version:      Version,
+	}
+	for _, suppression := range b.suppressions {
+		saved.Suppressions = append(saved.Suppressions, suppression)
+	}
+	sort.Slice(saved.Suppressions, func(i, j int) bool {
+		a, c := saved.Suppressions[i], saved.Suppressions[j]
+		if a.Path != c.Path {
+			return a.Path < c.Path
+		}
+		if a.Category != c.Category {
+			return a.Category < c.Category
+		}
+		return a.key() < c.key()
+	})
+	contents, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, ErrMsgBaselineSave)
+	}
+
+	if err := fileio.WriteFileAtomic(path, append(contents, '\n'), FileMode); err != nil {
+		return errors.Wrap(err, ErrMsgBaselineSave)
+	}
+
+	return nil
+}
+
+// Suppress() method adds a suppression with the provided author, reason and
+// (optional) expiry for the Fingerprint of each record that is not already
+// suppressed, and returns the number of added suppressions.
+func (b *Baseline) Suppress(records []rrr.ResultRecord, author, reason string, expires *time.Time, now time.Time) (int, error) {
+	added := 0
+	for _, record := range records {
+		if _, ok := b.Match(record, now); ok {
+			continue
+		}
+		err := b.Add(Suppression{
+			Author:      author,
+			Category:    record.Category,
+			Created:     now.UTC(),
+			Expires:     expires,
+			Fingerprint: b.Fingerprint(record),
+			Path:        record.Object.Path,
+			Reason:      reason,
+		})
+		if err != nil {
+			return added, err
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+// Fingerprint() method returns the content fingerprint of the record with
+// the key of the Baseline (see Fingerprint()).
+func (b *Baseline) Fingerprint(record rrr.ResultRecord) string {
+	return Fingerprint(b.key, record)
+}
+
+// Fingerprint() function returns the content fingerprint of the record,
+// which is a HMAC-SHA256 with the provided key of the type and path of the
+// object (e.g. file) of the record and the category and the HMAC of the text
+// of its result (see rrr.TextHMAC()). The fingerprint does not depend on the
+// commit, the blob or the position of the result, so it still matches the
+// finding when the lines of the file are shifted by other changes. The
+// fingerprint is keyed, such that the text of the result cannot be recovered
+// from the baseline (e.g. by hashing every name) without the key.
+func Fingerprint(key []byte, record rrr.ResultRecord) string {
+	object_type := record.Object.Type
+	if object_type == "" {
+		object_type = rrr.ObjectTypeFile
+	}
+	// the HMAC of the text of a protected result replaces its text, which
+	// is the same HMAC of the text of a raw result with the same key, so the
+	// fingerprint of a result does not depend on the mode of its text
+	text_hmac := record.TextHMAC
+	if text_hmac == "" {
+		text_hmac = rrr.TextHMAC(key, record.Text)
+	}
+	elements := []string{
+		object_type,
+		record.Object.Path,
+		record.Category,
+		text_hmac,
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(elements, rrr.ResultSeparatorUID)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
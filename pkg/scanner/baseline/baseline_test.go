@@ -0,0 +1,172 @@
+package baseline
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testKey is the key of the fingerprints of the test baselines.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// testRecord() helper function returns a new rrr.ResultRecord for the result
+// with the provided text at the provided offset of the file at path.
+func testRecord(commit_id, path, text string, offset int) rrr.ResultRecord {
+	record := rrr.ResultRecord{Result: rrr.Result{Category: "Person", Length: len(text), Offset: offset, Text: text}}
+	record.Commit.ID = commit_id
+	record.Object.Path = path
+	record.Object.Type = rrr.ObjectTypeFile
+	record.Repository.ID = "test_repo"
+	record.Hash = record.Result.Hash(record.Repository.ID, commit_id, path)
+	return record
+}
+
+// TestLoad() unit test function tests the Load() function and the Save()
+// method of the Baseline.
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load("", testKey)
+	assert.ErrorIs(t, err, ErrBaselinePathEmpty)
+	_, err = Load(filepath.Join(t.TempDir(), "baseline.json"), nil)
+	assert.ErrorIs(t, err, ErrBaselineKeyEmpty)
+	_, err = NewBaseline(nil)
+	assert.ErrorIs(t, err, ErrBaselineKeyEmpty)
+
+	// a missing file is an empty baseline
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	b, err := Load(path, testKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 0, b.Len())
+
+	// a corrupt file should not be silently discarded
+	assert.NoError(t, os.WriteFile(path, []byte("{"), FileMode))
+	_, err = Load(path, testKey)
+	assert.ErrorContains(t, err, ErrMsgBaselineLoad)
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), FileMode))
+	_, err = Load(path, testKey)
+	assert.ErrorIs(t, err, ErrBaselineVersion)
+	// the fingerprints of the first version of the baseline were not keyed
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version": 1}`), FileMode))
+	_, err = Load(path, testKey)
+	assert.ErrorIs(t, err, ErrBaselineVersion)
+
+	b, _ = NewBaseline(testKey)
+	assert.ErrorIs(t, b.Add(Suppression{Reason: "test"}), ErrSuppressionKeyEmpty)
+	assert.ErrorIs(t, b.Add(Suppression{Hash: "test_hash"}), ErrSuppressionReasonEmpty)
+	assert.NoError(t, b.Add(Suppression{Author: "test", Hash: "test_hash", Reason: "test"}))
+	assert.NoError(t, b.Add(Suppression{Author: "test", Fingerprint: "test_fingerprint", Reason: "test"}))
+	assert.NoError(t, b.Save(path))
+
+	loaded, err := Load(path, testKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, b, loaded)
+	}
+}
+
+// TestBaseline_Filter() unit test function tests that the records that are
+// suppressed by fingerprint or by hash are filtered, ignoring the
+// suppressions that have expired.
+func TestBaseline_Filter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	expires := now.Add(time.Hour)
+
+	accepted := testRecord("commit_1", "docs/a.md", "John Smith", 10)
+	by_hash := testRecord("commit_1", "docs/b.md", "Jane Doe", 20)
+	stale := testRecord("commit_1", "docs/c.md", "Joe Bloggs", 30)
+	b, _ := NewBaseline(testKey)
+	assert.NoError(t, b.Add(Suppression{Author: "test", Expires: &expires, Fingerprint: b.Fingerprint(accepted), Reason: "test data"}))
+	assert.NoError(t, b.Add(Suppression{Author: "test", Hash: by_hash.Hash, Reason: "false positive"}))
+	assert.NoError(t, b.Add(Suppression{Author: "test", Expires: &expired, Fingerprint: b.Fingerprint(stale), Reason: "expired"}))
+
+	records := []rrr.ResultRecord{
+		accepted,
+		// the same finding in another commit at another offset
+		testRecord("commit_2", "docs/a.md", "John Smith", 42),
+		by_hash,
+		// the hash does not match the same finding in another commit
+		testRecord("commit_2", "docs/b.md", "Jane Doe", 20),
+		stale,
+		// the same text in another file is a new finding
+		testRecord("commit_1", "docs/d.md", "John Smith", 10),
+	}
+	new_records, suppressed := b.Filter(records, now)
+	assert.Len(t, suppressed, 3)
+	assert.Equal(t, []rrr.ResultRecord{records[3], records[4], records[5]}, new_records)
+
+	// the suppression by fingerprint expires at the expiry time
+	new_records, _ = b.Filter(records, expires)
+	assert.Len(t, new_records, 5)
+
+	assert.Equal(t, 1, b.DropExpired(now))
+	assert.Equal(t, 2, b.Len())
+}
+
+// TestBaseline_Suppress() unit test function tests that the Suppress()
+// method only adds the records that are not already suppressed.
+func TestBaseline_Suppress(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	b, _ := NewBaseline(testKey)
+	records := []rrr.ResultRecord{
+		testRecord("commit_1", "docs/a.md", "John Smith", 10),
+		testRecord("commit_2", "docs/a.md", "John Smith", 42),
+		testRecord("commit_1", "docs/b.md", "Jane Doe", 20),
+	}
+	added, err := b.Suppress(records, "test", "legacy data", nil, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	suppression, ok := b.Match(records[1], now)
+	if assert.True(t, ok) {
+		assert.Equal(t, "test", suppression.Author)
+		assert.Equal(t, "Person", suppression.Category)
+		assert.Equal(t, now, suppression.Created)
+		assert.Equal(t, "docs/a.md", suppression.Path)
+		assert.Equal(t, "legacy data", suppression.Reason)
+		assert.Nil(t, suppression.Expires)
+	}
+
+	_, err = b.Suppress(records, "test", "", nil, now.Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = b.Suppress([]rrr.ResultRecord{testRecord("commit_1", "docs/c.md", "Jane Doe", 0)}, "test", "", nil, now)
+	assert.ErrorIs(t, err, ErrSuppressionReasonEmpty)
+}
+
+// TestFingerprint() unit test function tests that the fingerprint of a
+// record is keyed, such that the text of the record cannot be recovered
+// from the fingerprint without the key, and that the fingerprint does not
+// depend on the mode of the text of the record.
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	record := testRecord("commit_1", "docs/a.md", "John Smith", 10)
+	fingerprint := Fingerprint(testKey, record)
+	assert.Len(t, fingerprint, 64)
+
+	// the unkeyed hash of the elements of the fingerprint does not match
+	elements := rrr.ObjectTypeFile + rrr.ResultSeparatorUID + "docs/a.md" + rrr.ResultSeparatorUID +
+		"Person" + rrr.ResultSeparatorUID + "John Smith"
+	unkeyed := sha1.Sum([]byte(elements))
+	assert.NotEqual(t, hex.EncodeToString(unkeyed[:]), fingerprint)
+	assert.NotEqual(t, fingerprint, Fingerprint([]byte("fedcba9876543210fedcba9876543210"), record))
+
+	// the HMAC of the text of a protected record matches its raw text
+	protected := record
+	protected.Text = ""
+	protected.TextHMAC = rrr.TextHMAC(testKey, record.Text)
+	assert.Equal(t, fingerprint, Fingerprint(testKey, protected))
+}
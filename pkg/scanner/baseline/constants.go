@@ -0,0 +1,11 @@
+package baseline
+
+import "os"
+
+// FileMode is the mode of the baseline file, which is meant to be committed
+// to the scanned repository (or to the repository of the CI pipeline).
+const FileMode os.FileMode = 0644
+
+// Version is the version of the format of the baseline file, where the
+// fingerprints of version 1 were not keyed.
+const Version int = 2
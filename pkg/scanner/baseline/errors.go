@@ -0,0 +1,16 @@
+package baseline
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgBaselineLoad = "failed to load baseline"
+	ErrMsgBaselineSave = "failed to save baseline"
+)
+
+var (
+	ErrBaselineKeyEmpty       = errors.New("baseline requires a key of the fingerprints of its findings")
+	ErrBaselinePathEmpty      = errors.New("baseline requires a path")
+	ErrBaselineVersion        = errors.New("baseline file has an unsupported version")
+	ErrSuppressionKeyEmpty    = errors.New("suppression requires a fingerprint or a hash")
+	ErrSuppressionReasonEmpty = errors.New("suppression requires a reason")
+)
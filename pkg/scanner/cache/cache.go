@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// Chunk struct contains the results detected in a single chunk of the
+// contents of a blob, which was sent to the detection service as a single
+// request.
+type Chunk struct {
+	// Length is the number of bytes in the text of the chunk.
+	Length int `json:"length"`
+	// Offset is the position of the text of the chunk within the blob.
+	Offset int `json:"offset"`
+	// Results contains the results detected in the text of the chunk.
+	Results []rrr.Result `json:"results"`
+}
+
+// Entry struct contains the results detected in every chunk of the contents
+// of a single blob.
+type Entry struct {
+	// ContentType is the content type of the document from which the text of
+	// the blob was extracted, which is empty if the contents of the blob were
+	// scanned as plain text.
+	ContentType string `json:"content_type,omitempty"`
+	// Chunks contains the results of each chunk of the blob that has any
+	// results, sorted by Offset.
+	Chunks []Chunk `json:"chunks"`
+	// Format is the format of the structured data of the blob, when the
+	// fields of the data were scanned instead of the contents of the blob.
+	Format string `json:"format,omitempty"`
+	// Timestamp is the time at which the blob was scanned.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// pendingEntry struct is used to collect the results of the requests for a
+// blob, until the responses to all of the requests have been added.
+type pendingEntry struct {
+	entry    Entry
+	failed   bool
+	requests map[string]bool
+}
+
+// blobCacheFile struct is the format of the file of a BlobCache.
+type blobCacheFile struct {
+	Blobs       map[string]Entry `json:"blobs"`
+	Fingerprint string           `json:"fingerprint"`
+}
+
+// BlobCache struct keeps the results detected in the contents of each blob
+// (i.e. file object identified by its hash) scanned by previous runs, such
+// that blobs that were already scanned with the same detector settings are
+// not sent to the detection service again. Safe for concurrent use.
+type BlobCache struct {
+	blobs       map[string]Entry
+	fingerprint string
+	keyring     *crypt.Keyring
+	mutex       *sync.Mutex
+	path        string
+	pending     map[string]*pendingEntry
+}
+
+// NewBlobCache() function returns a new BlobCache that is saved in the
+// provided directory, loading the blobs saved by previous runs. The saved
+// blobs are discarded when the fingerprint of the detector settings used
+// to scan them does not match the provided fingerprint. The file of the
+// BlobCache is encrypted with the (optional) keyring.
+func NewBlobCache(dir, fingerprint string, keyring *crypt.Keyring) (*BlobCache, error) {
+	if dir == "" {
+		return nil, ErrBlobCacheDirEmpty
+	}
+	if fingerprint == "" {
+		return nil, ErrBlobCacheFingerprintEmpty
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return nil, errors.Wrapf(err, "failed to create blob cache directory %s", dir)
+	}
+
+	bc := &BlobCache{
+		blobs:       make(map[string]Entry),
+		fingerprint: fingerprint,
+		keyring:     keyring,
+		mutex:       &sync.Mutex{},
+		path:        filepath.Join(dir, FileName),
+		pending:     make(map[string]*pendingEntry),
+	}
+
+	contents, err := os.ReadFile(bc.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bc, nil
+		}
+		return nil, errors.Wrap(err, ErrMsgBlobCacheLoad)
+	}
+	contents, err = crypt.Decode(keyring, contents)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgBlobCacheLoad)
+	}
+	saved := blobCacheFile{}
+	if err := json.Unmarshal(contents, &saved); err != nil {
+		return nil, errors.Wrap(err, ErrMsgBlobCacheLoad)
+	}
+	// keep the saved blobs only if they were scanned with the same settings
+	if saved.Fingerprint == fingerprint && saved.Blobs != nil {
+		bc.blobs = saved.Blobs
+	}
+
+	return bc, nil
+}
+
+// Add() method adds the results of the response to the pending entry of its
+// blob (i.e. Object.ID), which must have been created by Expect(). Once the
+// responses to all of the requests of the blob have been added, the blob is
+// added to the BlobCache, unless any of the requests failed.
+func (bc *BlobCache) Add(response rrr.Response) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	pending, ok := bc.pending[response.Object.ID]
+	if !ok {
+		return
+	}
+	if _, expected := pending.requests[response.ID]; !expected {
+		return
+	}
+	pending.requests[response.ID] = true
+	if response.Error != "" {
+		pending.failed = true
+	}
+	pending.entry.ContentType = response.Object.ContentType
+	pending.entry.Format = response.Object.Format
+	if len(response.Results) > 0 {
+		pending.entry.Chunks = append(pending.entry.Chunks, Chunk{
+			Length:  response.Object.Length,
+			Offset:  response.Object.Offset,
+			Results: response.Results,
+		})
+	}
+
+	for _, is_complete := range pending.requests {
+		if !is_complete {
+			return
+		}
+	}
+	delete(bc.pending, response.Object.ID)
+	if pending.failed {
+		return
+	}
+	sort.Slice(pending.entry.Chunks, func(i, j int) bool {
+		return pending.entry.Chunks[i].Offset < pending.entry.Chunks[j].Offset
+	})
+	pending.entry.Timestamp = rrr.TimestampNow()
+	bc.blobs[response.Object.ID] = pending.entry
+}
+
+// Expect() method creates a pending entry for the blob, which is added to
+// the BlobCache once the responses to all of the requests with the provided
+// IDs have been added with Add().
+func (bc *BlobCache) Expect(blob string, request_ids []string) {
+	if blob == "" || len(request_ids) == 0 {
+		return
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	pending := &pendingEntry{
+		entry:    Entry{Chunks: make([]Chunk, 0)},
+		requests: make(map[string]bool, len(request_ids)),
+	}
+	for _, request_id := range request_ids {
+		pending.requests[request_id] = false
+	}
+	bc.pending[blob] = pending
+}
+
+// Get() method returns the Entry of the blob, if the blob was scanned by
+// this or a previous run.
+func (bc *BlobCache) Get(blob string) (entry Entry, exists bool) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	entry, exists = bc.blobs[blob]
+	return
+}
+
+// Len() method returns the number of blobs in the BlobCache.
+func (bc *BlobCache) Len() int {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	return len(bc.blobs)
+}
+
+// Save() method writes the blobs of the BlobCache to its file, along with
+// the fingerprint of the detector settings, encrypted with the keyring of
+// the BlobCache (if any). The file is replaced atomically,
+// such that an interrupted save never corrupts the previous file.
+func (bc *BlobCache) Save() error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	contents, err := json.Marshal(blobCacheFile{
+		Blobs:       bc.blobs,
+		Fingerprint: bc.fingerprint,
+	})
+	if err != nil {
+		return errors.Wrap(err, ErrMsgBlobCacheSave)
+	}
+	contents, err = crypt.Encode(bc.keyring, contents)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgBlobCacheSave)
+	}
+
+	if err := fileio.WriteFileAtomic(bc.path, contents, FileMode); err != nil {
+		return errors.Wrap(err, ErrMsgBlobCacheSave)
+	}
+
+	return nil
+}
+
+// Reencrypt() function encrypts the file of the BlobCache saved in the
+// provided directory with the primary key of the keyring (see
+// crypt.ReencryptFile()), if the file exists, and returns true if the file
+// was rewritten.
+func Reencrypt(dir string, keyring *crypt.Keyring) (bool, error) {
+	path := filepath.Join(dir, FileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	changed, err := crypt.ReencryptFile(keyring, path)
+	if err != nil {
+		return false, errors.Wrap(err, ErrMsgBlobCacheReencrypt)
+	}
+
+	return changed, nil
+}
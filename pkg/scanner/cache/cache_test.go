@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testResponse() helper function returns a new rrr.Response for the request
+// with the provided ID of the chunk of the blob at the provided offset.
+func testResponse(blob, request_id string, offset int, err string, results ...rrr.Result) rrr.Response {
+	response := rrr.Response{Error: err, Results: results}
+	response.ID = request_id
+	response.Object.ID = blob
+	response.Object.Length = 10
+	response.Object.Offset = offset
+	return response
+}
+
+// TestNewBlobCache() unit test function tests the NewBlobCache() function.
+func TestNewBlobCache(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewBlobCache("", "test_fingerprint", nil)
+	assert.ErrorIs(t, err, ErrBlobCacheDirEmpty)
+	_, err = NewBlobCache(t.TempDir(), "", nil)
+	assert.ErrorIs(t, err, ErrBlobCacheFingerprintEmpty)
+
+	// a corrupt file should not be silently discarded
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("{"), FileMode))
+	_, err = NewBlobCache(dir, "test_fingerprint", nil)
+	assert.ErrorContains(t, err, ErrMsgBlobCacheLoad)
+
+	blob_cache, err := NewBlobCache(filepath.Join(t.TempDir(), "cache"), "test_fingerprint", nil)
+	assert.NoError(t, err)
+	if assert.NotNil(t, blob_cache) {
+		assert.Equal(t, 0, blob_cache.Len())
+	}
+}
+
+// TestBlobCache_Add() unit test function tests that blobs are only added to
+// the BlobCache once every expected request has a successful response.
+func TestBlobCache_Add(t *testing.T) {
+	t.Parallel()
+
+	blob_cache, err := NewBlobCache(t.TempDir(), "test_fingerprint", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	person := rrr.Result{Category: "Person", Text: "John Smith"}
+	email := rrr.Result{Category: "Email", Text: "john@example.com"}
+
+	// responses for blobs that are not expected are ignored
+	blob_cache.Add(testResponse("blob_unknown", "request_0", 0, ""))
+	_, exists := blob_cache.Get("blob_unknown")
+	assert.False(t, exists)
+
+	blob_cache.Expect("blob_1", []string{"request_1", "request_2", "request_3"})
+	blob_cache.Add(testResponse("blob_1", "request_2", 20, "", email))
+	blob_cache.Add(testResponse("blob_1", "request_other", 0, ""))
+	blob_cache.Add(testResponse("blob_1", "request_1", 0, "", person))
+	_, exists = blob_cache.Get("blob_1")
+	assert.False(t, exists)
+	blob_cache.Add(testResponse("blob_1", "request_3", 40, ""))
+	entry, exists := blob_cache.Get("blob_1")
+	if assert.True(t, exists) {
+		assert.Equal(t, []Chunk{
+			{Length: 10, Offset: 0, Results: []rrr.Result{person}},
+			{Length: 10, Offset: 20, Results: []rrr.Result{email}},
+		}, entry.Chunks)
+		assert.NotZero(t, entry.Timestamp)
+	}
+
+	// blobs with any failed request must be scanned again
+	blob_cache.Expect("blob_2", []string{"request_4", "request_5"})
+	blob_cache.Add(testResponse("blob_2", "request_4", 0, "status code 429"))
+	blob_cache.Add(testResponse("blob_2", "request_5", 10, "", person))
+	_, exists = blob_cache.Get("blob_2")
+	assert.False(t, exists)
+
+	// the content type of blobs scanned as extracted text is kept
+	blob_cache.Expect("blob_3", []string{"request_6"})
+	response := testResponse("blob_3", "request_6", 0, "", person)
+	response.Object.ContentType = "application/pdf"
+	blob_cache.Add(response)
+	entry, exists = blob_cache.Get("blob_3")
+	if assert.True(t, exists) {
+		assert.Equal(t, "application/pdf", entry.ContentType)
+	}
+
+	assert.Equal(t, 2, blob_cache.Len())
+}
+
+// TestBlobCache_Save() unit test function tests that saved blobs are loaded
+// by a new BlobCache, unless the fingerprint has changed.
+func TestBlobCache_Save(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	blob_cache, err := NewBlobCache(dir, "test_fingerprint", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	blob_cache.Expect("blob_1", []string{"request_1"})
+	blob_cache.Add(testResponse("blob_1", "request_1", 0, "", rrr.Result{Category: "Person", Text: "John Smith"}))
+	assert.NoError(t, blob_cache.Save())
+
+	loaded, err := NewBlobCache(dir, "test_fingerprint", nil)
+	if assert.NoError(t, err) {
+		entry, exists := loaded.Get("blob_1")
+		assert.True(t, exists)
+		saved, _ := blob_cache.Get("blob_1")
+		assert.Equal(t, saved, entry)
+	}
+
+	// changing the detector settings should invalidate the saved blobs
+	changed, err := NewBlobCache(dir, "other_fingerprint", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, changed.Len())
+	}
+}
+
+// TestBlobCache_Save_encrypted() unit test function tests that the file of a
+// BlobCache with a keyring is encrypted, and that it is re-encrypted with the
+// primary key of a rotated keyring by the Reencrypt() function.
+func TestBlobCache_Save_encrypted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	old_keyring, _ := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	blob_cache, err := NewBlobCache(dir, "test_fingerprint", old_keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	blob_cache.Expect("blob_1", []string{"request_1"})
+	blob_cache.Add(testResponse("blob_1", "request_1", 0, "", rrr.Result{Category: "Person", Text: "John Smith"}))
+	assert.NoError(t, blob_cache.Save())
+
+	contents, err := os.ReadFile(filepath.Join(dir, FileName))
+	if assert.NoError(t, err) {
+		assert.True(t, crypt.IsEnvelope(contents))
+		assert.NotContains(t, string(contents), "John Smith")
+	}
+	// the encrypted file cannot be loaded without the key
+	_, err = NewBlobCache(dir, "test_fingerprint", nil)
+	assert.ErrorIs(t, err, crypt.ErrKeyringRequired)
+
+	keyring, _ := crypt.NewKeyring([]crypt.Key{
+		{ID: "key-2", Secret: bytes.Repeat([]byte{2}, crypt.KeySize)},
+		{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)},
+	})
+	changed, err := Reencrypt(dir, keyring)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	_, err = NewBlobCache(dir, "test_fingerprint", old_keyring)
+	assert.ErrorIs(t, err, crypt.ErrKeyNotFound)
+	loaded, err := NewBlobCache(dir, "test_fingerprint", keyring)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, loaded.Len())
+	}
+
+	// a missing file is not re-encrypted
+	changed, err = Reencrypt(t.TempDir(), keyring)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
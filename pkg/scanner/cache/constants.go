@@ -0,0 +1,7 @@
+package cache
+
+import "os"
+
+const DirMode os.FileMode = 0700
+const FileMode os.FileMode = 0600
+const FileName string = "blobs.json"
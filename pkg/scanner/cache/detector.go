@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// CachedPhiDetector struct implements the rrr.RequestResponsePhiDetector
+// interface by answering every request with the results that were detected
+// in the same chunk of the same blob by a previous scan, as saved in a
+// BlobCache, such that the results of previous scans can be reused (e.g.
+// to redact files) without the detection service. Requests for blobs that
+// are not in the BlobCache are answered with an error response.
+type CachedPhiDetector struct {
+	blob_cache *BlobCache
+}
+
+// NewCachedPhiDetector() function returns a new CachedPhiDetector that reads
+// the results of previous scans from the provided BlobCache.
+func NewCachedPhiDetector(blob_cache *BlobCache) *CachedPhiDetector {
+	return &CachedPhiDetector{
+		blob_cache: blob_cache,
+	}
+}
+
+// Run() method listens for requests, translates each rrr.Request to a new
+// rrr.Response with the cached results of its chunk, and sends responses
+// using the provided channels.
+func (detector *CachedPhiDetector) Run(
+	ctx context.Context,
+	chan_requests_in <-chan rrr.Request,
+	chan_responses_out chan<- rrr.Response,
+) {
+	defer close(chan_responses_out)
+
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Msg("started cached detector")
+	defer logger.Info().Msg("finished cached detector")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Warn().Msg("stopping cached detector : context done")
+			// exit the function when the context is done
+			return
+		case request := <-chan_requests_in:
+			response := detector.respond(&request)
+			// send the Response to the output channel, unless the context
+			// is done while waiting for the Response to be received
+			select {
+			case chan_responses_out <- response:
+			case <-ctx.Done():
+				logger.Warn().Msg("stopping cached detector : context done")
+				return
+			}
+		}
+	}
+}
+
+// respond() method returns the response to the request, which contains the
+// cached results of the chunk of the blob with the same offset and length as
+// the request, or no results if the chunk had no results.
+func (detector *CachedPhiDetector) respond(request *rrr.Request) rrr.Response {
+	entry, cached := detector.blob_cache.Get(request.Object.ID)
+	if !cached {
+		return rrr.NewErrorResponse(request, ErrMsgBlobCacheMiss)
+	}
+
+	response := rrr.NewResponse(request)
+	for _, chunk := range entry.Chunks {
+		if chunk.Offset == request.Object.Offset && chunk.Length == request.Object.Length {
+			response.Results = append(response.Results, chunk.Results...)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestCachedPhiDetector_Run() unit test function tests that the
+// CachedPhiDetector answers each request with the cached results of its
+// chunk, and answers requests for blobs that are not cached with an error.
+func TestCachedPhiDetector_Run(t *testing.T) {
+	t.Parallel()
+
+	blob_cache, err := NewBlobCache(t.TempDir(), "test_fingerprint", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	person := rrr.Result{Category: "Person", Text: "John Smith"}
+	blob_cache.Expect("blob_1", []string{"request_1", "request_2"})
+	blob_cache.Add(testResponse("blob_1", "request_1", 0, "", person))
+	blob_cache.Add(testResponse("blob_1", "request_2", 20, ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chan_requests_in := make(chan rrr.Request)
+	chan_responses_out := make(chan rrr.Response)
+	go NewCachedPhiDetector(blob_cache).Run(ctx, chan_requests_in, chan_responses_out)
+
+	tests := []struct {
+		blob        string
+		expect_err  string
+		expect_len  int
+		name        string
+		offset      int
+		request_len int
+	}{
+		{blob: "blob_1", expect_len: 1, name: "Cached_Results", offset: 0, request_len: 10},
+		{blob: "blob_1", expect_len: 0, name: "Cached_No_Results", offset: 20, request_len: 10},
+		{blob: "blob_1", expect_len: 0, name: "Other_Chunk", offset: 0, request_len: 20},
+		{blob: "blob_2", expect_err: ErrMsgBlobCacheMiss, name: "Not_Cached"},
+	}
+	for _, test := range tests {
+		request := rrr.Request{}
+		request.ID = test.name
+		request.Object.ID = test.blob
+		request.Object.Length = test.request_len
+		request.Object.Offset = test.offset
+		chan_requests_in <- request
+
+		response := <-chan_responses_out
+		assert.Equal(t, test.name, response.ID, test.name)
+		assert.Equal(t, test.expect_err, response.Error, test.name)
+		if assert.Len(t, response.Results, test.expect_len, test.name) && test.expect_len > 0 {
+			assert.Equal(t, person, response.Results[0], test.name)
+		}
+	}
+
+	// the responses channel is closed once the context is done
+	cancel()
+	_, open := <-chan_responses_out
+	assert.False(t, open)
+}
@@ -0,0 +1,15 @@
+package cache
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgBlobCacheLoad      = "failed to load blob cache"
+	ErrMsgBlobCacheMiss      = "blob not found in blob cache"
+	ErrMsgBlobCacheReencrypt = "failed to re-encrypt blob cache"
+	ErrMsgBlobCacheSave      = "failed to save blob cache"
+)
+
+var (
+	ErrBlobCacheDirEmpty         = errors.New("blob cache requires a directory")
+	ErrBlobCacheFingerprintEmpty = errors.New("blob cache requires a fingerprint of the detector settings")
+)
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// fingerprintSettings struct contains every setting that can change the
+// results detected in the contents of a blob, such that a change to any of
+// the settings invalidates the results cached for every blob.
+type fingerprintSettings struct {
+	CategoryThresholds  map[string]float64 `json:"category_thresholds"`
+	ChunkOverlap        int                `json:"chunk_overlap"`
+	ConfidenceThreshold float64            `json:"confidence_threshold"`
+	Domain              string             `json:"domain"`
+	DryRun              bool               `json:"dry_run"`
+	ExtractText         bool               `json:"extract_text"`
+	IgnoreCategories    []string           `json:"ignore_categories"`
+	Language            string             `json:"language"`
+	LanguageDetection   string             `json:"language_detection"`
+	MaxRequestChunkSize int                `json:"max_request_chunk_size"`
+	ModelVersion        string             `json:"model_version"`
+	PiiCategories       []string           `json:"pii_categories"`
+	ResultTextKeyID     string             `json:"result_text_key_id"`
+	ResultTextMode      string             `json:"result_text_mode"`
+	Service             string             `json:"service"`
+	SourceCode          bool               `json:"source_code"`
+	StructuredData      bool               `json:"structured_data"`
+}
+
+// FingerprintFromConfig() function returns a fingerprint of the detector
+// settings of the Config, which is a SHA1 hash of the settings that can
+// change the results detected in the contents of a blob.
+func FingerprintFromConfig(c *cfg.Config) string {
+	settings := fingerprintSettings{
+		CategoryThresholds:  make(map[string]float64, len(c.AzureAI.CategoryThresholds)),
+		ChunkOverlap:        c.Git.Scan.Limits.ChunkOverlap,
+		ConfidenceThreshold: c.AzureAI.ConfidenceThreshold,
+		Domain:              c.AzureAI.Domain,
+		DryRun:              c.AzureAI.DryRun,
+		ExtractText:         c.Git.Scan.ExtractText,
+		IgnoreCategories:    normalizeList(c.AzureAI.IgnoreCategories),
+		Language:            c.AzureAI.Language,
+		LanguageDetection:   c.AzureAI.LanguageDetection,
+		MaxRequestChunkSize: c.Git.Scan.Limits.MaxRequestChunkSize,
+		ModelVersion:        c.AzureAI.ModelVersion,
+		PiiCategories:       normalizeList(c.AzureAI.PiiCategories),
+		ResultTextMode:      c.Git.Scan.ResultText.Mode,
+		Service:             strings.TrimRight(c.AzureAI.Service, "/"),
+		SourceCode:          c.Git.Scan.SourceCode,
+		StructuredData:      c.Git.Scan.StructuredData,
+	}
+	// the cached results of protected text are only valid for the same key,
+	// which is identified without being part of the fingerprint
+	if c.Git.Scan.ResultText.Mode == cfg.GitScanResultTextModeHMAC {
+		settings.ResultTextKeyID = rrr.TextHMACKeyID([]byte(c.Git.Scan.ResultText.Key))
+	}
+	for category, threshold := range c.AzureAI.CategoryThresholds {
+		settings.CategoryThresholds[strings.TrimSpace(category)] = threshold
+	}
+
+	// the keys of the map of category thresholds are sorted when encoded,
+	// so the encoded settings are the same for the same Config
+	encoded, _ := json.Marshal(settings)
+	sum := sha1.Sum(encoded)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeList() function returns a sorted copy of the list of categories,
+// such that the order of the categories in config does not change the
+// fingerprint.
+func normalizeList(list []string) []string {
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, strings.TrimSpace(item))
+	}
+	sort.Strings(out)
+
+	return out
+}
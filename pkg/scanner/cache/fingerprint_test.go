@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+)
+
+// TestFingerprintFromConfig() unit test function tests that the fingerprint
+// only changes when a detector setting changes.
+func TestFingerprintFromConfig(t *testing.T) {
+	t.Parallel()
+
+	newConfig := func() *cfg.Config {
+		c := cfg.NewDefaultConfig()
+		c.AzureAI.CategoryThresholds = map[string]float64{"Person": 0.8, "Email": 0.7}
+		c.AzureAI.ConfidenceThreshold = 0.6
+		c.AzureAI.IgnoreCategories = []string{"DateTime", "Organization"}
+		c.AzureAI.Service = "https://test.cognitiveservices.azure.com/"
+		return c
+	}
+	expected := FingerprintFromConfig(newConfig())
+	assert.Len(t, expected, 40)
+
+	tests := []struct {
+		changed bool
+		modify  func(c *cfg.Config)
+		name    string
+	}{
+		{
+			name:   "Unchanged",
+			modify: func(c *cfg.Config) {},
+		},
+		{
+			name:   "IgnoreCategoriesOrder",
+			modify: func(c *cfg.Config) { c.AzureAI.IgnoreCategories = []string{"Organization", "DateTime"} },
+		},
+		{
+			name:   "ServiceTrailingSlash",
+			modify: func(c *cfg.Config) { c.AzureAI.Service = "https://test.cognitiveservices.azure.com" },
+		},
+		{
+			name:   "ResultTextKeyUnused",
+			modify: func(c *cfg.Config) { c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef" },
+		},
+		{
+			name:   "AuthKey",
+			modify: func(c *cfg.Config) { c.AzureAI.AuthKey = "other-key" },
+		},
+		{
+			changed: true,
+			name:    "CategoryThresholds",
+			modify:  func(c *cfg.Config) { c.AzureAI.CategoryThresholds["Person"] = 0.9 },
+		},
+		{
+			changed: true,
+			name:    "ConfidenceThreshold",
+			modify:  func(c *cfg.Config) { c.AzureAI.ConfidenceThreshold = 0.5 },
+		},
+		{
+			changed: true,
+			name:    "ExtractText",
+			modify:  func(c *cfg.Config) { c.Git.Scan.ExtractText = true },
+		},
+		{
+			changed: true,
+			name:    "DryRun",
+			modify:  func(c *cfg.Config) { c.AzureAI.DryRun = true },
+		},
+		{
+			changed: true,
+			name:    "ChunkOverlap",
+			modify:  func(c *cfg.Config) { c.Git.Scan.Limits.ChunkOverlap = 100 },
+		},
+		{
+			changed: true,
+			name:    "MaxRequestChunkSize",
+			modify:  func(c *cfg.Config) { c.Git.Scan.Limits.MaxRequestChunkSize = 1000 },
+		},
+		{
+			changed: true,
+			name:    "ModelVersion",
+			modify:  func(c *cfg.Config) { c.AzureAI.ModelVersion = "2023-09-01" },
+		},
+		{
+			changed: true,
+			name:    "ResultTextMode",
+			modify: func(c *cfg.Config) {
+				c.Git.Scan.ResultText.Key = "0123456789abcdef0123456789abcdef"
+				c.Git.Scan.ResultText.Mode = cfg.GitScanResultTextModeHMAC
+			},
+		},
+		{
+			changed: true,
+			name:    "Service",
+			modify:  func(c *cfg.Config) { c.AzureAI.Service = "https://other.cognitiveservices.azure.com/" },
+		},
+		{
+			changed: true,
+			name:    "SourceCode",
+			modify:  func(c *cfg.Config) { c.Git.Scan.SourceCode = true },
+		},
+		{
+			changed: true,
+			name:    "StructuredData",
+			modify:  func(c *cfg.Config) { c.Git.Scan.StructuredData = true },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newConfig()
+			test.modify(c)
+			if test.changed {
+				assert.NotEqual(t, expected, FingerprintFromConfig(c))
+			} else {
+				assert.Equal(t, expected, FingerprintFromConfig(c))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/checkpoint"
+)
+
+// deleteCheckpoint() method deletes the checkpoint of the scan of the
+// repository with the provided ID, which is no longer needed once the scan
+// is complete.
+func (s *Scanner) deleteCheckpoint(repository_id string) {
+	if s.checkpoint_store == nil {
+		return
+	}
+	if err := s.checkpoint_store.Delete(repository_id, s.ID); err != nil {
+		s.logger.Error().Err(err).Msgf("repository %s : scan ID = %s", repository_id, s.ID)
+	}
+}
+
+// loadCheckpoint() method loads the checkpoint (if any) of a previous run
+// of the scan of the repository with the provided ID, then imports the
+// requests and results of the checkpoint that do not need to be scanned
+// again. The commits and files of the checkpoint that do not need to be
+// scanned again are kept, to be imported by resumeCheckpoint() once the
+// ScanRepository has been created.
+func (s *Scanner) loadCheckpoint(repository_id string) error {
+	if s.checkpoint_store == nil {
+		return nil
+	}
+
+	loaded, err := s.checkpoint_store.Load(repository_id, s.ID)
+	if err != nil {
+		if errors.Is(err, checkpoint.ErrCheckpointNotFound) {
+			s.logger.Info().Msgf("repository %s : starting new scan ID = %s", repository_id, s.ID)
+			return nil
+		}
+		return err
+	}
+
+	// results are identified by their hash, such that writing results that
+	// are detected again by the resumed scan does not create duplicates
+	if len(loaded.Results) > 0 {
+		if err := s.result_io.Write(loaded.Results); err != nil {
+			return errors.Wrap(err, ErrMsgResultWriteFailed)
+		}
+	}
+	commits, files, requests := loaded.Resumable()
+	s.resume_commits = commits
+	s.resume_files = files
+	imported := s.TrackerRequests.Import(requests)
+	s.logger.Info().Msgf(
+		"repository %s : resuming scan ID = %s : %d commits : %d files : %d requests : %d results",
+		repository_id,
+		s.ID,
+		len(commits),
+		len(files),
+		imported,
+		len(loaded.Results),
+	)
+
+	return nil
+}
+
+// resumeCheckpoint() method imports the commits and files of the loaded
+// checkpoint (if any) into the trackers of the ScanRepository, such that
+// they are skipped by the scan of the repository.
+func (s *Scanner) resumeCheckpoint(scan_repo *ScanRepository) {
+	if len(s.resume_commits) == 0 && len(s.resume_files) == 0 {
+		return
+	}
+	scan_repo.TrackerCommits.Import(s.resume_commits)
+	scan_repo.TrackerFiles.Import(s.resume_files)
+	s.resume_commits = nil
+	s.resume_files = nil
+}
+
+// saveCheckpoint() method saves a checkpoint of the current state of the
+// scan of the repository, including the results written for the repository.
+func (s *Scanner) saveCheckpoint(scan_repo *ScanRepository) {
+	if s.checkpoint_store == nil {
+		return
+	}
+
+	records, err := s.result_io.List()
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("repository %s : %s", scan_repo.ID, checkpoint.ErrMsgCheckpointSave)
+		return
+	}
+
+	out := checkpoint.NewCheckpoint(scan_repo.ID, s.ID)
+	out.Commits = scan_repo.TrackerCommits.GetKeysData()
+	out.Files = scan_repo.TrackerFiles.GetKeysData()
+	out.Requests = s.TrackerRequests.GetKeysData()
+	for _, record := range records {
+		if record.Repository.ID == scan_repo.ID {
+			out.Results = append(out.Results, record)
+		}
+	}
+
+	if err := s.checkpoint_store.Save(out); err != nil {
+		s.logger.Error().Err(err).Msgf("repository %s : scan ID = %s", scan_repo.ID, s.ID)
+		return
+	}
+	s.logger.Debug().Msgf("repository %s : saved checkpoint of scan ID = %s", scan_repo.ID, s.ID)
+}
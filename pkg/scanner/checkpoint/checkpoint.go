@@ -0,0 +1,98 @@
+package checkpoint
+
+import (
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// Checkpoint struct contains the state of the scan of a single repository,
+// which is saved periodically during the scan so that an interrupted scan
+// can be resumed without scanning (and paying for) the same objects again.
+type Checkpoint struct {
+	// Commits is the KeyData of each commit tracked by the scan.
+	Commits map[string]tracker.KeyData `json:"commits"`
+	// Files is the KeyData of each file tracked by the scan.
+	Files map[string]tracker.KeyData `json:"files"`
+	// Repository is the ID (i.e. URL) of the scanned repository.
+	Repository string `json:"repository"`
+	// Requests is the KeyData of each request tracked by the scan.
+	Requests map[string]tracker.KeyData `json:"requests"`
+	// Results contains the result records written by the scan for the
+	// repository.
+	Results []rrr.ResultRecord `json:"results"`
+	// ScanID is the ID of the scan, which must be reused to resume the scan.
+	ScanID string `json:"scan_id"`
+	// Timestamp is the time at which the Checkpoint was created.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewCheckpoint() function returns a new Checkpoint for the scan with the
+// provided ID of the repository with the provided ID.
+func NewCheckpoint(repository_id, scan_id string) *Checkpoint {
+	return &Checkpoint{
+		Commits:    make(map[string]tracker.KeyData),
+		Files:      make(map[string]tracker.KeyData),
+		Repository: repository_id,
+		Requests:   make(map[string]tracker.KeyData),
+		Results:    make([]rrr.ResultRecord, 0),
+		ScanID:     scan_id,
+		Timestamp:  rrr.TimestampNow(),
+	}
+}
+
+// Resumable() method returns the KeyData of the commits, files and requests
+// of the Checkpoint that do not need to be scanned again, which are:
+//   - requests that are complete without an error from the detection service;
+//   - files that are ignored, or that are complete with all of their child
+//     requests resumable;
+//   - commits that are complete with all of their child files resumable.
+func (c *Checkpoint) Resumable() (commits, files, requests map[string]tracker.KeyData) {
+	requests = make(map[string]tracker.KeyData)
+	for key, key_data := range c.Requests {
+		if key_data.Code == tracker.KeyCodeComplete && key_data.Message == "" {
+			requests[key] = key_data
+		}
+	}
+
+	files = make(map[string]tracker.KeyData)
+	for key, key_data := range c.Files {
+		switch key_data.Code {
+		case tracker.KeyCodeIgnore:
+			files[key] = key_data
+		case tracker.KeyCodeComplete:
+			if allChildrenIn(key_data, requests) {
+				files[key] = key_data
+			}
+		}
+	}
+
+	commits = make(map[string]tracker.KeyData)
+	for key, key_data := range c.Commits {
+		if key_data.Code == tracker.KeyCodeComplete && allChildrenIn(key_data, files) {
+			commits[key] = key_data
+		}
+	}
+
+	return
+}
+
+// Store interface defines the methods for saving and loading the Checkpoint
+// of a scan to/from some persistent store (e.g. files, database), where each
+// Checkpoint is identified by its repository and scan ID.
+type Store interface {
+	Delete(repository_id, scan_id string) error
+	Load(repository_id, scan_id string) (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+}
+
+// allChildrenIn() function returns true if every child key of the KeyData
+// is a key of the provided map.
+func allChildrenIn(key_data tracker.KeyData, keys map[string]tracker.KeyData) bool {
+	for child_key := range key_data.Children {
+		if _, ok := keys[child_key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,49 @@
+package checkpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestCheckpoint_Resumable() unit test function tests the Resumable() method
+// of the Checkpoint type.
+func TestCheckpoint_Resumable(t *testing.T) {
+	t.Parallel()
+
+	checkpoint := NewCheckpoint("test_repository", "test_scan")
+	checkpoint.Requests = map[string]tracker.KeyData{
+		"request_ok":      {Code: tracker.KeyCodeComplete},
+		"request_failed":  {Code: tracker.KeyCodeComplete, Message: "status code 429"},
+		"request_pending": {Code: tracker.KeyCodePending},
+	}
+	checkpoint.Files = map[string]tracker.KeyData{
+		"file_ok":      {Code: tracker.KeyCodeComplete, Children: map[string]bool{"request_ok": true}},
+		"file_failed":  {Code: tracker.KeyCodeComplete, Children: map[string]bool{"request_ok": true, "request_failed": true}},
+		"file_ignore":  {Code: tracker.KeyCodeIgnore},
+		"file_pending": {Code: tracker.KeyCodePending, Children: map[string]bool{"request_pending": false}},
+	}
+	checkpoint.Commits = map[string]tracker.KeyData{
+		"commit_ok":      {Code: tracker.KeyCodeComplete, Children: map[string]bool{"file_ok": true}},
+		"commit_empty":   {Code: tracker.KeyCodeComplete},
+		"commit_failed":  {Code: tracker.KeyCodeComplete, Children: map[string]bool{"file_ok": true, "file_failed": true}},
+		"commit_pending": {Code: tracker.KeyCodePending, Children: map[string]bool{"file_pending": false}},
+	}
+
+	commits, files, requests := checkpoint.Resumable()
+
+	assert.ElementsMatch(t, []string{"commit_empty", "commit_ok"}, keys(commits))
+	assert.ElementsMatch(t, []string{"file_ignore", "file_ok"}, keys(files))
+	assert.ElementsMatch(t, []string{"request_ok"}, keys(requests))
+}
+
+// keys() helper function returns the keys of the provided map.
+func keys(m map[string]tracker.KeyData) []string {
+	out := make([]string, 0, len(m))
+	for key := range m {
+		out = append(out, key)
+	}
+	return out
+}
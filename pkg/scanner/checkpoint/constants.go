@@ -0,0 +1,7 @@
+package checkpoint
+
+import "os"
+
+const FileExtension string = ".json"
+const FileMode os.FileMode = 0600
+const DirMode os.FileMode = 0700
@@ -0,0 +1,18 @@
+package checkpoint
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgCheckpointDelete    = "failed to delete checkpoint"
+	ErrMsgCheckpointLoad      = "failed to load checkpoint"
+	ErrMsgCheckpointReencrypt = "failed to re-encrypt checkpoints"
+	ErrMsgCheckpointSave      = "failed to save checkpoint"
+)
+
+var (
+	ErrCheckpointNil             = errors.New("checkpoint is nil")
+	ErrCheckpointNotFound        = errors.New("checkpoint not found")
+	ErrCheckpointRepositoryEmpty = errors.New("checkpoint repository is empty")
+	ErrCheckpointScanIDInvalid   = errors.New("checkpoint scan ID is invalid")
+	ErrFileStoreDirEmpty         = errors.New("checkpoint file store requires a directory")
+)
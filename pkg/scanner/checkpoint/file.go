@@ -0,0 +1,178 @@
+package checkpoint
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+)
+
+// FileStore struct provides an implementation of the Store interface that
+// saves each Checkpoint as a JSON file in a directory per repository, which
+// is encrypted when the FileStore has a keyring.
+type FileStore struct {
+	dir     string
+	keyring *crypt.Keyring
+	mutex   *sync.Mutex
+}
+
+// NewFileStore() function returns a new FileStore that saves checkpoints
+// in the provided directory, which is created if it does not exist. The
+// checkpoints are encrypted with the (optional) keyring.
+func NewFileStore(dir string, keyring *crypt.Keyring) (*FileStore, error) {
+	if dir == "" {
+		return nil, ErrFileStoreDirEmpty
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return nil, errors.Wrapf(err, "failed to create checkpoint directory %s", dir)
+	}
+
+	return &FileStore{
+		dir:     dir,
+		keyring: keyring,
+		mutex:   &sync.Mutex{},
+	}, nil
+}
+
+// Delete() method deletes the Checkpoint for the repository and scan ID, if
+// it exists.
+func (fs *FileStore) Delete(repository_id, scan_id string) error {
+	path, err := fs.path(repository_id, scan_id)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointDelete)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, ErrMsgCheckpointDelete)
+	}
+
+	return nil
+}
+
+// Load() method returns the Checkpoint for the repository and scan ID, or
+// an error wrapping ErrCheckpointNotFound if no Checkpoint has been saved.
+func (fs *FileStore) Load(repository_id, scan_id string) (*Checkpoint, error) {
+	path, err := fs.path(repository_id, scan_id)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgCheckpointLoad)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrap(ErrCheckpointNotFound, ErrMsgCheckpointLoad)
+		}
+		return nil, errors.Wrap(err, ErrMsgCheckpointLoad)
+	}
+
+	contents, err = crypt.Decode(fs.keyring, contents)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgCheckpointLoad)
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(contents, checkpoint); err != nil {
+		return nil, errors.Wrap(err, ErrMsgCheckpointLoad)
+	}
+
+	return checkpoint, nil
+}
+
+// Save() method writes the Checkpoint to its file, replacing any previous
+// Checkpoint for the same repository and scan ID. The file is replaced
+// atomically, such that an interrupted save never corrupts the previous
+// Checkpoint.
+func (fs *FileStore) Save(checkpoint *Checkpoint) error {
+	if checkpoint == nil {
+		return errors.Wrap(ErrCheckpointNil, ErrMsgCheckpointSave)
+	}
+	path, err := fs.path(checkpoint.Repository, checkpoint.ScanID)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointSave)
+	}
+
+	contents, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointSave)
+	}
+	contents, err = crypt.Encode(fs.keyring, contents)
+	if err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointSave)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), DirMode); err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointSave)
+	}
+	if err := fileio.WriteFileAtomic(path, contents, FileMode); err != nil {
+		return errors.Wrap(err, ErrMsgCheckpointSave)
+	}
+
+	return nil
+}
+
+// Reencrypt() method encrypts every Checkpoint file of the FileStore with the
+// primary key of its keyring (see crypt.ReencryptFile()), and returns the
+// number of files that were rewritten.
+func (fs *FileStore) Reencrypt() (int, error) {
+	if fs.keyring == nil {
+		return 0, errors.Wrap(crypt.ErrKeyringEmpty, ErrMsgCheckpointReencrypt)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	count := 0
+	walk_err := filepath.WalkDir(fs.dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != FileExtension {
+			return nil
+		}
+		changed, reencrypt_err := crypt.ReencryptFile(fs.keyring, path)
+		if reencrypt_err != nil {
+			return reencrypt_err
+		}
+		if changed {
+			count++
+		}
+		return nil
+	})
+	if walk_err != nil {
+		return count, errors.Wrap(walk_err, ErrMsgCheckpointReencrypt)
+	}
+
+	return count, nil
+}
+
+// path() method returns the path of the Checkpoint file for the repository
+// and scan ID, where the repository ID (i.e. URL) is hashed to produce a
+// safe directory name.
+func (fs *FileStore) path(repository_id, scan_id string) (string, error) {
+	if repository_id == "" {
+		return "", ErrCheckpointRepositoryEmpty
+	}
+	if scan_id == "" || scan_id == "." || scan_id == ".." || strings.ContainsAny(scan_id, `/\`) {
+		return "", errors.Wrapf(ErrCheckpointScanIDInvalid, "scan ID = %q", scan_id)
+	}
+	sum := sha1.Sum([]byte(repository_id))
+
+	return filepath.Join(fs.dir, hex.EncodeToString(sum[:]), scan_id+FileExtension), nil
+}
@@ -0,0 +1,211 @@
+package checkpoint
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestNewFileStore() unit test function tests the NewFileStore() function.
+func TestNewFileStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFileStore("", nil)
+	assert.ErrorIs(t, err, ErrFileStoreDirEmpty)
+
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	store, err := NewFileStore(dir, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, store)
+	info, stat_err := os.Stat(dir)
+	if assert.NoError(t, stat_err) {
+		assert.True(t, info.IsDir())
+	}
+}
+
+// TestFileStore() unit test function tests saving, loading and deleting
+// checkpoints with the FileStore type.
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewFileStore(t.TempDir(), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	repository_id := "https://github.com/test-org/test-repo.git"
+
+	// loading a checkpoint that was never saved should fail
+	_, err = store.Load(repository_id, "scan_1")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+
+	checkpoint := NewCheckpoint(repository_id, "scan_1")
+	checkpoint.Files["file_1"] = tracker.KeyData{
+		Children: map[string]bool{"request_1": true},
+		Code:     tracker.KeyCodeComplete,
+		State:    tracker.KeyStateComplete,
+	}
+	record := rrr.ResultRecord{Hash: "hash_1", Result: rrr.Result{Category: "Person", Text: "John Smith"}}
+	record.Repository.ID = repository_id
+	checkpoint.Results = append(checkpoint.Results, record)
+	assert.NoError(t, store.Save(checkpoint))
+
+	loaded, err := store.Load(repository_id, "scan_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, checkpoint, loaded)
+	}
+	// checkpoints of other scans of the same repository are separate
+	_, err = store.Load(repository_id, "scan_2")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+
+	// saving again should replace the checkpoint
+	checkpoint.Files["file_2"] = tracker.KeyData{Code: tracker.KeyCodeIgnore, State: tracker.KeyStateIgnore}
+	assert.NoError(t, store.Save(checkpoint))
+	loaded, err = store.Load(repository_id, "scan_1")
+	if assert.NoError(t, err) {
+		assert.Len(t, loaded.Files, 2)
+	}
+
+	assert.NoError(t, store.Delete(repository_id, "scan_1"))
+	_, err = store.Load(repository_id, "scan_1")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+	// deleting a missing checkpoint is not an error
+	assert.NoError(t, store.Delete(repository_id, "scan_1"))
+}
+
+// TestFileStore_path() unit test function tests that the FileStore rejects
+// invalid repository and scan IDs.
+func TestFileStore_path(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewFileStore(t.TempDir(), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		expected_err  error
+		name          string
+		repository_id string
+		scan_id       string
+	}{
+		{
+			name:          "Valid",
+			repository_id: "test_repository",
+			scan_id:       "2024-01-01_nightly",
+		},
+		{
+			expected_err: ErrCheckpointRepositoryEmpty,
+			name:         "EmptyRepository",
+			scan_id:      "scan_1",
+		},
+		{
+			expected_err:  ErrCheckpointScanIDInvalid,
+			name:          "EmptyScanID",
+			repository_id: "test_repository",
+		},
+		{
+			expected_err:  ErrCheckpointScanIDInvalid,
+			name:          "TraversalScanID",
+			repository_id: "test_repository",
+			scan_id:       "../scan_1",
+		},
+		{
+			expected_err:  ErrCheckpointScanIDInvalid,
+			name:          "DotDotScanID",
+			repository_id: "test_repository",
+			scan_id:       "..",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := store.path(test.repository_id, test.scan_id)
+			if test.expected_err != nil {
+				assert.ErrorIs(t, err, test.expected_err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, store.dir, filepath.Dir(filepath.Dir(path)))
+		})
+	}
+}
+
+// TestFileStore_encrypted() unit test function tests that the checkpoints of
+// a FileStore with a keyring are encrypted, and that they are re-encrypted
+// with the primary key of a rotated keyring by the Reencrypt() method.
+func TestFileStore_encrypted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repository_id := "https://github.com/test-org/test-repo.git"
+	record := rrr.ResultRecord{Hash: "hash_1", Result: rrr.Result{Category: "Person", Text: "John Smith"}}
+
+	// save a checkpoint before encryption is enabled
+	plain_store, err := NewFileStore(dir, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	plain_checkpoint := NewCheckpoint(repository_id, "scan_1")
+	plain_checkpoint.Results = append(plain_checkpoint.Results, record)
+	assert.NoError(t, plain_store.Save(plain_checkpoint))
+
+	old_keyring, _ := crypt.NewKeyring([]crypt.Key{{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)}})
+	store, err := NewFileStore(dir, old_keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	checkpoint := NewCheckpoint(repository_id, "scan_2")
+	checkpoint.Results = append(checkpoint.Results, record)
+	assert.NoError(t, store.Save(checkpoint))
+
+	path, _ := store.path(repository_id, "scan_2")
+	contents, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.True(t, crypt.IsEnvelope(contents))
+		assert.NotContains(t, string(contents), "John Smith")
+	}
+	loaded, err := store.Load(repository_id, "scan_2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, checkpoint, loaded)
+	}
+	// checkpoints saved before encryption was enabled can still be loaded
+	loaded, err = store.Load(repository_id, "scan_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, plain_checkpoint, loaded)
+	}
+	// encrypted checkpoints cannot be loaded without the key
+	_, err = plain_store.Load(repository_id, "scan_2")
+	assert.ErrorIs(t, err, crypt.ErrKeyringRequired)
+
+	_, err = plain_store.Reencrypt()
+	assert.ErrorIs(t, err, crypt.ErrKeyringEmpty)
+	keyring, _ := crypt.NewKeyring([]crypt.Key{
+		{ID: "key-2", Secret: bytes.Repeat([]byte{2}, crypt.KeySize)},
+		{ID: "key-1", Secret: bytes.Repeat([]byte{1}, crypt.KeySize)},
+	})
+	rotated_store, err := NewFileStore(dir, keyring)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	count, err := rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = rotated_store.Reencrypt()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// the old key can no longer load the re-encrypted checkpoints
+	_, err = store.Load(repository_id, "scan_1")
+	assert.ErrorIs(t, err, crypt.ErrKeyNotFound)
+	loaded, err = rotated_store.Load(repository_id, "scan_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, plain_checkpoint, loaded)
+	}
+}
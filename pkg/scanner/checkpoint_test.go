@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	gitmemory "github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/checkpoint"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestScanner_checkpoint() unit test function tests that the state of a scan
+// saved by the saveCheckpoint() method of one Scanner is resumed by another
+// Scanner with the same scan ID.
+func TestScanner_checkpoint(t *testing.T) {
+	t.Parallel()
+
+	config := test_valid_git_config_func()
+	config.Scan.Checkpoint.Dir = t.TempDir()
+	config.Scan.Checkpoint.ScanID = "test_scan"
+
+	repository, init_err := git.Init(gitmemory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	newScanRepository := func() *ScanRepository {
+		scan_repo, err := NewScanRepository(NewScanRepositoryInput{
+			ChannelErrors:   make(chan<- error),
+			ChannelRequests: make(chan<- rrr.Request),
+			Config:          test_repo_scan_config,
+			Context:         test_context,
+			Repository:      repository,
+			URL:             test_repo_url,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return scan_repo
+	}
+
+	// run the first (interrupted) scan
+	scanner, err := NewScanner(test_context, config, memory.NewMemoryResultRecordIO(test_context))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "test_scan", scanner.ID)
+	assert.NoError(t, scanner.loadCheckpoint(test_repo_url))
+	scan_repo := newScanRepository()
+	_, err = scanner.TrackerRequests.Update("request_1", tracker.KeyCodeComplete, "", nil)
+	assert.NoError(t, err)
+	_, err = scanner.TrackerRequests.Update("request_2", tracker.KeyCodePending, "", nil)
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerFiles.Update("file_1", tracker.KeyCodeComplete, "", []string{"request_1"})
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerFiles.Update("file_2", tracker.KeyCodePending, "", []string{"request_2"})
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerCommits.Update("commit_1", tracker.KeyCodeComplete, "", []string{"file_1"})
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerCommits.Update("commit_2", tracker.KeyCodePending, "", []string{"file_1", "file_2"})
+	assert.NoError(t, err)
+	record := rrr.ResultRecord{Result: rrr.Result{Category: "Person", Text: "John Smith"}}
+	record.Repository.ID = test_repo_url
+	record.Hash = record.Result.Hash(test_repo_url, "commit_1", "file_1")
+	assert.NoError(t, scanner.result_io.Write([]rrr.ResultRecord{record}))
+	scanner.saveCheckpoint(scan_repo)
+
+	// resume the scan with a new Scanner
+	resumed, err := NewScanner(test_context, config, memory.NewMemoryResultRecordIO(test_context))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, resumed.loadCheckpoint(test_repo_url))
+	resumed_repo := newScanRepository()
+	resumed.resumeCheckpoint(resumed_repo)
+
+	assert.ElementsMatch(t, []string{"commit_1"}, resumed_repo.TrackerCommits.GetKeys())
+	assert.ElementsMatch(t, []string{"file_1"}, resumed_repo.TrackerFiles.GetKeys())
+	assert.ElementsMatch(t, []string{"request_1"}, resumed.TrackerRequests.GetKeys())
+	records, err := resumed.result_io.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []rrr.ResultRecord{record}, records)
+	// commits and files of the checkpoint should be skipped by the scan
+	code, err := resumed_repo.TrackerCommits.Update("commit_1", tracker.KeyCodeInit, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tracker.KeyCodeComplete, code)
+
+	// the checkpoint should be deleted once the scan is complete
+	resumed.deleteCheckpoint(test_repo_url)
+	_, err = resumed.checkpoint_store.Load(test_repo_url, "test_scan")
+	assert.ErrorIs(t, err, checkpoint.ErrCheckpointNotFound)
+}
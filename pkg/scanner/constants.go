@@ -1,15 +1,48 @@
 package scanner
 
-import "time"
+import (
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
 
 const IgnoreReasonDefault string = "ignored_by_default"
 const IgnoreReasonDirPath string = "directory_path"
 const IgnoreReasonFileExtensionIgnoredByConfig string = "file_extension_ignored_by_config"
 const IgnoreReasonFileExtensionIgnoredByPolicy string = "file_extension_ignored_by_policy"
 const IgnoreReasonFileExtensionNotIncluded string = "file_extension_not_included"
+const IgnoreReasonArchiveTooDeep string = "archive_exceeds_max_depth"
+const IgnoreReasonFileHasNoExtractedText string = "file_has_no_extracted_text"
+const IgnoreReasonFileHasNoSourceText string = "file_has_no_source_text"
 const IgnoreReasonFileIsBinary string = "file_is_binary"
 const IgnoreReasonFileIsEmpty string = "file_is_empty"
 const IgnoreReasonFileName string = "file_name"
 const IgnoreReasonFilePath string = "file_path"
+const IgnoreReasonFilePathExcludedByConfig string = "file_path_excluded_by_config"
+const IgnoreReasonFilePathIgnoredByGitignore string = "file_path_ignored_by_gitignore"
+
+// GitignoreFileName is the name of the files in a repository that contain
+// patterns of file paths ignored by git, which are ignored by the scan when
+// the scan is configured to respect them.
+const GitignoreFileName string = ".gitignore"
+
+// Labels of the fields of the metadata of commits, tags and notes, which are
+// scanned along with each value (e.g. "message: ...").
+const (
+	MetadataLabelAuthor    string = "author"
+	MetadataLabelCommitter string = "committer"
+	MetadataLabelMessage   string = "message"
+	MetadataLabelNote      string = "note"
+	MetadataLabelTagger    string = "tagger"
+)
+
+// NotesRef is the ref of the git notes of commits that are scanned when the
+// scan is configured to scan notes.
+const NotesRef plumbing.ReferenceName = "refs/notes/commits"
+
+// ScanScopeRemote is the name of the remote used to resolve the refs of the
+// scan scope that are not checked out, which is the default remote of a
+// cloned repository.
+const ScanScopeRemote string = "origin"
 
 const ScanRefreshInterval time.Duration = time.Second * 5
@@ -0,0 +1,28 @@
+package crypt
+
+import "os"
+
+// EnvelopePrefix is the prefix of every envelope, which identifies the data
+// that is encrypted (see Keyring.Seal()) along with the version of the
+// format of the envelope.
+const EnvelopePrefix string = "nophi-enc:v1:"
+
+// EnvelopeSeparator separates the parts of an envelope, which are the ID of
+// the key that wraps the data key, the wrapped data key and the encrypted
+// data (each with its nonce).
+const EnvelopeSeparator string = ":"
+
+// KeyEntrySeparator separates the entries of a list of keys, KeyIDSeparator
+// separates the ID of a key from the (base64-encoded) key of each entry and
+// KeyCommentPrefix is the prefix of the comment lines of a key file.
+const (
+	KeyCommentPrefix  string = "#"
+	KeyEntrySeparator string = ","
+	KeyIDSeparator    string = ":"
+)
+
+// KeySize is the size (in bytes) of every key, which selects AES-256.
+const KeySize int = 32
+
+// FileMode is the mode of the files that are re-encrypted.
+const FileMode os.FileMode = 0600
@@ -0,0 +1,182 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IsEnvelope() function returns true if the provided data is an envelope,
+// as opposed to data that was stored without encryption.
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(EnvelopePrefix))
+}
+
+// EnvelopeKeyID() function returns the ID of the key that sealed the
+// provided envelope, or false if the data is not an envelope.
+func EnvelopeKeyID(data []byte) (string, bool) {
+	if !IsEnvelope(data) {
+		return "", false
+	}
+	key_id, _, found := strings.Cut(string(data[len(EnvelopePrefix):]), EnvelopeSeparator)
+
+	return key_id, found
+}
+
+// Seal() method encrypts the provided plaintext with a new random data key,
+// which is in turn encrypted (i.e. wrapped) by the primary key, and returns
+// the envelope that contains the ID of the primary key, the wrapped data
+// key and the ciphertext in the format:
+//
+//	nophi-enc:v1:<key ID>:<base64 wrapped data key>:<base64 ciphertext>
+//
+// where both AES-GCM ciphertexts are prefixed by their nonce. The envelope
+// is plain text, such that it can also replace the value of a string field.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	data_key := make([]byte, KeySize)
+	if _, err := rand.Read(data_key); err != nil {
+		return nil, errors.Wrap(err, ErrMsgEnvelopeSeal)
+	}
+
+	header := EnvelopePrefix + k.primary + EnvelopeSeparator
+	wrapped_key, err := sealAESGCM(k.keys[k.primary], data_key, []byte(header))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgEnvelopeSeal)
+	}
+	header += base64.StdEncoding.EncodeToString(wrapped_key) + EnvelopeSeparator
+	ciphertext, err := sealAESGCM(data_key, plaintext, []byte(header))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgEnvelopeSeal)
+	}
+
+	return []byte(header + base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+// Open() method decrypts the provided envelope with the key that sealed it,
+// returning an error if the key is not in the Keyring or if the envelope was
+// modified.
+func (k *Keyring) Open(envelope []byte) ([]byte, error) {
+	if !IsEnvelope(envelope) {
+		return nil, errors.Wrap(ErrEnvelopeInvalid, ErrMsgEnvelopeOpen)
+	}
+	parts := strings.Split(string(envelope[len(EnvelopePrefix):]), EnvelopeSeparator)
+	if len(parts) != 3 {
+		return nil, errors.Wrap(ErrEnvelopeInvalid, ErrMsgEnvelopeOpen)
+	}
+	key, exists := k.keys[parts[0]]
+	if !exists {
+		return nil, errors.Wrapf(ErrKeyNotFound, "%s : key ID = %s", ErrMsgEnvelopeOpen, parts[0])
+	}
+	wrapped_key, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(ErrEnvelopeInvalid, ErrMsgEnvelopeOpen)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(ErrEnvelopeInvalid, ErrMsgEnvelopeOpen)
+	}
+
+	header := EnvelopePrefix + parts[0] + EnvelopeSeparator
+	data_key, err := openAESGCM(key, wrapped_key, []byte(header))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgEnvelopeOpen)
+	}
+	header += parts[1] + EnvelopeSeparator
+	plaintext, err := openAESGCM(data_key, ciphertext, []byte(header))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgEnvelopeOpen)
+	}
+
+	return plaintext, nil
+}
+
+// Reseal() method returns the provided data sealed with the primary key,
+// opening the data first if it is an envelope, along with true if the data
+// changed. Envelopes that were already sealed with the primary key are
+// returned unchanged.
+func (k *Keyring) Reseal(data []byte) ([]byte, bool, error) {
+	if key_id, ok := EnvelopeKeyID(data); ok {
+		if key_id == k.primary {
+			return data, false, nil
+		}
+		plaintext, err := k.Open(data)
+		if err != nil {
+			return nil, false, err
+		}
+		data = plaintext
+	}
+	envelope, err := k.Seal(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return envelope, true, nil
+}
+
+// Decode() function returns the plaintext of the provided data, which is
+// opened with the (optional) keyring if it is an envelope, or else returned
+// as it is, such that data stored before encryption was enabled can still be
+// read (until it is re-encrypted).
+func Decode(keyring *Keyring, data []byte) ([]byte, error) {
+	if !IsEnvelope(data) {
+		return data, nil
+	}
+	if keyring == nil {
+		return nil, errors.Wrap(ErrKeyringRequired, ErrMsgEnvelopeOpen)
+	}
+
+	return keyring.Open(data)
+}
+
+// Encode() function returns the provided plaintext sealed with the
+// (optional) keyring, or else the plaintext if encryption is disabled.
+func Encode(keyring *Keyring, plaintext []byte) ([]byte, error) {
+	if keyring == nil {
+		return plaintext, nil
+	}
+
+	return keyring.Seal(plaintext)
+}
+
+// sealAESGCM() function encrypts the plaintext with AES-GCM and returns the
+// ciphertext prefixed by its random nonce.
+func sealAESGCM(key, plaintext, additional_data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additional_data), nil
+}
+
+// openAESGCM() function decrypts the nonce-prefixed ciphertext with AES-GCM.
+func openAESGCM(key, ciphertext, additional_data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrEnvelopeInvalid
+	}
+
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], additional_data)
+}
+
+// newGCM() function returns the AES-GCM cipher of the provided key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
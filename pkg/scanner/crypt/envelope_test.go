@@ -0,0 +1,140 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey() helper function returns a Key with the provided ID, where every
+// byte of the key is the provided value.
+func testKey(id string, value byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{value}, KeySize)}
+}
+
+// testKeyring() helper function returns a new Keyring with the provided keys.
+func testKeyring(t *testing.T, keys ...Key) *Keyring {
+	keyring, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+// TestKeyring_Seal() unit test function tests sealing and opening envelopes
+// with the Seal() and Open() methods of the Keyring type.
+func TestKeyring_Seal(t *testing.T) {
+	t.Parallel()
+
+	keyring := testKeyring(t, testKey("key-1", 1))
+	plaintext := []byte(`{"text":"John Smith"}`)
+
+	envelope, err := keyring.Seal(plaintext)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, IsEnvelope(envelope))
+	assert.NotContains(t, string(envelope), "John Smith")
+	key_id, ok := EnvelopeKeyID(envelope)
+	assert.True(t, ok)
+	assert.Equal(t, "key-1", key_id)
+
+	// every envelope uses a new data key and nonce
+	other, err := keyring.Seal(plaintext)
+	assert.NoError(t, err)
+	assert.NotEqual(t, envelope, other)
+
+	opened, err := keyring.Open(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	// modified envelopes cannot be opened
+	separator := strings.LastIndex(string(envelope), EnvelopeSeparator) + 1
+	ciphertext, _ := base64.StdEncoding.DecodeString(string(envelope[separator:]))
+	ciphertext[len(ciphertext)-1] ^= 1
+	tampered := string(envelope[:separator]) + base64.StdEncoding.EncodeToString(ciphertext)
+	_, err = keyring.Open([]byte(tampered))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrEnvelopeInvalid)
+	_, err = keyring.Open(bytes.Replace(envelope, []byte("key-1"), []byte("key-2"), 1))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = keyring.Open(plaintext)
+	assert.ErrorIs(t, err, ErrEnvelopeInvalid)
+
+	// envelopes cannot be opened with a different key of the same ID
+	_, err = testKeyring(t, testKey("key-1", 2)).Open(envelope)
+	assert.Error(t, err)
+}
+
+// TestKeyring_Reseal() unit test function tests rotating the primary key of
+// a Keyring with the Reseal() method.
+func TestKeyring_Reseal(t *testing.T) {
+	t.Parallel()
+
+	old_keyring := testKeyring(t, testKey("key-1", 1))
+	envelope, err := old_keyring.Seal([]byte("John Smith"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the rotated keyring can open envelopes sealed with the old key
+	keyring := testKeyring(t, testKey("key-2", 2), testKey("key-1", 1))
+	opened, err := keyring.Open(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith", string(opened))
+
+	resealed, changed, err := keyring.Reseal(envelope)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	key_id, _ := EnvelopeKeyID(resealed)
+	assert.Equal(t, "key-2", key_id)
+
+	// the old key is no longer required once resealed
+	opened, err = testKeyring(t, testKey("key-2", 2)).Open(resealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith", string(opened))
+
+	// envelopes of the primary key are unchanged
+	unchanged, changed, err := keyring.Reseal(resealed)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, resealed, unchanged)
+
+	// plaintext is sealed
+	sealed, changed, err := keyring.Reseal([]byte("Jane Doe"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsEnvelope(sealed))
+}
+
+// TestDecode() unit test function tests the Encode() and Decode() functions
+// with and without a keyring.
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	keyring := testKeyring(t, testKey("key-1", 1))
+	plaintext := []byte("John Smith")
+
+	// encryption is disabled without a keyring
+	encoded, err := Encode(nil, plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, encoded)
+
+	encoded, err = Encode(keyring, plaintext)
+	assert.NoError(t, err)
+	assert.True(t, IsEnvelope(encoded))
+	decoded, err := Decode(keyring, encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decoded)
+
+	// plaintext stored before encryption was enabled is still readable
+	decoded, err = Decode(keyring, plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decoded)
+
+	_, err = Decode(nil, encoded)
+	assert.ErrorIs(t, err, ErrKeyringRequired)
+}
@@ -0,0 +1,20 @@
+package crypt
+
+import "github.com/pkg/errors"
+
+const (
+	ErrMsgEnvelopeOpen = "failed to open envelope"
+	ErrMsgEnvelopeSeal = "failed to seal envelope"
+	ErrMsgKeyringLoad  = "failed to load encryption keys"
+	ErrMsgReencrypt    = "failed to re-encrypt file"
+)
+
+var (
+	ErrEnvelopeInvalid = errors.New("envelope is invalid")
+	ErrKeyDuplicate    = errors.New("encryption key ID is duplicated")
+	ErrKeyIDInvalid    = errors.New("encryption key ID is invalid")
+	ErrKeyInvalid      = errors.New("encryption key must be 32 base64-encoded bytes")
+	ErrKeyNotFound     = errors.New("encryption key not found")
+	ErrKeyringEmpty    = errors.New("encryption keyring has no keys")
+	ErrKeyringRequired = errors.New("encryption keys are required to open encrypted data")
+)
@@ -0,0 +1,36 @@
+package crypt
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/fileio"
+)
+
+// ReencryptFile() function seals the contents of the file at the provided
+// path with the primary key of the keyring (see Keyring.Reseal()), returning
+// true if the file was rewritten. The file is replaced atomically, such that
+// an interrupted re-encryption never corrupts the file.
+func ReencryptFile(keyring *Keyring, path string) (bool, error) {
+	if keyring == nil {
+		return false, errors.Wrap(ErrKeyringEmpty, ErrMsgReencrypt)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return false, errors.Wrap(err, ErrMsgReencrypt)
+	}
+	envelope, changed, err := keyring.Reseal(contents)
+	if err != nil {
+		return false, errors.Wrapf(err, "%s %s", ErrMsgReencrypt, path)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if err := fileio.WriteFileAtomic(path, envelope, FileMode); err != nil {
+		return false, errors.Wrap(err, ErrMsgReencrypt)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,111 @@
+package crypt
+
+import (
+	"encoding/base64"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// keyIDPattern matches the valid IDs of keys, which must not contain the
+// EnvelopeSeparator.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Key struct contains a key of a Keyring along with its ID, which is stored
+// in every envelope sealed with the key.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring struct contains the keys used to seal and open envelopes, where
+// the primary key is used to seal new envelopes and every key can be used to
+// open the envelopes sealed by it, which allows keys to be rotated.
+type Keyring struct {
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyring() function returns a new Keyring with the provided keys, where
+// the first key is the primary key.
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrKeyringEmpty
+	}
+	keyring := &Keyring{
+		keys:    make(map[string][]byte, len(keys)),
+		primary: keys[0].ID,
+	}
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, errors.Wrapf(ErrKeyIDInvalid, "key ID = %q", key.ID)
+		}
+		if len(key.Secret) != KeySize {
+			return nil, errors.Wrapf(ErrKeyInvalid, "key ID = %s", key.ID)
+		}
+		if _, exists := keyring.keys[key.ID]; exists {
+			return nil, errors.Wrapf(ErrKeyDuplicate, "key ID = %s", key.ID)
+		}
+		keyring.keys[key.ID] = key.Secret
+	}
+
+	return keyring, nil
+}
+
+// LoadKeyring() function returns the Keyring with the keys of the provided
+// key file, or else with the provided (comma-separated) keys, or nil if
+// neither the key file nor the keys are set, in which case encryption is
+// disabled.
+func LoadKeyring(key_file, keys string) (*Keyring, error) {
+	entries := strings.Split(keys, KeyEntrySeparator)
+	if key_file != "" {
+		contents, err := os.ReadFile(key_file)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrMsgKeyringLoad)
+		}
+		entries = strings.Split(string(contents), "\n")
+	} else if keys == "" {
+		return nil, nil
+	}
+
+	parsed, err := ParseKeys(entries)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgKeyringLoad)
+	}
+	keyring, err := NewKeyring(parsed)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgKeyringLoad)
+	}
+
+	return keyring, nil
+}
+
+// ParseKeys() function parses each "<id>:<base64 key>" entry of the provided
+// list, ignoring empty entries and comments.
+func ParseKeys(entries []string) ([]Key, error) {
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, KeyCommentPrefix) {
+			continue
+		}
+		id, encoded, found := strings.Cut(entry, KeyIDSeparator)
+		if !found {
+			return nil, errors.Wrapf(ErrKeyIDInvalid, "entry must have the format <id>%s<base64 key>", KeyIDSeparator)
+		}
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, errors.Wrapf(ErrKeyInvalid, "key ID = %s", id)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Secret: secret})
+	}
+
+	return keys, nil
+}
+
+// Primary() method returns the ID of the primary key of the Keyring.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
@@ -0,0 +1,99 @@
+package crypt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadKeyring() unit test function tests loading a Keyring from a key
+// file or a list of keys with the LoadKeyring() function.
+func TestLoadKeyring(t *testing.T) {
+	t.Parallel()
+
+	const key_1 = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	const key_2 = "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8="
+	dir := t.TempDir()
+	key_file := filepath.Join(dir, "keys")
+	if err := os.WriteFile(key_file, []byte("# primary key first\nkey-2:"+key_2+"\n\nkey-1:"+key_1+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expected_err     error
+		expected_primary string
+		key_file         string
+		keys             string
+		name             string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			expected_primary: "key-2",
+			key_file:         key_file,
+			name:             "KeyFile",
+		},
+		{
+			expected_primary: "key-1",
+			keys:             "key-1:" + key_1 + ", key-2:" + key_2,
+			name:             "Keys",
+		},
+		{
+			expected_err: ErrKeyringEmpty,
+			keys:         ",",
+			name:         "Keys_Empty",
+		},
+		{
+			expected_err: ErrKeyDuplicate,
+			keys:         "key-1:" + key_1 + ",key-1:" + key_2,
+			name:         "Keys_Duplicate",
+		},
+		{
+			expected_err: ErrKeyIDInvalid,
+			keys:         key_1,
+			name:         "Keys_Missing_ID",
+		},
+		{
+			expected_err: ErrKeyIDInvalid,
+			keys:         "key 1:" + key_1,
+			name:         "Keys_Invalid_ID",
+		},
+		{
+			expected_err: ErrKeyInvalid,
+			keys:         "key-1:c2VjcmV0",
+			name:         "Keys_Short",
+		},
+		{
+			expected_err: ErrKeyInvalid,
+			keys:         "key-1:not base64",
+			name:         "Keys_Not_Base64",
+		},
+		{
+			expected_err: os.ErrNotExist,
+			key_file:     filepath.Join(dir, "missing"),
+			name:         "KeyFile_Missing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyring, err := LoadKeyring(test.key_file, test.keys)
+			if test.expected_err != nil {
+				assert.ErrorIs(t, err, test.expected_err)
+				return
+			}
+			assert.NoError(t, err)
+			if test.expected_primary == "" {
+				assert.Nil(t, keyring)
+				return
+			}
+			if assert.NotNil(t, keyring) {
+				assert.Equal(t, test.expected_primary, keyring.Primary())
+				assert.Len(t, keyring.keys, 2)
+			}
+		})
+	}
+}
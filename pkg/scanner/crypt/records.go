@@ -0,0 +1,95 @@
+package crypt
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// EncryptedResultRecordIO struct provides an implementation of the
+// ResultRecordIO interface that wraps another ResultRecordIO (i.e. store),
+// where the text of every result record is sealed in an envelope before it
+// is written to the store and opened after it is read from the store. The
+// encryption is transparent to the callers, which only ever see the text of
+// the result records in plaintext.
+type EncryptedResultRecordIO struct {
+	keyring *Keyring
+	store   rrr.ResultRecordIO
+}
+
+// NewEncryptedResultRecordIO() function returns a new EncryptedResultRecordIO
+// that encrypts the result records of the provided store with the provided
+// keyring.
+func NewEncryptedResultRecordIO(store rrr.ResultRecordIO, keyring *Keyring) (*EncryptedResultRecordIO, error) {
+	if keyring == nil {
+		return nil, ErrKeyringEmpty
+	}
+
+	return &EncryptedResultRecordIO{
+		keyring: keyring,
+		store:   store,
+	}, nil
+}
+
+// Delete() method deletes the result record with matching id from the store.
+func (io *EncryptedResultRecordIO) Delete(id string) error {
+	return io.store.Delete(id)
+}
+
+// List() method returns every result record of the store, with the text of
+// each result record opened.
+func (io *EncryptedResultRecordIO) List() ([]rrr.ResultRecord, error) {
+	records, err := io.store.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]rrr.ResultRecord, 0, len(records))
+	for _, record := range records {
+		opened, open_err := io.open(record)
+		if open_err != nil {
+			return nil, open_err
+		}
+		out = append(out, opened)
+	}
+
+	return out, nil
+}
+
+// Read() method returns the result record with matching id from the store,
+// with the text of the result record opened.
+func (io *EncryptedResultRecordIO) Read(id string) (rrr.ResultRecord, error) {
+	record, err := io.store.Read(id)
+	if err != nil {
+		return rrr.ResultRecord{}, err
+	}
+
+	return io.open(record)
+}
+
+// Write() method writes the provided result records to the store, with the
+// text of each result record sealed. The provided result records are not
+// modified.
+func (io *EncryptedResultRecordIO) Write(records []rrr.ResultRecord) error {
+	sealed := make([]rrr.ResultRecord, 0, len(records))
+	for _, record := range records {
+		text, err := io.keyring.Seal([]byte(record.Text))
+		if err != nil {
+			return errors.Wrapf(err, "result record %s", record.Hash)
+		}
+		record.Text = string(text)
+		sealed = append(sealed, record)
+	}
+
+	return io.store.Write(sealed)
+}
+
+// open() method returns the provided result record with its text opened.
+func (io *EncryptedResultRecordIO) open(record rrr.ResultRecord) (rrr.ResultRecord, error) {
+	text, err := Decode(io.keyring, []byte(record.Text))
+	if err != nil {
+		return rrr.ResultRecord{}, errors.Wrapf(err, "result record %s", record.Hash)
+	}
+	record.Text = string(text)
+
+	return record, nil
+}
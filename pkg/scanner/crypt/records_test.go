@@ -0,0 +1,72 @@
+package crypt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestEncryptedResultRecordIO() unit test function tests that the text of the
+// result records is encrypted in the wrapped store, and that the encryption
+// is transparent to the callers of the EncryptedResultRecordIO.
+func TestEncryptedResultRecordIO(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewEncryptedResultRecordIO(memory.NewMemoryResultRecordIO(context.Background()), nil)
+	assert.ErrorIs(t, err, ErrKeyringEmpty)
+
+	store := memory.NewMemoryResultRecordIO(context.Background())
+	io, err := NewEncryptedResultRecordIO(store, testKeyring(t, testKey("key-1", 1)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	records := []rrr.ResultRecord{
+		{Hash: "hash_1", Result: rrr.Result{Category: "Person", Text: "John Smith"}},
+		{Hash: "hash_2", Result: rrr.Result{Category: "Email", Text: "john@example.com"}},
+	}
+	assert.NoError(t, io.Write(records))
+	// the provided result records are not modified
+	assert.Equal(t, "John Smith", records[0].Text)
+
+	stored, err := store.Read("hash_1")
+	if assert.NoError(t, err) {
+		assert.True(t, IsEnvelope([]byte(stored.Text)))
+		assert.Equal(t, "Person", stored.Category)
+	}
+
+	read, err := io.Read("hash_1")
+	assert.NoError(t, err)
+	assert.Equal(t, records[0], read)
+	listed, err := io.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, records, listed)
+
+	// the result records sealed with a rotated key are still opened, while
+	// new result records are sealed with the primary key
+	rotated, err := NewEncryptedResultRecordIO(store, testKeyring(t, testKey("key-2", 2), testKey("key-1", 1)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	read, err = rotated.Read("hash_2")
+	assert.NoError(t, err)
+	assert.Equal(t, records[1], read)
+	assert.NoError(t, rotated.Write(records[1:]))
+	stored, err = store.Read("hash_2")
+	if assert.NoError(t, err) {
+		key_id, _ := EnvelopeKeyID([]byte(stored.Text))
+		assert.Equal(t, "key-2", key_id)
+	}
+	// the old key can no longer open the result records of the new key
+	_, err = io.Read("hash_2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, rotated.Delete("hash_1"))
+	listed, err = rotated.List()
+	assert.NoError(t, err)
+	assert.Equal(t, records[1:], listed)
+}
@@ -63,8 +63,14 @@ func (detector *DryRunPhiDetector) Run(
 			}
 			// add the dummy Result to the Response
 			response.Results = append(response.Results, result)
-			// send the Response to the output channel
-			chan_responses_out <- response
+			// send the Response to the output channel, unless the context
+			// is done while waiting for the Response to be received
+			select {
+			case chan_responses_out <- response:
+			case <-ctx.Done():
+				logger.Warn().Msg("stopping dry run detector : context done")
+				return
+			}
 			logger.Debug().Msgf(
 				"dry run detector processed request ID = %s : RepositoryID = %s : CommitID = %s : ObjectID = %s",
 				request.ID,
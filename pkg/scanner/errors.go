@@ -3,15 +3,19 @@ package scanner
 import "github.com/pkg/errors"
 
 const (
-	ErrMsgAddScanRepository     = "failed to add ScanRepository"
-	ErrMsgCloneRepository       = "failed to clone repository"
-	ErrMsgErrorChannelNil       = "received nil error channel as input"
-	ErrMsgResultWriteFailed     = "failed to write result"
-	ErrMsgScanRepositoryCreate  = "failed to create new ScanRepository object"
-	ErrMsgScanRepositoryScan    = "failed to scan repository"
-	ErrMsgScanTrackerUpdateFile = "failed to update tracker for file %s"
-	ErrMsgScannerCreate         = "failed to create new Scanner"
-	ErrMsgTrackerUpdateCommit   = "failed to update tracker for commit %s"
+	ErrMsgAddScanRepository      = "failed to add ScanRepository"
+	ErrMsgCloneRepository        = "failed to clone repository"
+	ErrMsgErrorChannelNil        = "received nil error channel as input"
+	ErrMsgResultWriteFailed      = "failed to write result"
+	ErrMsgScanRepositoryCanceled = "scan of repository %s cancelled"
+	ErrMsgScanRepositoryCreate   = "failed to create new ScanRepository object"
+	ErrMsgScanRepositoryScan     = "failed to scan repository"
+	ErrMsgScanScope              = "failed to select commits in scope of scan"
+	ErrMsgScanScopeRef           = "failed to resolve commits of ref %s"
+	ErrMsgScanTrackerUpdateFile  = "failed to update tracker for file %s"
+	ErrMsgScannerCreate          = "failed to create new Scanner"
+	ErrMsgScannerReport          = "failed to create scan report"
+	ErrMsgTrackerUpdateCommit    = "failed to update tracker for commit %s"
 )
 
 var (
@@ -27,4 +31,6 @@ var (
 	ErrScanRepositoryContextNil         = errors.New("ScanRepository requires a non-nil context")
 	ErrScanRepositoryCloneGitManagerNil = errors.New("ScanRepository git manager is nil")
 	ErrScanRepositoryRepositoryNil      = errors.New("ScanRepository repository pointer is nil")
+	ErrScanRepositoryResultIONil        = errors.New("ScanRepository requires a result IO to use a blob cache")
+	ErrScanScopeModeInvalid             = errors.New("invalid scan scope mode")
 )
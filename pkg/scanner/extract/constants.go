@@ -0,0 +1,21 @@
+package extract
+
+// DetectHeadSize is the number of bytes at the start of the contents of a
+// file that are used to detect its content type.
+const DetectHeadSize int = 512
+
+// MaxExtractedSize is the maximum number of bytes that are decompressed from
+// any single part (e.g. zip entry or PDF stream) of a document, which limits
+// the memory used to extract the text of malicious (e.g. zip bomb) documents.
+const MaxExtractedSize int64 = 32 << 20
+
+// MIME types of the documents supported by the default extractors.
+const (
+	MIMETypeDOCX string = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMETypeEML  string = "message/rfc822"
+	MIMETypeODS  string = "application/vnd.oasis.opendocument.spreadsheet"
+	MIMETypeODT  string = "application/vnd.oasis.opendocument.text"
+	MIMETypePDF  string = "application/pdf"
+	MIMETypeXLSX string = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIMETypeZip  string = "application/zip"
+)
@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// zipExtensions maps the file extensions of zip-based documents to their
+// content type, which is used when the content type of the zip file cannot
+// be detected from its first entry.
+var zipExtensions = map[string]string{
+	".docx": MIMETypeDOCX,
+	".ods":  MIMETypeODS,
+	".odt":  MIMETypeODT,
+	".xlsx": MIMETypeXLSX,
+}
+
+// DetectContentType() function returns the content (MIME) type of the file
+// with the provided name and contents, where only the first DetectHeadSize
+// bytes of the contents are needed. The content type is detected from the
+// contents as in http.DetectContentType(), except that:
+//   - zip files are detected as the type of document stored in the zip file
+//   - plain-text files with email headers are detected as email messages
+//
+// The returned content type never includes parameters (e.g. charset).
+func DetectContentType(name string, contents []byte) string {
+	head := contents
+	if len(head) > DetectHeadSize {
+		head = head[:DetectHeadSize]
+	}
+
+	content_type, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	extension := strings.ToLower(filepath.Ext(name))
+	switch {
+	case content_type == MIMETypeZip:
+		return detectZipContentType(extension, head)
+	case strings.HasPrefix(content_type, "text/plain"):
+		if extension == ".eml" || isEmailHeader(head) {
+			return MIMETypeEML
+		}
+	}
+
+	return content_type
+}
+
+// detectZipContentType() function returns the content type of the document
+// stored in a zip file. OpenDocument files store their content type in the
+// (uncompressed) first entry of the zip file, named "mimetype". The content
+// type of other documents (e.g. Office Open XML) is detected from the file
+// extension.
+func detectZipContentType(extension string, head []byte) string {
+	// the local file header of a zip entry is 30 bytes, followed by the name
+	// of the entry, an extra field and then the (stored) contents
+	const local_header_size = 30
+	const odf_name = "mimetype"
+	if len(head) > local_header_size+len(odf_name) &&
+		string(head[local_header_size:local_header_size+len(odf_name)]) == odf_name {
+		size := int(binary.LittleEndian.Uint32(head[18:22]))
+		start := local_header_size + len(odf_name) + int(binary.LittleEndian.Uint16(head[28:30]))
+		if start+size <= len(head) {
+			content_type := string(head[start : start+size])
+			if strings.HasPrefix(content_type, "application/vnd.oasis.opendocument.") {
+				return content_type
+			}
+		}
+	}
+	if content_type, exists := zipExtensions[extension]; exists {
+		return content_type
+	}
+
+	return MIMETypeZip
+}
+
+// isEmailHeader() function returns true if the text starts with a header
+// of an email message, which must include the From header along with either
+// the MIME-Version or Received header.
+func isEmailHeader(head []byte) bool {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(head)))
+	// the header may be truncated by the end of the head, which is not an
+	// error as long as the expected fields were read
+	header, _ := reader.ReadMIMEHeader()
+	if header == nil || header.Get("From") == "" {
+		return false
+	}
+
+	return header.Get("MIME-Version") != "" || header.Get("Received") != ""
+}
@@ -0,0 +1,156 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// emlHeaders are the headers of an email message that are included in the
+// extracted text, in order.
+var emlHeaders = []string{"From", "To", "Cc", "Bcc", "Reply-To", "Date", "Subject"}
+
+// htmlTagPattern matches the tags of an HTML document.
+var htmlTagPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// emlExtractor struct extracts the text of an email message, including the
+// text of any attachments that are supported by its registry.
+type emlExtractor struct {
+	registry *Registry
+}
+
+// Extract() method returns the headers of the email message that identify
+// the sender, recipients and subject, followed by the text of each part of
+// the body of the message. HTML parts are included without their tags, and
+// attachments are included if the registry has an Extractor for their type.
+func (x *emlExtractor) Extract(contents []byte) (string, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(contents))
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	decoder := &mime.WordDecoder{}
+	for _, name := range emlHeaders {
+		value := message.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		builder.WriteString(name + ": " + value + "\n")
+	}
+	builder.WriteString("\n")
+
+	err = x.writePart(&builder, message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), "", message.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// writePart() method writes the text of a part of an email message to the
+// builder, recursing into the parts of multipart messages.
+func (x *emlExtractor) writePart(builder *strings.Builder, content_type, encoding, filename string, body io.Reader) error {
+	media_type, params, err := mime.ParseMediaType(content_type)
+	if err != nil {
+		// the default content type of a message without a valid header
+		media_type = "text/plain"
+		params = map[string]string{}
+	}
+
+	if strings.HasPrefix(media_type, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			err = x.writePart(
+				builder,
+				part.Header.Get("Content-Type"),
+				part.Header.Get("Content-Transfer-Encoding"),
+				part.FileName(),
+				part,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	contents, err := readLimited(decodeTransferEncoding(encoding, body))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case media_type == "text/plain":
+		builder.Write(contents)
+	case media_type == "text/html":
+		builder.WriteString(htmlText(string(contents)))
+	default:
+		// extract the text of attachments with a supported content type
+		if x.registry == nil {
+			return nil
+		}
+		_, text, err := x.registry.Extract(filename, contents)
+		if err != nil {
+			// attachments that are not supported are skipped
+			return nil
+		}
+		builder.WriteString(text)
+	}
+	if !strings.HasSuffix(builder.String(), "\n") {
+		builder.WriteString("\n")
+	}
+
+	return nil
+}
+
+// decodeTransferEncoding() function returns a reader of the decoded body of
+// a part of an email message with the provided Content-Transfer-Encoding.
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// the decoder ignores the line breaks of the encoded body
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// htmlText() function returns the text of an HTML document, where every tag
+// is replaced with a newline for block elements or removed otherwise.
+func htmlText(html string) string {
+	html = htmlTagPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		name := strings.ToLower(strings.Trim(tag, "</> \t\r\n"))
+		if i := strings.IndexAny(name, " \t\r\n"); i >= 0 {
+			name = name[:i]
+		}
+		switch name {
+		case "br", "div", "li", "p", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+			return "\n"
+		case "td", "th":
+			return "\t"
+		}
+		return ""
+	})
+
+	replacer := strings.NewReplacer("&nbsp;", " ", "&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", "\"", "&#39;", "'")
+
+	return replacer.Replace(html)
+}
@@ -0,0 +1,88 @@
+package extract
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEmlExtractor_Extract() unit test function tests that the Extract()
+// method of the email extractor returns the headers of the message, along
+// with the text of each part of the body and of any supported attachments.
+func TestEmlExtractor_Extract(t *testing.T) {
+	t.Parallel()
+
+	attachment := testZip(t,
+		[2]string{"[Content_Types].xml", "<Types/>"},
+		[2]string{"xl/sharedStrings.xml", `<sst><si><t>Jane Doe</t></si></sst>`},
+		[2]string{"xl/worksheets/sheet1.xml", `<worksheet><sheetData><row><c t="s"><v>0</v></c><c><v>42</v></c></row></sheetData></worksheet>`},
+	)
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded)
+
+	message := strings.Join([]string{
+		"From: Dr. Who <dr.who@example.com>",
+		"To: front-desk@example.com",
+		"Subject: =?UTF-8?Q?Follow-up_for_Jos=C3=A9?=",
+		"Date: Mon, 1 Jan 2024 09:00:00 +0000",
+		"Received: from mail.example.com",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Patient John Smith, DOB 1970-01-01, called =",
+		"today.",
+		"--inner",
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		"<html><body><p>Patient <b>John Smith</b> &amp; family</p></body></html>",
+		"--inner--",
+		"--outer",
+		"Content-Type: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		`Content-Disposition: attachment; filename="patients.xlsx"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		wrapped.String(),
+		"--outer",
+		"Content-Type: image/png",
+		`Content-Disposition: attachment; filename="logo.png"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n")),
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	text, err := NewDefaultRegistry().extractors[MIMETypeEML].Extract([]byte(message))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"From: Dr. Who <dr.who@example.com>",
+		"To: front-desk@example.com",
+		"Date: Mon, 1 Jan 2024 09:00:00 +0000",
+		"Subject: Follow-up for José",
+		"",
+		"Patient John Smith, DOB 1970-01-01, called today.",
+		"",
+		"Patient John Smith & family",
+		"Jane Doe\t42",
+		"",
+	}, "\n"), strings.ReplaceAll(text, "\r\n", "\n"))
+
+	// a message without a MIME body is plain text
+	text, err = NewDefaultRegistry().extractors[MIMETypeEML].Extract([]byte("Subject: hi\r\n\r\nJohn Smith\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Subject: hi\n\nJohn Smith\r\n", text)
+}
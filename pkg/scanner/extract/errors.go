@@ -0,0 +1,13 @@
+package extract
+
+import "github.com/pkg/errors"
+
+const ErrMsgExtractText = "failed to extract text of %s document"
+
+var (
+	ErrDocumentEncrypted      = errors.New("document is encrypted")
+	ErrDocumentPartMissing    = errors.New("document is missing a required part")
+	ErrDocumentPartTooLarge   = errors.New("document part exceeds the maximum extracted size")
+	ErrExtractorNil           = errors.New("extractor is nil")
+	ErrUnsupportedContentType = errors.New("content type is not supported by any extractor")
+)
@@ -0,0 +1,174 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Extractor interface defines the method used to extract the text of a
+// document of a specific content type, such that the text can be scanned
+// like the contents of a plain-text file.
+type Extractor interface {
+	// Extract() method returns the text of the document with the provided
+	// contents. Offsets of detection results in the scanned text are offsets
+	// within the returned text, not within the contents of the document.
+	Extract(contents []byte) (string, error)
+}
+
+// ExtractorFunc type allows a function to be used as an Extractor.
+type ExtractorFunc func(contents []byte) (string, error)
+
+// Extract() method calls the function.
+func (f ExtractorFunc) Extract(contents []byte) (string, error) {
+	return f(contents)
+}
+
+// Registry struct contains the Extractor of each supported content (MIME)
+// type. Registry is not safe for concurrent use while extractors are
+// registered, but is safe for concurrent use once every extractor has been
+// registered.
+type Registry struct {
+	extractors map[string]Extractor
+}
+
+// NewRegistry() function returns a new Registry without any extractors.
+func NewRegistry() *Registry {
+	return &Registry{
+		extractors: make(map[string]Extractor),
+	}
+}
+
+// NewDefaultRegistry() function returns a new Registry with the default
+// extractors for office documents (DOCX, XLSX, ODT, ODS), PDF text layers
+// and email messages.
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(MIMETypeDOCX, ExtractorFunc(extractDOCX))
+	r.Register(MIMETypeODS, ExtractorFunc(extractODF))
+	r.Register(MIMETypeODT, ExtractorFunc(extractODF))
+	r.Register(MIMETypePDF, ExtractorFunc(extractPDF))
+	r.Register(MIMETypeXLSX, ExtractorFunc(extractXLSX))
+	// attachments of email messages are extracted with the same registry
+	r.Register(MIMETypeEML, &emlExtractor{registry: r})
+
+	return r
+}
+
+// Detect() method returns the content type of the file with the provided
+// name and contents (or the first DetectHeadSize bytes of the contents),
+// if the Registry has an Extractor for the content type. Returns an empty
+// string if the content type is not supported.
+func (r *Registry) Detect(name string, head []byte) string {
+	if r == nil {
+		return ""
+	}
+	content_type := DetectContentType(name, head)
+	if !r.Supports(content_type) {
+		return ""
+	}
+
+	return content_type
+}
+
+// Extract() method detects the content type of the file with the provided
+// name and contents, then returns the content type along with the text
+// extracted by the Extractor for the content type. Returns an error that
+// wraps ErrUnsupportedContentType if there is no Extractor for the content
+// type.
+func (r *Registry) Extract(name string, contents []byte) (content_type string, text string, e error) {
+	content_type = DetectContentType(name, contents)
+	if r == nil || !r.Supports(content_type) {
+		e = errors.Wrapf(ErrUnsupportedContentType, "content type = %s", content_type)
+		return
+	}
+
+	text, e = r.extractors[content_type].Extract(contents)
+	if e != nil {
+		e = errors.Wrapf(e, ErrMsgExtractText, content_type)
+		text = ""
+	}
+
+	return
+}
+
+// Register() method sets the Extractor used for the content type, replacing
+// any Extractor that was registered for the same content type.
+func (r *Registry) Register(content_type string, extractor Extractor) {
+	if extractor == nil {
+		delete(r.extractors, content_type)
+		return
+	}
+	r.extractors[content_type] = extractor
+}
+
+// Supports() method returns true if the Registry has an Extractor for the
+// content type.
+func (r *Registry) Supports(content_type string) bool {
+	if r == nil {
+		return false
+	}
+	_, exists := r.extractors[content_type]
+
+	return exists
+}
+
+// readLimited() function reads all of the reader, returning an error that
+// wraps ErrDocumentPartTooLarge if there are more than MaxExtractedSize bytes.
+func readLimited(reader io.Reader) ([]byte, error) {
+	contents, err := io.ReadAll(io.LimitReader(reader, MaxExtractedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(contents)) > MaxExtractedSize {
+		return nil, ErrDocumentPartTooLarge
+	}
+
+	return contents, nil
+}
+
+// xmlText() function returns the text of the XML document, where the text
+// (i.e. character data) of the elements with the provided local names is
+// kept, and the start or end of an element with a local name in separators
+// is replaced with the associated separator (e.g. a newline at the end of a
+// paragraph).
+func xmlText(contents []byte, text_elements map[string]bool, start_separators, end_separators map[string]string) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(contents))
+	// documents can declare any encoding, but office documents are UTF-8
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var builder strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if text_elements[t.Name.Local] {
+				depth++
+			}
+			builder.WriteString(start_separators[t.Name.Local])
+		case xml.EndElement:
+			if text_elements[t.Name.Local] && depth > 0 {
+				depth--
+			}
+			builder.WriteString(end_separators[t.Name.Local])
+		case xml.CharData:
+			if depth > 0 {
+				builder.Write(t)
+			}
+		}
+	}
+
+	return builder.String(), nil
+}
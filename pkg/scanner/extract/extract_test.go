@@ -0,0 +1,126 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testZip() helper function returns the contents of a zip file with the
+// provided entries, where the first entry is stored (uncompressed) with its
+// size in the local file header, as in OpenDocument files.
+func testZip(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for i, entry := range entries {
+		if i == 0 {
+			header := &zip.FileHeader{
+				CRC32:              crc32.ChecksumIEEE([]byte(entry[1])),
+				CompressedSize64:   uint64(len(entry[1])),
+				Method:             zip.Store,
+				Name:               entry[0],
+				UncompressedSize64: uint64(len(entry[1])),
+			}
+			w, err := writer.CreateRaw(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(entry[1])); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		w, err := writer.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// TestDetectContentType() unit test function tests that DetectContentType()
+// detects the type of documents from their contents, using the extension of
+// the file name only for zip files without an OpenDocument mimetype entry.
+func TestDetectContentType(t *testing.T) {
+	t.Parallel()
+
+	docx := testZip(t, [2]string{"[Content_Types].xml", "<Types/>"}, [2]string{"word/document.xml", "<w:document/>"})
+	odt := testZip(t, [2]string{"mimetype", MIMETypeODT}, [2]string{"content.xml", "<office:document-content/>"})
+
+	tests := []struct {
+		contents []byte
+		expected string
+		name     string
+	}{
+		{contents: docx, expected: MIMETypeDOCX, name: "notes.docx"},
+		{contents: docx, expected: MIMETypeXLSX, name: "patients.XLSX"},
+		{contents: docx, expected: MIMETypeZip, name: "archive.zip"},
+		{contents: odt, expected: MIMETypeODT, name: "notes.zip"},
+		{contents: []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"), expected: MIMETypePDF, name: "scan.bin"},
+		{contents: []byte("Subject: hi\r\n\r\nbody"), expected: MIMETypeEML, name: "message.eml"},
+		{contents: []byte("From: a@example.com\r\nMIME-Version: 1.0\r\n\r\nbody"), expected: MIMETypeEML, name: "message.txt"},
+		{contents: []byte("From: the desk of Dr. Smith\n\nnotes"), expected: "text/plain", name: "notes.txt"},
+		{contents: []byte("name,dob\nJohn Smith,1970-01-01\n"), expected: "text/plain", name: "patients.csv"},
+		{contents: []byte{0x00, 0x01, 0x02, 0x03}, expected: "application/octet-stream", name: "data.bin"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, DetectContentType(test.name, test.contents))
+		})
+	}
+}
+
+// TestRegistry() unit test function tests that a Registry only detects and
+// extracts the content types of its extractors.
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register(MIMETypePDF, ExtractorFunc(func(contents []byte) (string, error) {
+		return "John Smith", nil
+	}))
+	registry.Register(MIMETypeEML, ExtractorFunc(func(contents []byte) (string, error) {
+		return "", errors.New("test error")
+	}))
+
+	assert.True(t, registry.Supports(MIMETypePDF))
+	assert.False(t, registry.Supports(MIMETypeDOCX))
+	assert.Equal(t, MIMETypePDF, registry.Detect("scan.pdf", []byte("%PDF-1.7\n")))
+	assert.Equal(t, "", registry.Detect("notes.txt", []byte("John Smith")))
+
+	content_type, text, err := registry.Extract("scan.pdf", []byte("%PDF-1.7\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, MIMETypePDF, content_type)
+	assert.Equal(t, "John Smith", text)
+
+	_, _, err = registry.Extract("notes.txt", []byte("John Smith"))
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+
+	content_type, text, err = registry.Extract("message.eml", []byte("Subject: hi\r\n\r\nbody"))
+	assert.Error(t, err)
+	assert.Equal(t, MIMETypeEML, content_type)
+	assert.Equal(t, "", text)
+
+	// removing the extractor of a content type
+	registry.Register(MIMETypePDF, nil)
+	assert.False(t, registry.Supports(MIMETypePDF))
+
+	// a nil Registry does not support any content type
+	var nil_registry *Registry
+	assert.False(t, nil_registry.Supports(MIMETypePDF))
+	assert.Equal(t, "", nil_registry.Detect("scan.pdf", []byte("%PDF-1.7\n")))
+}
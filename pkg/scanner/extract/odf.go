@@ -0,0 +1,110 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// extractODF() function returns the text of the body of an OpenDocument text
+// document (ODT) or spreadsheet (ODS), with a newline at the end of each
+// paragraph, heading and table row, and a tab at the end of each table cell.
+func extractODF(contents []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return "", err
+	}
+
+	files := zipFiles(reader, "content.xml")
+	if len(files) == 0 {
+		return "", errors.Wrap(ErrDocumentPartMissing, "content.xml")
+	}
+	part, err := readZipFile(files[0])
+	if err != nil {
+		return "", errors.Wrap(err, files[0].Name)
+	}
+
+	text, err := odfText(part)
+	if err != nil {
+		return "", errors.Wrap(err, files[0].Name)
+	}
+
+	return text, nil
+}
+
+// odfText() function returns the text of the paragraphs and headings of the
+// content of an OpenDocument file, where the cells of each table row are
+// separated by tabs and each row ends with a newline.
+func odfText(part []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(part))
+
+	var builder strings.Builder
+	// cell_depth is the number of table cells that contain the current
+	// element, and text_depth is the number of paragraphs or headings
+	cell_depth, text_depth := 0, 0
+	// separators between the paragraphs and cells of a table are only
+	// written before the next text, such that empty cells at the end of a
+	// row are not written
+	pending_space, pending_tabs := false, 0
+	write := func(text string) {
+		if pending_tabs > 0 {
+			builder.WriteString(strings.Repeat("\t", pending_tabs))
+		} else if pending_space {
+			builder.WriteString(" ")
+		}
+		pending_space, pending_tabs = false, 0
+		builder.WriteString(text)
+	}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "h", "p":
+				text_depth++
+			case "line-break":
+				write("\n")
+			case "s":
+				write(" ")
+			case "tab":
+				write("\t")
+			case "table-cell":
+				cell_depth++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "h", "p":
+				text_depth--
+				// the paragraphs of a table cell are separated by spaces
+				if cell_depth > 0 {
+					pending_space = true
+				} else {
+					write("\n")
+				}
+			case "table-cell":
+				cell_depth--
+				pending_space = false
+				pending_tabs++
+			case "table-row":
+				pending_space, pending_tabs = false, 0
+				write("\n")
+			}
+		case xml.CharData:
+			if text_depth > 0 {
+				write(string(t))
+			}
+		}
+	}
+
+	return builder.String(), nil
+}
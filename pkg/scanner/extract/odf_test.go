@@ -0,0 +1,32 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractODF() unit test function tests that extractODF() returns the
+// text of the paragraphs and tables of the body of the document.
+func TestExtractODF(t *testing.T) {
+	t.Parallel()
+
+	contents := testZip(t,
+		[2]string{"mimetype", MIMETypeODT},
+		[2]string{"content.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0">
+<office:automatic-styles><style:style xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" style:name="P1"/></office:automatic-styles>
+<office:body><office:text>
+<text:h>Visit notes</text:h>
+<text:p>Patient<text:tab/>John<text:s/>Smith<text:line-break/>DOB <text:span>1970-01-01</text:span></text:p>
+<table:table><table:table-row><table:table-cell><text:p>MRN</text:p></table:table-cell><table:table-cell><text:p>12345</text:p></table:table-cell></table:table-row></table:table>
+</office:text></office:body></office:document-content>`},
+	)
+
+	text, err := extractODF(contents)
+	assert.NoError(t, err)
+	assert.Equal(t, "Visit notes\nPatient\tJohn Smith\nDOB 1970-01-01\nMRN\t12345\n", text)
+
+	_, err = extractODF(testZip(t, [2]string{"mimetype", MIMETypeODT}))
+	assert.ErrorIs(t, err, ErrDocumentPartMissing)
+}
@@ -0,0 +1,256 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// docxParts are the glob patterns of the parts of a DOCX document that
+// contain text, in the order in which their text is extracted.
+var docxParts = []string{
+	"word/document.xml",
+	"word/header*.xml",
+	"word/footer*.xml",
+	"word/footnotes.xml",
+	"word/endnotes.xml",
+	"word/comments.xml",
+}
+
+// extractDOCX() function returns the text of the paragraphs of a DOCX
+// document, including its headers, footers, notes and comments, with a
+// newline at the end of each paragraph.
+func extractDOCX(contents []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for i, pattern := range docxParts {
+		files := zipFiles(reader, pattern)
+		if i == 0 && len(files) == 0 {
+			return "", errors.Wrap(ErrDocumentPartMissing, pattern)
+		}
+		for _, file := range files {
+			part, err := readZipFile(file)
+			if err != nil {
+				return "", errors.Wrap(err, file.Name)
+			}
+			text, err := xmlText(
+				part,
+				map[string]bool{"delText": true, "t": true},
+				map[string]string{"br": "\n", "cr": "\n", "tab": "\t"},
+				map[string]string{"p": "\n"},
+			)
+			if err != nil {
+				return "", errors.Wrap(err, file.Name)
+			}
+			builder.WriteString(text)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// extractXLSX() function returns the text of the cells of each worksheet of
+// an XLSX workbook, where the cells of each row are separated by tabs and
+// each row ends with a newline, such that the values in each row are kept
+// together with the values of the header row.
+func extractXLSX(contents []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return "", err
+	}
+
+	// the text of most cells is stored in the table of shared strings
+	var shared_strings []string
+	if files := zipFiles(reader, "xl/sharedStrings.xml"); len(files) > 0 {
+		part, err := readZipFile(files[0])
+		if err != nil {
+			return "", errors.Wrap(err, files[0].Name)
+		}
+		if shared_strings, err = xlsxSharedStrings(part); err != nil {
+			return "", errors.Wrap(err, files[0].Name)
+		}
+	}
+
+	sheets := zipFiles(reader, "xl/worksheets/sheet*.xml")
+	if len(sheets) == 0 {
+		return "", errors.Wrap(ErrDocumentPartMissing, "xl/worksheets/sheet*.xml")
+	}
+	// sort the worksheets by number (e.g. sheet2 before sheet10)
+	sort.SliceStable(sheets, func(i, j int) bool {
+		return xlsxSheetNumber(sheets[i].Name) < xlsxSheetNumber(sheets[j].Name)
+	})
+
+	var builder strings.Builder
+	for _, sheet := range sheets {
+		part, err := readZipFile(sheet)
+		if err != nil {
+			return "", errors.Wrap(err, sheet.Name)
+		}
+		if err := xlsxSheetText(&builder, part, shared_strings); err != nil {
+			return "", errors.Wrap(err, sheet.Name)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// readZipFile() function returns the decompressed contents of the file in
+// a zip archive, limited to MaxExtractedSize bytes.
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readLimited(reader)
+}
+
+// xlsxSharedStrings() function returns the text of each item in the table
+// of shared strings of an XLSX workbook.
+func xlsxSharedStrings(part []byte) ([]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(part))
+	shared_strings := make([]string, 0)
+
+	var builder strings.Builder
+	in_text := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				builder.Reset()
+			case "t":
+				in_text = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				shared_strings = append(shared_strings, builder.String())
+			case "t":
+				in_text = false
+			}
+		case xml.CharData:
+			if in_text {
+				builder.Write(t)
+			}
+		}
+	}
+
+	return shared_strings, nil
+}
+
+// xlsxSheetNumber() function returns the number of the worksheet with the
+// provided part name (e.g. 2 for "xl/worksheets/sheet2.xml").
+func xlsxSheetNumber(name string) int {
+	number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "sheet"), ".xml"))
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
+
+// xlsxSheetText() function writes the text of the cells of the worksheet to
+// the builder, where shared string cells are replaced with their text.
+func xlsxSheetText(builder *strings.Builder, part []byte, shared_strings []string) error {
+	decoder := xml.NewDecoder(bytes.NewReader(part))
+
+	var cell_type string
+	var value strings.Builder
+	in_value := false
+	row_cells := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row_cells = 0
+			case "c":
+				cell_type = ""
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "t" {
+						cell_type = attr.Value
+					}
+				}
+				value.Reset()
+			case "v", "t":
+				in_value = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "row":
+				if row_cells > 0 {
+					builder.WriteString("\n")
+				}
+			case "c":
+				text := value.String()
+				if cell_type == "s" {
+					index, err := strconv.Atoi(strings.TrimSpace(text))
+					if err != nil || index < 0 || index >= len(shared_strings) {
+						text = ""
+					} else {
+						text = shared_strings[index]
+					}
+				}
+				if text == "" {
+					continue
+				}
+				if row_cells > 0 {
+					builder.WriteString("\t")
+				}
+				builder.WriteString(text)
+				row_cells++
+			case "v", "t":
+				in_value = false
+			}
+		case xml.CharData:
+			if in_value {
+				value.Write(t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// zipFiles() function returns the files in the zip archive with names that
+// match the pattern (see path.Match), sorted by name.
+func zipFiles(reader *zip.Reader, pattern string) []*zip.File {
+	files := make([]*zip.File, 0)
+	for _, file := range reader.File {
+		if matched, _ := path.Match(pattern, file.Name); matched {
+			files = append(files, file)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	return files
+}
@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractDOCX() unit test function tests that extractDOCX() returns the
+// text of each paragraph of the document, its headers and its comments.
+func TestExtractDOCX(t *testing.T) {
+	t.Parallel()
+
+	contents := testZip(t,
+		[2]string{"[Content_Types].xml", "<Types/>"},
+		[2]string{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Patient:</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">John </w:t></w:r><w:r><w:t>Smith</w:t></w:r></w:p>
+<w:p><w:r><w:t>DOB 1970-01-01</w:t><w:br/><w:t>MRN 12345</w:t></w:r><w:del><w:r><w:delText>SSN 123-45-6789</w:delText></w:r></w:del></w:p>
+<w:sectPr><w:pgSz w:w="12240"/></w:sectPr>
+</w:body></w:document>`},
+		[2]string{"word/header1.xml", `<w:hdr xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:p><w:r><w:t>Confidential</w:t></w:r></w:p></w:hdr>`},
+		[2]string{"word/comments.xml", `<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:comment><w:p><w:r><w:t>call Jane Doe</w:t></w:r></w:p></w:comment></w:comments>`},
+	)
+
+	text, err := extractDOCX(contents)
+	assert.NoError(t, err)
+	assert.Equal(t, "Patient:\tJohn Smith\nDOB 1970-01-01\nMRN 12345SSN 123-45-6789\nConfidential\ncall Jane Doe\n", text)
+
+	// a zip file without the main document part is not a DOCX document
+	_, err = extractDOCX(testZip(t, [2]string{"[Content_Types].xml", "<Types/>"}))
+	assert.ErrorIs(t, err, ErrDocumentPartMissing)
+
+	_, err = extractDOCX([]byte("not a zip file"))
+	assert.Error(t, err)
+}
+
+// TestExtractXLSX() unit test function tests that extractXLSX() returns the
+// text of the cells of each row of each worksheet, in order.
+func TestExtractXLSX(t *testing.T) {
+	t.Parallel()
+
+	contents := testZip(t,
+		[2]string{"[Content_Types].xml", "<Types/>"},
+		[2]string{"xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="4" uniqueCount="4">
+<si><t>Name</t></si><si><t>DOB</t></si><si><r><t>John </t></r><r><t>Smith</t></r></si><si><t>Jane Doe</t></si></sst>`},
+		[2]string{"xl/worksheets/sheet10.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>3</v></c></row></sheetData></worksheet>`},
+		[2]string{"xl/worksheets/sheet2.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>Notes</t></is></c></row>
+<row r="2"></row></sheetData></worksheet>`},
+		[2]string{"xl/worksheets/sheet1.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>25569</v></c><c r="C2"><f>B2+1</f><v>25570</v></c></row>
+</sheetData></worksheet>`},
+	)
+
+	text, err := extractXLSX(contents)
+	assert.NoError(t, err)
+	assert.Equal(t, "Name\tDOB\nJohn Smith\t25569\t25570\nNotes\nJane Doe\n", text)
+
+	_, err = extractXLSX(testZip(t, [2]string{"[Content_Types].xml", "<Types/>"}))
+	assert.ErrorIs(t, err, ErrDocumentPartMissing)
+}
@@ -0,0 +1,403 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfInlineImageEndPattern matches the end (EI operator) of the data of an
+// inline image of a content stream.
+var pdfInlineImageEndPattern = regexp.MustCompile(`\sEI(\s|$)`)
+
+// pdfLengthPattern matches the Length of the dictionary of a stream, where
+// the first group is the length, and the second group is only matched if the
+// length is a reference to an indirect object.
+var pdfLengthPattern = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+
+// pdfObjectPattern matches the start of each indirect object of a PDF
+// document (e.g. "12 0 obj").
+var pdfObjectPattern = regexp.MustCompile(`\d+\s+\d+\s+obj\b`)
+
+// pdfStreamPattern matches the end of the dictionary of a stream object and
+// the keyword that starts the data of the stream.
+var pdfStreamPattern = regexp.MustCompile(`>>\s*stream\r?\n`)
+
+// pdfFilterPattern matches the filters of the dictionary of a stream, where
+// the first group is either an array of filters or a single filter name
+// (e.g. "/FlateDecode").
+var pdfFilterPattern = regexp.MustCompile(`/Filter\s*(\[[^\]]*\]|/[A-Za-z0-9]+)`)
+
+// pdfSkippedDictPattern matches the dictionaries of streams that never
+// contain text operators (e.g. fonts, images, metadata and cross-reference
+// streams), except for form XObjects, which can contain text.
+var pdfSkippedDictPattern = regexp.MustCompile(`/Length[123]\b|/Subtype\s*/(Image|Type1C|CIDFontType0C|OpenType|XML)\b|/Type\s*/(XRef|ObjStm|Metadata|EmbeddedFile)\b`)
+
+// extractPDF() function returns the text of the text layer of a PDF document,
+// which is the text shown by the text operators of the content streams of the
+// document. Text that is only part of an image (e.g. a scanned page) is not
+// extracted. Only text shown with fonts that use a single-byte or UTF-16
+// encoding is extracted as readable text.
+func extractPDF(contents []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(contents, " \t\r\n"), []byte("%PDF-")) {
+		return "", ErrDocumentPartMissing
+	}
+	if bytes.Contains(contents, []byte("/Encrypt")) {
+		return "", ErrDocumentEncrypted
+	}
+
+	var builder strings.Builder
+	objects := pdfObjectPattern.FindAllIndex(contents, -1)
+	for i, object := range objects {
+		body := contents[object[1]:]
+		if i+1 < len(objects) {
+			body = contents[object[1]:objects[i+1][0]]
+		}
+		stream := pdfStreamPattern.FindIndex(body)
+		if stream == nil {
+			continue
+		}
+		dict := body[:stream[0]+2]
+		if pdfSkippedDictPattern.Match(dict) {
+			continue
+		}
+		data, ok := pdfStreamData(dict, body[stream[1]:])
+		if !ok {
+			continue
+		}
+
+		data, ok = pdfDecodeStream(dict, data)
+		if !ok || !bytes.Contains(data, []byte("BT")) {
+			continue
+		}
+		text := pdfContentText(data)
+		if text == "" {
+			continue
+		}
+		builder.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// pdfDecodeStream() function returns the decoded data of a stream, which is
+// either not encoded or encoded with the FlateDecode filter. Returns false if
+// the data cannot be decoded.
+func pdfDecodeStream(dict, data []byte) ([]byte, bool) {
+	match := pdfFilterPattern.FindSubmatch(dict)
+	if match == nil {
+		return data, true
+	}
+	filters := strings.Fields(strings.NewReplacer("/", " ", "[", " ", "]", " ").Replace(string(match[1])))
+	if len(filters) != 1 || filters[0] != "FlateDecode" {
+		return nil, false
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+	decoded, err := readLimited(reader)
+	if err != nil {
+		return nil, false
+	}
+
+	return decoded, true
+}
+
+// pdfStreamData() function returns the (encoded) data of a stream, which
+// starts at the start of body. The length of the data is the Length of the
+// dictionary of the stream, unless the Length is an indirect object, in which
+// case the data ends at the end of line before the endstream keyword.
+func pdfStreamData(dict, body []byte) ([]byte, bool) {
+	if match := pdfLengthPattern.FindSubmatch(dict); match != nil && len(match[2]) == 0 {
+		length, err := strconv.Atoi(string(match[1]))
+		if err == nil && length <= len(body) {
+			return body[:length], true
+		}
+	}
+
+	end := bytes.LastIndex(body, []byte("endstream"))
+	if end < 0 {
+		return nil, false
+	}
+	data := body[:end]
+	if bytes.HasSuffix(data, []byte("\r\n")) {
+		data = data[:len(data)-2]
+	} else if bytes.HasSuffix(data, []byte("\n")) || bytes.HasSuffix(data, []byte("\r")) {
+		data = data[:len(data)-1]
+	}
+
+	return data, true
+}
+
+// pdfOperand struct is a single operand of an operator of a content stream.
+type pdfOperand struct {
+	number float64
+	text   string
+	kind   byte
+}
+
+const (
+	pdfOperandArrayEnd   byte = ']'
+	pdfOperandArrayStart byte = '['
+	pdfOperandName       byte = '/'
+	pdfOperandNumber     byte = '0'
+	pdfOperandString     byte = '('
+)
+
+// pdfContentText() function returns the text shown by the text operators of
+// the content stream, where each line of text ends with a newline.
+func pdfContentText(data []byte) string {
+	var builder strings.Builder
+	// newline() ends the current line of text, if any text was written
+	newline := func() {
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), "\n") {
+			builder.WriteString("\n")
+		}
+	}
+	// space() separates words of the current line of text
+	space := func() {
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), "\n") && !strings.HasSuffix(builder.String(), " ") {
+			builder.WriteString(" ")
+		}
+	}
+
+	operands := make([]pdfOperand, 0)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			text, n := pdfLiteralString(data[i:])
+			operands = append(operands, pdfOperand{kind: pdfOperandString, text: text})
+			i += n
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			// dictionaries are only operands of marked-content operators
+			i += 2
+		case c == '<':
+			text, n := pdfHexString(data[i:])
+			operands = append(operands, pdfOperand{kind: pdfOperandString, text: text})
+			i += n
+		case c == '>':
+			i++
+		case c == '[':
+			operands = append(operands, pdfOperand{kind: pdfOperandArrayStart})
+			i++
+		case c == ']':
+			operands = append(operands, pdfOperand{kind: pdfOperandArrayEnd})
+			i++
+		case c == '/':
+			n := 1
+			for i+n < len(data) && !isPDFDelimiter(data[i+n]) {
+				n++
+			}
+			operands = append(operands, pdfOperand{kind: pdfOperandName, text: string(data[i+1 : i+n])})
+			i += n
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			n := 1
+			for i+n < len(data) && !isPDFDelimiter(data[i+n]) {
+				n++
+			}
+			number, _ := strconv.ParseFloat(string(data[i:i+n]), 64)
+			operands = append(operands, pdfOperand{kind: pdfOperandNumber, number: number})
+			i += n
+		default:
+			n := 1
+			for i+n < len(data) && !isPDFDelimiter(data[i+n]) {
+				n++
+			}
+			operator := string(data[i : i+n])
+			i += n
+
+			switch operator {
+			case "Tj":
+				builder.WriteString(lastPDFString(operands))
+			case "'", "\"":
+				newline()
+				builder.WriteString(lastPDFString(operands))
+			case "TJ":
+				for _, operand := range operands {
+					switch operand.kind {
+					case pdfOperandString:
+						builder.WriteString(operand.text)
+					case pdfOperandNumber:
+						// a large negative adjustment (in thousandths of a
+						// unit of text space) separates words
+						if operand.number < -200 {
+							space()
+						}
+					}
+				}
+			case "T*", "Tm", "ET":
+				newline()
+			case "Td", "TD":
+				if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+					newline()
+				} else {
+					space()
+				}
+			case "ID":
+				// skip the data of an inline image, which ends with EI
+				if end := pdfInlineImageEndPattern.FindIndex(data[i:]); end != nil {
+					i += end[1]
+				} else {
+					i = len(data)
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+	newline()
+
+	return builder.String()
+}
+
+// isPDFDelimiter() function returns true if the byte ends a token of a
+// content stream.
+func isPDFDelimiter(c byte) bool {
+	return isPDFWhitespace(c) || strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// isPDFWhitespace() function returns true if the byte is a whitespace
+// character of a PDF document.
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+// lastPDFString() function returns the text of the last string operand.
+func lastPDFString(operands []pdfOperand) string {
+	for i := len(operands) - 1; i >= 0; i-- {
+		if operands[i].kind == pdfOperandString {
+			return operands[i].text
+		}
+	}
+
+	return ""
+}
+
+// pdfHexString() function returns the decoded text of the hexadecimal string
+// at the start of the data, along with the number of bytes of the string.
+func pdfHexString(data []byte) (string, int) {
+	end := bytes.IndexByte(data, '>')
+	if end < 0 {
+		end = len(data) - 1
+	}
+	digits := make([]byte, 0, end)
+	for _, c := range data[1:end] {
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		b, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			break
+		}
+		decoded = append(decoded, byte(b))
+	}
+
+	return pdfDecodeText(decoded), end + 1
+}
+
+// pdfLiteralString() function returns the decoded text of the literal string
+// at the start of the data, along with the number of bytes of the string.
+func pdfLiteralString(data []byte) (string, int) {
+	decoded := make([]byte, 0)
+	depth := 0
+	i := 0
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfDecodeText(decoded), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(data) {
+				continue
+			}
+			switch e := data[i]; e {
+			case 'n':
+				decoded = append(decoded, '\n')
+			case 'r':
+				decoded = append(decoded, '\r')
+			case 't':
+				decoded = append(decoded, '\t')
+			case 'b':
+				decoded = append(decoded, '\b')
+			case 'f':
+				decoded = append(decoded, '\f')
+			case '\r':
+				// line continuation
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					n := 1
+					for n < 3 && i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '7' {
+						n++
+					}
+					b, _ := strconv.ParseUint(string(data[i:i+n]), 8, 16)
+					decoded = append(decoded, byte(b))
+					i += n - 1
+				} else {
+					decoded = append(decoded, e)
+				}
+			}
+			continue
+		}
+		decoded = append(decoded, c)
+	}
+
+	return pdfDecodeText(decoded), i
+}
+
+// pdfDecodeText() function returns the text of the bytes of a string, which
+// are either UTF-16BE (with a byte order mark) or a single-byte encoding,
+// where each byte is decoded as the Latin-1 character with the same code.
+func pdfDecodeText(decoded []byte) string {
+	if len(decoded) >= 2 && decoded[0] == 0xFE && decoded[1] == 0xFF {
+		units := make([]uint16, 0, len(decoded)/2)
+		for i := 2; i+1 < len(decoded); i += 2 {
+			units = append(units, uint16(decoded[i])<<8|uint16(decoded[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, 0, len(decoded))
+	for _, b := range decoded {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' {
+			continue
+		}
+		runes = append(runes, rune(b))
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,67 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPDF() helper function returns the contents of a PDF document with a
+// stream object for each of the provided dictionaries and stream data.
+func testPDF(t *testing.T, streams ...[2]string) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	buffer.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	for i, stream := range streams {
+		fmt.Fprintf(buffer, "%d 0 obj\n<< %s /Length %d >>\nstream\n%s\nendstream\nendobj\n", i+3, stream[0], len(stream[1]), stream[1])
+	}
+	buffer.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+	return buffer.Bytes()
+}
+
+// testFlate() helper function returns the data compressed with zlib, as
+// with the FlateDecode filter.
+func testFlate(t *testing.T, data string) string {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := zlib.NewWriter(buffer)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.String()
+}
+
+// TestExtractPDF() unit test function tests that extractPDF() returns the
+// text shown by the text operators of the content streams of the document.
+func TestExtractPDF(t *testing.T) {
+	t.Parallel()
+
+	contents := testPDF(t,
+		[2]string{"", "BT /F1 12 Tf 72 720 Td (Patient: John Smith) Tj 0 -14 Td [(D)20(OB)-300(1970-01-01)] TJ ET"},
+		[2]string{"/Filter /FlateDecode", testFlate(t, "q BT /F1 12 Tf 72 700 Td (MRN \\(primary\\)) Tj T* <FEFF004A0061006E006500200044006F0065> Tj ET Q")},
+		[2]string{"/Filter [/FlateDecode /DCTDecode]", "not decoded BT (ignored) Tj ET"},
+		[2]string{"/Type /XObject /Subtype /Image /Width 1 /Height 1", "BT (ignored) Tj ET"},
+		[2]string{"", "BT 72 680 Td (Visit \\0501\\051) Tj ET BI /W 1 /H 1 ID \x00BT (x) Tj ET\x01 EI"},
+	)
+
+	text, err := extractPDF(contents)
+	assert.NoError(t, err)
+	assert.Equal(t, "Patient: John Smith\nDOB 1970-01-01\nMRN (primary)\nJane Doe\nVisit (1)\n", text)
+
+	_, err = extractPDF([]byte("not a pdf"))
+	assert.ErrorIs(t, err, ErrDocumentPartMissing)
+
+	_, err = extractPDF([]byte("%PDF-1.4\ntrailer\n<< /Root 1 0 R /Encrypt 5 0 R >>\n"))
+	assert.ErrorIs(t, err, ErrDocumentEncrypted)
+}
@@ -0,0 +1,5 @@
+package fileio
+
+const (
+	ErrMsgWriteFile = "failed to write file"
+)
@@ -0,0 +1,60 @@
+package fileio
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic() function writes the provided data to the file with the
+// provided path and mode, where the file is replaced atomically: the data is
+// written to a temporary file in the same directory, which is synced to disk
+// before it is renamed to the path, such that neither an interrupted write
+// nor a crash ever leaves a partial file (or an empty file) at the path.
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	temp_file, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, ErrMsgWriteFile)
+	}
+	// remove the temporary file if it was not renamed
+	defer os.Remove(temp_file.Name())
+
+	if _, err := temp_file.Write(data); err != nil {
+		temp_file.Close()
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+	if err := temp_file.Chmod(mode); err != nil {
+		temp_file.Close()
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+	if err := temp_file.Sync(); err != nil {
+		temp_file.Close()
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+	if err := temp_file.Close(); err != nil {
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+	if err := os.Rename(temp_file.Name(), path); err != nil {
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+
+	// sync the directory, so that the rename itself survives a crash
+	if err := syncDir(dir); err != nil {
+		return errors.Wrapf(err, "%s %s", ErrMsgWriteFile, path)
+	}
+
+	return nil
+}
+
+// syncDir() function syncs the directory with the provided path to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
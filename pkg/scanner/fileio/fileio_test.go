@@ -0,0 +1,38 @@
+package fileio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWriteFileAtomic() unit test function tests that WriteFileAtomic()
+// replaces the contents and mode of a file without leaving any temporary
+// file behind.
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	assert.NoError(t, WriteFileAtomic(path, []byte(`{"version":1}`), 0600))
+	assert.NoError(t, WriteFileAtomic(path, []byte(`{"version":2}`), 0640))
+
+	contents, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"version":2}`, string(contents))
+	}
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(dir)
+	if assert.NoError(t, err) {
+		assert.Len(t, entries, 1)
+	}
+
+	// the file is not written to a missing directory
+	err = WriteFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("{}"), 0600)
+	assert.ErrorContains(t, err, ErrMsgWriteFile)
+}
@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"strings"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// ResultFilter struct decides whether a detection result should be kept,
+// based on the confidence score and the category of the result. The same
+// ResultFilter is used for results of repository scans and for results of
+// webhook events, so that both report the same findings for the same text.
+type ResultFilter struct {
+	categoryThresholds map[string]float64
+	ignoreCategories   map[string]bool
+	threshold          float64
+}
+
+// NewResultFilter() function returns a new ResultFilter that keeps results
+// with a confidence score of at least threshold (or the threshold for the
+// category of the result in category_thresholds), unless the category of the
+// result is in ignore_categories. Categories are matched case-insensitively.
+func NewResultFilter(threshold float64, category_thresholds map[string]float64, ignore_categories []string) *ResultFilter {
+	f := &ResultFilter{
+		categoryThresholds: make(map[string]float64, len(category_thresholds)),
+		ignoreCategories:   make(map[string]bool, len(ignore_categories)),
+		threshold:          threshold,
+	}
+	for category, category_threshold := range category_thresholds {
+		f.categoryThresholds[normalizeCategory(category)] = category_threshold
+	}
+	for _, category := range ignore_categories {
+		f.ignoreCategories[normalizeCategory(category)] = true
+	}
+
+	return f
+}
+
+// NewResultFilterFromConfig() function returns a new ResultFilter using the
+// thresholds and ignored categories of the AzureAI config.
+func NewResultFilterFromConfig(c *cfg.Config) *ResultFilter {
+	return NewResultFilter(
+		c.AzureAI.ConfidenceThreshold,
+		c.AzureAI.CategoryThresholds,
+		c.AzureAI.IgnoreCategories,
+	)
+}
+
+// Allow() method returns true if a result with the provided category and
+// confidence score should be kept.
+func (f *ResultFilter) Allow(category string, confidence float64) bool {
+	if f.ignoreCategories[normalizeCategory(category)] {
+		return false
+	}
+
+	return confidence >= f.Threshold(category)
+}
+
+// FilterResults() method returns the results that should be kept, along with
+// the number of results that were discarded.
+func (f *ResultFilter) FilterResults(results []rrr.Result) (kept []rrr.Result, discarded int) {
+	kept = make([]rrr.Result, 0, len(results))
+	for _, result := range results {
+		if f.Allow(result.Category, result.ConfidenceScore) {
+			kept = append(kept, result)
+		} else {
+			discarded++
+		}
+	}
+
+	return
+}
+
+// Threshold() method returns the minimum confidence score required to keep
+// a result with the provided category.
+func (f *ResultFilter) Threshold(category string) float64 {
+	if category_threshold, ok := f.categoryThresholds[normalizeCategory(category)]; ok {
+		return category_threshold
+	}
+
+	return f.threshold
+}
+
+// normalizeCategory() function returns the category in the form used for
+// matching categories from config against categories of results.
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
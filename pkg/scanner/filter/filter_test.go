@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestResultFilter_Allow() unit test function tests the Allow() method of
+// the ResultFilter struct.
+func TestResultFilter_Allow(t *testing.T) {
+	t.Parallel()
+
+	f := NewResultFilter(
+		0.6,
+		map[string]float64{"Person": 0.9, "usSocialSecurityNumber": 0.3},
+		[]string{"DateTime", " organization "},
+	)
+
+	tests := []struct {
+		category   string
+		confidence float64
+		expected   bool
+		name       string
+	}{
+		{category: "Address", confidence: 0.6, expected: true, name: "Default_Threshold_Equal"},
+		{category: "Address", confidence: 0.59, expected: false, name: "Default_Threshold_Below"},
+		{category: "Person", confidence: 0.8, expected: false, name: "Category_Threshold_Below"},
+		{category: "Person", confidence: 0.95, expected: true, name: "Category_Threshold_Above"},
+		{category: "USSocialSecurityNumber", confidence: 0.4, expected: true, name: "Category_Threshold_Case_Insensitive"},
+		{category: "DateTime", confidence: 1.0, expected: false, name: "Ignored_Category"},
+		{category: "Organization", confidence: 1.0, expected: false, name: "Ignored_Category_Normalized"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, f.Allow(test.category, test.confidence))
+		})
+	}
+}
+
+// TestResultFilter_FilterResults() unit test function tests the
+// FilterResults() method of the ResultFilter struct.
+func TestResultFilter_FilterResults(t *testing.T) {
+	t.Parallel()
+
+	config := cfg.NewDefaultConfig()
+	config.AzureAI.ConfidenceThreshold = 0.5
+	config.AzureAI.IgnoreCategories = []string{"DateTime"}
+	f := NewResultFilterFromConfig(config)
+
+	kept, discarded := f.FilterResults([]rrr.Result{
+		{Category: "Person", ConfidenceScore: 0.9},
+		{Category: "Person", ConfidenceScore: 0.1},
+		{Category: "DateTime", ConfidenceScore: 0.9},
+	})
+	assert.Len(t, kept, 1)
+	assert.Equal(t, 2, discarded)
+
+	kept, discarded = f.FilterResults(nil)
+	assert.Empty(t, kept)
+	assert.Zero(t, discarded)
+}
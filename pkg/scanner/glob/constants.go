@@ -0,0 +1,4 @@
+package glob
+
+// DoubleStar is the pattern segment that matches zero or more segments.
+const DoubleStar string = "**"
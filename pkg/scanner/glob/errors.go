@@ -0,0 +1,8 @@
+package glob
+
+import "github.com/pkg/errors"
+
+var (
+	ErrPatternEmpty   = errors.New("glob pattern is empty")
+	ErrPatternInvalid = errors.New("glob pattern is invalid")
+)
@@ -0,0 +1,78 @@
+package glob
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Match() function returns true if the (slash-separated) name matches the
+// pattern, where each segment of the pattern is matched against a single
+// segment of the name as defined by path.Match(), except for a "**" segment,
+// which matches zero or more segments. For example, "docs/**/*.txt" matches
+// "docs/a.txt" and "docs/a/b/c.txt". Any leading "/" of the pattern and the
+// name is ignored. Returns false if the pattern is invalid.
+func Match(pattern, name string) bool {
+	return matchSegments(split(pattern), split(name))
+}
+
+// Validate() function returns an error if the pattern is empty or if any
+// segment of the pattern is malformed.
+func Validate(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return ErrPatternEmpty
+	}
+	for _, segment := range split(pattern) {
+		if _, err := path.Match(segment, ""); err != nil {
+			return errors.Wrapf(ErrPatternInvalid, "pattern = %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// matchSegments() function returns true if the segments of the name match
+// the segments of the pattern.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == DoubleStar {
+			// consecutive "**" segments are the same as a single "**"
+			for len(pattern) > 0 && pattern[0] == DoubleStar {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			// try to match the rest of the pattern against every suffix of
+			// the name, including the whole name
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], name[0]); err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// split() function returns the segments of the slash-separated string,
+// ignoring any leading or trailing "/".
+func split(s string) []string {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(s, "/")
+}
@@ -0,0 +1,54 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatch() unit test function tests the Match() function.
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected bool
+		name     string
+		pattern  string
+	}{
+		{expected: true, pattern: "test/fixtures/**", name: "test/fixtures/patients.csv"},
+		{expected: true, pattern: "test/fixtures/**", name: "test/fixtures/a/b/patients.csv"},
+		{expected: false, pattern: "test/fixtures/**", name: "test/other/patients.csv"},
+		{expected: false, pattern: "test/fixtures/**", name: "src/test/fixtures/patients.csv"},
+		{expected: true, pattern: "docs/**/*.txt", name: "docs/readme.txt"},
+		{expected: true, pattern: "docs/**/*.txt", name: "docs/a/b/readme.txt"},
+		{expected: false, pattern: "docs/**/*.txt", name: "docs/a/b/readme.md"},
+		{expected: true, pattern: "**/*.csv", name: "patients.csv"},
+		{expected: true, pattern: "**/*.csv", name: "a/b/patients.csv"},
+		{expected: true, pattern: "**/fixtures/**", name: "a/fixtures/b/c.json"},
+		{expected: true, pattern: "a/**/**/b", name: "a/b"},
+		{expected: true, pattern: "*.csv", name: "patients.csv"},
+		{expected: false, pattern: "*.csv", name: "data/patients.csv"},
+		{expected: true, pattern: "/data/*.csv", name: "data/patients.csv"},
+		{expected: true, pattern: "data/patient?.csv", name: "/data/patient1.csv"},
+		{expected: true, pattern: "data/[a-c].csv", name: "data/b.csv"},
+		{expected: false, pattern: "data/[", name: "data/["},
+		{expected: true, pattern: "**", name: "any/path/at/all"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+"|"+test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Match(test.pattern, test.name))
+		})
+	}
+}
+
+// TestValidate() unit test function tests the Validate() function.
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Validate("docs/**/*.txt"))
+	assert.NoError(t, Validate("data/[a-c].csv"))
+	assert.ErrorIs(t, Validate(""), ErrPatternEmpty)
+	assert.ErrorIs(t, Validate("  "), ErrPatternEmpty)
+	assert.ErrorIs(t, Validate("data/["), ErrPatternInvalid)
+}
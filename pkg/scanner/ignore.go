@@ -1,10 +1,21 @@
 package scanner
 
 import (
+	"io"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/archive"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/extract"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/glob"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
 )
 
 // IgnorePaths is a map of paths that should not be scanned.
@@ -27,18 +38,218 @@ var IgnoreExtensions = map[string]bool{
 	".png": true,
 }
 
+// IgnoreRules struct contains the (user) config rules used to decide whether
+// a file should be ignored (i.e. not scanned), in addition to the rules that
+// are enforced by (app) policy.
+type IgnoreRules struct {
+	// Archives controls whether archives (e.g. zip) are scanned, instead of
+	// being ignored as binary files, such that their members are scanned.
+	Archives bool
+	// Exclude is a list of glob patterns of file paths that are ignored,
+	// which take precedence over Include.
+	Exclude []string
+	// Extensions is a list of file extensions that are scanned.
+	Extensions []string
+	// Extractors is the registry of the extractors used to extract the text
+	// of documents (e.g. PDF), which are scanned regardless of the file
+	// extension when the registry supports the content type of the file.
+	// The text of documents is not extracted when Extractors is nil.
+	Extractors *extract.Registry
+	// IgnoreExtensions is a list of file extensions that are ignored.
+	IgnoreExtensions []string
+	// Include is a list of glob patterns of file paths that are scanned,
+	// regardless of the file extension.
+	Include []string
+	// SourceCode controls whether notebooks and source code files (e.g. .py)
+	// are scanned regardless of the file extension.
+	SourceCode bool
+
+	gitignore_patterns []gitignore.Pattern
+	gitignore_rules    []string
+}
+
+// NewIgnoreRules() function returns new IgnoreRules using the rules of the
+// provided GitScanConfig.
+func NewIgnoreRules(config *cfg.GitScanConfig) *IgnoreRules {
+	rules := &IgnoreRules{
+		Archives:         config.Archives.Enabled,
+		Exclude:          config.ExcludePaths,
+		Extensions:       config.Extensions,
+		IgnoreExtensions: config.IgnoreExtensions,
+		Include:          config.IncludePaths,
+		SourceCode:       config.SourceCode,
+	}
+	if config.ExtractText {
+		rules.Extractors = extract.NewDefaultRegistry()
+	}
+
+	return rules
+}
+
+// detectArchive() method returns the format of the file if the file is an
+// archive (e.g. zip) and Archives is set, or an empty string otherwise.
+func (rules *IgnoreRules) detectArchive(file *object.File) string {
+	if !rules.Archives {
+		return ""
+	}
+
+	return archive.Detect(file.Name, fileHead(file))
+}
+
+// detectExtractable() method returns the content type of the file if its
+// text can be extracted by the Extractors, or an empty string otherwise.
+func (rules *IgnoreRules) detectExtractable(file *object.File) string {
+	if rules.Extractors == nil {
+		return ""
+	}
+
+	return rules.Extractors.Detect(file.Name, fileHead(file))
+}
+
+// fileHead() function returns the first bytes of the contents of the file,
+// which are used to detect the type of the file.
+func fileHead(file *object.File) []byte {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	head := make([]byte, extract.DetectHeadSize)
+	n, _ := io.ReadFull(reader, head)
+
+	return head[:n]
+}
+
+// WithGitignore() method returns a copy of the IgnoreRules that also ignores
+// the files matched by the patterns of the .gitignore files in the tree,
+// where the patterns of each .gitignore file apply to the files in its
+// directory.
+func (rules *IgnoreRules) WithGitignore(tree *object.Tree) (*IgnoreRules, error) {
+	type gitignoreFile struct {
+		domain []string
+		lines  []string
+		path   string
+	}
+	var files []gitignoreFile
+
+	err := tree.Files().ForEach(func(file *object.File) error {
+		if path.Base(file.Name) != GitignoreFileName {
+			return nil
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", file.Name)
+		}
+		domain := []string{}
+		if dir := path.Dir(file.Name); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		files = append(files, gitignoreFile{
+			domain: domain,
+			lines:  strings.Split(contents, "\n"),
+			path:   file.Name,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// patterns are matched in reverse order, so the patterns of the
+	// .gitignore files in deeper directories must come last in order to
+	// take precedence
+	sort.SliceStable(files, func(i, j int) bool {
+		return len(files[i].domain) < len(files[j].domain)
+	})
+
+	out := *rules
+	out.gitignore_patterns = nil
+	out.gitignore_rules = nil
+	for _, file := range files {
+		for _, line := range file.lines {
+			// skip blank lines and comments
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			out.gitignore_patterns = append(out.gitignore_patterns, gitignore.ParsePattern(line, file.domain))
+			out.gitignore_rules = append(out.gitignore_rules, file.path+":"+line)
+		}
+	}
+
+	return &out, nil
+}
+
+// matchExclude() method returns the first Exclude pattern that matches the
+// path, if any.
+func (rules *IgnoreRules) matchExclude(file_path string) (rule string, matched bool) {
+	for _, pattern := range rules.Exclude {
+		if glob.Match(pattern, file_path) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// matchGitignore() method returns the .gitignore rule that ignores the path,
+// if any, where the last matching pattern decides whether the path is ignored.
+func (rules *IgnoreRules) matchGitignore(file_path string) (rule string, matched bool) {
+	path_segments := strings.Split(strings.TrimPrefix(file_path, "/"), "/")
+	for i := len(rules.gitignore_patterns) - 1; i >= 0; i-- {
+		switch rules.gitignore_patterns[i].Match(path_segments, false) {
+		case gitignore.Exclude:
+			return rules.gitignore_rules[i], true
+		case gitignore.Include:
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// matchInclude() method returns the first Include pattern that matches the
+// path, if any.
+func (rules *IgnoreRules) matchInclude(file_path string) (rule string, matched bool) {
+	for _, pattern := range rules.Include {
+		if glob.Match(pattern, file_path) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
 // IgnoreFileObject() function can be used to check whether a file should be
 // ignored (i.e. not scanned) for any reason, such as:
-//   - file extension must be allowed by user-provided config (i.e. default ignore)
-//   - file extension cannot be forbidden by app policy
-//   - binary files are always ignored
+//   - binary files are always ignored, unless their text can be extracted
+//     or they are archives
 //   - empty files are always ignored
+//   - file path must not be excluded by user-provided config or .gitignore
+//   - file path, name or extension cannot be forbidden by app policy
+//   - file path must be included by user-provided config, or else the file
+//     extension must not be ignored by user-provided config and either the
+//     text of the file can be extracted (e.g. PDF), the file is an archive
+//     (e.g. zip), the file is source code (e.g. notebook) or the file
+//     extension must be allowed by user-provided config (i.e. default ignore)
 //
-// Returns a boolean to indicate whether the file object should be ignored, along
-// with a reason (string) if ignore=true.
-func IgnoreFileObject(file *object.File, supported_extensions, ignored_extensions []string) (ignore bool, reason string) {
-	// ignore binary files
-	if is_binary, _ := file.IsBinary(); is_binary {
+// Returns a boolean to indicate whether the file object should be ignored,
+// along with a reason (string) if ignore=true. Also returns the rule (e.g.
+// glob pattern, file extension or the content type of a document) that
+// decided whether to ignore the file, if the decision was made by a
+// specific rule.
+func IgnoreFileObject(file *object.File, rules *IgnoreRules) (ignore bool, reason string, rule string) {
+	// check whether the text of the file can be extracted (e.g. PDF), or
+	// else whether the file is an archive (e.g. zip) with members to scan
+	content_type := rules.detectExtractable(file)
+	archive_format := ""
+	if content_type == "" {
+		archive_format = rules.detectArchive(file)
+	}
+
+	// ignore binary files, unless their text can be extracted or they are
+	// archives
+	if is_binary, _ := file.IsBinary(); is_binary && content_type == "" && archive_format == "" {
 		ignore = true
 		reason = IgnoreReasonFileIsBinary
 		return
@@ -51,27 +262,71 @@ func IgnoreFileObject(file *object.File, supported_extensions, ignored_extension
 		return
 	}
 
+	// check if the file path is explicitly excluded by (user) config
+	if rule, ignore = rules.matchExclude(file.Name); ignore {
+		reason = IgnoreReasonFilePathExcludedByConfig
+		return
+	}
+
+	// check if the file path is ignored by the .gitignore files of the repository
+	if rule, ignore = rules.matchGitignore(file.Name); ignore {
+		reason = IgnoreReasonFilePathIgnoredByGitignore
+		return
+	}
+
 	// check if the file path should be ignored by (app) policy
-	ignore, reason = IgnoreFilePath(file.Name)
+	ignore, reason, rule = IgnoreFilePath(file.Name)
 	if ignore && reason != "" {
 		return
 	}
 
+	// check if the file path is explicitly included by (user) config
+	if rule, ignore = rules.matchInclude(file.Name); ignore {
+		ignore = false
+		return
+	}
+
 	// get the file extension from the path
 	file_extension := filepath.Ext(file.Name)
 	// check against each file extension that is explicitly ignored by (user) config
-	for _, ignored_extension := range ignored_extensions {
+	for _, ignored_extension := range rules.IgnoreExtensions {
 		if file_extension == ignored_extension {
 			ignore = true
 			reason = IgnoreReasonFileExtensionIgnoredByConfig
+			rule = ignored_extension
 			return
 		}
 	}
 
-	for _, ext := range supported_extensions {
+	// scan documents with text that can be extracted
+	if content_type != "" {
+		ignore = false
+		reason = ""
+		rule = content_type
+		return
+	}
+
+	// scan the members of archives
+	if archive_format != "" {
+		ignore = false
+		reason = ""
+		rule = archive_format
+		return
+	}
+
+	// scan the cells of notebooks and the comments and strings of source code
+	if format := structured.DetectFormat(file.Name); rules.SourceCode && structured.IsSourceCode(format) {
+		ignore = false
+		reason = ""
+		rule = format
+		return
+	}
+
+	for _, ext := range rules.Extensions {
 		if file_extension == ext {
 			ignore = false
 			reason = ""
+			rule = ext
 			return
 		}
 	}
@@ -81,6 +336,7 @@ func IgnoreFileObject(file *object.File, supported_extensions, ignored_extension
 	// unlikely to contain PHI/PII data.
 	ignore = true
 	reason = IgnoreReasonDefault
+	rule = ""
 
 	return
 }
@@ -89,11 +345,13 @@ func IgnoreFileObject(file *object.File, supported_extensions, ignored_extension
 // (i.e. not scanned) based on the file extansion and name. Returns
 // ignore = true if the file should be ignored, and ignore = false
 // if the file should be scanned. Also returns a reason string that
-// explains the justification for ignoring the file, if applicable.
-func IgnoreFilePath(path string) (ignore bool, reason string) {
+// explains the justification for ignoring the file, if applicable,
+// along with the (policy) rule that matched the path.
+func IgnoreFilePath(path string) (ignore bool, reason string, rule string) {
 	// explicitly set defaults for return values
 	ignore = false
 	reason = ""
+	rule = ""
 
 	// get the file name from the path
 	file_name := filepath.Base(path)
@@ -102,6 +360,7 @@ func IgnoreFilePath(path string) (ignore bool, reason string) {
 		// ignore the file / do not scan
 		ignore = true
 		reason = IgnoreReasonFileName
+		rule = file_name
 		return
 	}
 
@@ -111,6 +370,7 @@ func IgnoreFilePath(path string) (ignore bool, reason string) {
 		// ignore the file / do not scan
 		ignore = true
 		reason = IgnoreReasonFileExtensionIgnoredByPolicy
+		rule = file_extension
 		return
 	}
 
@@ -121,15 +381,17 @@ func IgnoreFilePath(path string) (ignore bool, reason string) {
 // (i.e. not scanned) based on the path itself, the directory (parent) of the
 // input path, or the top-level directory of the input path. Returns ignore=true
 // if the path should be ignored, and ignore=false if the path should be scanned.
-func ignorePath(path string) (ignore bool, reason string) {
+func ignorePath(path string) (ignore bool, reason string, rule string) {
 	// explicitly set defaults for return values
 	ignore = false
 	reason = ""
+	rule = ""
 
 	// check if the path itself is in the IgnorePaths map
 	if ignore_file_path, exists := IgnorePaths[path]; exists && ignore_file_path {
 		ignore = true
 		reason = IgnoreReasonFilePath
+		rule = path
 		return
 	}
 
@@ -142,6 +404,7 @@ func ignorePath(path string) (ignore bool, reason string) {
 		if ignore_dir, exists := IgnorePaths[dir_path]; exists && ignore_dir {
 			ignore = true
 			reason = IgnoreReasonDirPath
+			rule = dir_path
 			return
 		}
 	}
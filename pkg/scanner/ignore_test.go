@@ -1,7 +1,20 @@
 package scanner
 
 import (
+	"archive/zip"
+	"bytes"
+	"sort"
+	"strings"
 	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 )
 
 func TestIgnoreFilePath(t *testing.T) {
@@ -9,6 +22,7 @@ func TestIgnoreFilePath(t *testing.T) {
 		path   string
 		ignore bool
 		reason string
+		rule   string
 	}{
 		{
 			path:   "/full/path/to/file.txt",
@@ -24,21 +38,357 @@ func TestIgnoreFilePath(t *testing.T) {
 			path:   "LOCK",
 			ignore: true,
 			reason: IgnoreReasonFileName,
+			rule:   "LOCK",
 		},
 		{
 			path:   "vendor/path/to/ignored_file.txt",
 			ignore: true,
 			reason: IgnoreReasonDirPath,
+			rule:   "vendor",
 		},
 	}
 
 	for _, test := range tests {
-		ignore, reason := IgnoreFilePath(test.path)
+		ignore, reason, rule := IgnoreFilePath(test.path)
 		if ignore != test.ignore {
 			t.Errorf("IgnoreFilePath(%q) returned ignore=%v, want %v", test.path, ignore, test.ignore)
 		}
 		if reason != test.reason {
 			t.Errorf("IgnoreFilePath(%q) returned reason=%q, want %q", test.path, reason, test.reason)
 		}
+		if rule != test.rule {
+			t.Errorf("IgnoreFilePath(%q) returned rule=%q, want %q", test.path, rule, test.rule)
+		}
+	}
+}
+
+// testStoreTree() helper function stores a tree of files with the provided
+// paths and contents in the repository, then returns the tree.
+func testStoreTree(t *testing.T, repository *git.Repository, files map[string]string) *object.Tree {
+	t.Helper()
+
+	store := func(o interface {
+		Encode(plumbing.EncodedObject) error
+	}) plumbing.Hash {
+		encoded := repository.Storer.NewEncodedObject()
+		if err := o.Encode(encoded); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := repository.Storer.SetEncodedObject(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	// storeDir() stores the tree of the files, where each path is relative
+	// to the directory of the tree
+	var storeDir func(files map[string]string) plumbing.Hash
+	storeDir = func(files map[string]string) plumbing.Hash {
+		tree := &object.Tree{}
+		sub_dirs := make(map[string]map[string]string)
+		for file_path, contents := range files {
+			if dir, rest, found := strings.Cut(file_path, "/"); found {
+				if sub_dirs[dir] == nil {
+					sub_dirs[dir] = make(map[string]string)
+				}
+				sub_dirs[dir][rest] = contents
+				continue
+			}
+			blob := &plumbing.MemoryObject{}
+			blob.SetType(plumbing.BlobObject)
+			blob.Write([]byte(contents))
+			blob_hash, err := repository.Storer.SetEncodedObject(blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: file_path, Mode: filemode.Regular, Hash: blob_hash})
+		}
+		for dir, sub_files := range sub_dirs {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: storeDir(sub_files)})
+		}
+		sort.Slice(tree.Entries, func(i, j int) bool {
+			return tree.Entries[i].Name < tree.Entries[j].Name
+		})
+		return store(tree)
+	}
+
+	tree, err := object.GetTree(repository.Storer, storeDir(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree
+}
+
+// testZip() helper function returns the contents of a zip archive that
+// contains the provided files.
+func testZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for name, contents := range files {
+		member, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := member.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.String()
+}
+
+// TestIgnoreFileObject() unit test function tests that the IgnoreFileObject()
+// function applies the include and exclude rules of the config, along with
+// the .gitignore files of the tree, and reports the rule that matched.
+func TestIgnoreFileObject(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	tree := testStoreTree(t, repository, map[string]string{
+		".gitignore":                "# build output\n*.log\n!keep.log\n/tmp/\n",
+		"data/patients.csv":         "name\nJohn Smith\n",
+		"docs/guide/notes.txt":      "John Smith",
+		"docs/readme.md":            "John Smith",
+		"empty.csv":                 "",
+		"keep.log":                  "John Smith",
+		"server.log":                "John Smith",
+		"src/.gitignore":            "secrets.csv\n",
+		"src/secrets.csv":           "John Smith",
+		"test/fixtures/docs/a.txt":  "John Smith",
+		"test/fixtures/patient.csv": "John Smith",
+		"tmp/export.csv":            "John Smith",
+		"vendor/lib/data.csv":       "John Smith",
+	})
+
+	config := &cfg.GitScanConfig{
+		ExcludePaths:     []string{"test/fixtures/**"},
+		Extensions:       []string{".csv", ".log"},
+		IgnoreExtensions: []string{".md"},
+		IncludePaths:     []string{"docs/**/*.txt", "**/*.md"},
+	}
+	rules, err := NewIgnoreRules(config).WithGitignore(tree)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		ignore   bool
+		path     string
+		reason   string
+		rule     string
+		no_rules bool
+	}{
+		{path: "data/patients.csv", rule: ".csv"},
+		{path: "docs/guide/notes.txt", rule: "docs/**/*.txt"},
+		{path: "docs/readme.md", rule: "**/*.md"},
+		{ignore: true, path: "empty.csv", reason: IgnoreReasonFileIsEmpty},
+		{path: "keep.log", rule: ".log"},
+		{ignore: true, path: "server.log", reason: IgnoreReasonFilePathIgnoredByGitignore, rule: ".gitignore:*.log"},
+		{ignore: true, path: "src/secrets.csv", reason: IgnoreReasonFilePathIgnoredByGitignore, rule: "src/.gitignore:secrets.csv"},
+		{ignore: true, path: "test/fixtures/docs/a.txt", reason: IgnoreReasonFilePathExcludedByConfig, rule: "test/fixtures/**"},
+		{ignore: true, path: "test/fixtures/patient.csv", reason: IgnoreReasonFilePathExcludedByConfig, rule: "test/fixtures/**"},
+		{ignore: true, path: "tmp/export.csv", reason: IgnoreReasonFilePathIgnoredByGitignore, rule: ".gitignore:/tmp/"},
+		{ignore: true, path: "vendor/lib/data.csv", reason: IgnoreReasonDirPath, rule: "vendor"},
+		// without the .gitignore files of the tree
+		{no_rules: true, path: "server.log", rule: ".log"},
+		{no_rules: true, path: "src/secrets.csv", rule: ".csv"},
+		{ignore: true, no_rules: true, path: "readme.txt", reason: IgnoreReasonDefault},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			test_rules := rules
+			if test.no_rules {
+				test_rules = NewIgnoreRules(config)
+			}
+			file, err := tree.File(test.path)
+			if err != nil {
+				// files that are not in the tree are only checked by path
+				blob := &plumbing.MemoryObject{}
+				blob.SetType(plumbing.BlobObject)
+				blob.Write([]byte("John Smith"))
+				hash, set_err := repository.Storer.SetEncodedObject(blob)
+				if !assert.NoError(t, set_err) {
+					t.FailNow()
+				}
+				stored, get_err := object.GetBlob(repository.Storer, hash)
+				if !assert.NoError(t, get_err) {
+					t.FailNow()
+				}
+				file = object.NewFile(test.path, filemode.Regular, stored)
+			}
+			ignore, reason, rule := IgnoreFileObject(file, test_rules)
+			assert.Equal(t, test.ignore, ignore)
+			assert.Equal(t, test.reason, reason)
+			assert.Equal(t, test.rule, rule)
+		})
+	}
+}
+
+// TestIgnoreFileObject_extractText() unit test function tests that documents
+// with text that can be extracted are scanned regardless of the file
+// extension, unless the file is excluded or its extension is ignored.
+func TestIgnoreFileObject_extractText(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	pdf := "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n\x00\x01binary"
+	tree := testStoreTree(t, repository, map[string]string{
+		"docs/visit.pdf":        pdf,
+		"docs/visit.bin":        pdf,
+		"fixtures/visit.pdf":    pdf,
+		"images/logo.gif":       "GIF89a\x00\x01\x00\x01",
+		"private/notes.pdf.bak": pdf,
+	})
+
+	config := &cfg.GitScanConfig{
+		ExcludePaths:     []string{"fixtures/**"},
+		ExtractText:      true,
+		IgnoreExtensions: []string{".bak"},
+	}
+	tests := []struct {
+		extract bool
+		ignore  bool
+		path    string
+		reason  string
+		rule    string
+	}{
+		{extract: true, path: "docs/visit.pdf", rule: "application/pdf"},
+		{extract: true, path: "docs/visit.bin", rule: "application/pdf"},
+		{extract: true, ignore: true, path: "fixtures/visit.pdf", reason: IgnoreReasonFilePathExcludedByConfig, rule: "fixtures/**"},
+		{extract: true, ignore: true, path: "images/logo.gif", reason: IgnoreReasonFileIsBinary},
+		{extract: true, ignore: true, path: "private/notes.pdf.bak", reason: IgnoreReasonFileExtensionIgnoredByConfig, rule: ".bak"},
+		{ignore: true, path: "docs/visit.pdf", reason: IgnoreReasonFileIsBinary},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			test_config := *config
+			test_config.ExtractText = test.extract
+			file, err := tree.File(test.path)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			ignore, reason, rule := IgnoreFileObject(file, NewIgnoreRules(&test_config))
+			assert.Equal(t, test.ignore, ignore)
+			assert.Equal(t, test.reason, reason)
+			assert.Equal(t, test.rule, rule)
+		})
+	}
+}
+
+// TestIgnoreFileObject_sourceCode() unit test function tests that notebooks
+// and source code files are scanned regardless of the file extension when
+// SourceCode is set, unless the extension is ignored by config.
+func TestIgnoreFileObject_sourceCode(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	tree := testStoreTree(t, repository, map[string]string{
+		"analysis.ipynb": `{"cells": []}`,
+		"main.go":        "package main\n",
+		"seed.sql":       "-- John Smith\n",
+		"tool.exe.txt":   "John Smith",
+	})
+
+	config := &cfg.GitScanConfig{
+		IgnoreExtensions: []string{".sql"},
+		SourceCode:       true,
+	}
+	tests := []struct {
+		ignore bool
+		path   string
+		reason string
+		rule   string
+		source bool
+	}{
+		{path: "analysis.ipynb", rule: "ipynb", source: true},
+		{path: "main.go", rule: "source", source: true},
+		{ignore: true, path: "seed.sql", reason: IgnoreReasonFileExtensionIgnoredByConfig, rule: ".sql", source: true},
+		{ignore: true, path: "tool.exe.txt", reason: IgnoreReasonDefault, source: true},
+		{ignore: true, path: "main.go", reason: IgnoreReasonDefault},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			test_config := *config
+			test_config.SourceCode = test.source
+			file, err := tree.File(test.path)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			ignore, reason, rule := IgnoreFileObject(file, NewIgnoreRules(&test_config))
+			assert.Equal(t, test.ignore, ignore)
+			assert.Equal(t, test.reason, reason)
+			assert.Equal(t, test.rule, rule)
+		})
+	}
+}
+
+// TestIgnoreFileObject_archives() unit test function tests that archives are
+// scanned regardless of the file extension when Archives is enabled, unless
+// the archive is excluded or the archive is a document (e.g. DOCX).
+func TestIgnoreFileObject_archives(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	contents := testZip(t, map[string]string{"notes.txt": "John Smith"})
+	tree := testStoreTree(t, repository, map[string]string{
+		"data.zip":          contents,
+		"docs/visit.docx":   contents,
+		"exports/data.jar":  contents,
+		"fixtures/data.zip": contents,
+	})
+
+	config := &cfg.GitScanConfig{
+		Archives:     cfg.GitScanArchivesConfig{Enabled: true},
+		ExcludePaths: []string{"fixtures/**"},
+	}
+	tests := []struct {
+		archives bool
+		ignore   bool
+		path     string
+		reason   string
+		rule     string
+	}{
+		{archives: true, path: "data.zip", rule: "zip"},
+		{archives: true, path: "exports/data.jar", rule: "zip"},
+		{archives: true, ignore: true, path: "docs/visit.docx", reason: IgnoreReasonFileIsBinary},
+		{archives: true, ignore: true, path: "fixtures/data.zip", reason: IgnoreReasonFilePathExcludedByConfig, rule: "fixtures/**"},
+		{ignore: true, path: "data.zip", reason: IgnoreReasonFileIsBinary},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			test_config := *config
+			test_config.Archives.Enabled = test.archives
+			file, err := tree.File(test.path)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			ignore, reason, rule := IgnoreFileObject(file, NewIgnoreRules(&test_config))
+			assert.Equal(t, test.ignore, ignore)
+			assert.Equal(t, test.reason, reason)
+			assert.Equal(t, test.rule, rule)
+		})
 	}
 }
@@ -0,0 +1,5 @@
+package redact
+
+// MaskCharacter is the character used to mask each character of detected
+// entities, which matches the default "CharacterMask" policy of Azure.
+const MaskCharacter rune = '*'
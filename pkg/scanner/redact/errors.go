@@ -0,0 +1,12 @@
+package redact
+
+import "github.com/pkg/errors"
+
+const ErrMsgRedactObject string = "failed to redact object %s"
+
+var (
+	ErrRedactorOutputDirEmpty  = errors.New("redactor requires a non-empty output directory")
+	ErrRedactorRepositoryNil   = errors.New("redactor requires a non-nil repository")
+	ErrRedactChunkOutOfRange   = errors.New("chunk is out of range of the object contents")
+	ErrRedactPathOutsideOutput = errors.New("path of object is outside of the output directory")
+)
@@ -0,0 +1,76 @@
+package redact
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// MaskText() function returns the text with the text of each of the results
+// masked by MaskCharacter. Each result is located by searching the text for
+// the occurrence of the result text closest to the offset of the result,
+// which works regardless of the units used by the detection service for the
+// offset. If the result text cannot be found, the span of the text defined
+// by the offset and length of the result (in runes) is masked instead.
+// Line endings are never masked, so that masked text keeps its lines.
+func MaskText(text string, results []rrr.Result) string {
+	runes := []rune(text)
+	for _, result := range results {
+		start, end := locateResult(text, result)
+		if start < 0 {
+			continue
+		}
+		for i := start; i < end && i < len(runes); i++ {
+			if runes[i] != '\n' && runes[i] != '\r' {
+				runes[i] = MaskCharacter
+			}
+		}
+	}
+
+	return string(runes)
+}
+
+// locateResult() function returns the rune positions of the start and end of
+// the result within the text, or -1 if the result cannot be located.
+func locateResult(text string, result rrr.Result) (start int, end int) {
+	start = -1
+	if result.Text != "" {
+		best_distance := -1
+		search_from := 0
+		for {
+			i := strings.Index(text[search_from:], result.Text)
+			if i < 0 {
+				break
+			}
+			byte_offset := search_from + i
+			rune_offset := utf8.RuneCountInString(text[:byte_offset])
+			distance := rune_offset - result.Offset
+			if distance < 0 {
+				distance = -distance
+			}
+			if best_distance < 0 || distance < best_distance {
+				best_distance = distance
+				start = rune_offset
+				end = rune_offset + utf8.RuneCountInString(result.Text)
+			}
+			search_from = byte_offset + len(result.Text)
+		}
+		if start >= 0 {
+			return
+		}
+	}
+
+	// fall back to the offset and length of the result
+	rune_count := utf8.RuneCountInString(text)
+	if result.Length <= 0 || result.Offset < 0 || result.Offset >= rune_count {
+		return -1, -1
+	}
+	start = result.Offset
+	end = result.Offset + result.Length
+	if end > rune_count {
+		end = rune_count
+	}
+
+	return
+}
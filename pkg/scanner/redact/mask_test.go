@@ -0,0 +1,63 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// TestMaskText() unit test function tests the MaskText() function.
+func TestMaskText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected string
+		name     string
+		results  []rrr.Result
+		text     string
+	}{
+		{
+			expected: "Patient: **********, DOB 1980",
+			name:     "Single_Result",
+			results:  []rrr.Result{{Length: 10, Offset: 9, Text: "John Smith"}},
+			text:     "Patient: John Smith, DOB 1980",
+		},
+		{
+			expected: "John Smith saw Dr. Ó **********",
+			name:     "Closest_Occurrence",
+			results:  []rrr.Result{{Length: 10, Offset: 21, Text: "John Smith"}},
+			text:     "John Smith saw Dr. Ó John Smith",
+		},
+		{
+			expected: "Name: ****\n***\n",
+			name:     "Keep_Line_Endings",
+			results:  []rrr.Result{{Length: 8, Offset: 6, Text: "John\nDoe"}},
+			text:     "Name: John\nDoe\n",
+		},
+		{
+			expected: "*****ipsum",
+			name:     "Offset_Fallback",
+			results:  []rrr.Result{{Length: 5, Offset: 0, Text: "not-found"}},
+			text:     "Loremipsum",
+		},
+		{
+			expected: "Lorem ipsum",
+			name:     "Out_Of_Range",
+			results:  []rrr.Result{{Length: 5, Offset: 50, Text: "not-found"}},
+			text:     "Lorem ipsum",
+		},
+		{
+			expected: "Lorem ipsum",
+			name:     "No_Results",
+			text:     "Lorem ipsum",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, MaskText(test.text, test.results))
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package redact
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/archive"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// redactChunk struct contains what the Redactor needs to know about a single
+// response for a chunk of a flagged object.
+type redactChunk struct {
+	length        int
+	offset        int
+	redacted_text string
+	results       []rrr.Result
+}
+
+// Redactor struct collects the responses that contain detection results and
+// uses them to write sanitized copies of the flagged files of a repository,
+// with the text of every result masked.
+type Redactor struct {
+	mutex      *sync.Mutex
+	objects    map[string]map[string][]redactChunk
+	output_dir string
+}
+
+// NewRedactor() function returns a new Redactor that writes sanitized copies
+// of files to the provided output directory.
+func NewRedactor(output_dir string) (*Redactor, error) {
+	if output_dir == "" {
+		return nil, ErrRedactorOutputDirEmpty
+	}
+
+	return &Redactor{
+		mutex:      &sync.Mutex{},
+		objects:    make(map[string]map[string][]redactChunk),
+		output_dir: output_dir,
+	}, nil
+}
+
+// Add() method collects the response if it contains any results. Responses
+// for text extracted from documents (e.g. PDF) are not collected, since the
+// offsets of their results are not positions within the file. The results
+// of responses for the fields of structured data are collected for the
+// value of each field that appears verbatim in the file. Responses for the
+// members of archives (e.g. zip) and for the metadata of the repository
+// (e.g. commit messages) are not collected, since they are not files of the
+// repository. Results within the overlap with the neighbouring chunks and
+// results ignored by inline annotations are dropped. Safe for concurrent use.
+func (r *Redactor) Add(response rrr.Response) {
+	response.DropOverlapResults()
+	ignored := response.DropIgnoredResults()
+	if len(response.Results) == 0 || response.Object.ContentType != "" {
+		return
+	}
+	if strings.Contains(response.Object.Path, archive.PathSeparator) {
+		return
+	}
+	if response.Object.Type != "" && response.Object.Type != rrr.ObjectTypeFile {
+		return
+	}
+	chunks := []redactChunk{{
+		length:        response.Object.Length,
+		offset:        response.Object.Offset,
+		redacted_text: response.RedactedText,
+		results:       response.Results,
+	}}
+	// the redacted text of a request also redacts the results that were
+	// dropped within the overlap or ignored, so the text is masked locally
+	if response.Object.Overlap != nil || ignored > 0 {
+		chunks[0].redacted_text = ""
+	}
+	if len(response.Fields) > 0 {
+		chunks = fieldChunks(response)
+	}
+	if len(chunks) == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	repository_objects, ok := r.objects[response.Repository.ID]
+	if !ok {
+		repository_objects = make(map[string][]redactChunk)
+		r.objects[response.Repository.ID] = repository_objects
+	}
+	repository_objects[response.Object.ID] = append(repository_objects[response.Object.ID], chunks...)
+}
+
+// fieldChunks() function returns a chunk for the value of each field of the
+// response that contains any results, where the offset of each result is
+// relative to the value. The values of fields that do not appear verbatim
+// in the file are not redacted.
+func fieldChunks(response rrr.Response) []redactChunk {
+	field_results := make(map[int][]rrr.Result)
+	for _, result := range response.Results {
+		i := response.FieldIndex(result)
+		result.Offset -= response.Fields[i].Offset
+		field_results[i] = append(field_results[i], result)
+	}
+
+	chunks := make([]redactChunk, 0, len(field_results))
+	for i, field := range response.Fields {
+		results, ok := field_results[i]
+		if !ok || field.SourceOffset < 0 || field.SourceLength <= 0 {
+			continue
+		}
+		chunks = append(chunks, redactChunk{
+			length:  field.SourceLength,
+			offset:  field.SourceOffset,
+			results: results,
+		})
+	}
+
+	return chunks
+}
+
+// CountObjects() method returns the number of flagged objects collected for
+// the repository with the provided ID.
+func (r *Redactor) CountObjects(repository_id string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.objects[repository_id])
+}
+
+// Write() method writes a sanitized copy of each flagged file in the HEAD
+// commit of the repository to the directory named after the repository in
+// the output directory, keeping the path of each file. Flagged objects that
+// only exist in the history of the repository are not written. Returns the
+// paths of the files that were written.
+func (r *Redactor) Write(repository_id, name string, repository *git.Repository) (written []string, e error) {
+	if repository == nil {
+		e = ErrRedactorRepositoryNil
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	written = make([]string, 0)
+	repository_objects := r.objects[repository_id]
+	if len(repository_objects) == 0 {
+		return
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		e = errors.Wrap(err, "failed to get HEAD of repository "+name)
+		return
+	}
+	commit, err := repository.CommitObject(head.Hash())
+	if err != nil {
+		e = errors.Wrap(err, "failed to get HEAD commit of repository "+name)
+		return
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		e = errors.Wrap(err, "failed to get tree of HEAD commit of repository "+name)
+		return
+	}
+
+	output_root := filepath.Join(r.output_dir, name)
+	e = tree.Files().ForEach(func(file *object.File) error {
+		chunks, flagged := repository_objects[file.Hash.String()]
+		if !flagged {
+			return nil
+		}
+		output_path, err := outputPath(output_root, file.Name)
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgRedactObject, file.Name)
+		}
+		sanitized, err := redactFile(file, chunks)
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgRedactObject, file.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(output_path), 0755); err != nil {
+			return errors.Wrapf(err, ErrMsgRedactObject, file.Name)
+		}
+		if err := os.WriteFile(output_path, sanitized, 0644); err != nil {
+			return errors.Wrapf(err, ErrMsgRedactObject, file.Name)
+		}
+		written = append(written, output_path)
+
+		return nil
+	})
+
+	return
+}
+
+// redactContents() function returns the contents with the text of every
+// chunk that has results replaced by its redacted text. The redacted text
+// from the detection service is used when it has the same length as the
+// chunk text, otherwise the chunk text is masked locally by MaskText(). Each
+// character of the contents that is redacted by any chunk is replaced, such
+// that chunks with overlapping text (e.g. requests with overlap) are all
+// redacted within the same contents.
+func redactContents(contents []byte, chunks []redactChunk) ([]byte, error) {
+	// replaced maps the byte position of each redacted character of the
+	// contents to the character that replaces it
+	replaced := make(map[int]rune)
+	for _, chunk := range chunks {
+		if chunk.offset < 0 || chunk.length <= 0 || chunk.offset+chunk.length > len(contents) {
+			return nil, ErrRedactChunkOutOfRange
+		}
+		original := string(contents[chunk.offset : chunk.offset+chunk.length])
+		replacement := chunk.redacted_text
+		if utf8.RuneCountInString(replacement) != utf8.RuneCountInString(original) {
+			replacement = MaskText(original, chunk.results)
+		}
+
+		replacement_runes := []rune(replacement)
+		i := 0
+		for position, character := range original {
+			if replacement_runes[i] != character {
+				replaced[chunk.offset+position] = replacement_runes[i]
+			}
+			i++
+		}
+	}
+	if len(replaced) == 0 {
+		return contents, nil
+	}
+
+	out := make([]byte, 0, len(contents))
+	for position := 0; position < len(contents); {
+		_, size := utf8.DecodeRune(contents[position:])
+		if character, ok := replaced[position]; ok {
+			out = utf8.AppendRune(out, character)
+		} else {
+			out = append(out, contents[position:position+size]...)
+		}
+		position += size
+	}
+
+	return out, nil
+}
+
+// outputPath() function returns the path within the output_root for the
+// path of a file in a repository, refusing any path that would be written
+// outside of the output_root.
+func outputPath(output_root, file_path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(file_path))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", ErrRedactPathOutsideOutput
+	}
+
+	return filepath.Join(output_root, cleaned), nil
+}
+
+// redactFile() function reads the contents of the file and returns the
+// redacted contents.
+func redactFile(file *object.File, chunks []redactChunk) ([]byte, error) {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactContents(contents, chunks)
+}
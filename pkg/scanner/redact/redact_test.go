@@ -0,0 +1,279 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testCommitFiles() helper function creates a local git repository with a
+// single commit containing the provided files and returns the repository
+// along with the blob hash of each file.
+func testCommitFiles(t *testing.T, files map[string]string) (*git.Repository, map[string]string) {
+	repo_path := t.TempDir()
+	repository, err := git.PlainInit(repo_path, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	worktree, err := repository.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, content := range files {
+		file_path := filepath.Join(repo_path, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file_path), 0755))
+		assert.NoError(t, os.WriteFile(file_path, []byte(content), 0644))
+		_, err = worktree.Add(name)
+		assert.NoError(t, err)
+	}
+	commit_hash, err := worktree.Commit("test commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	commit, err := repository.CommitObject(commit_hash)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	hashes := make(map[string]string)
+	for name := range files {
+		file, err := commit.File(name)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		hashes[name] = file.Hash.String()
+	}
+
+	return repository, hashes
+}
+
+// TestRedactor_Write() unit test function tests that the Redactor writes a
+// sanitized copy of each flagged file using the collected responses.
+func TestRedactor_Write(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testCommitFiles(t, map[string]string{
+		"data/patients.csv": "name,ssn\nJohn Smith,555-01-2345\nJane Doe,555-01-9876\n",
+		"notes/visit.md":    "Seen by Dr. Ó Jones today.\n",
+		"README.md":         "nothing to see here\n",
+	})
+
+	output_dir := t.TempDir()
+	redactor, err := NewRedactor(output_dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the first chunk of patients.csv uses the redacted text of the service
+	response := rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 9, Length: 10, Text: "John Smith"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["data/patients.csv"]
+	response.Object.Offset = 0
+	response.Object.Length = 32
+	response.RedactedText = "name,ssn\n**********,***********\n"
+	redactor.Add(response)
+	// the second chunk of patients.csv is masked locally
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 0, Length: 8, Text: "Jane Doe"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["data/patients.csv"]
+	response.Object.Offset = 32
+	response.Object.Length = 21
+	redactor.Add(response)
+	// visit.md is masked locally, where the file contains a multi-byte rune
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 12, Length: 8, Text: "Ó Jones"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["notes/visit.md"]
+	response.Object.Length = len("Seen by Dr. Ó Jones today.\n")
+	redactor.Add(response)
+	// responses without results are not collected
+	response = rrr.Response{}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["README.md"]
+	redactor.Add(response)
+	// responses for text extracted from a document are not collected
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 0, Length: 7, Text: "nothing"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ContentType = "application/pdf"
+	response.Object.ID = hashes["README.md"]
+	redactor.Add(response)
+	// responses for the members of archives are not collected
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 0, Length: 7, Text: "nothing"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["README.md"]
+	response.Object.Path = "data.zip!/README.md"
+	redactor.Add(response)
+	// responses for the metadata of the repository are not collected
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 0, Length: 7, Text: "nothing"}}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["README.md"]
+	response.Object.Type = rrr.ObjectTypeCommit
+	redactor.Add(response)
+	// responses with only results ignored by inline annotations are not
+	// collected
+	response = rrr.Response{Results: []rrr.Result{{Category: "Person", Offset: 0, Length: 7, Text: "nothing"}}}
+	response.Ignore = []rrr.IgnoreRange{{Length: 20, Offset: 0}}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["README.md"]
+	redactor.Add(response)
+	assert.Equal(t, 2, redactor.CountObjects("test_repo"))
+
+	written, err := redactor.Write("test_repo", "test-repo", repository)
+	assert.NoError(t, err)
+	assert.Len(t, written, 2)
+
+	patients, err := os.ReadFile(filepath.Join(output_dir, "test-repo", "data", "patients.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "name,ssn\n**********,***********\n********,555-01-9876\n", string(patients))
+
+	visit, err := os.ReadFile(filepath.Join(output_dir, "test-repo", "notes", "visit.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Seen by Dr. ******* today.\n", string(visit))
+
+	_, err = os.Stat(filepath.Join(output_dir, "test-repo", "README.md"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestRedactor_Write_fields() unit test function tests that the results of
+// responses for the fields of structured data are redacted within the value
+// of each field that appears verbatim in the file.
+func TestRedactor_Write_fields(t *testing.T) {
+	t.Parallel()
+
+	repository, hashes := testCommitFiles(t, map[string]string{
+		"data/patient.json": `{"name": "John Smith", "dob": "1980-01-02", "note": "a\"b"}` + "\n",
+	})
+
+	output_dir := t.TempDir()
+	redactor, err := NewRedactor(output_dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the text of the request is "name: John Smith\ndob: 1980-01-02\nnote: a\"b\n"
+	response := rrr.Response{
+		Fields: []rrr.Field{
+			{Label: "name", Length: 10, Location: "/name", Offset: 6, SourceLength: 10, SourceOffset: 10},
+			{Label: "dob", Length: 10, Location: "/dob", Offset: 22, SourceLength: 10, SourceOffset: 31},
+			{Label: "note", Length: 3, Location: "/note", Offset: 39, SourceOffset: -1},
+		},
+		Results: []rrr.Result{
+			{Category: "Person", Offset: 6, Length: 4, Text: "John"},
+			{Category: "Person", Offset: 11, Length: 5, Text: "Smith"},
+			{Category: "DateTime", Offset: 22, Length: 10, Text: "1980-01-02"},
+			{Category: "Person", Offset: 39, Length: 3, Text: `a"b`},
+		},
+	}
+	response.Repository.ID = "test_repo"
+	response.Object.Format = "json"
+	response.Object.ID = hashes["data/patient.json"]
+	response.Object.Offset = 10
+	response.Object.Length = 31
+	redactor.Add(response)
+
+	written, err := redactor.Write("test_repo", "test-repo", repository)
+	assert.NoError(t, err)
+	assert.Len(t, written, 1)
+
+	patient, err := os.ReadFile(filepath.Join(output_dir, "test-repo", "data", "patient.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "**** *****", "dob": "**********", "note": "a\"b"}`+"\n", string(patient))
+}
+
+// TestRedactor_Write_overlap() unit test function tests that the results of
+// responses for requests with overlap are redacted within the same contents,
+// where the results within the overlap are dropped.
+func TestRedactor_Write_overlap(t *testing.T) {
+	t.Parallel()
+
+	contents := "Seen by Dr. Jones on Monday.\nReferred to John Smith at 12 Main Street.\n"
+	repository, hashes := testCommitFiles(t, map[string]string{"notes/visit.md": contents})
+
+	output_dir := t.TempDir()
+	redactor, err := NewRedactor(output_dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the text of the first request is "Seen by Dr. Jones on Monday.\nReferred to"
+	response := rrr.Response{
+		RedactedText: "Seen by Dr. ***** on ******.\n******** to",
+		Results: []rrr.Result{
+			{Category: "Person", Offset: 12, Length: 5, Text: "Jones"},
+			{Category: "DateTime", Offset: 21, Length: 6, Text: "Monday"},
+		},
+	}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["notes/visit.md"]
+	response.Object.Length = 40
+	response.Object.Overlap = &rrr.Overlap{After: 11, Length: 40}
+	redactor.Add(response)
+	// the text of the second request is "on Monday.\nReferred to John Smith at 12 Main Street.\n"
+	response = rrr.Response{
+		Results: []rrr.Result{
+			{Category: "DateTime", Offset: 3, Length: 6, Text: "Monday"},
+			{Category: "Person", Offset: 23, Length: 10, Text: "John Smith"},
+		},
+	}
+	response.Repository.ID = "test_repo"
+	response.Object.ID = hashes["notes/visit.md"]
+	response.Object.Offset = 18
+	response.Object.Length = len(contents) - 18
+	response.Object.Overlap = &rrr.Overlap{Before: 11, Length: 53}
+	redactor.Add(response)
+
+	written, err := redactor.Write("test_repo", "test-repo", repository)
+	assert.NoError(t, err)
+	assert.Len(t, written, 1)
+
+	visit, err := os.ReadFile(filepath.Join(output_dir, "test-repo", "notes", "visit.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Seen by Dr. ***** on ******.\nReferred to ********** at 12 Main Street.\n", string(visit))
+}
+
+// TestNewRedactor() unit test function tests the NewRedactor() function.
+func TestNewRedactor(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRedactor("")
+	assert.ErrorIs(t, err, ErrRedactorOutputDirEmpty)
+
+	redactor, err := NewRedactor(t.TempDir())
+	assert.NoError(t, err)
+	_, err = redactor.Write("test_repo", "test-repo", nil)
+	assert.ErrorIs(t, err, ErrRedactorRepositoryNil)
+}
+
+// Test_redactContents() unit test function tests the redactContents()
+// function for chunks that are out of range of the contents.
+func Test_redactContents(t *testing.T) {
+	t.Parallel()
+
+	_, err := redactContents([]byte("short"), []redactChunk{{length: 10, offset: 0}})
+	assert.ErrorIs(t, err, ErrRedactChunkOutOfRange)
+
+	out, err := redactContents([]byte("short"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "short", string(out))
+}
+
+// Test_outputPath() unit test function tests that the outputPath() function
+// refuses paths outside of the output directory.
+func Test_outputPath(t *testing.T) {
+	t.Parallel()
+
+	path, err := outputPath("/out/repo", "docs/notes.md")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/out/repo", "docs", "notes.md"), path)
+
+	_, err = outputPath("/out/repo", "../../etc/passwd")
+	assert.ErrorIs(t, err, ErrRedactPathOutsideOutput)
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/usage"
+)
+
+// Report struct summarizes the scan of a single repository, including the
+// (optional) usage and estimated cost of the detection service.
+type Report struct {
+	// Commits contains the counts of scanned commits in each state.
+	Commits tracker.KeyDataCounts `json:"commits"`
+	// Files contains the counts of scanned files in each state.
+	Files tracker.KeyDataCounts `json:"files"`
+	// FilesCached is the number of complete files that were not scanned
+	// because they were already scanned by a previous run, where the
+	// results of the previous run are included in Results.
+	FilesCached int64 `json:"files_cached"`
+	// Repository is the ID of the scanned repository.
+	Repository string `json:"repository"`
+	// Requests contains the counts of requests in each state.
+	Requests tracker.KeyDataCounts `json:"requests"`
+	// Results maps each result category to the number of result records
+	// of that category stored for the repository, excluding the records
+	// that are suppressed by the baseline (if any).
+	Results map[string]int `json:"results"`
+	// ResultsIgnored is the number of results that were ignored by the
+	// inline annotations (e.g. "no-phi-ai:ignore") of the scanned files,
+	// which are not included in Results.
+	ResultsIgnored int64 `json:"results_ignored"`
+	// ScanID is the ID of the Scanner that ran the scan.
+	ScanID string `json:"scan_id"`
+	// Suppressed is the number of result records of the repository that are
+	// suppressed by the baseline, which is only set when a baseline is used.
+	Suppressed int `json:"suppressed,omitempty"`
+	// Usage is the usage of the detection service for the repository, which
+	// is only set when the service reports usage.
+	Usage *usage.Usage `json:"usage,omitempty"`
+}
+
+// Counts() method returns a new Report for the scan of the repository with
+// the provided ID that only contains the counts of the trackers of the scan,
+// without the counts of the stored result records (i.e. Results is empty),
+// which is cheap enough to be called (e.g. for metrics) while scanning.
+func (s *Scanner) Counts(repository_id string) (*Report, error) {
+	scan_repo, err := s.getScanRepository(repository_id)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgScannerReport)
+	}
+
+	return &Report{
+		Commits:        scan_repo.TrackerCommits.GetCounts(),
+		Files:          scan_repo.TrackerFiles.GetCounts(),
+		FilesCached:    scan_repo.CountFilesCached(),
+		Repository:     scan_repo.ID,
+		Requests:       s.TrackerRequests.GetCounts(),
+		Results:        make(map[string]int),
+		ResultsIgnored: scan_repo.CountResultsIgnored(),
+		ScanID:         s.ID,
+	}, nil
+}
+
+// Report() method returns a new Report for the scan of the repository with
+// the provided ID.
+func (s *Scanner) Report(repository_id string) (*Report, error) {
+	report, err := s.Counts(repository_id)
+	if err != nil {
+		return nil, err
+	}
+
+	records, suppressed, err := s.NewResultRecords(repository_id)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrMsgScannerReport)
+	}
+	for _, record := range records {
+		report.Results[record.Category]++
+	}
+	report.Suppressed = len(suppressed)
+
+	return report, nil
+}
+
+// NewResultRecords() method returns the result records stored for the
+// repository with the provided ID, split into the new records and the
+// records that are suppressed by the baseline (if any).
+func (s *Scanner) NewResultRecords(repository_id string) (new_records, suppressed []rrr.ResultRecord, e error) {
+	scan_repo, err := s.getScanRepository(repository_id)
+	if err != nil {
+		e = err
+		return
+	}
+	records, err := s.result_io.List()
+	if err != nil {
+		e = err
+		return
+	}
+
+	new_records = make([]rrr.ResultRecord, 0)
+	for _, record := range records {
+		if record.Repository.ID == scan_repo.ID {
+			new_records = append(new_records, record)
+		}
+	}
+	suppressed = make([]rrr.ResultRecord, 0)
+	if s.baseline != nil {
+		new_records, suppressed = s.baseline.Filter(new_records, time.Now())
+	}
+
+	return
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	gitmemory "github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestScanner_Report() unit test function tests the Report() and Counts()
+// methods of the Scanner object type.
+func TestScanner_Report(t *testing.T) {
+	t.Parallel()
+
+	result_io := memory.NewMemoryResultRecordIO(test_context)
+	scanner, err := NewScanner(test_context, test_valid_git_config_func(), result_io)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the report for an unknown repository should fail
+	_, err = scanner.Report(test_repo_url)
+	assert.ErrorContains(t, err, ErrMsgScannerReport)
+
+	repository, init_err := git.Init(gitmemory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	scan_repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: make(chan<- rrr.Request),
+		Config:          test_repo_scan_config,
+		Context:         test_context,
+		Repository:      repository,
+		URL:             test_repo_url,
+	})
+	if !assert.NoError(t, err) || !assert.NoError(t, scanner.addScanRepository(scan_repo)) {
+		t.FailNow()
+	}
+	_, err = scan_repo.TrackerCommits.Update("commit_1", tracker.KeyCodeComplete, "", nil)
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerFiles.Update("file_1", tracker.KeyCodeComplete, "", nil)
+	assert.NoError(t, err)
+	_, err = scan_repo.TrackerFiles.Update("file_2", tracker.KeyCodeIgnore, "", nil)
+	assert.NoError(t, err)
+
+	record := func(repository_id, category, text string) rrr.ResultRecord {
+		r := rrr.ResultRecord{Result: rrr.Result{Category: category, Text: text}}
+		r.Repository.ID = repository_id
+		r.Hash = r.Result.Hash(repository_id, "", "")
+		return r
+	}
+	assert.NoError(t, result_io.Write([]rrr.ResultRecord{
+		record(test_repo_url, "Person", "John Smith"),
+		record(test_repo_url, "Person", "Jane Doe"),
+		record(test_repo_url, "Email", "jane@example.com"),
+		record("other_repository", "Person", "Someone Else"),
+	}))
+
+	report, err := scanner.Report(test_repo_url)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, test_repo_url, report.Repository)
+	assert.Equal(t, scanner.ID, report.ScanID)
+	assert.Equal(t, 1, report.Commits.Complete)
+	assert.Equal(t, 1, report.Files.Complete)
+	assert.Equal(t, 1, report.Files.Ignore)
+	assert.Equal(t, map[string]int{"Email": 1, "Person": 2}, report.Results)
+	assert.Nil(t, report.Usage)
+
+	// the counts of the scan do not include the result records
+	counts, err := scanner.Counts(test_repo_url)
+	if assert.NoError(t, err) {
+		assert.Equal(t, report.Files, counts.Files)
+		assert.Empty(t, counts.Results)
+	}
+}
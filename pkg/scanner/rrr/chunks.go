@@ -1,124 +1,343 @@
 package rrr
 
 import (
-	"bufio"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
 )
 
 // ChunkFileInput struct contains the input parameters required for
 // the ChunkFileToRequests() function.
 type ChunkFileInput struct {
-	CommitID     string
-	File         *object.File
+	CommitID string
+	// ContentType is the content type of the document from which Text was
+	// extracted, if the File is a document (e.g. PDF) instead of plain text.
+	ContentType string
+	// Fields contains the values of the structured data of the File, which
+	// are chunked instead of the contents of the File when Format is set.
+	Fields []structured.Field
+	File   *object.File
+	// Format is the format of the structured data of the File.
+	Format       string
 	MaxChunkSize int
-	RepoID       string
+	// Overlap is the number of characters of the previous and the next
+	// requests that are included at the start and the end of each request,
+	// which must be less than half of the MaxChunkSize.
+	Overlap int
+	RepoID  string
+	// Text is the text extracted from the File, which is chunked instead of
+	// the contents of the File when ContentType is set.
+	Text string
 }
 
 // ChunkFileToRequests() function reads the input object.File and
 // generates a slice of requests, where the text in each request is
-// limited to MaxChunkSize characters.
+// limited to MaxChunkSize characters (i.e. code points). The file is split
+// between lines, or between sentences or words of lines that are too long,
+// such that the text of each request is an exact substring of the file
+// contents, with the Object.Offset and Object.Length of the request set to
+// the (byte) position of the text within the file. When Overlap is set, the
+// text of each request also includes up to Overlap characters of the text of
+// the previous and the next requests, as set by the Object.Overlap of the
+// request, such that entities at the boundary between requests are detected
+// with their context. When ContentType is set, the extracted Text is chunked
+// instead, and the Object.Offset and Object.Length of each request are
+// positions within the extracted Text. When Format is set, the Fields are
+// chunked by ChunkObjectToRequests() without any overlap. The Ignore ranges
+// of each request are set by the inline annotations (e.g. IgnoreMarker) of
+// the file.
 func ChunkFileToRequests(in ChunkFileInput) (requests []Request, e error) {
 	if in.File == nil {
 		e = ErrChunkFileToRequestsInFileNil
 		return
 	}
-
-	requests = make([]Request, 0)
-	file_reader, err := in.File.Reader()
-	if err != nil {
-		e = errors.Wrapf(err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
-		return
-	}
-
-	file_scanner := bufio.NewScanner(file_reader)
-	file_scanner.Split(bufio.ScanLines)
-
-	var current_offset int
-	var current_text string
-
-	for file_scanner.Scan() {
-		line := file_scanner.Text()
-		next_length := len(line) + len(current_text) + len("\n")
-
-		if next_length < in.MaxChunkSize {
-			current_text += "\n" + line
-			continue
-		}
-
-		// create a request from the current_text
-		request, err := NewRequest(
-			in.RepoID,
-			in.CommitID,
-			in.File.ID().String(),
-			current_text, // create Request from current_text
-		)
+	if in.Format != "" {
+		contents, err := in.File.Contents()
 		if err != nil {
-			e = errors.Wrap(err, ErrMsgScanFileRequestsGenerate)
+			e = errors.Wrapf(err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
 			return
 		}
-		requests = append(requests, request)
-		// increment the current_offset by the length of the current_text
-		current_offset += len(current_text)
-		// reset the current_text variable to prepare for the next
-		// chunk of the file
-		current_text = ""
-
-		if len(line) < in.MaxChunkSize {
-			current_text = line
-			continue
-		}
-		// chunk the line into smaller pieces of MaxChunkSize
-		var line_requests []Request
-		var req_err error
-		// pass the current_offset to the ChunkLineToRequests() function
-		// and get back the updated current_offset, a slice of requests
-		// generated just for this line, and any error in generating the
-		// requests
-		current_offset, line_requests, req_err = ChunkLineToRequests(ChunkLineInput{
+		requests, e = ChunkObjectToRequests(ChunkObjectInput{
 			CommitID:     in.CommitID,
-			Line:         line,
+			Fields:       in.Fields,
 			MaxChunkSize: in.MaxChunkSize,
 			ObjectID:     in.File.ID().String(),
-			Offset:       current_offset,
+			Path:         in.File.Name,
 			RepoID:       in.RepoID,
+			Type:         ObjectTypeFile,
 		})
-		if req_err != nil {
-			e = errors.Wrap(req_err, ErrMsgScanFileRequestsGenerate)
+		// set the format of the file for every request, along with the
+		// fields that are ignored by the annotations of the file
+		annotations := parseIgnoreAnnotations(contents)
+		for i := range requests {
+			requests[i].Ignore = fieldIgnoreRanges(requests[i].Fields, annotations)
+			requests[i].Object.Format = in.Format
+		}
+		return
+	}
+	if in.Overlap < 0 || in.MaxChunkSize-2*in.Overlap <= 1 {
+		e = ErrChunkOverlapInvalid
+		return
+	}
+
+	requests = make([]Request, 0)
+	contents := in.Text
+	if in.ContentType == "" {
+		var err error
+		contents, err = in.File.Contents()
+		if err != nil {
+			e = errors.Wrapf(err, ErrMsgScanFileRequestsGenerate, in.File.Hash.String())
 			return
 		}
-		requests = append(requests, line_requests...)
-		continue
 	}
 
-	// ensure that the last chunk of the file is included in the requests
-	if current_text != "" {
-		// create a new request for the remaining text
-		request, err := NewRequest(
+	// the overlap before and after each chunk is included within the
+	// MaxChunkSize of each request, unless the contents fit in one request
+	chunk_size := in.MaxChunkSize
+	if utf8.RuneCountInString(contents) >= in.MaxChunkSize {
+		chunk_size -= 2 * in.Overlap
+	}
+	spans := chunkSpans(contents, chunk_size)
+	annotations := parseIgnoreAnnotations(contents)
+	for i := range spans {
+		span, overlap := overlapSpan(contents, spans, i, in.Overlap)
+		request, err := newChunkRequest(
 			in.RepoID,
 			in.CommitID,
 			in.File.ID().String(),
-			current_text,
+			contents[span.start:span.end],
+			span.start,
 		)
 		if err != nil {
-			e = err
+			e = errors.Wrap(err, ErrMsgScanFileRequestsGenerate)
 			return
 		}
+		request.Ignore = ignoreRanges(contents, span, annotations)
+		request.Object.Overlap = overlap
 		requests = append(requests, request)
 	}
 
 	// validate that the chunking process produced requests if the file
-	// has a size greater than 0
-	if in.File.Size > 0 && len(requests) == 0 {
+	// (or its extracted text) is not empty
+	if len(contents) > 0 && len(requests) == 0 {
 		e = ErrChunkFileToRequestsFailed
 		return
 	}
 
+	// set the path, type and content type of the file for every request
+	for i := range requests {
+		requests[i].Object.ContentType = in.ContentType
+		requests[i].Object.Path = in.File.Name
+		requests[i].Object.Type = ObjectTypeFile
+	}
+
+	return
+}
+
+// chunkSpans() function returns the position of each chunk of the contents,
+// where each chunk is less than max_size characters. Lines are kept together
+// in the same chunk where possible, and lines that are too long for a single
+// chunk are split by splitText(). The chunks are contiguous, such that the
+// contents can be rebuilt from the text of the chunks.
+func chunkSpans(contents string, max_size int) []lineWord {
+	spans := make([]lineWord, 0)
+	// chunk_start and chunk_end are the position of the current chunk, and
+	// chunk_size is its number of characters
+	var chunk_start, chunk_end, chunk_size int
+
+	flush := func() {
+		if chunk_end > chunk_start {
+			spans = append(spans, lineWord{end: chunk_end, start: chunk_start})
+		}
+		chunk_start, chunk_size = chunk_end, 0
+	}
+
+	// split the contents into lines, where each line keeps its line ending
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		if line == "" {
+			continue
+		}
+		line_size := utf8.RuneCountInString(line)
+		if chunk_size+line_size < max_size {
+			chunk_end += len(line)
+			chunk_size += line_size
+			continue
+		}
+
+		flush()
+
+		if line_size < max_size {
+			chunk_end += len(line)
+			chunk_size = line_size
+			continue
+		}
+		// split the line into smaller pieces of less than max_size
+		for _, piece := range splitText(line, max_size) {
+			spans = append(spans, lineWord{end: chunk_start + piece.end, start: chunk_start + piece.start})
+		}
+		chunk_end += len(line)
+		chunk_start = chunk_end
+	}
+
+	// ensure that the last chunk of the contents is included
+	flush()
+
+	return spans
+}
+
+// ChunkObjectInput struct contains the input parameters required for the
+// ChunkObjectToRequests() function.
+type ChunkObjectInput struct {
+	CommitID string
+	// Fields contains the values of the object, which are chunked with their
+	// labels.
+	Fields       []structured.Field
+	MaxChunkSize int
+	// ObjectID is the hash of the object (e.g. file, commit or tag).
+	ObjectID string
+	// Path is the path of the file or the name of the ref (e.g. tag) of the
+	// object, if any.
+	Path   string
+	RepoID string
+	// Type is the type of the object, which is one of the ObjectType
+	// constants.
+	Type string
+}
+
+// ChunkObjectToRequests() function generates a slice of requests for the
+// Fields of an object (e.g. the values of structured data or the message of
+// a commit), where the text of each request is a line "<label>: <value>" for
+// each field, such that each value is scanned with the context of its label.
+// The text of each request is limited to MaxChunkSize characters, where the
+// value of a field that does not fit in a single request is split between
+// words into several fields with the same label and location. The
+// Object.Offset and Object.Length of each request are the span of the
+// object that contains the values of its fields.
+func ChunkObjectToRequests(in ChunkObjectInput) (requests []Request, e error) {
+	if in.MaxChunkSize <= 0 {
+		e = ErrMaxChunkSizeInvalid
+		return
+	}
+
+	requests = make([]Request, 0)
+	var builder strings.Builder
+	var fields []Field
+	// text_length is the number of characters of the current text, which is
+	// used for the offsets of the fields
+	text_length := 0
+
+	// flush() creates a request from the current text and fields, then
+	// resets them to prepare for the next chunk of the fields
+	flush := func() error {
+		if builder.Len() == 0 {
+			return nil
+		}
+		request, err := NewRequest(in.RepoID, in.CommitID, in.ObjectID, builder.String())
+		if err != nil {
+			return err
+		}
+		request.Fields = fields
+		request.Object.Offset, request.Object.Length = fieldsSpan(fields)
+		requests = append(requests, request)
+		builder.Reset()
+		fields = nil
+		text_length = 0
+
+		return nil
+	}
+
+	// add() adds the line of a field to the current text
+	add := func(field structured.Field) error {
+		prefix := field.Label + ": "
+		line := prefix + field.Value + "\n"
+		if text_length > 0 && text_length+utf8.RuneCountInString(line) >= in.MaxChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		fields = append(fields, Field{
+			Label:        field.Label,
+			Length:       utf8.RuneCountInString(field.Value),
+			Location:     field.Location,
+			Offset:       text_length + utf8.RuneCountInString(prefix),
+			SourceLength: field.Length,
+			SourceOffset: field.Offset,
+		})
+		builder.WriteString(line)
+		text_length += utf8.RuneCountInString(line)
+
+		return nil
+	}
+
+	for _, field := range in.Fields {
+		value_length := utf8.RuneCountInString(field.Value)
+		line_length := utf8.RuneCountInString(field.Label) + len(": ") + value_length + len("\n")
+		if line_length < in.MaxChunkSize {
+			if err := add(field); err != nil {
+				e = errors.Wrap(err, ErrMsgScanFileRequestsGenerate)
+				return
+			}
+			continue
+		}
+		// split the value between sentences or words into pieces that fit
+		// in a request with the label of the field
+		for _, piece := range splitText(field.Value, in.MaxChunkSize-(line_length-value_length)) {
+			piece_field := field
+			piece_field.Value = field.Value[piece.start:piece.end]
+			if field.Offset >= 0 {
+				piece_field.Length = piece.end - piece.start
+				piece_field.Offset = field.Offset + piece.start
+			}
+			if err := add(piece_field); err != nil {
+				e = errors.Wrap(err, ErrMsgScanFileRequestsGenerate)
+				return
+			}
+		}
+	}
+
+	// ensure that the last chunk of the fields is included in the requests
+	if err := flush(); err != nil {
+		e = err
+		return
+	}
+
+	// set the path and type of the object for every request
+	for i := range requests {
+		requests[i].Object.Path = in.Path
+		requests[i].Object.Type = in.Type
+	}
+
 	return
 }
 
+// fieldsSpan() function returns the byte position and length of the span of
+// the file that contains the values of the fields, ignoring any value that
+// does not appear verbatim in the file.
+func fieldsSpan(fields []Field) (offset int, length int) {
+	start, end := -1, -1
+	for _, field := range fields {
+		if field.SourceOffset < 0 {
+			continue
+		}
+		if start < 0 || field.SourceOffset < start {
+			start = field.SourceOffset
+		}
+		if field.SourceOffset+field.SourceLength > end {
+			end = field.SourceOffset + field.SourceLength
+		}
+	}
+	if start < 0 {
+		return 0, 0
+	}
+
+	return start, end - start
+}
+
 // ChunkLineInput struct contains the input parameters required for the
 // ChunkLineToRequests() function.
 type ChunkLineInput struct {
@@ -131,8 +350,9 @@ type ChunkLineInput struct {
 }
 
 // ChunkLineToRequests() function chunks the input line (string) of text
-// into smaller pieces of MaxChunkSize and sends requests to the channel
-// for processing.
+// into smaller pieces of less than MaxChunkSize characters, splitting the
+// line by splitText(). The text of each request is an exact substring of
+// the line.
 func ChunkLineToRequests(in ChunkLineInput) (offset int, requests []Request, e error) {
 	offset = in.Offset
 
@@ -144,61 +364,154 @@ func ChunkLineToRequests(in ChunkLineInput) (offset int, requests []Request, e e
 		return
 	}
 
-	line_reader := strings.NewReader(in.Line)
-	// scan the words of the line
-	line_scanner := bufio.NewScanner(line_reader)
-	line_scanner.Split(bufio.ScanWords)
-
-	var current_text string
-
-	for line_scanner.Scan() {
-		word := line_scanner.Text()
-		next_length := len(current_text) + len(" ") + len(word)
-
-		if next_length < in.MaxChunkSize {
-			if current_text == "" {
-				current_text = word
-			} else {
-				current_text += (" " + word)
-			}
-			continue
-		}
-
-		// create a new request when current text is within a word of
-		// the MaxChunkSize
-		request, err := NewRequest(
+	for _, piece := range splitText(in.Line, in.MaxChunkSize) {
+		request, err := newChunkRequest(
 			in.RepoID,
 			in.CommitID,
 			in.ObjectID,
-			current_text,
+			in.Line[piece.start:piece.end],
+			in.Offset+piece.start,
 		)
 		if err != nil {
 			e = err
 			return
 		}
 		requests = append(requests, request)
-		// increment the offset by the length of the current_text
-		offset += len(current_text) + len(" ")
-		// reset the current_text to the value of the current word
-		current_text = word
+		// increment the offset by the length of the piece
+		offset += piece.end - piece.start
 	}
 
-	if current_text != "" {
-		// create a new request for the remaining text
-		request, err := NewRequest(
-			in.RepoID,
-			in.CommitID,
-			in.ObjectID,
-			current_text,
-		)
-		if err != nil {
-			e = err
-			return
+	return
+}
+
+// splitText() function returns the position of each piece of the text when
+// the text is split into pieces of less than max_size characters, where the
+// text is split after the end of a sentence if the piece is at least half of
+// max_size, or else between words. Any whitespace between words is kept at
+// the end of the piece if it fits, or else at the start of the next piece,
+// and a single word that is too long for a piece is split between
+// characters, such that no piece is too long.
+func splitText(text string, max_size int) []lineWord {
+	// limit is the maximum number of characters of each piece
+	limit := max(max_size-1, 1)
+	pieces := make([]lineWord, 0)
+	words := lineWords(text)
+	// next is the index of the first word that ends after piece_start
+	next := 0
+	for piece_start := 0; piece_start < len(text); {
+		limit_end := runeOffset(text, piece_start, limit)
+		if limit_end == len(text) {
+			pieces = append(pieces, lineWord{end: len(text), start: piece_start})
+			break
 		}
-		requests = append(requests, request)
-		// increment the offset by the length of the current_text
-		offset += len(current_text)
+
+		piece_end, sentence_end := -1, -1
+		for next < len(words) && words[next].end <= piece_start {
+			next++
+		}
+		for i := next; i < len(words) && words[i].end <= limit_end; i++ {
+			piece_end = words[i].end
+			if isSentenceEnd(text[words[i].start:words[i].end]) {
+				sentence_end = words[i].end
+			}
+		}
+		if sentence_end > piece_start && 2*utf8.RuneCountInString(text[piece_start:sentence_end]) >= limit {
+			piece_end = sentence_end
+		}
+		if piece_end <= piece_start {
+			// split a word that is too long between characters
+			piece_end = limit_end
+		}
+		// keep the whitespace after the piece within the piece if it fits
+		for piece_end < limit_end {
+			r, size := utf8.DecodeRuneInString(text[piece_end:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			piece_end += size
+		}
+		pieces = append(pieces, lineWord{end: piece_end, start: piece_start})
+		piece_start = piece_end
 	}
 
-	return
+	return pieces
+}
+
+// isSentenceEnd() function returns true if the word ends a sentence, which
+// is a word that ends with a full stop, question mark or exclamation mark
+// (optionally followed by closing quotes or brackets), unless the word is a
+// common abbreviation (e.g. "Dr.") or an initial (e.g. "J.") that is likely
+// to be followed by a name.
+func isSentenceEnd(word string) bool {
+	word = strings.TrimRight(word, SentenceClosers)
+	last, size := utf8.DecodeLastRuneInString(word)
+	if !strings.ContainsRune(SentenceTerminators, last) {
+		return false
+	}
+	if last != '.' {
+		return true
+	}
+	stem := word[:len(word)-size]
+	if utf8.RuneCountInString(stem) == 1 {
+		return false
+	}
+
+	return !SentenceAbbreviations[strings.ToLower(stem)]
+}
+
+// runeOffset() function returns the byte position within the text that is
+// count characters after the start position, or the length of the text if
+// the text ends first.
+func runeOffset(text string, start int, count int) int {
+	for i := range text[start:] {
+		if count == 0 {
+			return start + i
+		}
+		count--
+	}
+
+	return len(text)
+}
+
+// lineWord struct is the position of a single word within a line.
+type lineWord struct {
+	end   int
+	start int
+}
+
+// lineWords() function returns the position of each word in the line, where
+// words are separated by whitespace (as with bufio.ScanWords).
+func lineWords(line string) []lineWord {
+	words := make([]lineWord, 0)
+	start := -1
+	for i, r := range line {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, lineWord{end: i, start: start})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, lineWord{end: len(line), start: start})
+	}
+
+	return words
+}
+
+// newChunkRequest() function initializes a new Request for a chunk of text
+// found at the provided offset within the source object.
+func newChunkRequest(repo_id, commit_id, object_id, text string, offset int) (Request, error) {
+	request, err := NewRequest(repo_id, commit_id, object_id, text)
+	if err != nil {
+		return request, err
+	}
+	request.Object.Length = len(text)
+	request.Object.Offset = offset
+
+	return request, nil
 }
@@ -1,9 +1,16 @@
 package rrr
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
 )
 
 var (
@@ -64,6 +71,275 @@ func TestChunkFileToRequests(t *testing.T) {
 	}
 }
 
+// testNewFile() helper function returns a new object.File with the provided
+// name and contents, backed by an in-memory blob.
+func testNewFile(t *testing.T, name, contents string) *object.File {
+	memory_object := &plumbing.MemoryObject{}
+	memory_object.SetType(plumbing.BlobObject)
+	_, err := memory_object.Write([]byte(contents))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	blob, err := object.DecodeBlob(memory_object)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return object.NewFile(name, filemode.Regular, blob)
+}
+
+// TestChunkFileToRequests_Rebuild unit test function tests that the text of
+// the requests generated by the ChunkFileToRequests() function can be used
+// to rebuild the original file contents.
+func TestChunkFileToRequests_Rebuild(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contents      string
+		max_chunk     int
+		name          string
+		expected_reqs int
+	}{
+		{contents: "Lorem ipsum\nJohn Smith\n", max_chunk: 100, name: "Single_Chunk", expected_reqs: 1},
+		{contents: "line one\r\nline two\r\nline three", max_chunk: 20, name: "CRLF_Lines", expected_reqs: 3},
+		{contents: "\n\n" + test_chunk_line_text_2 + "\n", max_chunk: 1000, name: "Long_Lines", expected_reqs: 10},
+		{contents: "  leading  and   trailing   spaces  \n", max_chunk: 10, name: "Whitespace", expected_reqs: 5},
+		{contents: "Ünïcödé téxt wïth äccénts\n", max_chunk: 14, name: "Non_ASCII", expected_reqs: 2},
+		{contents: strings.Repeat("0123456789", 5), max_chunk: 20, name: "Long_Word", expected_reqs: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests, err := ChunkFileToRequests(ChunkFileInput{
+				CommitID:     "test_commit",
+				File:         testNewFile(t, "docs/test.txt", test.contents),
+				MaxChunkSize: test.max_chunk,
+				RepoID:       "test_repo",
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Len(t, requests, test.expected_reqs)
+
+			var rebuilt strings.Builder
+			for _, request := range requests {
+				// every request should be positioned directly after the
+				// previous request within the file
+				assert.Equal(t, rebuilt.Len(), request.Object.Offset)
+				assert.Equal(t, len(request.Text), request.Object.Length)
+				assert.Equal(t, "docs/test.txt", request.Object.Path)
+				// every request is less than the max chunk size in characters
+				assert.Less(t, utf8.RuneCountInString(request.Text), test.max_chunk)
+				assert.Nil(t, request.Object.Overlap)
+				rebuilt.WriteString(request.Text)
+			}
+			assert.Equal(t, test.contents, rebuilt.String())
+		})
+	}
+}
+
+// TestChunkFileToRequests_Overlap() unit test function tests that the text of
+// each request includes the end of the previous request and the start of the
+// next request, without splitting words, and that results within the overlap
+// are dropped from the response of the request.
+func TestChunkFileToRequests_Overlap(t *testing.T) {
+	t.Parallel()
+
+	contents := "Seen by Dr. Jones on Monday.\nReferred to John Smith at 12 Main Street.\n"
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         testNewFile(t, "docs/visit.txt", contents),
+		MaxChunkSize: 71,
+		Overlap:      12,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, requests, 2) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "Seen by Dr. Jones on Monday.\nReferred to", requests[0].Text)
+	assert.Equal(t, &Overlap{After: 11, Before: 0, Length: 40}, requests[0].Object.Overlap)
+	assert.Equal(t, "on Monday.\nReferred to John Smith at 12 Main Street.\n", requests[1].Text)
+	assert.Equal(t, &Overlap{After: 0, Before: 11, Length: 53}, requests[1].Object.Overlap)
+	var rebuilt strings.Builder
+	for _, request := range requests {
+		assert.LessOrEqual(t, utf8.RuneCountInString(request.Text), 71)
+		assert.Equal(t, contents[request.Object.Offset:request.Object.Offset+request.Object.Length], request.Text)
+		// the text that is not copied from the neighbouring requests
+		overlap := request.Object.Overlap
+		rebuilt.WriteString(string([]rune(request.Text)[overlap.Before : overlap.Length-overlap.After]))
+	}
+	assert.Equal(t, contents, rebuilt.String())
+
+	// contents that fit in one request are not split by the overlap
+	requests_single, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         testNewFile(t, "docs/visit.txt", contents),
+		MaxChunkSize: 72,
+		Overlap:      12,
+		RepoID:       "test_repo",
+	})
+	if assert.NoError(t, err) && assert.Len(t, requests_single, 1) {
+		assert.Equal(t, contents, requests_single[0].Text)
+		assert.Nil(t, requests_single[0].Object.Overlap)
+	}
+
+	// "Monday" is detected by both requests, but only kept by the first
+	response := NewResponse(&requests[1])
+	response.Results = []Result{
+		{Category: "DateTime", Offset: 3, Length: 6, Text: "Monday"},
+		{Category: "Person", Offset: 23, Length: 10, Text: "John Smith"},
+	}
+	response.DropOverlapResults()
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "John Smith", response.Results[0].Text)
+	}
+
+	_, err = ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         testNewFile(t, "docs/visit.txt", contents),
+		MaxChunkSize: 80,
+		Overlap:      40,
+		RepoID:       "test_repo",
+	})
+	assert.ErrorIs(t, err, ErrChunkOverlapInvalid)
+}
+
+// Test_splitText() unit test function tests that the splitText() function
+// prefers to split text after the end of a sentence.
+func Test_splitText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expected []string
+		max_size int
+		name     string
+		text     string
+	}{
+		{
+			expected: []string{"First sentence here. ", "Second one follows."},
+			max_size: 30,
+			name:     "Sentence",
+			text:     "First sentence here. Second one follows.",
+		},
+		{
+			expected: []string{"Patient seen by Dr. Jones ", "today."},
+			max_size: 30,
+			name:     "Abbreviation",
+			text:     "Patient seen by Dr. Jones today.",
+		},
+		{
+			expected: []string{"Short. A much longer sentence ", "follows."},
+			max_size: 31,
+			name:     "ShortSentence",
+			text:     "Short. A much longer sentence follows.",
+		},
+		{
+			expected: []string{"ééééé", "ééééé", "éé"},
+			max_size: 6,
+			name:     "LongWord",
+			text:     "éééééééééééé",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual []string
+			for _, piece := range splitText(test.text, test.max_size) {
+				actual = append(actual, test.text[piece.start:piece.end])
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// TestChunkFileToRequests_Extracted() unit test function tests that the text
+// extracted from a document is chunked instead of the contents of the file,
+// with offsets within the extracted text.
+func TestChunkFileToRequests_Extracted(t *testing.T) {
+	t.Parallel()
+
+	extracted := "Patient:\tJohn Smith\nDOB 1970-01-01\n"
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		ContentType:  "application/pdf",
+		File:         testNewFile(t, "docs/visit.pdf", "%PDF-1.4 binary contents"),
+		MaxChunkSize: 24,
+		RepoID:       "test_repo",
+		Text:         extracted,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, requests, 2)
+
+	var rebuilt strings.Builder
+	for _, request := range requests {
+		assert.Equal(t, rebuilt.Len(), request.Object.Offset)
+		assert.Equal(t, "application/pdf", request.Object.ContentType)
+		assert.Equal(t, "docs/visit.pdf", request.Object.Path)
+		rebuilt.WriteString(request.Text)
+	}
+	assert.Equal(t, extracted, rebuilt.String())
+}
+
+// TestChunkFileToRequests_Fields() unit test function tests that the fields
+// of structured data are chunked into "<label>: <value>" lines, where the
+// Fields of each request locate each value within the text of the request
+// and within the file, and long values are split between words.
+func TestChunkFileToRequests_Fields(t *testing.T) {
+	t.Parallel()
+
+	contents := `{"patient": {"name": "Jöhn Smith", "notes": "seen by Dr. Who on 1980-01-02"}}`
+	_, fields, err := structured.Parse("patient.json", []byte(contents))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		Fields:       fields,
+		File:         testNewFile(t, "data/patient.json", contents),
+		Format:       structured.FormatJSON,
+		MaxChunkSize: 40,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	expected := []struct {
+		location string
+		text     string
+	}{
+		{location: "/patient/name", text: "patient.name: Jöhn Smith\n"},
+		{location: "/patient/notes", text: "patient.notes: seen by Dr. Who on \n"},
+		{location: "/patient/notes", text: "patient.notes: 1980-01-02\n"},
+	}
+	if !assert.Len(t, requests, len(expected)) {
+		t.FailNow()
+	}
+	for i, request := range requests {
+		assert.Equal(t, expected[i].text, request.Text)
+		assert.Equal(t, structured.FormatJSON, request.Object.Format)
+		assert.Equal(t, "data/patient.json", request.Object.Path)
+		assert.Equal(t, ObjectTypeFile, request.Object.Type)
+		if !assert.Len(t, request.Fields, 1) {
+			continue
+		}
+		field := request.Fields[0]
+		assert.Equal(t, expected[i].location, field.Location)
+		// the value of the field is the same within the text of the request
+		// and within the file
+		value := string([]rune(request.Text)[field.Offset : field.Offset+field.Length])
+		assert.Equal(t, contents[field.SourceOffset:field.SourceOffset+field.SourceLength], value)
+		assert.Equal(t, field.SourceOffset, request.Object.Offset)
+		assert.Equal(t, field.SourceLength, request.Object.Length)
+	}
+}
+
 // TestChunkLineToRequests unit test function tests the
 // ChunkLineToRequests() function.
 func TestChunkLineToRequests(t *testing.T) {
@@ -135,7 +411,7 @@ func TestChunkLineToRequests(t *testing.T) {
 		{
 			expected_error:        nil,
 			expected_final_offset: len(test_chunk_line_text_2),
-			expected_num_requests: 7,
+			expected_num_requests: 8,
 			in: ChunkLineInput{
 				CommitID:     "test_commit",
 				Line:         test_chunk_line_text_2,
@@ -149,7 +425,7 @@ func TestChunkLineToRequests(t *testing.T) {
 		{
 			expected_error:        nil,
 			expected_final_offset: len(test_chunk_line_text_2) + 333,
-			expected_num_requests: 7,
+			expected_num_requests: 8,
 			in: ChunkLineInput{
 				CommitID:     "test_commit",
 				Line:         test_chunk_line_text_2,
@@ -5,3 +5,62 @@ const (
 	ResultReplaceEmptyElement      string = "###@@@###"
 	ResultSeparatorUID             string = "__"
 )
+
+// Types of the objects that contain the source text of requests, where the
+// metadata of a repository (e.g. commit messages) is scanned in addition to
+// the files of each commit.
+const (
+	ObjectTypeCommit string = "commit"
+	ObjectTypeFile   string = "file"
+	ObjectTypeNote   string = "note"
+	ObjectTypeTag    string = "tag"
+)
+
+// SentenceAbbreviations is a set of the (lower case) abbreviations that end
+// with a full stop without ending a sentence, such as the titles of names.
+var SentenceAbbreviations = map[string]bool{
+	"dr":   true,
+	"e.g":  true,
+	"i.e":  true,
+	"jr":   true,
+	"mr":   true,
+	"mrs":  true,
+	"ms":   true,
+	"no":   true,
+	"prof": true,
+	"sr":   true,
+	"st":   true,
+	"vs":   true,
+}
+
+// SentenceClosers are the characters (e.g. closing quotes) that may follow
+// the end of a sentence, and SentenceTerminators are the characters that end
+// a sentence.
+const (
+	SentenceClosers     string = "\"')]}”’"
+	SentenceTerminators string = ".!?。！？"
+)
+
+// IgnoreMarker is the inline annotation that ignores the results of the line
+// on which it appears (e.g. "# no-phi-ai:ignore category=Person"), where the
+// IgnoreMarkerFile and IgnoreMarkerNextLine suffixes of the marker ignore
+// the results of the whole file and of the next line instead. The results
+// of every category are ignored, unless the categories are listed by one or
+// more IgnoreCategoryOption options (e.g. "category=Person,Email").
+const (
+	IgnoreCategoryOption string = "category="
+	IgnoreMarker         string = "no-phi-ai:ignore"
+	IgnoreMarkerFile     string = "-file"
+	IgnoreMarkerNextLine string = "-next-line"
+)
+
+// PreviewMaskCharacter is the character that masks the text of a protected
+// result, where the first character of each word of at least
+// PreviewMinWordLength characters is kept in the preview, and
+// TextHMACKeyIDMessage is the message of the HMAC that identifies the key of
+// the HMAC of the text of protected results.
+const (
+	PreviewMaskCharacter rune   = '*'
+	PreviewMinWordLength int    = 4
+	TextHMACKeyIDMessage string = "no-phi-ai:key-id"
+)
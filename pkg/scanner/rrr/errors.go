@@ -5,6 +5,7 @@ import "github.com/pkg/errors"
 var (
 	ErrChunkFileToRequestsFailed    = errors.New("failed to chunk non-empty file into one or more requests")
 	ErrChunkFileToRequestsInFileNil = errors.New("cannot chunk input file with nil pointer")
+	ErrChunkOverlapInvalid          = errors.New("chunk overlap must be less than half of the max chunk size")
 	ErrMaxChunkSizeInvalid          = errors.New("invalid max chunk size")
 	ErrNewRequestEmptyCommitID      = errors.New("cannot create a new request with an empty commit ID")
 	ErrNewRequestEmptyObjectID      = errors.New("cannot create a new request with an empty object ID")
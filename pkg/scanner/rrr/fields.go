@@ -0,0 +1,52 @@
+package rrr
+
+// Field struct contains the location of a single value of structured data
+// (e.g. a CSV cell or the value of a JSON key) within the source text of a
+// request, which consists of a "<label>: <value>" line for each value, and
+// within the file from which the value was read.
+type Field struct {
+	// Label is the label of the value within the source text, such as the
+	// key path of a JSON value (e.g. "patient.dob").
+	Label string `json:"label"`
+	// Length is the number of characters (i.e. code points) of the value
+	// within the source text.
+	Length int `json:"length"`
+	// Location is the exact location of the value within the structured
+	// data, such as a JSON pointer, XPath or CSV row and column.
+	Location string `json:"location"`
+	// Offset is the position of the value within the source text, counted
+	// in characters (i.e. code points) like the offsets of results.
+	Offset int `json:"offset"`
+	// SourceLength is the number of bytes of the value within the file.
+	SourceLength int `json:"source_length"`
+	// SourceOffset is the byte position of the value within the file, or -1
+	// if the value does not appear verbatim in the file (e.g. a JSON string
+	// with escape sequences).
+	SourceOffset int `json:"source_offset"`
+}
+
+// FieldIndex() method returns the index of the field of the response that
+// contains the result, or -1 if the response has no fields. A result within
+// the label of a line belongs to the field of that line, since the line of
+// each field ends after its value.
+func (r *Response) FieldIndex(result Result) int {
+	for i, field := range r.Fields {
+		if result.Offset < field.Offset+field.Length {
+			return i
+		}
+	}
+
+	return len(r.Fields) - 1
+}
+
+// LocateResults() method sets the Location of each result of the response
+// to the Location of the field that contains the result, if the response is
+// for the fields of structured data.
+func (r *Response) LocateResults() {
+	if len(r.Fields) == 0 {
+		return
+	}
+	for i := range r.Results {
+		r.Results[i].Location = r.Fields[r.FieldIndex(r.Results[i])].Location
+	}
+}
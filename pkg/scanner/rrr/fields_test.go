@@ -0,0 +1,38 @@
+package rrr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResponse_LocateResults() unit test function tests that LocateResults()
+// sets the Location of each result to the Location of the field of the line
+// that contains the result, including results within the label of a line.
+func TestResponse_LocateResults(t *testing.T) {
+	t.Parallel()
+
+	// "name: John Smith\ndob: 1980-01-02\n"
+	response := Response{
+		Fields: []Field{
+			{Label: "name", Length: 10, Location: "row 2, column 1", Offset: 6, SourceOffset: 9},
+			{Label: "dob", Length: 10, Location: "row 2, column 2", Offset: 22, SourceOffset: 20},
+		},
+		Results: []Result{
+			{Length: 10, Offset: 6, Text: "John Smith"},
+			{Length: 3, Offset: 17, Text: "dob"},
+			{Length: 10, Offset: 22, Text: "1980-01-02"},
+		},
+	}
+	response.LocateResults()
+
+	assert.Equal(t, "row 2, column 1", response.Results[0].Location)
+	assert.Equal(t, "row 2, column 2", response.Results[1].Location)
+	assert.Equal(t, "row 2, column 2", response.Results[2].Location)
+	assert.Equal(t, 1, response.FieldIndex(Result{Offset: 40}))
+
+	plain := Response{Results: []Result{{Length: 4, Offset: 0, Text: "John"}}}
+	plain.LocateResults()
+	assert.Equal(t, "", plain.Results[0].Location)
+	assert.Equal(t, -1, plain.FieldIndex(plain.Results[0]))
+}
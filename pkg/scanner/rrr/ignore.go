@@ -0,0 +1,196 @@
+package rrr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IgnoreRange struct contains a range of the source text of a request in
+// which the results are ignored, as declared by an inline annotation (e.g.
+// "no-phi-ai:ignore") of the file that contains the source text.
+type IgnoreRange struct {
+	// Categories contains the categories of the results that are ignored,
+	// or is empty if the results of every category are ignored.
+	Categories []string `json:"categories,omitempty"`
+	// Length is the number of characters (i.e. code points) of the range.
+	Length int `json:"length"`
+	// Offset is the position of the range within the source text, counted
+	// in characters (i.e. code points) like the offsets of results.
+	Offset int `json:"offset"`
+}
+
+// Matches() method returns true if the result starts within the range and
+// has one of the categories of the range.
+func (ir IgnoreRange) Matches(result Result) bool {
+	if result.Offset < ir.Offset || result.Offset >= ir.Offset+ir.Length {
+		return false
+	}
+	if len(ir.Categories) == 0 {
+		return true
+	}
+	for _, category := range ir.Categories {
+		if strings.EqualFold(category, result.Category) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DropIgnoredResults() method removes the results of the response that are
+// matched by any of its Ignore ranges, and returns the number of removed
+// results. The results of the response are replaced by a new slice, so that
+// the results are not changed for other copies of the response.
+func (r *Response) DropIgnoredResults() int {
+	if len(r.Ignore) == 0 || len(r.Results) == 0 {
+		return 0
+	}
+	results := make([]Result, 0, len(r.Results))
+	for _, result := range r.Results {
+		ignored := false
+		for _, ignore := range r.Ignore {
+			if ignore.Matches(result) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			results = append(results, result)
+		}
+	}
+	dropped := len(r.Results) - len(results)
+	r.Results = results
+
+	return dropped
+}
+
+// ignoreAnnotation struct contains the (byte) position of the contents of
+// a file in which results are ignored by an inline annotation.
+type ignoreAnnotation struct {
+	categories []string
+	end        int
+	start      int
+}
+
+// parseIgnoreAnnotations() function returns the ranges of the contents that
+// are ignored by the inline annotations of the contents.
+func parseIgnoreAnnotations(contents string) []ignoreAnnotation {
+	if !strings.Contains(contents, IgnoreMarker) {
+		return nil
+	}
+
+	var annotations []ignoreAnnotation
+	// next_line contains the categories of the annotations of the previous
+	// line that ignore the results of the current line
+	var next_line [][]string
+	offset := 0
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		if line == "" {
+			continue
+		}
+		for _, categories := range next_line {
+			annotations = append(annotations, ignoreAnnotation{categories: categories, end: offset + len(line), start: offset})
+		}
+		next_line = nil
+
+		suffix, categories, ok := parseIgnoreMarker(line)
+		switch {
+		case !ok:
+		case suffix == IgnoreMarkerFile:
+			annotations = append(annotations, ignoreAnnotation{categories: categories, end: len(contents), start: 0})
+		case suffix == IgnoreMarkerNextLine:
+			next_line = append(next_line, categories)
+		default:
+			annotations = append(annotations, ignoreAnnotation{categories: categories, end: offset + len(line), start: offset})
+		}
+		offset += len(line)
+	}
+
+	return annotations
+}
+
+// parseIgnoreMarker() function returns the suffix of the IgnoreMarker in the
+// line (e.g. IgnoreMarkerNextLine) along with the categories listed by the
+// options that follow the marker, if the line contains a valid marker.
+func parseIgnoreMarker(line string) (suffix string, categories []string, ok bool) {
+	i := strings.Index(line, IgnoreMarker)
+	if i < 0 {
+		return
+	}
+	rest := line[i+len(IgnoreMarker):]
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return r != '-' && !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	if marker := rest[:end]; marker != "" && marker != IgnoreMarkerFile && marker != IgnoreMarkerNextLine {
+		return
+	}
+	suffix, rest, ok = rest[:end], rest[end:], true
+
+	// the options follow the marker until the first word that is not an
+	// option (e.g. the end of a comment)
+	for _, word := range strings.Fields(rest) {
+		if !strings.HasPrefix(word, IgnoreCategoryOption) {
+			break
+		}
+		for _, category := range strings.Split(word[len(IgnoreCategoryOption):], ",") {
+			category = strings.TrimFunc(category, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if category != "" {
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	return
+}
+
+// ignoreRanges() function returns the ranges of the text of the request at
+// the span of the contents that are ignored by the annotations.
+func ignoreRanges(contents string, span lineWord, annotations []ignoreAnnotation) []IgnoreRange {
+	var ranges []IgnoreRange
+	for _, annotation := range annotations {
+		start := max(annotation.start, span.start)
+		end := min(annotation.end, span.end)
+		if start >= end {
+			continue
+		}
+		ranges = append(ranges, IgnoreRange{
+			Categories: annotation.categories,
+			Length:     utf8.RuneCountInString(contents[start:end]),
+			Offset:     utf8.RuneCountInString(contents[span.start:start]),
+		})
+	}
+
+	return ranges
+}
+
+// fieldIgnoreRanges() function returns the ranges of the text of a request
+// for the fields of structured data that are ignored by the annotations,
+// which is the line of each field whose value starts within an annotated
+// range of the file. Fields whose values do not appear verbatim in the file
+// cannot be ignored.
+func fieldIgnoreRanges(fields []Field, annotations []ignoreAnnotation) []IgnoreRange {
+	var ranges []IgnoreRange
+	line_start := 0
+	for _, field := range fields {
+		line_end := field.Offset + field.Length
+		for _, annotation := range annotations {
+			if field.SourceOffset < annotation.start || field.SourceOffset >= annotation.end {
+				continue
+			}
+			ranges = append(ranges, IgnoreRange{
+				Categories: annotation.categories,
+				Length:     line_end - line_start,
+				Offset:     line_start,
+			})
+		}
+		line_start = line_end
+	}
+
+	return ranges
+}
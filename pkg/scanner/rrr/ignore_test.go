@@ -0,0 +1,132 @@
+package rrr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
+)
+
+// Test_parseIgnoreMarker() unit test function tests the parseIgnoreMarker()
+// function.
+func Test_parseIgnoreMarker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		categories []string
+		line       string
+		name       string
+		ok         bool
+		suffix     string
+	}{
+		{line: "John Smith\n", name: "None"},
+		{line: "name = 'John Smith' # no-phi-ai:ignore\n", name: "Line", ok: true},
+		{line: "// no-phi-ai:ignore-next-line category=Person\n", name: "Next_Line", ok: true, suffix: IgnoreMarkerNextLine, categories: []string{"Person"}},
+		{line: "<!-- no-phi-ai:ignore-file category=Person,Email category=Address -->\n", name: "File", ok: true, suffix: IgnoreMarkerFile, categories: []string{"Person", "Email", "Address"}},
+		{line: "/* no-phi-ai:ignore synthetic data category=Person */\n", name: "Options_After_Text", ok: true},
+		{line: "<!--no-phi-ai:ignore category=Person-->\n", name: "No_Spaces", ok: true, categories: []string{"Person"}},
+		{line: "# no-phi-ai:ignored\n", name: "Unknown_Suffix"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suffix, categories, ok := parseIgnoreMarker(test.line)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.suffix, suffix)
+			assert.Equal(t, test.categories, categories)
+		})
+	}
+}
+
+// TestChunkFileToRequests_Ignore() unit test function tests that the Ignore
+// ranges of requests are set by the inline annotations of the file, and that
+// the results within those ranges are dropped from the responses.
+func TestChunkFileToRequests_Ignore(t *testing.T) {
+	t.Parallel()
+
+	contents := "# no-phi-ai:ignore-next-line category=Person\n" +
+		"Jöhn Smith, 555-01-2345\n" +
+		"Jane Doe # no-phi-ai:ignore\n" +
+		"Joe Bloggs\n"
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         testNewFile(t, "docs/test.txt", contents),
+		MaxChunkSize: 70,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, requests, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, []IgnoreRange{{Categories: []string{"Person"}, Length: 24, Offset: 45}}, requests[0].Ignore)
+	assert.Equal(t, []IgnoreRange{{Length: 28, Offset: 0}}, requests[1].Ignore)
+
+	response := NewResponse(&requests[0])
+	response.Results = []Result{
+		{Category: "Person", Offset: 45, Length: 10, Text: "Jöhn Smith"},
+		{Category: "USSocialSecurityNumber", Offset: 57, Length: 11, Text: "555-01-2345"},
+	}
+	assert.Equal(t, 1, response.DropIgnoredResults())
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "USSocialSecurityNumber", response.Results[0].Category)
+	}
+	response = NewResponse(&requests[1])
+	response.Results = []Result{
+		{Category: "Person", Offset: 0, Length: 8, Text: "Jane Doe"},
+		{Category: "Person", Offset: 28, Length: 10, Text: "Joe Bloggs"},
+	}
+	assert.Equal(t, 1, response.DropIgnoredResults())
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "Joe Bloggs", response.Results[0].Text)
+	}
+
+	// a file without annotations has no Ignore ranges
+	requests, err = ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		File:         testNewFile(t, "docs/test.txt", "Joe Bloggs\n"),
+		MaxChunkSize: 70,
+		RepoID:       "test_repo",
+	})
+	if assert.NoError(t, err) && assert.Len(t, requests, 1) {
+		assert.Nil(t, requests[0].Ignore)
+	}
+}
+
+// TestChunkFileToRequests_IgnoreFields() unit test function tests that the
+// Ignore ranges of requests for the fields of structured data cover the
+// lines of the fields whose values are on annotated lines.
+func TestChunkFileToRequests_IgnoreFields(t *testing.T) {
+	t.Parallel()
+
+	contents := "patient:\n" +
+		"  # no-phi-ai:ignore-next-line\n" +
+		"  name: John Smith\n" +
+		"  doctor: Jane Doe\n"
+	_, fields, err := structured.Parse("patient.yaml", []byte(contents))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	requests, err := ChunkFileToRequests(ChunkFileInput{
+		CommitID:     "test_commit",
+		Fields:       fields,
+		File:         testNewFile(t, "data/patient.yaml", contents),
+		Format:       structured.FormatYAML,
+		MaxChunkSize: 100,
+		RepoID:       "test_repo",
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, requests, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "patient.name: John Smith\npatient.doctor: Jane Doe\n", requests[0].Text)
+	assert.Equal(t, []IgnoreRange{{Length: 24, Offset: 0}}, requests[0].Ignore)
+
+	response := NewResponse(&requests[0])
+	response.Results = []Result{
+		{Category: "Person", Offset: 14, Length: 10, Text: "John Smith"},
+		{Category: "Person", Offset: 40, Length: 8, Text: "Jane Doe"},
+	}
+	assert.Equal(t, 1, response.DropIgnoredResults())
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "Jane Doe", response.Results[0].Text)
+	}
+}
@@ -0,0 +1,119 @@
+package rrr
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Overlap struct contains the number of characters (i.e. code points) at the
+// start and at the end of the source text of a request that are copied from
+// the text of the previous and the next requests for the same object, which
+// are included to keep the context of the entities at the boundaries between
+// requests.
+type Overlap struct {
+	// After is the number of characters at the end of the source text that
+	// are copied from the next request.
+	After int `json:"after"`
+	// Before is the number of characters at the start of the source text
+	// that are copied from the previous request.
+	Before int `json:"before"`
+	// Length is the number of characters of the source text.
+	Length int `json:"length"`
+}
+
+// DropOverlapResults() method removes the results of the response that start
+// within the overlap with the previous or the next request, since each of
+// those results belongs to the neighbouring request, which detects the same
+// entity within the text that is not copied from another request. The
+// results of the response are replaced by a new slice, so that the results
+// are not changed for other copies of the response.
+func (r *Response) DropOverlapResults() {
+	if r.Object.Overlap == nil {
+		return
+	}
+	end := r.Object.Overlap.Length - r.Object.Overlap.After
+	results := make([]Result, 0, len(r.Results))
+	for _, result := range r.Results {
+		if result.Offset >= r.Object.Overlap.Before && result.Offset < end {
+			results = append(results, result)
+		}
+	}
+	r.Results = results
+}
+
+// overlapSpan() function returns the position of the text of the request for
+// the chunk at index i of the spans of the contents, which is the chunk with
+// up to overlap characters of the previous and the next chunks, along with
+// the Overlap of the request, or nil if the text has no overlap. The overlap
+// does not start or end within a word, unless the word is too long for the
+// overlap.
+func overlapSpan(contents string, spans []lineWord, i int, overlap int) (lineWord, *Overlap) {
+	span := spans[i]
+	if overlap <= 0 {
+		return span, nil
+	}
+
+	start := span.start
+	if i > 0 {
+		// move back by up to overlap characters of the previous chunk
+		for n := 0; n < overlap && start > spans[i-1].start; n++ {
+			_, size := utf8.DecodeLastRuneInString(contents[:start])
+			start -= size
+		}
+		// then forward to the start of a word
+		if cut := wordStart(contents, start, span.start); cut < span.start {
+			start = cut
+		}
+	}
+	end := span.end
+	if i < len(spans)-1 {
+		// move forward by up to overlap characters of the next chunk
+		for n := 0; n < overlap && end < spans[i+1].end; n++ {
+			_, size := utf8.DecodeRuneInString(contents[end:])
+			end += size
+		}
+		// then back to the end of a word
+		if cut := wordEnd(contents, span.end, end); cut > span.end {
+			end = cut
+		}
+	}
+	if start == span.start && end == span.end {
+		return span, nil
+	}
+
+	return lineWord{end: end, start: start}, &Overlap{
+		After:  utf8.RuneCountInString(contents[span.end:end]),
+		Before: utf8.RuneCountInString(contents[start:span.start]),
+		Length: utf8.RuneCountInString(contents[start:end]),
+	}
+}
+
+// wordStart() function returns the first position from start (up to limit)
+// that is not within a word, or limit if there is no such position.
+func wordStart(contents string, start int, limit int) int {
+	for start < limit {
+		before, _ := utf8.DecodeLastRuneInString(contents[:start])
+		if start == 0 || unicode.IsSpace(before) {
+			return start
+		}
+		_, size := utf8.DecodeRuneInString(contents[start:])
+		start += size
+	}
+
+	return limit
+}
+
+// wordEnd() function returns the last position before end (down to limit)
+// that is not within a word, or limit if there is no such position.
+func wordEnd(contents string, limit int, end int) int {
+	for end > limit {
+		after, _ := utf8.DecodeRuneInString(contents[end:])
+		if end == len(contents) || unicode.IsSpace(after) {
+			return end
+		}
+		_, size := utf8.DecodeLastRuneInString(contents[:end])
+		end -= size
+	}
+
+	return limit
+}
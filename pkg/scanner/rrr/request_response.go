@@ -27,14 +27,39 @@ type MetadataRequestResponseCommit struct {
 }
 
 type MetadataRequestResponseObject struct {
+	// ContentType is the content (MIME) type of the document from which the
+	// source text was extracted (e.g. a PDF or DOCX file), which is empty
+	// when the source text is the contents of the file itself. Offset and
+	// Length are positions within the extracted text of the document when
+	// ContentType is set.
+	ContentType string `json:"content_type,omitempty"`
+	// Format is the format (e.g. "csv" or "json") of the structured data of
+	// the file when the source text consists of the fields of the data, as
+	// a "<label>: <value>" line for each field, instead of the contents of
+	// the file. Results are located within the file by the Fields of the
+	// response when Format is set, and Offset and Length are the span of
+	// the file that contains the values of the fields.
+	Format string `json:"format,omitempty"`
 	// ID is the string version of the file's SHA1 hash, which is unique
 	// to the file's content and context (e.g. repository, commit, etc.)
 	ID string `json:"id"`
-	// Length is the number of characters in the source text.
+	// Length is the number of bytes in the source text.
 	Length int `json:"length"`
-	// Offset is the starting character position of the source text within its
+	// Offset is the starting byte position of the source text within its
 	// original context (e.g. offset fromn start of file).
 	Offset int `json:"offset"`
+	// Overlap contains the number of characters of the source text that are
+	// copied from the previous and the next requests for the same object,
+	// if any. Offset and Length include the overlap.
+	Overlap *Overlap `json:"overlap,omitempty"`
+	// Path is the path of the file within the repository at the time of the
+	// associated commit, or the name of the ref (e.g. tag) of the metadata
+	// of the repository that contains the source text.
+	Path string `json:"path"`
+	// Type is the type of the object that contains the source text, which is
+	// one of the ObjectType constants (e.g. "file" or "commit"). The ID of
+	// an object that is not a file is the hash of the commit, tag or note.
+	Type string `json:"type,omitempty"`
 }
 
 type MetadataRequestResponseRepository struct {
@@ -59,6 +84,12 @@ type Request struct {
 	// embed the MetadataRequestResponse struct
 	MetadataRequestResponse
 
+	// Fields contains the location of each value of structured data within
+	// the Text, if the Text consists of the fields of structured data.
+	Fields []Field `json:"fields,omitempty"`
+	// Ignore contains the ranges of the Text in which results are ignored,
+	// as declared by the inline annotations of the file.
+	Ignore []IgnoreRange `json:"ignore,omitempty"`
 	// Text is the source text to be scanned for PHI/PII data and is only
 	// included in the Request (not the Response) object in order to limit the
 	// size of the response and the exposure of the source text.
@@ -107,6 +138,19 @@ func NewRequest(repo_id, commit_id, object_id, text string) (Request, error) {
 type Response struct {
 	// embed the MetadataRequestResponse struct
 	MetadataRequestResponse
+	// Error is a (non-empty) description of the failure when the detection
+	// service was unable to process the associated request.
+	Error string `json:"error,omitempty"`
+	// Fields contains the location of each value of structured data within
+	// the source text, copied from the associated request.
+	Fields []Field `json:"fields,omitempty"`
+	// Ignore contains the ranges of the source text in which results are
+	// ignored, copied from the associated request.
+	Ignore []IgnoreRange `json:"ignore,omitempty"`
+	// RedactedText is the source text of the associated request with the
+	// text of every detected entity masked, when provided by the detection
+	// service.
+	RedactedText string `json:"redactedText,omitempty"`
 	// Results is a slice of detection results from the detection services.
 	Results []Result `json:"results"`
 }
@@ -116,10 +160,21 @@ type Response struct {
 func NewResponse(request *Request) Response {
 	return Response{
 		MetadataRequestResponse: request.MetadataRequestResponse,
+		Fields:                  request.Fields,
+		Ignore:                  request.Ignore,
 		Results:                 make([]Result, 0),
 	}
 }
 
+// NewErrorResponse() function initializes a new Response object from the
+// provided Request for the case where the request could not be processed.
+func NewErrorResponse(request *Request, message string) Response {
+	response := NewResponse(request)
+	response.Error = message
+
+	return response
+}
+
 // RequestResponsePhiDetector interface defines the inputs of the
 // Run() method, which is used to process Requests sent from
 // the Scanner and to send Responses back to the Scanner.
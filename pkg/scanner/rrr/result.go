@@ -18,6 +18,11 @@ type Result struct {
 	ConfidenceScore float64 `json:"confidenceScore"`
 	// Length is the number of characters in the result text.
 	Length int `json:"length"`
+	// Location is the location of the value of structured data (e.g. a JSON
+	// pointer, XPath or CSV row and column) that contains the result text,
+	// which is only set for results of structured data. Location is not
+	// part of the String() of the result.
+	Location string `json:"location,omitempty"`
 	// Offset is the start position of the result text within the source
 	// text, which may have its own offset.
 	Offset int `json:"offset"`
@@ -26,8 +31,13 @@ type Result struct {
 	Service string `json:"service"`
 	// Subcategory is the (optional) result sub-type.
 	Subcategory string `json:"subcategory"`
-	// Text is the result text as it appears in the document
+	// Text is the result text as it appears in the document, or a masked
+	// preview of the text if the result is protected (see Protect()).
 	Text string `json:"text"`
+	// TextHMAC is the keyed HMAC of the result text, which is only set if
+	// the result is protected, in which case the HMAC replaces the Text in
+	// the String() of the result.
+	TextHMAC string `json:"textHmac,omitempty"`
 }
 
 // Hash() method returns a unique identifier for the result, which is a
@@ -52,12 +62,21 @@ func (r Result) String(repo_id, commit_id, object_id string) string {
 		noEmpty(strconv.Itoa(r.Offset)),
 		noEmpty(r.Service),
 		noEmpty(r.Subcategory),
-		noEmpty(r.Text),
+		noEmpty(r.text()),
 	}
 	// join the elements into a single string
 	return strings.Join(elements, ResultSeparatorUID)
 }
 
+// text() method returns the TextHMAC of the result if the result is
+// protected, or else the Text of the result.
+func (r Result) text() string {
+	if r.TextHMAC != "" {
+		return r.TextHMAC
+	}
+	return r.Text
+}
+
 // ResultRecord struct embeds structs containing all fields required to
 // store a result record in the database, including metadata from the
 // request/response and the result itself.
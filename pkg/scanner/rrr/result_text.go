@@ -0,0 +1,74 @@
+package rrr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode"
+)
+
+// MaskPreview() function returns a preview of the text in which every
+// character of each word is masked by PreviewMaskCharacter, except for the
+// first letter of words of at least PreviewMinWordLength characters (e.g.
+// "John Smith" is "J*** S****"), such that the preview can be used to review
+// a result without exposing its text. Digits are always masked.
+func MaskPreview(text string) string {
+	runes := []rune(text)
+	word_start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			continue
+		}
+		// mask the word that ends at i
+		keep := word_start
+		if i-word_start >= PreviewMinWordLength && unicode.IsLetter(runes[word_start]) {
+			keep++
+		}
+		for j := keep; j < i; j++ {
+			runes[j] = PreviewMaskCharacter
+		}
+		word_start = i + 1
+	}
+
+	return string(runes)
+}
+
+// TextHMAC() function returns the hex-encoded HMAC-SHA256 of the text with
+// the provided key.
+func TextHMAC(key []byte, text string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(text))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TextHMACKeyID() function returns an identifier of the key of TextHMAC(),
+// which changes with the key without exposing the key.
+func TextHMACKeyID(key []byte) string {
+	return TextHMAC(key, TextHMACKeyIDMessage)[:16]
+}
+
+// Protect() method replaces the Text of the result with a masked preview of
+// the text, and keeps the TextHMAC of the text with the provided key, such
+// that results with the same text can still be matched without storing the
+// text. Results that are already protected are unchanged.
+func (r *Result) Protect(key []byte) {
+	if r.TextHMAC != "" {
+		return
+	}
+	r.TextHMAC = TextHMAC(key, r.Text)
+	r.Text = MaskPreview(r.Text)
+}
+
+// ProtectResults() method protects the text of every result of the response
+// by the Protect() method of the result. The results of the response are
+// replaced by a new slice, so that the results are not changed for other
+// copies of the response (e.g. the copy used to redact files).
+func (r *Response) ProtectResults(key []byte) {
+	results := make([]Result, len(r.Results))
+	for i, result := range r.Results {
+		result.Protect(key)
+		results[i] = result
+	}
+	r.Results = results
+}
@@ -0,0 +1,53 @@
+package rrr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaskPreview() unit test function tests the MaskPreview() function.
+func TestMaskPreview(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":            "",
+		"John Smith":  "J*** S****",
+		"Jo Ng":       "** **",
+		"555-01-2345": "***-**-****",
+		"jöhn@x.org":  "j***@*.***",
+	}
+	for text, expected := range tests {
+		assert.Equal(t, expected, MaskPreview(text), text)
+	}
+}
+
+// TestResponse_ProtectResults() unit test function tests that the results of
+// a response are protected without changing the results of other copies of
+// the response.
+func TestResponse_ProtectResults(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	response := Response{Results: []Result{{Category: "Person", Length: 10, Offset: 4, Text: "John Smith"}}}
+	original := response
+	raw_hash := response.Results[0].Hash("test_repo", "test_commit", "test_object")
+
+	response.ProtectResults(key)
+	result := response.Results[0]
+	assert.Equal(t, "J*** S****", result.Text)
+	assert.Equal(t, TextHMAC(key, "John Smith"), result.TextHMAC)
+	assert.Equal(t, "John Smith", original.Results[0].Text)
+	// the hash of a protected result does not depend on its preview
+	assert.NotEqual(t, raw_hash, result.Hash("test_repo", "test_commit", "test_object"))
+	assert.NotContains(t, result.String("test_repo", "test_commit", "test_object"), "John Smith")
+
+	// protecting a result again does not change it
+	response.ProtectResults(key)
+	assert.Equal(t, result, response.Results[0])
+
+	// the HMAC depends on the key
+	assert.NotEqual(t, TextHMAC([]byte("another key"), "John Smith"), result.TextHMAC)
+	assert.Len(t, TextHMACKeyID(key), 16)
+	assert.NotEqual(t, TextHMACKeyID(key), TextHMACKeyID([]byte("another key")))
+}
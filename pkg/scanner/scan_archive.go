@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/archive"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// scanArchive() method scans each member of the archive with the provided
+// scan function, as a file of the commit with the path of the archive and
+// the path of the member (e.g. "data.zip!/export/patients.csv"), then marks
+// the archive as complete. Members are identified by the hash of their
+// contents, like any other file. An archive that cannot be opened (e.g.
+// corrupt or too large) is marked as failed, and the members of an archive
+// that is nested deeper than the MaxDepth are not scanned. The members of a
+// top-level archive (i.e. a nil budget) and of every archive nested within it
+// are read within a single archive.Budget of the configured limits, such that
+// nested archives (e.g. a zip of zip bombs) cannot exceed the limits.
+func (sr *ScanRepository) scanArchive(
+	commit *object.Commit,
+	file *object.File,
+	format string,
+	budget *archive.Budget,
+	scan func(*object.File, *archive.Budget) error,
+) error {
+	depth := strings.Count(file.Name, archive.PathSeparator) + 1
+	if depth > sr.config.Archives.MaxDepth {
+		sr.logger.Trace().Msgf(
+			"commit %s : skipping scan of archive %s : %s",
+			commit.Hash.String(),
+			file.Hash.String(),
+			IgnoreReasonArchiveTooDeep,
+		)
+		_, err := sr.TrackerFiles.Update(
+			file.Hash.String(),
+			tracker.KeyCodeIgnore,
+			IgnoreReasonArchiveTooDeep,
+			[]string{},
+		)
+		return err
+	}
+
+	if budget == nil {
+		budget = archive.NewBudget(archive.Limits{
+			MaxMembers: sr.config.Archives.MaxMembers,
+			MaxSize:    sr.config.Archives.MaxSize,
+		})
+	}
+	contents, err := file.Contents()
+	var members []archive.Member
+	if err == nil {
+		members, err = archive.Open(format, file.Name, []byte(contents), budget)
+	}
+	if err != nil {
+		// an archive that cannot be opened must not stop the scan of the
+		// other files of the commit
+		sr.logger.Warn().Err(err).Msgf(
+			"commit %s : failed to open archive %s : %s",
+			commit.Hash.String(),
+			file.Hash.String(),
+			file.Name,
+		)
+		_, err = sr.TrackerFiles.Update(
+			file.Hash.String(),
+			tracker.KeyCodeError,
+			err.Error(),
+			[]string{},
+		)
+		return err
+	}
+
+	sr.logger.Debug().Msgf(
+		"commit %s : scanning %d members of archive %s : %s",
+		commit.Hash.String(),
+		len(members),
+		file.Hash.String(),
+		file.Name,
+	)
+	storage := memory.NewStorage()
+	for _, member := range members {
+		member_file, err := newMemberFile(storage, file.Name+archive.PathSeparator+member.Name, member.Contents)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read member %s of archive %s", member.Name, file.Name)
+		}
+		if err := scan(member_file, budget); err != nil {
+			return err
+		}
+	}
+
+	_, err = sr.TrackerFiles.Update(
+		file.Hash.String(),
+		tracker.KeyCodeComplete,
+		"",
+		[]string{},
+	)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, file.Hash.String())
+	}
+
+	return nil
+}
+
+// newMemberFile() function returns a new object.File for a member of an
+// archive with the provided path and contents, where the blob of the file is
+// stored in the provided storage.
+func newMemberFile(storage storer.EncodedObjectStorer, name string, contents []byte) (*object.File, error) {
+	encoded := storage.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+	writer, err := encoded.Writer()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(contents); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	hash, err := storage.SetEncodedObject(encoded)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := object.GetBlob(storage, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewFile(name, filemode.Regular, blob), nil
+}
@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// loadMetadata() method loads the annotated tags and the git notes of the
+// repository by the hash of the commit they refer to, if the scan is
+// configured to scan them along with the metadata of each commit. Tags and
+// notes that cannot be loaded are not scanned.
+func (sr *ScanRepository) loadMetadata() {
+	sr.commit_notes = make(map[plumbing.Hash]*object.File)
+	sr.commit_tags = make(map[plumbing.Hash][]*object.Tag)
+
+	if sr.config.Metadata.Tags {
+		if err := sr.loadTags(); err != nil {
+			sr.logger.Warn().Err(err).Msgf("repository %s : failed to load annotated tags ; scanning without them", sr.URL)
+		}
+	}
+	if sr.config.Metadata.Notes {
+		if err := sr.loadNotes(); err != nil {
+			sr.logger.Warn().Err(err).Msgf("repository %s : failed to load %s ; scanning without them", sr.URL, NotesRef)
+		}
+	}
+}
+
+// loadTags() method loads the annotated tags of the repository that refer
+// to a commit.
+func (sr *ScanRepository) loadTags() error {
+	tags, err := sr.repository.TagObjects()
+	if err != nil {
+		return err
+	}
+
+	return tags.ForEach(func(tag *object.Tag) error {
+		if tag.TargetType == plumbing.CommitObject {
+			sr.commit_tags[tag.Target] = append(sr.commit_tags[tag.Target], tag)
+		}
+		return nil
+	})
+}
+
+// loadNotes() method loads the git notes of the repository, where the path
+// of each note within the tree of the notes ref is the hash of the commit
+// (with or without fanout directories, e.g. "ab/cdef...").
+func (sr *ScanRepository) loadNotes() error {
+	ref, err := sr.repository.Reference(NotesRef, true)
+	if err == plumbing.ErrReferenceNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	commit, err := sr.repository.CommitObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	return tree.Files().ForEach(func(file *object.File) error {
+		name := strings.ReplaceAll(file.Name, "/", "")
+		if plumbing.IsHash(name) {
+			sr.commit_notes[plumbing.NewHash(name)] = file
+		}
+		return nil
+	})
+}
+
+// scanCommitMetadata() method scans the metadata of the commit that is
+// enabled by config, which is the message and the author of the commit, the
+// messages of the annotated tags of the commit and the git notes of the
+// commit. The metadata is sent through the same pipeline as the files of
+// the commit, where each object (i.e. commit, tag or note) is identified by
+// its hash and each value is scanned along with its label.
+func (sr *ScanRepository) scanCommitMetadata(commit *object.Commit) error {
+	metadata := sr.config.Metadata
+
+	// the message and the author of the commit
+	var fields []structured.Field
+	if metadata.Authors {
+		fields = append(fields, signatureField(MetadataLabelAuthor, commit.Author))
+		if commit.Committer.Name != commit.Author.Name || commit.Committer.Email != commit.Author.Email {
+			fields = append(fields, signatureField(MetadataLabelCommitter, commit.Committer))
+		}
+	}
+	if metadata.Messages {
+		fields = append(fields, textFields(MetadataLabelMessage, commit.Message)...)
+	}
+	err := sr.scanMetadataObject(commit, rrr.ChunkObjectInput{
+		Fields:   fields,
+		ObjectID: commit.Hash.String(),
+		Type:     rrr.ObjectTypeCommit,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the messages and the taggers of the annotated tags of the commit
+	for _, tag := range sr.commit_tags[commit.Hash] {
+		fields = nil
+		if metadata.Authors {
+			fields = append(fields, signatureField(MetadataLabelTagger, tag.Tagger))
+		}
+		fields = append(fields, textFields(MetadataLabelMessage, tag.Message)...)
+		err = sr.scanMetadataObject(commit, rrr.ChunkObjectInput{
+			Fields:   fields,
+			ObjectID: tag.Hash.String(),
+			Path:     plumbing.NewTagReferenceName(tag.Name).String(),
+			Type:     rrr.ObjectTypeTag,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// the git notes of the commit
+	if note, ok := sr.commit_notes[commit.Hash]; ok {
+		contents, err := note.Contents()
+		if err != nil {
+			sr.logger.Warn().Err(err).Msgf(
+				"commit %s : failed to read note %s",
+				commit.Hash.String(),
+				note.Hash.String(),
+			)
+			return nil
+		}
+		return sr.scanMetadataObject(commit, rrr.ChunkObjectInput{
+			Fields:   textFields(MetadataLabelNote, contents),
+			ObjectID: note.Hash.String(),
+			Path:     NotesRef.String(),
+			Type:     rrr.ObjectTypeNote,
+		})
+	}
+
+	return nil
+}
+
+// scanMetadataObject() method generates and sends the requests for the
+// fields of an object of the metadata of the commit (e.g. commit message),
+// then marks the object as "pending" like the files of the commit. Objects
+// without any fields or that were already scanned are skipped.
+func (sr *ScanRepository) scanMetadataObject(commit *object.Commit, in rrr.ChunkObjectInput) error {
+	if len(in.Fields) == 0 {
+		return nil
+	}
+
+	code, err := sr.TrackerFiles.Update(
+		in.ObjectID,
+		tracker.KeyCodeInit,
+		"",
+		[]string{},
+	)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, in.ObjectID)
+	}
+	// skip objects that have already been scanned (e.g. a note of a commit
+	// with the same contents as the note of another commit)
+	if code > tracker.KeyCodeInit {
+		return nil
+	}
+
+	sr.logger.Debug().Msgf(
+		"commit %s : scanning %s %s",
+		commit.Hash.String(),
+		in.Type,
+		in.ObjectID,
+	)
+	in.CommitID = commit.Hash.String()
+	in.MaxChunkSize = sr.config.Limits.MaxRequestChunkSize
+	in.RepoID = sr.ID
+	requests, err := rrr.ChunkObjectToRequests(in)
+	if err != nil {
+		sr.TrackerFiles.Update(
+			in.ObjectID,
+			tracker.KeyCodeError,
+			err.Error(),
+			[]string{},
+		)
+		return err
+	}
+
+	var child_keys []string
+	for _, req := range requests {
+		child_keys = append(child_keys, req.ID)
+	}
+	if err = sr.sendRequests(requests); err != nil {
+		return err
+	}
+	_, err = sr.TrackerFiles.Update(
+		in.ObjectID,
+		tracker.KeyCodePending,
+		"",
+		child_keys,
+	)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, in.ObjectID)
+	}
+	_, err = sr.TrackerCommits.Update(
+		commit.Hash.String(),
+		tracker.KeyCodePending,
+		"",
+		[]string{in.ObjectID},
+	)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
+	}
+
+	return nil
+}
+
+// signatureField() function returns a field for the name and email of the
+// signature (e.g. author) of a commit or tag.
+func signatureField(label string, signature object.Signature) structured.Field {
+	value := signature.Name + " <" + signature.Email + ">"
+
+	return structured.Field{
+		Label:    label,
+		Length:   len(value),
+		Location: label,
+		Offset:   -1,
+		Value:    value,
+	}
+}
+
+// textFields() function returns a field for each line of the text (e.g. a
+// commit message) that is not blank, where the location of each field is
+// the line of the text and the offset of each field is the byte position of
+// the line within the text.
+func textFields(label string, text string) []structured.Field {
+	var fields []structured.Field
+	offset := 0
+	for i, line := range strings.SplitAfter(text, "\n") {
+		value := strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(value) != "" {
+			fields = append(fields, structured.Field{
+				Label:    label,
+				Length:   len(value),
+				Location: "line " + strconv.Itoa(i+1),
+				Offset:   offset,
+				Value:    value,
+			})
+		}
+		offset += len(line)
+	}
+
+	return fields
+}
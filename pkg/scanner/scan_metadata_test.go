@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
+)
+
+// TestScanRepository_scanCommitMetadata() unit test function tests that the
+// message and the author of a commit, the messages of its annotated tags and
+// its git notes are scanned as objects of their own type.
+func TestScanRepository_scanCommitMetadata(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	store := func(o interface {
+		Encode(plumbing.EncodedObject) error
+	}) plumbing.Hash {
+		encoded := repository.Storer.NewEncodedObject()
+		if err := o.Encode(encoded); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := repository.Storer.SetEncodedObject(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	author := object.Signature{Name: "Dev One", Email: "dev@example.com", When: time.Now()}
+	commit_hash := store(&object.Commit{
+		Author:    author,
+		Committer: author,
+		Message:   "Fix intake form\n\nReported by John Smith (DOB 1980-01-02)\n",
+		TreeHash:  testStoreTree(t, repository, map[string]string{"README.md": "intake"}).Hash,
+	})
+	_, err := repository.CreateTag("v1.0.0", commit_hash, &git.CreateTagOptions{
+		Message: "Release for Jane Doe",
+		Tagger:  &author,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	notes_hash := store(&object.Commit{
+		Author:    author,
+		Committer: author,
+		Message:   "Notes added by 'git notes add'",
+		TreeHash:  testStoreTree(t, repository, map[string]string{commit_hash.String(): "Called Jane Doe at 555-0100\n"}).Hash,
+	})
+	err = repository.Storer.SetReference(plumbing.NewHashReference(NotesRef, notes_hash))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	commit, err := repository.CommitObject(commit_hash)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		expected []string
+		metadata cfg.GitScanMetadataConfig
+		name     string
+	}{
+		{
+			expected: []string{
+				"commit :  : message: Fix intake form\nmessage: Reported by John Smith (DOB 1980-01-02)\n",
+				"tag : refs/tags/v1.0.0 : message: Release for Jane Doe\n",
+				"note : refs/notes/commits : note: Called Jane Doe at 555-0100\n",
+			},
+			metadata: cfg.GitScanMetadataConfig{Messages: true, Notes: true, Tags: true},
+			name:     "MessagesNotesTags",
+		},
+		{
+			expected: []string{
+				"commit :  : author: Dev One <dev@example.com>\n",
+				"tag : refs/tags/v1.0.0 : tagger: Dev One <dev@example.com>\nmessage: Release for Jane Doe\n",
+			},
+			metadata: cfg.GitScanMetadataConfig{Authors: true, Tags: true},
+			name:     "AuthorsTags",
+		},
+		{
+			metadata: cfg.GitScanMetadataConfig{Notes: true},
+			name:     "NotesOfOtherCommits",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			channel_requests := make(chan rrr.Request, 10)
+			repo, err := NewScanRepository(NewScanRepositoryInput{
+				ChannelErrors:   make(chan<- error),
+				ChannelRequests: channel_requests,
+				Config: &cfg.GitScanConfig{
+					Limits:   cfg.GitScanLimitsConfig{MaxRequestChunkSize: 1000},
+					Metadata: test.metadata,
+				},
+				Context:    context.Background(),
+				Repository: repository,
+				URL:        test_repo_url,
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			repo.loadMetadata()
+			if test.expected == nil {
+				// the notes of a commit other than the scanned commit
+				delete(repo.commit_notes, commit_hash)
+			}
+
+			assert.NoError(t, repo.scanCommitMetadata(commit))
+			close(channel_requests)
+			var actual []string
+			for request := range channel_requests {
+				actual = append(actual, request.Object.Type+" : "+request.Object.Path+" : "+request.Text)
+				assert.Equal(t, commit_hash.String(), request.Commit.ID)
+				file_data, exists := repo.TrackerFiles.Get(request.Object.ID)
+				assert.True(t, exists)
+				assert.Equal(t, tracker.KeyCodePending, file_data.Code)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// Test_textFields() unit test function tests that the textFields() function
+// returns a field for each line that is not blank.
+func Test_textFields(t *testing.T) {
+	t.Parallel()
+
+	text := "Subject\r\n\r\n  \nBody line\n"
+	fields := textFields(MetadataLabelMessage, text)
+	if assert.Len(t, fields, 2) {
+		assert.Equal(t, "line 1", fields[0].Location)
+		assert.Equal(t, "Subject", fields[0].Value)
+		assert.Equal(t, "line 4", fields[1].Location)
+		assert.Equal(t, "Body line", text[fields[1].Offset:fields[1].Offset+fields[1].Length])
+	}
+}
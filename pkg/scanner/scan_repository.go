@@ -2,16 +2,22 @@ package scanner
 
 import (
 	"context"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 	nogit "github.com/has-ghas/no-phi-ai/pkg/client/no-git"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/archive"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/structured"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
 )
 
@@ -29,25 +35,37 @@ type ScanRepository struct {
 	TrackerCommits *tracker.KeyTracker
 	TrackerFiles   *tracker.KeyTracker
 
+	blob_cache       *cache.BlobCache
 	channel_commits  chan *object.Commit
 	channel_errors   chan<- error
 	channel_files    chan *object.File
 	channel_requests chan<- rrr.Request
+	commit_notes     map[plumbing.Hash]*object.File
+	commit_tags      map[plumbing.Hash][]*object.Tag
 	config           *cfg.GitScanConfig
 	ctx              context.Context
+	files_cached     atomic.Int64
+	ignore_rules     *IgnoreRules
 	is_scan_complete bool
 	logger           *zerolog.Logger
 	repository       *git.Repository
+	results_ignored  atomic.Int64
+	result_io        rrr.ResultRecordIO
 }
 
 // NewScanRepositoryInput struct type is used to pass input parameters to the
 // NewScanRepository() function.
 type NewScanRepositoryInput struct {
+	// BlobCache is optional and is used to skip the scan of files that were
+	// scanned by previous runs, in which case ResultIO is required in order
+	// to write the results of the previous runs for the skipped files.
+	BlobCache       *cache.BlobCache
 	ChannelErrors   chan<- error
 	ChannelRequests chan<- rrr.Request
 	Config          *cfg.GitScanConfig
 	Context         context.Context
 	Repository      *git.Repository
+	ResultIO        rrr.ResultRecordIO
 	URL             string
 }
 
@@ -68,6 +86,9 @@ func NewScanRepository(in NewScanRepositoryInput) (*ScanRepository, error) {
 	if in.Repository == nil {
 		return nil, errors.Wrap(ErrScanRepositoryRepositoryNil, ErrMsgScanRepositoryCreate)
 	}
+	if in.BlobCache != nil && in.ResultIO == nil {
+		return nil, errors.Wrap(ErrScanRepositoryResultIONil, ErrMsgScanRepositoryCreate)
+	}
 
 	name, err := nogit.ParseRepoNameFromURL(in.URL)
 	if err != nil {
@@ -91,20 +112,36 @@ func NewScanRepository(in NewScanRepositoryInput) (*ScanRepository, error) {
 		ID:               in.URL,
 		Name:             name,
 		URL:              in.URL,
+		blob_cache:       in.BlobCache,
 		channel_commits:  make(chan *object.Commit),
 		channel_errors:   in.ChannelErrors,
 		channel_files:    make(chan *object.File),
 		channel_requests: in.ChannelRequests,
 		ctx:              in.Context,
 		config:           in.Config,
+		ignore_rules:     NewIgnoreRules(in.Config),
 		is_scan_complete: false,
 		logger:           logger,
 		repository:       in.Repository,
+		result_io:        in.ResultIO,
 		TrackerCommits:   tracker_commits,
 		TrackerFiles:     tracker_files,
 	}, nil
 }
 
+// CountFilesCached() method returns the number of files that were not
+// scanned because they were already scanned by a previous run.
+func (sr *ScanRepository) CountFilesCached() int64 {
+	return sr.files_cached.Load()
+}
+
+// CountResultsIgnored() method returns the number of results that were
+// ignored by the inline annotations (e.g. "no-phi-ai:ignore") of the files
+// of the repository.
+func (sr *ScanRepository) CountResultsIgnored() int64 {
+	return sr.results_ignored.Load()
+}
+
 // GetRepository() method returns a pointer to the git.Repository
 // associated with the ScanRepository.
 func (sr *ScanRepository) GetRepository() *git.Repository {
@@ -121,34 +158,35 @@ func (sr *ScanRepository) Scan(gm *nogit.GitManager) (e error) {
 	sr.logger.Debug().Msgf("started scan of repository %s", sr.URL)
 	defer sr.logger.Debug().Msgf("finished scan of repository %s", sr.URL)
 
-	// get an iterator for the commits in the repository
-	var commit_iterator object.CommitIter
-	commit_iterator, e = sr.repository.CommitObjects()
-	if e != nil {
-		if commit_iterator != nil {
-			commit_iterator.Close()
-		}
-		return
+	// load the annotated tags and notes scanned with the metadata of commits
+	if sr.config.Metadata.Enabled() {
+		sr.loadMetadata()
 	}
-	defer commit_iterator.Close()
 
 	wg := &sync.WaitGroup{}
 	// start a goroutine to process commits generated by the iterator
 	wg.Add(1)
 	go sr.processCommits(wg)
 
-	// iterate through the commits in the repository history
-	e = commit_iterator.ForEach(sr.scanCommit)
-	if e != nil {
-		e = errors.Wrapf(e, "failed to iterate through commits in repository %s", sr.URL)
-		// return any error encountered while iterating through the commits
-		return
-	}
+	// iterate through the commits in the scope of the scan
+	e = sr.forEachCommit(sr.scanCommit)
 	// close the channel for commits to signal the processCommits goroutine
-	// to finish
+	// to finish, even if the iteration failed (e.g. for an unknown ref of
+	// the scope), so that the goroutine does not leak
 	close(sr.channel_commits)
 	// wait for the processCommits goroutine to finish
 	wg.Wait()
+	if e != nil && sr.ctx.Err() == nil {
+		e = errors.Wrapf(e, "failed to iterate through commits in repository %s", sr.URL)
+		// return any error encountered while iterating through the commits
+		return
+	}
+	// the scan is not complete if it was cancelled, in which case the commits
+	// and files that were not scanned are scanned when the scan is resumed
+	if ctx_err := sr.ctx.Err(); ctx_err != nil {
+		e = errors.Wrapf(ctx_err, ErrMsgScanRepositoryCanceled, sr.URL)
+		return
+	}
 
 	// set the scan complete flag to true
 	sr.is_scan_complete = true
@@ -182,7 +220,7 @@ func (sr *ScanRepository) processCommits(wg_main *sync.WaitGroup) {
 			)
 			if err != nil {
 				err = errors.Wrapf(err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
-				sr.channel_errors <- err
+				sr.sendError(err)
 				return
 			}
 		}
@@ -190,6 +228,11 @@ func (sr *ScanRepository) processCommits(wg_main *sync.WaitGroup) {
 		// iterate through the files in the commit tree
 		err = tree.Files().ForEach(sr.scanFile(commit))
 		if err != nil {
+			// the files of a cancelled scan are left to be scanned again when
+			// the scan is resumed
+			if sr.ctx.Err() != nil {
+				return
+			}
 			err = errors.Wrapf(err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
 			sr.TrackerCommits.Update(
 				commit.Hash.String(),
@@ -197,9 +240,24 @@ func (sr *ScanRepository) processCommits(wg_main *sync.WaitGroup) {
 				err.Error(),
 				[]string{},
 			)
-			sr.channel_errors <- err
+			sr.sendError(err)
 			return
 		}
+		// scan the metadata (e.g. message) of the commit
+		if sr.config.Metadata.Enabled() {
+			err = sr.scanCommitMetadata(commit)
+			if err != nil {
+				err = errors.Wrapf(err, ErrMsgTrackerUpdateCommit, commit.Hash.String())
+				sr.TrackerCommits.Update(
+					commit.Hash.String(),
+					tracker.KeyCodeError,
+					err.Error(),
+					[]string{},
+				)
+				sr.sendError(err)
+				return
+			}
+		}
 
 		// attempt to update the commit code to "complete" status, but ignore any error
 		// and accept that the commit may be left in "pending" status if the key has
@@ -221,6 +279,10 @@ func (sr *ScanRepository) processCommits(wg_main *sync.WaitGroup) {
 // scanCommit() method scans the tree of the object.Commit for files
 // containing any PHI/PII entities.
 func (sr *ScanRepository) scanCommit(commit *object.Commit) error {
+	// stop iterating through the commits once the scan is cancelled
+	if err := sr.ctx.Err(); err != nil {
+		return err
+	}
 	update_code, init_err := sr.TrackerCommits.Update(
 		commit.Hash.String(),
 		tracker.KeyCodeInit,
@@ -242,15 +304,74 @@ func (sr *ScanRepository) scanCommit(commit *object.Commit) error {
 	}
 
 	// send the commit to the channel for processing
-	sr.channel_commits <- commit
+	select {
+	case sr.channel_commits <- commit:
+	case <-sr.ctx.Done():
+		return sr.ctx.Err()
+	}
+
+	return nil
+}
+
+// sendError() method sends the error to the channel for errors of the scan,
+// unless the context of the scan is done.
+func (sr *ScanRepository) sendError(err error) {
+	select {
+	case sr.channel_errors <- err:
+	case <-sr.ctx.Done():
+	}
+}
+
+// sendRequests() method sends each of the requests to the channel for
+// requests of the scan. Returns the error of the context of the scan if the
+// context is done before every request is sent.
+func (sr *ScanRepository) sendRequests(requests []rrr.Request) error {
+	for _, req := range requests {
+		select {
+		case sr.channel_requests <- req:
+		case <-sr.ctx.Done():
+			return sr.ctx.Err()
+		}
+	}
 
 	return nil
 }
 
+// commitIgnoreRules() method returns the IgnoreRules used to decide whether
+// each file in the tree of the commit should be ignored, which include the
+// patterns of the .gitignore files in the tree if the scan is configured to
+// respect them.
+func (sr *ScanRepository) commitIgnoreRules(commit *object.Commit) *IgnoreRules {
+	if !sr.config.RespectGitignore {
+		return sr.ignore_rules
+	}
+
+	tree, err := commit.Tree()
+	if err == nil {
+		var rules *IgnoreRules
+		if rules, err = sr.ignore_rules.WithGitignore(tree); err == nil {
+			return rules
+		}
+	}
+	sr.logger.Warn().Err(err).Msgf(
+		"commit %s : failed to load %s files ; scanning without them",
+		commit.Hash.String(),
+		GitignoreFileName,
+	)
+
+	return sr.ignore_rules
+}
+
 // scanFile() method returns an anonymous function that can be used to iterate through
 // the files in the associated commit tree and scan each file for PHI/PII entities.
 func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) error {
-	return func(file *object.File) error {
+	rules := sr.commitIgnoreRules(commit)
+
+	// scan is also used to scan the members of archives, where the members
+	// of nested archives share the archive.Budget of the top-level archive
+	// (which is nil for files that are not members of an archive)
+	var scan func(*object.File, *archive.Budget) error
+	scan = func(file *object.File, budget *archive.Budget) error {
 		code, err := sr.TrackerFiles.Update(
 			file.Hash.String(),
 			tracker.KeyCodeInit,
@@ -272,11 +393,10 @@ func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) err
 		}
 
 		// check if the file should be ignored instead of scanned
-		should_ignore, ignore_reason := IgnoreFileObject(
-			file,
-			sr.config.Extensions,
-			sr.config.IgnoreExtensions,
-		)
+		should_ignore, ignore_reason, ignore_rule := IgnoreFileObject(file, rules)
+		if should_ignore && ignore_rule != "" {
+			ignore_reason += " : " + ignore_rule
+		}
 		if should_ignore {
 			sr.logger.Trace().Msgf(
 				"commit %s : skipping scan of file %s : %s",
@@ -300,18 +420,51 @@ func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) err
 				ignore_reason,
 			)
 		}
+		// scan the members of archives (e.g. zip) instead of the archive
+		if format := rules.detectArchive(file); format != "" {
+			return sr.scanArchive(commit, file, format, budget, scan)
+		}
+		// reuse the results of a previous run for a file that was already
+		// scanned, instead of scanning the file again
+		if sr.blob_cache != nil {
+			if entry, cached := sr.blob_cache.Get(file.Hash.String()); cached {
+				return sr.scanCachedFile(commit, file, entry)
+			}
+		}
 		sr.logger.Debug().Msgf(
 			"commit %s : scanning file %s : %s",
 			commit.Hash.String(),
 			file.Hash.String(),
 			file.Name,
 		)
+		// extract the text of documents (e.g. PDF) that cannot be scanned
+		// as plain text
+		content_type, text, extracted, err := sr.extractFileText(commit, file, rules)
+		if err != nil || !extracted {
+			return err
+		}
+		// scan the values of structured data (e.g. JSON) with their labels,
+		// or only the comments and strings of source code
+		var format string
+		var fields []structured.Field
+		if content_type == "" {
+			var parsed bool
+			format, fields, parsed, err = sr.parseFileFields(commit, file)
+			if err != nil || !parsed {
+				return err
+			}
+		}
 		// generate and send requests for the contents of the file
 		requests, r_err := rrr.ChunkFileToRequests(rrr.ChunkFileInput{
 			CommitID:     commit.Hash.String(),
+			ContentType:  content_type,
+			Fields:       fields,
 			File:         file,
+			Format:       format,
 			MaxChunkSize: sr.config.Limits.MaxRequestChunkSize,
+			Overlap:      sr.config.Limits.ChunkOverlap,
 			RepoID:       sr.ID,
+			Text:         text,
 		})
 		if r_err != nil {
 			sr.logger.Error().Err(r_err).Msgf("commit %s : failed to generate requests for file %s", commit.Hash.String(), file.Hash.String())
@@ -324,7 +477,7 @@ func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) err
 			return r_err
 		}
 		// any zero-size file should have been ignored by the IgnoreFileObject() function
-		if len(requests) == 0 && file.Size > 0 {
+		if len(requests) == 0 && file.Size > 0 && content_type == "" {
 			err = errors.New("no requests generated for file ID=" + file.Hash.String())
 			sr.TrackerFiles.Update(
 				file.Hash.String(),
@@ -342,10 +495,17 @@ func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) err
 			return err
 		}
 		var child_keys []string
-		// send each request to the channel for processing
 		for _, req := range requests {
 			child_keys = append(child_keys, req.ID)
-			sr.channel_requests <- req
+		}
+		// collect the results of the requests for the file, so that the file
+		// is not scanned again by the next run
+		if sr.blob_cache != nil {
+			sr.blob_cache.Expect(file.Hash.String(), child_keys)
+		}
+		// send each request to the channel for processing
+		if err = sr.sendRequests(requests); err != nil {
+			return err
 		}
 		// update tracker to mark the scan of this file as "pending"
 		_, err = sr.TrackerFiles.Update(
@@ -370,4 +530,173 @@ func (sr *ScanRepository) scanFile(commit *object.Commit) func(*object.File) err
 
 		return nil
 	}
+
+	return func(file *object.File) error {
+		return scan(file, nil)
+	}
+}
+
+// extractFileText() method returns the content type and extracted text of
+// the file if the file is a document supported by the Extractors of the
+// rules, or an empty content type if the contents of the file should be
+// scanned as plain text. Returns extracted=false if the file should not be
+// scanned, because its text could not be extracted or is empty, in which
+// case the file is marked as failed or ignored.
+func (sr *ScanRepository) extractFileText(commit *object.Commit, file *object.File, rules *IgnoreRules) (content_type string, text string, extracted bool, e error) {
+	extracted = true
+	if rules.detectExtractable(file) == "" {
+		return
+	}
+
+	contents, err := file.Contents()
+	if err == nil {
+		content_type, text, err = rules.Extractors.Extract(file.Name, []byte(contents))
+	}
+	if err != nil {
+		// a document that cannot be read (e.g. corrupt or encrypted) must
+		// not stop the scan of the other files of the commit
+		sr.logger.Warn().Err(err).Msgf(
+			"commit %s : failed to extract text of file %s : %s",
+			commit.Hash.String(),
+			file.Hash.String(),
+			file.Name,
+		)
+		extracted = false
+		_, e = sr.TrackerFiles.Update(
+			file.Hash.String(),
+			tracker.KeyCodeError,
+			err.Error(),
+			[]string{},
+		)
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		// e.g. a scanned document without a text layer
+		sr.logger.Trace().Msgf(
+			"commit %s : skipping scan of file %s : %s",
+			commit.Hash.String(),
+			file.Hash.String(),
+			IgnoreReasonFileHasNoExtractedText,
+		)
+		extracted = false
+		_, e = sr.TrackerFiles.Update(
+			file.Hash.String(),
+			tracker.KeyCodeIgnore,
+			IgnoreReasonFileHasNoExtractedText,
+			[]string{},
+		)
+		return
+	}
+
+	return
+}
+
+// parseFileFields() method returns the format and the values of the
+// structured data (e.g. JSON) or the source code (e.g. notebook) of the
+// file, or an empty format if the file should be scanned as plain text
+// because the format of the file is not supported or not enabled by config,
+// or the file cannot be parsed (e.g. invalid JSON) or has no values. Returns
+// parsed=false if the file should not be scanned, because the file is
+// source code without any comments or strings, in which case the file is
+// marked as ignored.
+func (sr *ScanRepository) parseFileFields(commit *object.Commit, file *object.File) (format string, fields []structured.Field, parsed bool, e error) {
+	parsed = true
+	format = structured.DetectFormat(file.Name)
+	is_source_code := structured.IsSourceCode(format)
+	if format == "" || (is_source_code && !sr.config.SourceCode) || (!is_source_code && !sr.config.StructuredData) {
+		return "", nil, parsed, nil
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return "", nil, parsed, nil
+	}
+	format, fields, err = structured.Parse(file.Name, []byte(contents))
+	if err != nil {
+		sr.logger.Debug().Err(err).Msgf(
+			"commit %s : scanning file %s as plain text : %s",
+			commit.Hash.String(),
+			file.Hash.String(),
+			file.Name,
+		)
+		return "", nil, parsed, nil
+	}
+	if len(fields) > 0 {
+		return
+	}
+	if !is_source_code {
+		return "", nil, parsed, nil
+	}
+
+	// e.g. source code without comments or a notebook without any cells
+	sr.logger.Trace().Msgf(
+		"commit %s : skipping scan of file %s : %s",
+		commit.Hash.String(),
+		file.Hash.String(),
+		IgnoreReasonFileHasNoSourceText,
+	)
+	parsed = false
+	_, e = sr.TrackerFiles.Update(
+		file.Hash.String(),
+		tracker.KeyCodeIgnore,
+		IgnoreReasonFileHasNoSourceText,
+		[]string{},
+	)
+
+	return
+}
+
+// scanCachedFile() method writes the results detected in the file by a
+// previous run as results of the associated commit, then marks the file
+// as complete without sending any requests.
+func (sr *ScanRepository) scanCachedFile(commit *object.Commit, file *object.File, entry cache.Entry) error {
+	sr.logger.Debug().Msgf(
+		"commit %s : skipping scan of cached file %s : %s",
+		commit.Hash.String(),
+		file.Hash.String(),
+		file.Name,
+	)
+
+	for _, chunk := range entry.Chunks {
+		response := rrr.Response{
+			MetadataRequestResponse: rrr.MetadataRequestResponse{
+				Commit: rrr.MetadataRequestResponseCommit{
+					ID: commit.Hash.String(),
+				},
+				Object: rrr.MetadataRequestResponseObject{
+					ContentType: entry.ContentType,
+					Format:      entry.Format,
+					ID:          file.Hash.String(),
+					Length:      chunk.Length,
+					Offset:      chunk.Offset,
+					Path:        file.Name,
+					Type:        rrr.ObjectTypeFile,
+				},
+				Repository: rrr.MetadataRequestResponseRepository{
+					ID: sr.ID,
+				},
+				Time: rrr.MetadataRequestResponseTime{
+					Start: entry.Timestamp,
+					Stop:  entry.Timestamp,
+				},
+			},
+			Results: chunk.Results,
+		}
+		if err := sr.result_io.Write(rrr.ResultRecordsFromResponse(&response)); err != nil {
+			return errors.Wrap(err, ErrMsgResultWriteFailed)
+		}
+	}
+
+	_, err := sr.TrackerFiles.Update(
+		file.Hash.String(),
+		tracker.KeyCodeComplete,
+		"",
+		[]string{},
+	)
+	if err != nil {
+		return errors.Wrapf(err, ErrMsgScanTrackerUpdateFile, file.Hash.String())
+	}
+	sr.files_cached.Add(1)
+
+	return nil
 }
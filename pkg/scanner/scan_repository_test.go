@@ -2,14 +2,22 @@ package scanner
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	scannermemory "github.com/has-ghas/no-phi-ai/pkg/scanner/memory"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
 )
 
 var (
@@ -115,6 +123,28 @@ func TestNewScanRepository(t *testing.T) {
 		assert.Nil(t, repo)
 	})
 
+	t.Run("NilResultIOWithBlobCache", func(t *testing.T) {
+		// initialize the bare *git.Repository
+		repository, init_err := git.Init(memory.NewStorage(), nil)
+		assert.NoError(t, init_err)
+		blob_cache, cache_err := cache.NewBlobCache(t.TempDir(), "test_fingerprint", nil)
+		assert.NoError(t, cache_err)
+
+		repo, err := NewScanRepository(NewScanRepositoryInput{
+			BlobCache:       blob_cache,
+			ChannelErrors:   channel_errors,
+			ChannelRequests: channel_requests,
+			Config:          test_repo_scan_config,
+			Context:         ctx,
+			Repository:      repository,
+			URL:             test_repo_url,
+		})
+
+		assert.ErrorContains(t, err, ErrMsgScanRepositoryCreate)
+		assert.ErrorContains(t, err, ErrScanRepositoryResultIONil.Error())
+		assert.Nil(t, repo)
+	})
+
 	t.Run("InvalidURL", func(t *testing.T) {
 		// initialize the bare *git.Repository
 		repository, init_err := git.Init(memory.NewStorage(), nil)
@@ -167,3 +197,385 @@ func TestScanRepository_GetRepository(t *testing.T) {
 		assert.NotNil(t, result)
 	})
 }
+
+// TestScanRepository_scanFile_cached() tests that the scanFile() method
+// reuses the results of a file that is in the blob cache, instead of
+// sending requests for the file.
+func TestScanRepository_scanFile_cached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+
+	// store the contents of the file as a blob in the repository
+	encoded := repository.Storer.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+	writer, err := encoded.Writer()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = writer.Write([]byte("name,dob\nJohn Smith,1970-01-01\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	blob_hash, err := repository.Storer.SetEncodedObject(encoded)
+	assert.NoError(t, err)
+	blob, err := object.GetBlob(repository.Storer, blob_hash)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	commit := &object.Commit{Hash: plumbing.NewHash("1111111111111111111111111111111111111111")}
+	file := object.NewFile("patients.csv", filemode.Regular, blob)
+	result := rrr.Result{Category: "Person", ConfidenceScore: 0.9, Length: 10, Offset: 4, Text: "John Smith"}
+
+	// add the file to the blob cache, as if scanned by a previous run
+	blob_cache, err := cache.NewBlobCache(t.TempDir(), "test_fingerprint", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	response := rrr.Response{Results: []rrr.Result{result}}
+	response.ID = "request_1"
+	response.Object.ID = file.Hash.String()
+	response.Object.Length = 32
+	response.Object.Offset = 100
+	blob_cache.Expect(file.Hash.String(), []string{response.ID})
+	blob_cache.Add(response)
+
+	result_io := scannermemory.NewMemoryResultRecordIO(ctx)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		BlobCache:       blob_cache,
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: make(chan<- rrr.Request),
+		Config:          &cfg.GitScanConfig{Extensions: []string{".csv"}},
+		Context:         ctx,
+		Repository:      repository,
+		ResultIO:        result_io,
+		URL:             test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the (unbuffered) requests channel is never received from, so the scan
+	// would block if any request was sent for the cached file
+	assert.NoError(t, repo.scanFile(commit)(file))
+
+	file_data, exists := repo.TrackerFiles.Get(file.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeComplete, file_data.Code)
+	assert.Equal(t, int64(1), repo.CountFilesCached())
+	records, err := result_io.List()
+	if assert.NoError(t, err) && assert.Len(t, records, 1) {
+		assert.Equal(t, result, records[0].Result)
+		assert.Equal(t, commit.Hash.String(), records[0].Commit.ID)
+		assert.Equal(t, file.Hash.String(), records[0].Object.ID)
+		assert.Equal(t, 100, records[0].Object.Offset)
+		assert.Equal(t, "patients.csv", records[0].Object.Path)
+		assert.Equal(t, test_repo_url, records[0].Repository.ID)
+	}
+}
+
+// TestScanRepository_scanFile_extracted() tests that the scanFile() method
+// sends requests for the text extracted from a document, instead of the
+// contents of the file, when the text of documents is extracted.
+func TestScanRepository_scanFile_extracted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	tree := testStoreTree(t, repository, map[string]string{
+		"docs/scan.pdf":  "%PDF-1.4\n1 0 obj\n<< /Length 5 >>\nstream\nhello\nendstream\nendobj\n",
+		"docs/visit.pdf": "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n3 0 obj\n<< /Length 44 >>\nstream\nBT 72 720 Td (Patient: John Smith) Tj ET\nendstream\nendobj\n",
+	})
+	commit := &object.Commit{Hash: plumbing.NewHash("2222222222222222222222222222222222222222")}
+
+	channel_requests := make(chan rrr.Request, 10)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: channel_requests,
+		Config:          &cfg.GitScanConfig{ExtractText: true, Limits: cfg.GitScanLimitsConfig{MaxRequestChunkSize: 100}},
+		Context:         ctx,
+		Repository:      repository,
+		URL:             test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	visit, err := tree.File("docs/visit.pdf")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(visit))
+	if assert.Len(t, channel_requests, 1) {
+		request := <-channel_requests
+		assert.Equal(t, "Patient: John Smith\n", request.Text)
+		assert.Equal(t, "application/pdf", request.Object.ContentType)
+		assert.Equal(t, 0, request.Object.Offset)
+		assert.Equal(t, "docs/visit.pdf", request.Object.Path)
+	}
+
+	// a document without any text is ignored
+	scan, err := tree.File("docs/scan.pdf")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(scan))
+	assert.Len(t, channel_requests, 0)
+	file_data, exists := repo.TrackerFiles.Get(scan.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeIgnore, file_data.Code)
+	assert.Equal(t, IgnoreReasonFileHasNoExtractedText, file_data.Message)
+}
+
+// TestScanRepository_scanFile_structured() unit test function tests that the
+// values of structured data are scanned with their labels when enabled by
+// config, and that data which cannot be parsed is scanned as plain text.
+func TestScanRepository_scanFile_structured(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	tree := testStoreTree(t, repository, map[string]string{
+		"data/invalid.json": `{"name": "John Smith"`,
+		"data/patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+	})
+	commit := &object.Commit{Hash: plumbing.NewHash("3333333333333333333333333333333333333333")}
+
+	channel_requests := make(chan rrr.Request, 10)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: channel_requests,
+		Config: &cfg.GitScanConfig{
+			Extensions:     []string{".csv", ".json"},
+			Limits:         cfg.GitScanLimitsConfig{MaxRequestChunkSize: 100},
+			StructuredData: true,
+		},
+		Context:    ctx,
+		Repository: repository,
+		URL:        test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	patients, err := tree.File("data/patients.csv")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(patients))
+	if assert.Len(t, channel_requests, 1) {
+		request := <-channel_requests
+		assert.Equal(t, "name: John Smith\ndob: 1980-01-02\n", request.Text)
+		assert.Equal(t, "csv", request.Object.Format)
+		if assert.Len(t, request.Fields, 2) {
+			assert.Equal(t, "row 2, column 1", request.Fields[0].Location)
+			assert.Equal(t, "row 2, column 2", request.Fields[1].Location)
+		}
+	}
+
+	invalid, err := tree.File("data/invalid.json")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(invalid))
+	if assert.Len(t, channel_requests, 1) {
+		request := <-channel_requests
+		assert.Equal(t, `{"name": "John Smith"`, request.Text)
+		assert.Equal(t, "", request.Object.Format)
+		assert.Empty(t, request.Fields)
+	}
+}
+
+// TestScanRepository_scanFile_sourceCode() unit test function tests that
+// only the comments and strings of source code are scanned, and that source
+// code without any comments or strings is ignored.
+func TestScanRepository_scanFile_sourceCode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	tree := testStoreTree(t, repository, map[string]string{
+		"cmd/empty.go":      "package main\n\nfunc main() {}\n",
+		"tests/fixtures.py": "# fixtures\npatient = {'name': 'John Smith'}\n",
+	})
+	commit := &object.Commit{Hash: plumbing.NewHash("4444444444444444444444444444444444444444")}
+
+	channel_requests := make(chan rrr.Request, 10)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: channel_requests,
+		Config: &cfg.GitScanConfig{
+			Limits:     cfg.GitScanLimitsConfig{MaxRequestChunkSize: 100},
+			SourceCode: true,
+		},
+		Context:    ctx,
+		Repository: repository,
+		URL:        test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	fixtures, err := tree.File("tests/fixtures.py")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(fixtures))
+	if assert.Len(t, channel_requests, 1) {
+		request := <-channel_requests
+		assert.Equal(t, "comment: fixtures\nstring: name\nstring: John Smith\n", request.Text)
+		assert.Equal(t, "source", request.Object.Format)
+		if assert.Len(t, request.Fields, 3) {
+			assert.Equal(t, "line 1", request.Fields[0].Location)
+			assert.Equal(t, "line 2", request.Fields[2].Location)
+		}
+	}
+
+	empty, err := tree.File("cmd/empty.go")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(empty))
+	assert.Len(t, channel_requests, 0)
+	file_data, exists := repo.TrackerFiles.Get(empty.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeIgnore, file_data.Code)
+	assert.Equal(t, IgnoreReasonFileHasNoSourceText, file_data.Message)
+}
+
+// TestScanRepository_scanFile_archive() unit test function tests that the
+// members of archives are scanned with the path of the archive in the path
+// of each member, and that archives nested deeper than the max depth are
+// ignored.
+func TestScanRepository_scanFile_archive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	nested := testZip(t, map[string]string{"notes.txt": "Seen by Dr. Jones"})
+	tree := testStoreTree(t, repository, map[string]string{
+		"data.zip": testZip(t, map[string]string{
+			"export/patients.csv": "name,dob\nJohn Smith,1980-01-02\n",
+			"export/logo.png":     "\x89PNG\r\n\x1a\n\x00\x00",
+			"nested.zip":          nested,
+		}),
+		"corrupt.zip": "PK\x03\x04 not really a zip",
+	})
+	commit := &object.Commit{Hash: plumbing.NewHash("5555555555555555555555555555555555555555")}
+
+	channel_requests := make(chan rrr.Request, 10)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: channel_requests,
+		Config: &cfg.GitScanConfig{
+			Archives:   cfg.GitScanArchivesConfig{Enabled: true, MaxDepth: 1, MaxMembers: 10, MaxSize: 1 << 20},
+			Extensions: []string{".csv", ".txt"},
+			Limits:     cfg.GitScanLimitsConfig{MaxRequestChunkSize: 100},
+		},
+		Context:    ctx,
+		Repository: repository,
+		URL:        test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	data, err := tree.File("data.zip")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(data))
+	if assert.Len(t, channel_requests, 1) {
+		request := <-channel_requests
+		assert.Equal(t, "data.zip!/export/patients.csv", request.Object.Path)
+		assert.Equal(t, "name,dob\nJohn Smith,1980-01-02\n", request.Text)
+	}
+	file_data, exists := repo.TrackerFiles.Get(data.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeComplete, file_data.Code)
+	nested_file, err := newMemberFile(memory.NewStorage(), "nested.zip", []byte(nested))
+	if assert.NoError(t, err) {
+		file_data, exists = repo.TrackerFiles.Get(nested_file.Hash.String())
+		assert.True(t, exists)
+		assert.Equal(t, tracker.KeyCodeIgnore, file_data.Code)
+		assert.Equal(t, IgnoreReasonArchiveTooDeep, file_data.Message)
+	}
+
+	corrupt, err := tree.File("corrupt.zip")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(corrupt))
+	assert.Len(t, channel_requests, 0)
+	file_data, exists = repo.TrackerFiles.Get(corrupt.Hash.String())
+	assert.True(t, exists)
+	assert.Equal(t, tracker.KeyCodeError, file_data.Code)
+}
+
+// TestScanRepository_scanFile_archiveBudget() unit test function tests that
+// the archives nested within an archive share the limits of the top-level
+// archive, such that an archive of (small) zip bombs cannot be used to
+// decompress more than the limits of a single archive.
+func TestScanRepository_scanFile_archiveBudget(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, init_err)
+	// each nested archive is within the limits on its own
+	nested := []string{
+		testZip(t, map[string]string{"a.txt": strings.Repeat("a", 600)}),
+		testZip(t, map[string]string{"b.txt": strings.Repeat("b", 600)}),
+		testZip(t, map[string]string{"c.txt": strings.Repeat("c", 600)}),
+	}
+	tree := testStoreTree(t, repository, map[string]string{
+		"bombs.zip": testZip(t, map[string]string{
+			"1.zip": nested[0],
+			"2.zip": nested[1],
+			"3.zip": nested[2],
+		}),
+	})
+	commit := &object.Commit{Hash: plumbing.NewHash("6666666666666666666666666666666666666666")}
+
+	channel_requests := make(chan rrr.Request, 100)
+	repo, err := NewScanRepository(NewScanRepositoryInput{
+		ChannelErrors:   make(chan<- error),
+		ChannelRequests: channel_requests,
+		Config: &cfg.GitScanConfig{
+			Archives:   cfg.GitScanArchivesConfig{Enabled: true, MaxDepth: 2, MaxMembers: 10, MaxSize: 2048},
+			Extensions: []string{".txt"},
+			Limits:     cfg.GitScanLimitsConfig{MaxRequestChunkSize: 1000},
+		},
+		Context:    ctx,
+		Repository: repository,
+		URL:        test_repo_url,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	bombs, err := tree.File("bombs.zip")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, repo.scanFile(commit)(bombs))
+	// the members of two of the nested archives fit within the limits of the
+	// top-level archive, but the members of the last archive do not
+	assert.Len(t, channel_requests, 2)
+	codes := make(map[int]int)
+	for i, contents := range nested {
+		nested_file, err := newMemberFile(memory.NewStorage(), strconv.Itoa(i+1)+".zip", []byte(contents))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		file_data, exists := repo.TrackerFiles.Get(nested_file.Hash.String())
+		assert.True(t, exists)
+		codes[file_data.Code]++
+	}
+	assert.Equal(t, map[int]int{tracker.KeyCodeComplete: 2, tracker.KeyCodeError: 1}, codes)
+}
@@ -2,16 +2,22 @@ package scanner
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"sync"
 	"time"
 
 	git "github.com/go-git/go-git/v5"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/has-ghas/no-phi-ai/pkg/cfg"
 	nogit "github.com/has-ghas/no-phi-ai/pkg/client/no-git"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/baseline"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/cache"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/checkpoint"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/crypt"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
 	"github.com/has-ghas/no-phi-ai/pkg/scanner/tracker"
 )
@@ -24,17 +30,41 @@ type Scanner struct {
 
 	TrackerRequests *tracker.KeyTracker
 
+	baseline          *baseline.Baseline
+	blob_cache        *cache.BlobCache
 	chan_requests     chan rrr.Request
 	chan_errors       chan error
+	chan_progress     chan struct{}
+	checkpoint_store  checkpoint.Store
 	ctx               context.Context
 	git_config        *cfg.GitConfig
 	git_manager       *nogit.GitManager
 	logger            *zerolog.Logger
 	result_io         rrr.ResultRecordIO
+	resume_commits    map[string]tracker.KeyData
+	resume_files      map[string]tracker.KeyData
 	scan_mutex        *sync.RWMutex
 	scan_repositories map[string]*ScanRepository
 }
 
+// DefaultScanID() function returns the ID of a scan of the repositories and
+// scope of the provided GitConfig, which is used when the ID of the scan is
+// not configured, such that the same scan has the same ID (and checkpoints)
+// each time it is run.
+func DefaultScanID(git_config *cfg.GitConfig) string {
+	scan := struct {
+		Repositories []string               `json:"repositories"`
+		Scope        cfg.GitScanScopeConfig `json:"scope"`
+	}{
+		Repositories: git_config.Scan.Repositories,
+		Scope:        git_config.Scan.Scope,
+	}
+	encoded, _ := json.Marshal(scan)
+	sum := sha1.Sum(encoded)
+
+	return hex.EncodeToString(sum[:])
+}
+
 // NewScanner() function initializes a new Scanner object.
 func NewScanner(
 	ctx context.Context,
@@ -55,11 +85,35 @@ func NewScanner(
 		return nil, errors.Wrap(t_err, ErrMsgScannerCreate)
 	}
 
+	// reuse the configured scan ID (if any), or else the ID derived from the
+	// repositories and scope of the scan, so that the checkpoint of an
+	// interrupted scan is used to resume the scan when it is run again
+	scan_id := git_config.Scan.Checkpoint.ScanID
+	if scan_id == "" {
+		scan_id = DefaultScanID(git_config)
+	}
+	// create a checkpoint.Store for saving the state of the scan (encrypted
+	// with the configured keys, if any), unless checkpoints are disabled
+	var checkpoint_store checkpoint.Store
+	if git_config.Scan.Checkpoint.Dir != "" {
+		keyring, k_err := crypt.LoadKeyring(git_config.Scan.Encryption.KeyFile, git_config.Scan.Encryption.Keys)
+		if k_err != nil {
+			return nil, errors.Wrap(k_err, ErrMsgScannerCreate)
+		}
+		file_store, fs_err := checkpoint.NewFileStore(git_config.Scan.Checkpoint.Dir, keyring)
+		if fs_err != nil {
+			return nil, errors.Wrap(fs_err, ErrMsgScannerCreate)
+		}
+		checkpoint_store = file_store
+	}
+
 	return &Scanner{
-		ID:                uuid.NewString(),
+		ID:                scan_id,
 		TrackerRequests:   tracker_requests,
 		chan_requests:     make(chan rrr.Request),
 		chan_errors:       make(chan error),
+		chan_progress:     make(chan struct{}, 1),
+		checkpoint_store:  checkpoint_store,
 		ctx:               ctx,
 		git_config:        git_config,
 		git_manager:       nogit.NewGitManager(git_config, ctx),
@@ -71,7 +125,9 @@ func NewScanner(
 }
 
 // Scan() method uses channels and goroutines to coordinate the scanning of
-// a git repository for PHI/PII data.
+// a git repository for PHI/PII data. Once the context of the Scanner is done,
+// the scan is stopped and its state (i.e. the checkpoint and blob cache) is
+// saved before the method returns, so that the scan can be resumed.
 func (s *Scanner) Scan(
 	chan_errors_send chan error,
 	chan_request_send chan<- rrr.Request,
@@ -84,37 +140,88 @@ func (s *Scanner) Scan(
 	// use s.git_manager to clone the repository
 	repository, repository_err := s.git_manager.CloneRepo(repo)
 	if repository_err != nil {
-		s.chan_errors <- errors.Wrap(repository_err, ErrMsgCloneRepository)
+		s.sendError(chan_errors_send, errors.Wrap(repository_err, ErrMsgCloneRepository))
 		return
 	}
+	// resume the scan from its checkpoint (if any), or else scan the
+	// repository from the start
+	if load_err := s.loadCheckpoint(repo); load_err != nil {
+		s.logger.Error().Err(load_err).Msgf("repository %s : scanning from the start", repo)
+	}
 
 	// create channels for coordinating between goroutines
 	chan_scan_done := make(chan struct{})
 	chan_quit := make(chan struct{})
 
+	// run each goroutine of the scan as part of wg, so that the state of the
+	// scan is only saved once every goroutine has stopped
+	wg := &sync.WaitGroup{}
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
 	// track the progress of the scan
-	go s.trackScanProgress(repo, chan_scan_done, chan_quit)
+	run(func() { s.trackScanProgress(repo, chan_scan_done, chan_quit) })
 	// listen for errors generated by the scan
-	go s.processErrors(chan_quit, s.chan_errors, chan_errors_send)
+	run(func() { s.processErrors(chan_quit, s.chan_errors, chan_errors_send) })
 	// process requests generated by the scan
-	go s.processRequests(
-		chan_quit,
-		s.chan_requests,
-		chan_request_send,
-		s.chan_errors,
-	)
+	run(func() {
+		s.processRequests(
+			chan_quit,
+			s.chan_requests,
+			chan_request_send,
+			s.chan_errors,
+		)
+	})
 	// process responses for requests
-	go s.processResponses(
-		chan_quit,
-		chan_response_receive,
-		s.chan_errors,
-	)
+	run(func() {
+		s.processResponses(
+			chan_quit,
+			chan_response_receive,
+			s.chan_errors,
+		)
+	})
 	// scan the repository
-	go s.scanRepository(repo, repository, s.chan_errors, chan_scan_done)
+	run(func() { s.scanRepository(repo, repository, s.chan_errors, chan_scan_done) })
 
 	// listen for quit signal
 	// TODO : replace with `go s.processResults()`
-	<-chan_quit
+	select {
+	case <-chan_quit:
+	case <-s.ctx.Done():
+		s.logger.Warn().Msgf("repository %s : stopping cancelled scan", repo)
+		wg.Wait()
+		s.saveCanceledScan(repo, chan_quit)
+	}
+}
+
+// GetResultRecordIO() method returns the rrr.ResultRecordIO used by the
+// Scanner to store the results of the scan.
+func (s *Scanner) GetResultRecordIO() rrr.ResultRecordIO {
+	return s.result_io
+}
+
+// GetScanRepository() method returns the ScanRepository with the provided ID,
+// which can be used to access the repository after the scan is complete.
+func (s *Scanner) GetScanRepository(id string) (*ScanRepository, error) {
+	return s.getScanRepository(id)
+}
+
+// SetBaseline() method sets the (optional) baseline.Baseline of the known
+// findings of previous scans, such that the Report of the scan only counts
+// the new findings.
+func (s *Scanner) SetBaseline(b *baseline.Baseline) {
+	s.baseline = b
+}
+
+// SetBlobCache() method sets the (optional) cache.BlobCache used to skip
+// the scan of files that were already scanned by previous runs, which must
+// be set before calling Scan().
+func (s *Scanner) SetBlobCache(blob_cache *cache.BlobCache) {
+	s.blob_cache = blob_cache
 }
 
 // addScanRepository() method adds a new ScanRepository to the Scanner's map of
@@ -169,13 +276,17 @@ func (s *Scanner) processErrors(
 			s.logger.Warn().Msg("scanner error processor received quit signal")
 			close(chan_errors_out)
 			return
+		case <-s.ctx.Done():
+			s.logger.Warn().Msg("scanner error processor : context done")
+			close(chan_errors_out)
+			return
 		case e := <-chan_errors_in:
 			if e != nil {
 				err_wrap_msg := "error running scanner"
 				s.logger.Error().Err(e).Msg(err_wrap_msg)
 				// handle error to determine if the scanner should continue
 				// TODO
-				chan_errors_out <- e
+				s.sendError(chan_errors_out, e)
 				close(chan_errors_out)
 				return
 			}
@@ -201,17 +312,21 @@ func (s *Scanner) processRequest(
 	)
 	// validate the request
 	if r.ID == "" {
-		chan_errors_out <- ErrProcessRequestNoID
+		s.sendError(chan_errors_out, ErrProcessRequestNoID)
 		return
 	}
 	// update TrackerRequests to track the ID of the pending request
 	_, err := s.TrackerRequests.Update(r.ID, tracker.KeyCodePending, "", []string{})
 	if err != nil {
-		chan_errors_out <- err
+		s.sendError(chan_errors_out, err)
 		return
 	}
-	// send the request for external processing
-	chan_requests_out <- r
+	// send the request for external processing, unless the scan is cancelled
+	// before the request is received
+	select {
+	case chan_requests_out <- r:
+	case <-s.ctx.Done():
+	}
 }
 
 // processRequests() method processes requests for documents generated by
@@ -230,6 +345,8 @@ func (s *Scanner) processRequests(
 		select {
 		case <-chan_quit_in:
 			return
+		case <-s.ctx.Done():
+			return
 		case r := <-chan_requests_in:
 			// keep the input channel clear by processing the request in the
 			// background via a separate goroutine, which sends any errors to
@@ -246,7 +363,7 @@ func (s *Scanner) processResponse(
 ) {
 	// validate the response
 	if r.ID == "" {
-		chan_errors_out <- ErrProcessResponseNoID
+		s.sendError(chan_errors_out, ErrProcessResponseNoID)
 		return
 	}
 	// log the response
@@ -258,23 +375,54 @@ func (s *Scanner) processResponse(
 		r.Commit.ID,
 		r.Object.ID,
 	)
+	// drop the results within the overlap with the neighbouring requests,
+	// which are detected by those requests
+	r.DropOverlapResults()
+	// drop the results that are ignored by the inline annotations of the
+	// file, which are counted once the repository of the response is known
+	ignored := r.DropIgnoredResults()
+	// locate the results within the structured data (e.g. JSON) of the file
+	r.LocateResults()
+	// keep only the HMAC and a masked preview of the text of each result,
+	// if configured, before the results are stored or cached
+	if result_text := s.git_config.Scan.ResultText; result_text.Mode == cfg.GitScanResultTextModeHMAC {
+		r.ProtectResults([]byte(result_text.Key))
+	}
 	// write the result(s) to the result_io store
 	if len(r.Results) > 0 {
 		// convert the response to a slice of rrr.ResultRecords, where
 		// each rrr.ResultRecord is uniquely identified by its SHA1 hash
 		result_records := rrr.ResultRecordsFromResponse(&r)
 		if err := s.result_io.Write(result_records); err != nil {
-			chan_errors_out <- errors.Wrap(err, ErrMsgResultWriteFailed)
+			s.sendError(chan_errors_out, errors.Wrap(err, ErrMsgResultWriteFailed))
 		}
 	}
-	// update TrackerRequests to mark the associated request (ID) as complete
-	s.TrackerRequests.Update(r.ID, tracker.KeyCodeComplete, "", []string{})
+	// update TrackerRequests to mark the associated request (ID) as complete,
+	// keeping any error from the detection service as the tracker message
+	if r.Error != "" {
+		s.logger.Error().Msgf("detection failed for request ID = %s : %s", r.ID, r.Error)
+	}
+	s.TrackerRequests.Update(r.ID, tracker.KeyCodeComplete, r.Error, []string{})
+	// keep the results for the file, so that it is not scanned again by
+	// the next run
+	if s.blob_cache != nil {
+		s.blob_cache.Add(r)
+	}
+	// signal progress without blocking, since a signal that is already
+	// waiting to be received covers this response too
+	defer func() {
+		select {
+		case s.chan_progress <- struct{}{}:
+		default:
+		}
+	}()
 
 	scan_repo, scan_repo_err := s.getScanRepository(r.Repository.ID)
 	if scan_repo_err != nil {
-		chan_errors_out <- scan_repo_err
+		s.sendError(chan_errors_out, scan_repo_err)
 		return
 	}
+	scan_repo.results_ignored.Add(int64(ignored))
 	// update the tracker for the associated File object to mark this
 	// request/response as complete. if all requests/responses for a
 	// File object are complete, the File object should be marked as
@@ -288,7 +436,7 @@ func (s *Scanner) processResponse(
 		[]string{r.ID},
 	)
 	if update_err != nil {
-		chan_errors_out <- update_err
+		s.sendError(chan_errors_out, update_err)
 	}
 	// only update the associated commit if file_update_code is tracker.KeyCodeComplete.
 	if file_update_code == tracker.KeyCodeComplete {
@@ -299,7 +447,7 @@ func (s *Scanner) processResponse(
 			[]string{r.Object.ID},
 		)
 		if update_err != nil {
-			chan_errors_out <- update_err
+			s.sendError(chan_errors_out, update_err)
 		}
 	}
 }
@@ -319,7 +467,13 @@ func (s *Scanner) processResponses(
 		select {
 		case <-chan_quit_in:
 			return
-		case r := <-chan_responses_in:
+		case <-s.ctx.Done():
+			return
+		case r, ok := <-chan_responses_in:
+			// the detector closes the channel once it stops
+			if !ok {
+				return
+			}
 			// keep the input channel clear by processing the response in the
 			// background via a separate goroutine, which sends any errors to
 			// chan_errors_out
@@ -415,6 +569,39 @@ func (s *Scanner) reconcilePending(scan_repo *ScanRepository) {
 	}
 }
 
+// saveCanceledScan() method saves the checkpoint of the cancelled scan of the
+// repository with the provided ID, along with the blob cache, unless the scan
+// was complete (i.e. chan_quit is closed) before it was cancelled.
+func (s *Scanner) saveCanceledScan(repository_id string, chan_quit <-chan struct{}) {
+	select {
+	case <-chan_quit:
+		return
+	default:
+	}
+	scan_repo, err := s.getScanRepository(repository_id)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("repository %s : failed to save cancelled scan", repository_id)
+		return
+	}
+	s.reconcilePending(scan_repo)
+	s.saveCheckpoint(scan_repo)
+	s.saveBlobCache()
+	s.logger.Info().Msgf("repository %s : saved state of cancelled scan ID = %s", repository_id, s.ID)
+}
+
+// saveBlobCache() method saves the (optional) cache.BlobCache, so that the
+// files scanned so far are not scanned again by the next run.
+func (s *Scanner) saveBlobCache() {
+	if s.blob_cache == nil {
+		return
+	}
+	if err := s.blob_cache.Save(); err != nil {
+		s.logger.Error().Err(err).Msg(cache.ErrMsgBlobCacheSave)
+		return
+	}
+	s.logger.Debug().Msgf("saved blob cache with %d blobs", s.blob_cache.Len())
+}
+
 // scanRepository() method scans the repositories defined in the git config
 // and sends the results to the requests channel. If an error occurs during
 // the scan, the error is sent to the error channel.
@@ -432,37 +619,51 @@ func (s *Scanner) scanRepository(
 		s.logger.Panic().Msg(ErrMsgErrorChannelNil)
 	}
 	if repository == nil {
-		errors_out <- ErrScannerRepositoryNil
+		s.sendError(errors_out, ErrScannerRepositoryNil)
 	}
 
 	// create a scan object for the repository
 	scan_repo, err := NewScanRepository(NewScanRepositoryInput{
+		BlobCache:       s.blob_cache,
 		ChannelErrors:   s.chan_errors,
 		ChannelRequests: s.chan_requests,
 		Config:          &s.git_config.Scan,
 		Context:         s.ctx,
 		Repository:      repository,
+		ResultIO:        s.result_io,
 		URL:             repo_url,
 	})
 	if err != nil {
-		errors_out <- errors.Wrap(err, ErrMsgScanRepositoryCreate)
+		s.sendError(errors_out, errors.Wrap(err, ErrMsgScanRepositoryCreate))
 		return
 	}
 
+	// skip the commits and files already scanned by a previous run of the scan
+	s.resumeCheckpoint(scan_repo)
+
 	// add the ScanRepository to the map of scan repositories so that other
 	// methods can access and update the state of the scan for the repository
 	if add_err := s.addScanRepository(scan_repo); add_err != nil {
-		errors_out <- errors.Wrap(add_err, ErrMsgAddScanRepository)
+		s.sendError(errors_out, errors.Wrap(add_err, ErrMsgAddScanRepository))
 		return
 	}
 
 	// scan the repository for PHI/PII data
 	if scan_err := scan_repo.Scan(s.git_manager); scan_err != nil {
-		errors_out <- errors.Wrap(scan_err, ErrMsgScanRepositoryScan)
+		s.sendError(errors_out, errors.Wrap(scan_err, ErrMsgScanRepositoryScan))
 		return
 	}
 }
 
+// sendError() method sends the error to the provided channel, unless the
+// context of the Scanner is done before the error is received.
+func (s *Scanner) sendError(chan_errors_out chan<- error, err error) {
+	select {
+	case chan_errors_out <- err:
+	case <-s.ctx.Done():
+	}
+}
+
 // trackScanProgress() method tracks the progress of the scan by periodically
 // checking if all requests have been completed. If the scan is complete, the
 // method returns. If the scan is not complete, the method continues to track
@@ -484,7 +685,7 @@ func (s *Scanner) trackScanProgress(
 		s.TrackerRequests.PrintCounts()
 	}
 
-	trackScanCounts := func() (done bool) {
+	trackScanCounts := func(print_counts bool) (done bool) {
 		done = false
 		scan_repo, err := s.getScanRepository(repository_id)
 		if err != nil {
@@ -492,7 +693,13 @@ func (s *Scanner) trackScanProgress(
 			return
 		}
 		// print the scan counts regardless of whether the scan is complete
-		printScanCounts(scan_repo)
+		if print_counts {
+			printScanCounts(scan_repo)
+			// save the state of the scan, so that it can be resumed if
+			// the scan is interrupted
+			s.saveCheckpoint(scan_repo)
+			s.saveBlobCache()
+		}
 
 		// check if the scan of the repository is complete, including all
 		// requests/responses created from the scan of the repository
@@ -520,6 +727,8 @@ func (s *Scanner) trackScanProgress(
 		s.logger.Debug().Msgf("tracking scan : cleaning up scan for repository %s", scan_repo.ID)
 		// print the scan counts again before actually cleaning up
 		printScanCounts(scan_repo)
+		s.saveBlobCache()
+		s.deleteCheckpoint(scan_repo.ID)
 		close(quit_out)
 		done = true
 		return
@@ -527,22 +736,36 @@ func (s *Scanner) trackScanProgress(
 
 	// create a ticker to periodically trigger a refresh of progress tracking
 	timer := time.NewTicker(ScanRefreshInterval)
+	defer timer.Stop()
 
 	// use scan_done var to avoid repeated processing of scan_done_in
 	var scan_done bool = false
 
 	for {
 		select {
+		case <-s.ctx.Done():
+			// the state of a cancelled scan is saved once every goroutine
+			// of the scan has stopped
+			return
 		case <-timer.C:
 			// print tracker counts, then wait for the next tick
-			if trackScanCounts() {
+			if trackScanCounts(true) {
+				return
+			}
+		case <-s.chan_progress:
+			// check for completion (without printing tracker counts) as soon
+			// as responses are processed after the repository scan is done,
+			// rather than waiting for the next tick
+			if scan_done && trackScanCounts(false) {
 				return
 			}
 		case <-scan_done_in:
 			if !scan_done {
 				s.logger.Debug().Msg("received scan done signal")
 				scan_done = true
-				if trackScanCounts() {
+				// stop receiving from the (closed) channel
+				scan_done_in = nil
+				if trackScanCounts(true) {
 					return
 				}
 			}
@@ -105,6 +105,37 @@ func TestNewScanner(t *testing.T) {
 	}
 }
 
+// TestDefaultScanID() unit test function tests that the default ID of a scan
+// only depends on the repositories and scope of the scan, and that it is not
+// used when the ID of the scan is configured.
+func TestDefaultScanID(t *testing.T) {
+	t.Parallel()
+
+	config := test_valid_git_config_func()
+	config.Scan.Repositories = []string{"test-org/test-repo"}
+	scan_id := DefaultScanID(config)
+	assert.Len(t, scan_id, 40)
+
+	scanner, err := NewScanner(test_context, config, memory.NewMemoryResultRecordIO(test_context))
+	if assert.NoError(t, err) {
+		assert.Equal(t, scan_id, scanner.ID)
+	}
+	config.Scan.Checkpoint.ScanID = "test_scan"
+	scanner, err = NewScanner(test_context, config, memory.NewMemoryResultRecordIO(test_context))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test_scan", scanner.ID)
+	}
+
+	// settings other than the repositories and scope do not change the ID
+	config.Scan.Extensions = []string{".txt"}
+	assert.Equal(t, scan_id, DefaultScanID(config))
+	config.Scan.Scope.Refs = []string{"main"}
+	assert.NotEqual(t, scan_id, DefaultScanID(config))
+	config.Scan.Scope.Refs = nil
+	config.Scan.Repositories = []string{"test-org/other-repo"}
+	assert.NotEqual(t, scan_id, DefaultScanID(config))
+}
+
 // TestScanner_Scan() unit test function tests the Scan() method of a new Scanner.
 func TestScanner_Scan(t *testing.T) {
 	t.Parallel()
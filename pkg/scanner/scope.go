@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/pkg/errors"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+)
+
+// forEachCommit() method calls fn for each commit of the repository that is
+// in the scope of the scan, as defined by the Scope of the GitScanConfig.
+func (sr *ScanRepository) forEachCommit(fn func(*object.Commit) error) error {
+	scope := sr.config.Scope
+	since, err := scope.SinceTime()
+	if err != nil {
+		return errors.Wrap(err, ErrMsgScanScope)
+	}
+
+	switch scope.Mode {
+	case cfg.GitScanScopeAll, "":
+		return sr.forEachCommitAll(since, fn)
+	case cfg.GitScanScopeHistory:
+		return sr.forEachCommitHistory(scope, since, fn)
+	case cfg.GitScanScopeTip:
+		return sr.forEachCommitTip(scope, fn)
+	default:
+		return errors.Wrapf(ErrScanScopeModeInvalid, "mode = %s", scope.Mode)
+	}
+}
+
+// forEachCommitAll() method calls fn for every commit object in the
+// repository, including commits that are not reachable from any ref, unless
+// the commit was committed before since.
+func (sr *ScanRepository) forEachCommitAll(since *time.Time, fn func(*object.Commit) error) error {
+	commit_iterator, err := sr.repository.CommitObjects()
+	if err != nil {
+		if commit_iterator != nil {
+			commit_iterator.Close()
+		}
+		return err
+	}
+	defer commit_iterator.Close()
+
+	return commit_iterator.ForEach(func(commit *object.Commit) error {
+		if since != nil && commit.Committer.When.Before(*since) {
+			return nil
+		}
+		return fn(commit)
+	})
+}
+
+// forEachCommitHistory() method calls fn for each commit in the history of
+// each of the refs of the scope, from the most recent commit, excluding
+// the commits in the history of the SinceCommit of the scope and commits
+// committed before since. At most MaxCommits commits of each ref are passed
+// to fn, when MaxCommits is set.
+func (sr *ScanRepository) forEachCommitHistory(scope cfg.GitScanScopeConfig, since *time.Time, fn func(*object.Commit) error) error {
+	// find the commits in the history of SinceCommit, which are excluded
+	excluded := make(map[plumbing.Hash]bool)
+	if scope.SinceCommit != "" {
+		since_hash, err := sr.resolveRef(scope.SinceCommit)
+		if err != nil {
+			return err
+		}
+		since_iterator, err := sr.repository.Log(&git.LogOptions{From: since_hash})
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgScanScopeRef, scope.SinceCommit)
+		}
+		err = since_iterator.ForEach(func(commit *object.Commit) error {
+			excluded[commit.Hash] = true
+			return nil
+		})
+		since_iterator.Close()
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgScanScopeRef, scope.SinceCommit)
+		}
+	}
+
+	for _, ref := range scopeRefs(scope) {
+		hash, err := sr.resolveRef(ref)
+		if err != nil {
+			return err
+		}
+		commit_iterator, err := sr.repository.Log(&git.LogOptions{
+			From:  hash,
+			Order: git.LogOrderCommitterTime,
+			Since: since,
+		})
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgScanScopeRef, ref)
+		}
+
+		count := 0
+		err = commit_iterator.ForEach(func(commit *object.Commit) error {
+			if excluded[commit.Hash] {
+				return nil
+			}
+			if scope.MaxCommits > 0 && count >= scope.MaxCommits {
+				return storer.ErrStop
+			}
+			count++
+			return fn(commit)
+		})
+		commit_iterator.Close()
+		if err != nil {
+			return err
+		}
+		sr.logger.Debug().Msgf("repository %s : found %d commits in history of %s", sr.URL, count, ref)
+	}
+
+	return nil
+}
+
+// forEachCommitTip() method calls fn for the commit at the tip of each of the
+// refs of the scope.
+func (sr *ScanRepository) forEachCommitTip(scope cfg.GitScanScopeConfig, fn func(*object.Commit) error) error {
+	for _, ref := range scopeRefs(scope) {
+		hash, err := sr.resolveRef(ref)
+		if err != nil {
+			return err
+		}
+		commit, err := sr.repository.CommitObject(hash)
+		if err != nil {
+			return errors.Wrapf(err, ErrMsgScanScopeRef, ref)
+		}
+		if err := fn(commit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveRef() method returns the hash of the commit of the ref, which can
+// be a branch, tag, or commit hash. Branches that are not checked out are
+// resolved from the branches of the ScanScopeRemote remote.
+func (sr *ScanRepository) resolveRef(ref string) (plumbing.Hash, error) {
+	hash, err := sr.repository.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		remote_hash, remote_err := sr.repository.ResolveRevision(plumbing.Revision(ScanScopeRemote + "/" + ref))
+		if remote_err != nil {
+			return plumbing.ZeroHash, errors.Wrapf(err, ErrMsgScanScopeRef, ref)
+		}
+		hash = remote_hash
+	}
+
+	return *hash, nil
+}
+
+// scopeRefs() function returns the refs of the scope, or HEAD if the scope
+// has no refs.
+func scopeRefs(scope cfg.GitScanScopeConfig) []string {
+	if len(scope.Refs) == 0 {
+		return []string{plumbing.HEAD.String()}
+	}
+
+	return scope.Refs
+}
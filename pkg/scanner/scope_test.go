@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/has-ghas/no-phi-ai/pkg/cfg"
+	"github.com/has-ghas/no-phi-ai/pkg/scanner/rrr"
+)
+
+// testStoreCommit() helper function stores a new commit of a tree with a
+// single file in the repository, then returns the hash of the commit.
+func testStoreCommit(t *testing.T, repository *git.Repository, date string, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+
+	store := func(o interface {
+		Encode(plumbing.EncodedObject) error
+	}) plumbing.Hash {
+		encoded := repository.Storer.NewEncodedObject()
+		if err := o.Encode(encoded); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := repository.Storer.SetEncodedObject(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	blob := repository.Storer.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	writer, _ := blob.Writer()
+	writer.Write([]byte(date))
+	writer.Close()
+	blob_hash, err := repository.Storer.SetEncodedObject(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree_hash := store(&object.Tree{Entries: []object.TreeEntry{
+		{Name: "notes.md", Mode: filemode.Regular, Hash: blob_hash},
+	}})
+
+	when, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := object.Signature{Name: "test", Email: "test@example.com", When: when}
+
+	return store(&object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      date,
+		ParentHashes: parents,
+		TreeHash:     tree_hash,
+	})
+}
+
+// TestScanRepository_forEachCommit() unit test function tests that the
+// forEachCommit() method selects the commits in the configured scope.
+func TestScanRepository_forEachCommit(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	// master : c1 <- c2 <- c3
+	// feature : c2 <- c4 (only on the "origin" remote)
+	// v1 : c1
+	c1 := testStoreCommit(t, repository, "2024-01-01")
+	c2 := testStoreCommit(t, repository, "2024-02-01", c1)
+	c3 := testStoreCommit(t, repository, "2024-03-01", c2)
+	c4 := testStoreCommit(t, repository, "2024-04-01", c2)
+	for _, ref := range []*plumbing.Reference{
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), c3),
+		plumbing.NewHashReference(plumbing.NewRemoteReferenceName(ScanScopeRemote, "feature"), c4),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("v1"), c1),
+	} {
+		assert.NoError(t, repository.Storer.SetReference(ref))
+	}
+
+	tests := []struct {
+		expected     []plumbing.Hash
+		expected_err bool
+		name         string
+		ordered      bool
+		scope        cfg.GitScanScopeConfig
+	}{
+		{
+			expected: []plumbing.Hash{c1, c2, c3, c4},
+			name:     "All",
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeAll},
+		},
+		{
+			expected: []plumbing.Hash{c3, c4},
+			name:     "All_Since",
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeAll, Since: "2024-02-15"},
+		},
+		{
+			expected: []plumbing.Hash{c3, c2, c1},
+			name:     "History_HEAD",
+			ordered:  true,
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory},
+		},
+		{
+			expected: []plumbing.Hash{c4, c2},
+			name:     "History_MaxCommits",
+			ordered:  true,
+			scope:    cfg.GitScanScopeConfig{MaxCommits: 2, Mode: cfg.GitScanScopeHistory, Refs: []string{"feature"}},
+		},
+		{
+			expected: []plumbing.Hash{c3, c2},
+			name:     "History_Since",
+			ordered:  true,
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory, Since: "2024-01-15"},
+		},
+		{
+			expected: []plumbing.Hash{c4},
+			name:     "History_SinceCommit",
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory, Refs: []string{"feature"}, SinceCommit: "master"},
+		},
+		{
+			expected: []plumbing.Hash{c3, c2},
+			name:     "History_SinceCommit_Tag",
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeHistory, SinceCommit: "v1"},
+		},
+		{
+			expected: []plumbing.Hash{c3},
+			name:     "Tip_HEAD",
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip},
+		},
+		{
+			expected: []plumbing.Hash{c4, c1, c2},
+			name:     "Tip_Refs",
+			ordered:  true,
+			scope:    cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, Refs: []string{"feature", "v1", c2.String()}},
+		},
+		{
+			expected_err: true,
+			name:         "Tip_UnknownRef",
+			scope:        cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeTip, Refs: []string{"unknown"}},
+		},
+		{
+			expected_err: true,
+			name:         "InvalidMode",
+			scope:        cfg.GitScanScopeConfig{Mode: "head"},
+		},
+		{
+			expected_err: true,
+			name:         "InvalidSince",
+			scope:        cfg.GitScanScopeConfig{Mode: cfg.GitScanScopeAll, Since: "last week"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, err := NewScanRepository(NewScanRepositoryInput{
+				ChannelErrors:   make(chan<- error),
+				ChannelRequests: make(chan<- rrr.Request),
+				Config:          &cfg.GitScanConfig{Scope: test.scope},
+				Context:         context.Background(),
+				Repository:      repository,
+				URL:             test_repo_url,
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			var hashes []plumbing.Hash
+			err = repo.forEachCommit(func(commit *object.Commit) error {
+				hashes = append(hashes, commit.Hash)
+				return nil
+			})
+			if test.expected_err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if test.ordered {
+				assert.Equal(t, test.expected, hashes)
+			} else {
+				assert.ElementsMatch(t, test.expected, hashes)
+			}
+		})
+	}
+}
+
+// TestScanRepository_Scan_scopeError() unit test function tests that a scan
+// that fails to iterate through the commits of its scope (e.g. for an
+// unknown ref) returns the error once its processCommits goroutine is done.
+func TestScanRepository_Scan_scopeError(t *testing.T) {
+	t.Parallel()
+
+	repository, init_err := git.Init(memory.NewStorage(), nil)
+	if !assert.NoError(t, init_err) {
+		t.FailNow()
+	}
+	testStoreCommit(t, repository, "2024-01-01")
+
+	for _, scope := range []cfg.GitScanScopeConfig{
+		{Mode: cfg.GitScanScopeTip, Refs: []string{"unknown"}},
+		{Mode: cfg.GitScanScopeHistory, SinceCommit: "unknown"},
+	} {
+		repo, err := NewScanRepository(NewScanRepositoryInput{
+			ChannelErrors:   make(chan<- error),
+			ChannelRequests: make(chan<- rrr.Request),
+			Config:          &cfg.GitScanConfig{Scope: scope},
+			Context:         context.Background(),
+			Repository:      repository,
+			URL:             test_repo_url,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.Error(t, repo.Scan(nil))
+		assert.False(t, repo.is_scan_complete)
+		// the channel of commits is closed for the processCommits goroutine
+		select {
+		case _, open := <-repo.channel_commits:
+			assert.False(t, open)
+		case <-time.After(time.Second):
+			t.Fatal("channel of commits was not closed")
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package structured
+
+// Formats of the structured data supported by Parse().
+const (
+	FormatCSV  string = "csv"
+	FormatJSON string = "json"
+	FormatXML  string = "xml"
+	FormatYAML string = "yaml"
+)
+
+// Formats of the source code supported by Parse(), where only the cells of
+// notebooks and the comments and string literals of source code are parsed.
+const (
+	FormatNotebook string = "ipynb"
+	FormatSource   string = "source"
+)
+
+// Kinds of the segments of source code, which are the labels of the fields
+// of source code.
+const (
+	SegmentKindComment string = "comment"
+	SegmentKindString  string = "string"
+)
+
+// MinSegmentAlphanumerics is the minimum number of letters and digits in a
+// line of a comment or string literal of source code for the line to be
+// scanned, which skips lines (e.g. format strings) that cannot contain PHI.
+const MinSegmentAlphanumerics int = 2
+
+// FormatExtensions is a map of the file extensions of the structured data
+// supported by Parse() to the format of the data.
+var FormatExtensions = map[string]string{
+	".csv":   FormatCSV,
+	".ipynb": FormatNotebook,
+	".json":  FormatJSON,
+	".tsv":   FormatCSV,
+	".xml":   FormatXML,
+	".yaml":  FormatYAML,
+	".yml":   FormatYAML,
+}
+
+// MaxDepth is the maximum depth of the nested objects, arrays or elements of
+// the structured data, which limits the recursion of malicious data.
+const MaxDepth int = 1000
+
+// RootLabel is the label of a value that is the root of the structured data
+// (e.g. a JSON document that is a single string).
+const RootLabel string = "value"
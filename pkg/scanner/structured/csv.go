@@ -0,0 +1,61 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseCSV() function returns a Field for each non-empty value of the rows
+// of CSV data, where the first row is the header that labels the values of
+// each column. Rows are numbered from 1 (i.e. the header) and columns are
+// numbered from 1, as in a spreadsheet.
+func parseCSV(contents []byte, comma rune) ([]Field, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	offsets := lineOffsets(contents)
+	fields := make([]Field, 0)
+	var header []string
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			header = record
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+			continue
+		}
+
+		for column, value := range record {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			label := "column " + strconv.Itoa(column+1)
+			if column < len(header) && strings.TrimSpace(header[column]) != "" {
+				label = strings.TrimSpace(header[column])
+			}
+			// the column of the position of a field is a byte index
+			line, byte_column := reader.FieldPos(column)
+			start := -1
+			if line >= 1 && line <= len(offsets) {
+				start = offsets[line-1] + byte_column - 1
+				if start < len(contents) && contents[start] == '"' {
+					start++
+				}
+			}
+			location := "row " + strconv.Itoa(row) + ", column " + strconv.Itoa(column+1)
+			fields = append(fields, newField(contents, start, label, location, value))
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,11 @@
+package structured
+
+import "github.com/pkg/errors"
+
+const ErrMsgParse = "failed to parse %s data"
+
+var (
+	ErrMaxDepthExceeded  = errors.New("structured data exceeds the maximum depth")
+	ErrTrailingData      = errors.New("structured data is followed by unexpected data")
+	ErrUnsupportedFormat = errors.New("format of the file is not supported structured data")
+)
@@ -0,0 +1,103 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// jsonParser struct walks the tokens of JSON data, keeping the position of
+// each token within the contents.
+type jsonParser struct {
+	contents []byte
+	decoder  *json.Decoder
+	fields   []Field
+}
+
+// parseJSON() function returns a Field for each string and number value of
+// JSON data, labelled by its key path. Boolean and null values are skipped.
+func parseJSON(contents []byte) ([]Field, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+	parser := &jsonParser{
+		contents: contents,
+		decoder:  decoder,
+		fields:   make([]Field, 0),
+	}
+	if err := parser.parseValue(nil); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, ErrTrailingData
+	}
+
+	return parser.fields, nil
+}
+
+// token() method returns the next token and the byte position of the start
+// of the token within the contents.
+func (p *jsonParser) token() (token json.Token, start int, e error) {
+	// the offset is the end of the previous token, which is followed by any
+	// whitespace and separators before the next token
+	start = int(p.decoder.InputOffset())
+	token, e = p.decoder.Token()
+	if e != nil {
+		return
+	}
+	for start < len(p.contents) && bytes.IndexByte([]byte(" \t\r\n,:"), p.contents[start]) >= 0 {
+		start++
+	}
+
+	return
+}
+
+// parseValue() method adds the fields of the next value, which is found at
+// the provided path.
+func (p *jsonParser) parseValue(path []pathSegment) error {
+	if len(path) > MaxDepth {
+		return ErrMaxDepthExceeded
+	}
+	token, start, err := p.token()
+	if err != nil {
+		return err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			for p.decoder.More() {
+				key_token, _, err := p.token()
+				if err != nil {
+					return err
+				}
+				key, _ := key_token.(string)
+				if err := p.parseValue(appendSegment(path, pathSegment{index: -1, key: key})); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for i := 0; p.decoder.More(); i++ {
+				if err := p.parseValue(appendSegment(path, pathSegment{index: i})); err != nil {
+					return err
+				}
+			}
+		}
+		// consume the closing delimiter of the object or array
+		if _, _, err := p.token(); err != nil {
+			return err
+		}
+	case string:
+		// line endings at the end of a string are not part of the value,
+		// such that a line of text (e.g. of a notebook) appears verbatim
+		if value := strings.TrimRight(t, "\r\n"); strings.TrimSpace(value) != "" {
+			// the value starts after the opening quote of the string
+			p.fields = append(p.fields, newField(p.contents, start+1, pathLabel(path), pathPointer(path), value))
+		}
+	case json.Number:
+		p.fields = append(p.fields, newField(p.contents, start, pathLabel(path), pathPointer(path), t.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package structured
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// notebookSourcePattern matches the JSON pointer of a line of the source of
+// a cell of a Jupyter notebook, where the source is a string or a list of
+// lines.
+var notebookSourcePattern = regexp.MustCompile(`^/cells/(\d+)/source(?:/(\d+))?$`)
+
+// notebookOutputPattern matches the JSON pointer of a line of the text of an
+// output of a cell of a Jupyter notebook, which is the text of a stream, the
+// plain text of a result or the value of an error.
+var notebookOutputPattern = regexp.MustCompile(`^/cells/(\d+)/outputs/(\d+)/(?:text|data/text~1plain|evalue)(?:/(\d+))?$`)
+
+// parseNotebook() function returns a Field for each line of the source and
+// of the text outputs of the cells of a Jupyter notebook, located by the
+// (1-based) numbers of the cell, output and line (e.g. "cell 2, output 1,
+// line 3"). The metadata of the notebook and other outputs (e.g. images)
+// are skipped.
+func parseNotebook(contents []byte) ([]Field, error) {
+	json_fields, err := parseJSON(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]Field, 0)
+	for _, field := range json_fields {
+		var label, location, line_index string
+		if match := notebookSourcePattern.FindStringSubmatch(field.Location); match != nil {
+			label = "cell " + oneBased(match[1]) + " source"
+			location = "cell " + oneBased(match[1])
+			line_index = match[2]
+		} else if match := notebookOutputPattern.FindStringSubmatch(field.Location); match != nil {
+			label = "cell " + oneBased(match[1]) + " output"
+			location = "cell " + oneBased(match[1]) + ", output " + oneBased(match[2])
+			line_index = match[3]
+		} else {
+			continue
+		}
+
+		// a list of lines has a line in each string
+		if line_index != "" || !strings.Contains(field.Value, "\n") {
+			field.Label = label
+			field.Location = location + ", line " + oneBased(line_index)
+			fields = append(fields, field)
+			continue
+		}
+		// a string has every line of the source or output, where the lines
+		// of the string are escaped within the contents
+		for i, line := range strings.Split(field.Value, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			fields = append(fields, Field{
+				Label:    label,
+				Location: location + ", line " + strconv.Itoa(i+1),
+				Offset:   -1,
+				Value:    line,
+			})
+		}
+	}
+
+	return fields, nil
+}
+
+// oneBased() function returns the (1-based) number of the (0-based) index,
+// where an empty index is the first.
+func oneBased(index string) string {
+	i, _ := strconv.Atoi(index)
+
+	return strconv.Itoa(i + 1)
+}
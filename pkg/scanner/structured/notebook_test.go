@@ -0,0 +1,65 @@
+package structured
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParse_notebook() unit test function tests that Parse() returns a Field
+// for each line of the source and text outputs of the cells of a notebook,
+// located by cell, output and line, and skips the metadata and images.
+func TestParse_notebook(t *testing.T) {
+	t.Parallel()
+
+	contents := `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {"tags": ["phi"]},
+   "source": "# Cohort\nPatients seen by Dr. Who"
+  },
+  {
+   "cell_type": "code",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["John Smith\n", "1980-01-02\n"]},
+    {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo=", "text/plain": ["<Figure>"]}},
+    {"output_type": "error", "ename": "KeyError", "evalue": "'Jane Doe'", "traceback": []}
+   ],
+   "source": ["df = load(\"patients.csv\")\n", "\n", "df.head()"]
+  }
+ ],
+ "metadata": {"kernelspec": {"name": "python3"}},
+ "nbformat": 4
+}`
+	expected := []testLocatedField{
+		{label: "cell 1 source", location: "cell 1, line 1", value: "# Cohort"},
+		{label: "cell 1 source", location: "cell 1, line 2", value: "Patients seen by Dr. Who"},
+		{label: "cell 2 output", location: "cell 2, output 1, line 1", value: "John Smith", verbatim: true},
+		{label: "cell 2 output", location: "cell 2, output 1, line 2", value: "1980-01-02", verbatim: true},
+		{label: "cell 2 output", location: "cell 2, output 2, line 1", value: "<Figure>", verbatim: true},
+		{label: "cell 2 output", location: "cell 2, output 3, line 1", value: "'Jane Doe'", verbatim: true},
+		{label: "cell 2 source", location: "cell 2, line 1", value: `df = load("patients.csv")`},
+		{label: "cell 2 source", location: "cell 2, line 3", value: "df.head()", verbatim: true},
+	}
+
+	format, fields, err := Parse("analysis.ipynb", []byte(contents))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatNotebook, format)
+	if !assert.Len(t, fields, len(expected)) {
+		t.FailNow()
+	}
+	for i, expected := range expected {
+		field := fields[i]
+		assert.Equal(t, expected.label, field.Label)
+		assert.Equal(t, expected.location, field.Location)
+		assert.Equal(t, expected.value, field.Value)
+		if !expected.verbatim {
+			assert.Equal(t, -1, field.Offset, expected.location)
+			continue
+		}
+		if assert.GreaterOrEqual(t, field.Offset, 0, expected.location) {
+			assert.Equal(t, expected.value, contents[field.Offset:field.Offset+field.Length])
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package structured
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sourceSyntax struct contains the delimiters of the comments and string
+// literals of a programming language.
+type sourceSyntax struct {
+	// block_comments are the start and end delimiters of block comments
+	block_comments [][2]string
+	// doubled_delimiters is true if a doubled delimiter is an escaped
+	// delimiter within raw string literals (e.g. 'O''Brien' in SQL)
+	doubled_delimiters bool
+	// line_comments are the delimiters of comments that end with the line
+	line_comments []string
+	// raw_strings are the delimiters of string literals without escape
+	// sequences, which may span lines
+	raw_strings []string
+	// strings are the delimiters of string literals with (backslash) escape
+	// sequences, where any delimiter of more than one character (e.g. the
+	// triple quotes of Python) may span lines
+	strings []string
+}
+
+var (
+	syntaxC = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//"},
+		strings:        []string{`"`, `'`},
+	}
+	syntaxGo = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//"},
+		raw_strings:    []string{"`"},
+		strings:        []string{`"`, `'`},
+	}
+	syntaxJava = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//"},
+		strings:        []string{`"""`, `"`, `'`},
+	}
+	// the template literals of JavaScript may span lines
+	syntaxJavaScript = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//"},
+		raw_strings:    []string{"`"},
+		strings:        []string{`"`, `'`},
+	}
+	syntaxPHP = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//", "#"},
+		strings:        []string{`"`, `'`},
+	}
+	syntaxPython = &sourceSyntax{
+		line_comments: []string{"#"},
+		strings:       []string{`"""`, `'''`, `"`, `'`},
+	}
+	syntaxRuby = &sourceSyntax{
+		line_comments: []string{"#"},
+		strings:       []string{`"`, `'`},
+	}
+	// the single quotes of Rust are also used for lifetimes
+	syntaxRust = &sourceSyntax{
+		block_comments: [][2]string{{"/*", "*/"}},
+		line_comments:  []string{"//"},
+		strings:        []string{`"`},
+	}
+	syntaxShell = &sourceSyntax{
+		line_comments: []string{"#"},
+		raw_strings:   []string{`'`},
+		strings:       []string{`"`},
+	}
+	syntaxSQL = &sourceSyntax{
+		block_comments:     [][2]string{{"/*", "*/"}},
+		doubled_delimiters: true,
+		line_comments:      []string{"--"},
+		raw_strings:        []string{`'`},
+	}
+)
+
+// sourceSyntaxes is a map of the file extensions of the source code
+// supported by Parse() to the syntax of the language.
+var sourceSyntaxes = map[string]*sourceSyntax{
+	".c":     syntaxC,
+	".cc":    syntaxC,
+	".cpp":   syntaxC,
+	".cs":    syntaxC,
+	".go":    syntaxGo,
+	".h":     syntaxC,
+	".hpp":   syntaxC,
+	".java":  syntaxJava,
+	".js":    syntaxJavaScript,
+	".jsx":   syntaxJavaScript,
+	".kt":    syntaxJava,
+	".php":   syntaxPHP,
+	".py":    syntaxPython,
+	".r":     syntaxRuby,
+	".rb":    syntaxRuby,
+	".rs":    syntaxRust,
+	".scala": syntaxJava,
+	".sh":    syntaxShell,
+	".sql":   syntaxSQL,
+	".swift": syntaxC,
+	".ts":    syntaxJavaScript,
+	".tsx":   syntaxJavaScript,
+}
+
+// sourceSegment struct is a comment or string literal of source code.
+type sourceSegment struct {
+	end   int
+	kind  string
+	start int
+}
+
+// parseSource() function returns a Field for each line of the comments and
+// string literals of source code, which are found by a tokenizer for the
+// syntax of the language of the file, such that the code itself is not
+// scanned. Lines without at least MinSegmentAlphanumerics letters or digits
+// (e.g. a format string or a single character) are skipped.
+func parseSource(name string, contents []byte) ([]Field, error) {
+	syntax, ok := sourceSyntaxes[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	offsets := lineOffsets(contents)
+	fields := make([]Field, 0)
+	for _, segment := range syntax.segments(string(contents)) {
+		text := string(contents[segment.start:segment.end])
+		line_start := 0
+		for _, line := range strings.SplitAfter(text, "\n") {
+			start := segment.start + line_start
+			line_start += len(line)
+
+			value := strings.TrimSpace(line)
+			if segment.kind == SegmentKindComment {
+				// trim the decoration of comments (e.g. "///" or " * ")
+				value = strings.TrimSpace(strings.TrimLeft(value, "/*#!-"))
+			}
+			if countAlphanumerics(value) < MinSegmentAlphanumerics {
+				continue
+			}
+			start += strings.Index(line, value)
+			location := "line " + strconv.Itoa(lineNumber(offsets, start))
+			fields = append(fields, newField(contents, start, segment.kind, location, value))
+		}
+	}
+
+	return fields, nil
+}
+
+// segments() method returns the comments and string literals of the source
+// code, where the start and end of each segment exclude its delimiters.
+// Unterminated comments and strings end with the source code, except for
+// strings with single-character delimiters, which end with the line.
+func (syntax *sourceSyntax) segments(source string) []sourceSegment {
+	segments := make([]sourceSegment, 0)
+	for i := 0; i < len(source); {
+		segment, next, found := syntax.segmentAt(source, i)
+		if !found {
+			i++
+			continue
+		}
+		if segment.end > segment.start {
+			segments = append(segments, segment)
+		}
+		i = next
+	}
+
+	return segments
+}
+
+// segmentAt() method returns the comment or string literal that starts at
+// position i of the source code, if any, along with the position after the
+// end delimiter of the segment.
+func (syntax *sourceSyntax) segmentAt(source string, i int) (segment sourceSegment, next int, found bool) {
+	rest := source[i:]
+	for _, delimiter := range syntax.line_comments {
+		if strings.HasPrefix(rest, delimiter) {
+			start := i + len(delimiter)
+			end := indexOrEnd(source, start, "\n")
+			return sourceSegment{end: end, kind: SegmentKindComment, start: start}, end, true
+		}
+	}
+	for _, delimiters := range syntax.block_comments {
+		if strings.HasPrefix(rest, delimiters[0]) {
+			start := i + len(delimiters[0])
+			end := indexOrEnd(source, start, delimiters[1])
+			return sourceSegment{end: end, kind: SegmentKindComment, start: start}, min(end+len(delimiters[1]), len(source)), true
+		}
+	}
+	for _, delimiter := range syntax.raw_strings {
+		if strings.HasPrefix(rest, delimiter) {
+			start := i + len(delimiter)
+			end := indexOrEnd(source, start, delimiter)
+			for syntax.doubled_delimiters && strings.HasPrefix(source[min(end+len(delimiter), len(source)):], delimiter) {
+				end = indexOrEnd(source, end+2*len(delimiter), delimiter)
+			}
+			return sourceSegment{end: end, kind: SegmentKindString, start: start}, min(end+len(delimiter), len(source)), true
+		}
+	}
+	for _, delimiter := range syntax.strings {
+		if strings.HasPrefix(rest, delimiter) {
+			start := i + len(delimiter)
+			end := stringEnd(source, start, delimiter)
+			return sourceSegment{end: end, kind: SegmentKindString, start: start}, min(end+len(delimiter), len(source)), true
+		}
+	}
+
+	return
+}
+
+// stringEnd() function returns the position of the end delimiter of the
+// string literal that starts at position start, skipping escape sequences.
+// Strings with a single-character delimiter end with the line if they are
+// not terminated.
+func stringEnd(source string, start int, delimiter string) int {
+	for i := start; i < len(source); i++ {
+		switch {
+		case source[i] == '\\':
+			i++
+		case strings.HasPrefix(source[i:], delimiter):
+			return i
+		case source[i] == '\n' && len(delimiter) == 1:
+			return i
+		}
+	}
+
+	return len(source)
+}
+
+// indexOrEnd() function returns the position of the first occurrence of the
+// delimiter at or after start, or the end of the source if there is none.
+func indexOrEnd(source string, start int, delimiter string) int {
+	if i := strings.Index(source[start:], delimiter); i >= 0 {
+		return start + i
+	}
+
+	return len(source)
+}
+
+// countAlphanumerics() function returns the number of letters and digits in
+// the text.
+func countAlphanumerics(text string) int {
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// lineNumber() function returns the (1-based) number of the line that
+// contains the byte position of the contents.
+func lineNumber(offsets []int, offset int) int {
+	// offsets are sorted, so find the last line that starts at or before
+	// the offset
+	low, high := 0, len(offsets)-1
+	for low < high {
+		middle := (low + high + 1) / 2
+		if offsets[middle] <= offset {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+
+	return low + 1
+}
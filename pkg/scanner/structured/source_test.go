@@ -0,0 +1,104 @@
+package structured
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParse_source() unit test function tests that Parse() returns a Field
+// for each line of the comments and string literals of source code, which
+// are located by line, and skips the code itself.
+func TestParse_source(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		expected []testLocatedField
+	}{
+		{
+			name:     "go",
+			file:     "main_test.go",
+			contents: "package main\n\n// patient John Smith\n/*\n * DOB 1980-01-02\n */\nvar x = f(\"%s\", \"Jane \\\"JD\\\" Doe\", 'a')\nvar y = `raw\n  multi line`\n",
+			expected: []testLocatedField{
+				{label: SegmentKindComment, location: "line 3", value: "patient John Smith"},
+				{label: SegmentKindComment, location: "line 5", value: "DOB 1980-01-02"},
+				{label: SegmentKindString, location: "line 7", value: `Jane \"JD\" Doe`},
+				{label: SegmentKindString, location: "line 8", value: "raw"},
+				{label: SegmentKindString, location: "line 9", value: "multi line"},
+			},
+		},
+		{
+			name:     "python",
+			file:     "fixtures.py",
+			contents: "# MRN 12345\ndef f():\n    '''Docstring for\n    Jane Doe'''\n    return {\"name\": 'John # Smith'}\n",
+			expected: []testLocatedField{
+				{label: SegmentKindComment, location: "line 1", value: "MRN 12345"},
+				{label: SegmentKindString, location: "line 3", value: "Docstring for"},
+				{label: SegmentKindString, location: "line 4", value: "Jane Doe"},
+				{label: SegmentKindString, location: "line 5", value: "name"},
+				{label: SegmentKindString, location: "line 5", value: "John # Smith"},
+			},
+		},
+		{
+			name:     "sql",
+			file:     "seed.SQL",
+			contents: "-- test data\nINSERT INTO patients VALUES ('O''Brien', \"ignored\");\n",
+			expected: []testLocatedField{
+				{label: SegmentKindComment, location: "line 1", value: "test data"},
+				{label: SegmentKindString, location: "line 2", value: "O''Brien"},
+			},
+		},
+		{
+			name:     "unterminated",
+			file:     "a.js",
+			contents: "let a = \"John Smith\nlet b = `Jane\nDoe",
+			expected: []testLocatedField{
+				{label: SegmentKindString, location: "line 1", value: "John Smith"},
+				{label: SegmentKindString, location: "line 2", value: "Jane"},
+				{label: SegmentKindString, location: "line 3", value: "Doe"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			format, fields, err := Parse(test.file, []byte(test.contents))
+			assert.NoError(t, err)
+			assert.Equal(t, FormatSource, format)
+			if !assert.Len(t, fields, len(test.expected)) {
+				return
+			}
+			for i, expected := range test.expected {
+				field := fields[i]
+				assert.Equal(t, expected.label, field.Label)
+				assert.Equal(t, expected.location, field.Location)
+				assert.Equal(t, expected.value, field.Value)
+				// segments of source code always appear verbatim
+				if assert.GreaterOrEqual(t, field.Offset, 0, expected.location) {
+					assert.Equal(t, expected.value, test.contents[field.Offset:field.Offset+field.Length])
+				}
+			}
+		})
+	}
+}
+
+// TestIsSourceCode() unit test function tests that DetectFormat() returns a
+// format of source code for notebooks and source files, and that only those
+// formats are source code.
+func TestIsSourceCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, FormatNotebook, DetectFormat("analysis.ipynb"))
+	assert.Equal(t, FormatSource, DetectFormat("cmd/main.go"))
+	assert.Equal(t, "", DetectFormat("README.md"))
+	assert.True(t, IsSourceCode(FormatNotebook))
+	assert.True(t, IsSourceCode(FormatSource))
+	assert.False(t, IsSourceCode(FormatJSON))
+	assert.False(t, IsSourceCode(""))
+}
@@ -0,0 +1,220 @@
+package structured
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Field struct is a single value of structured data (e.g. a CSV cell or the
+// value of a JSON key), along with the label and location that identify the
+// value within the data.
+type Field struct {
+	// Label is the readable path of the value, such as the key path of a
+	// JSON or YAML value (e.g. "patient.dob"), the column header of a CSV
+	// value or the kind of a segment of source code (e.g. "comment"), which
+	// gives context to the value when it is scanned.
+	Label string
+	// Length is the number of bytes of the value within the contents, which
+	// is zero when Offset is -1.
+	Length int
+	// Location is the exact location of the value within the data, which is
+	// a JSON pointer (e.g. "/patient/dob") for JSON and YAML, an XPath (e.g.
+	// "/patients/patient[1]/dob[1]") for XML, the row and column of a CSV
+	// value (e.g. "row 2, column 3"), the cell and line of a notebook (e.g.
+	// "cell 2, line 3") or the line of source code (e.g. "line 12").
+	Location string
+	// Offset is the byte position of the value within the contents, or -1
+	// if the value does not appear verbatim in the contents (e.g. a JSON
+	// string with escape sequences).
+	Offset int
+	// Value is the (decoded) value.
+	Value string
+}
+
+// DetectFormat() function returns the format of the structured data or the
+// source code of the file based on the extension of the file name, or an
+// empty string if the format is not supported.
+func DetectFormat(name string) string {
+	extension := strings.ToLower(filepath.Ext(name))
+	if format, ok := FormatExtensions[extension]; ok {
+		return format
+	}
+	if _, ok := sourceSyntaxes[extension]; ok {
+		return FormatSource
+	}
+
+	return ""
+}
+
+// IsSourceCode() function returns true if the format is a format of source
+// code (i.e. notebook or source), instead of a format of structured data.
+func IsSourceCode(format string) bool {
+	return format == FormatNotebook || format == FormatSource
+}
+
+// Parse() function returns the format of the structured data or the source
+// code of the file with the provided name and contents, along with every
+// (non-empty) value of the data, or every line of the comments and strings
+// of the source code, in the order in which they appear in the contents.
+// Returns an error if the format of the file is not supported or if the
+// contents cannot be parsed, in which case the file can still be scanned
+// as plain text.
+func Parse(name string, contents []byte) (format string, fields []Field, e error) {
+	format = DetectFormat(name)
+	switch format {
+	case FormatCSV:
+		comma := ','
+		if strings.EqualFold(filepath.Ext(name), ".tsv") {
+			comma = '\t'
+		}
+		fields, e = parseCSV(contents, comma)
+	case FormatJSON:
+		fields, e = parseJSON(contents)
+	case FormatNotebook:
+		fields, e = parseNotebook(contents)
+	case FormatSource:
+		fields, e = parseSource(name, contents)
+	case FormatXML:
+		fields, e = parseXML(contents)
+	case FormatYAML:
+		fields, e = parseYAML(contents)
+	default:
+		e = ErrUnsupportedFormat
+		return
+	}
+	if e != nil {
+		e = errors.Wrapf(e, ErrMsgParse, format)
+	}
+
+	return
+}
+
+// newField() function returns a new Field for the value found at the byte
+// position start within the contents, where the Offset of the Field is -1
+// if the value does not appear verbatim at that position.
+func newField(contents []byte, start int, label, location, value string) Field {
+	field := Field{
+		Label:    label,
+		Location: location,
+		Offset:   -1,
+		Value:    value,
+	}
+	end := start + len(value)
+	if start >= 0 && end <= len(contents) && string(contents[start:end]) == value {
+		field.Length = len(value)
+		field.Offset = start
+	}
+
+	return field
+}
+
+// lineOffsets() function returns the byte position of the start of each
+// line of the contents.
+func lineOffsets(contents []byte) []int {
+	offsets := []int{0}
+	for i, b := range contents {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+
+	return offsets
+}
+
+// positionOffset() function returns the byte position within the contents
+// of the 1-based line and (rune) column, or -1 if the position is outside
+// of the contents.
+func positionOffset(contents []byte, offsets []int, line, column int) int {
+	if line < 1 || line > len(offsets) || column < 1 {
+		return -1
+	}
+	offset := offsets[line-1]
+	for i := 1; i < column; i++ {
+		if offset >= len(contents) || contents[offset] == '\n' {
+			return -1
+		}
+		_, size := utf8.DecodeRune(contents[offset:])
+		offset += size
+	}
+
+	return offset
+}
+
+// pathSegment struct is a single key or (array) index of the path of a
+// value within JSON or YAML data.
+type pathSegment struct {
+	// index is the index of the value within an array, or -1 for a key
+	index int
+	key   string
+}
+
+// appendSegment() function returns a copy of the path with the segment
+// appended, such that the path can be shared between sibling values.
+func appendSegment(path []pathSegment, segment pathSegment) []pathSegment {
+	out := make([]pathSegment, len(path), len(path)+1)
+	copy(out, path)
+
+	return append(out, segment)
+}
+
+// pathLabel() function returns the readable label of the path, where keys
+// are separated by dots and indexes are in brackets (e.g. "patients[0].dob").
+func pathLabel(path []pathSegment) string {
+	if len(path) == 0 {
+		return RootLabel
+	}
+	var builder strings.Builder
+	for _, segment := range path {
+		if segment.index >= 0 {
+			builder.WriteString("[" + strconv.Itoa(segment.index) + "]")
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(segment.key)
+	}
+
+	return builder.String()
+}
+
+// pathPointer() function returns the JSON pointer (RFC 6901) of the path.
+func pathPointer(path []pathSegment) string {
+	replacer := strings.NewReplacer("~", "~0", "/", "~1")
+	var builder strings.Builder
+	for _, segment := range path {
+		builder.WriteString("/")
+		if segment.index >= 0 {
+			builder.WriteString(strconv.Itoa(segment.index))
+			continue
+		}
+		builder.WriteString(replacer.Replace(segment.key))
+	}
+
+	return builder.String()
+}
+
+// trimmedOffset() function returns the byte position of the value trimmed
+// of leading whitespace, where the untrimmed value starts at start.
+func trimmedOffset(start int, value string) int {
+	return start + len(value) - len(strings.TrimLeft(value, " \t\r\n"))
+}
+
+// indexFrom() function returns the byte position of the first occurrence of
+// the value in the contents at or after from, or -1 if there is none.
+func indexFrom(contents []byte, from int, value string) int {
+	if from < 0 || from > len(contents) {
+		return -1
+	}
+	i := bytes.Index(contents[from:], []byte(value))
+	if i < 0 {
+		return -1
+	}
+
+	return from + i
+}
@@ -0,0 +1,164 @@
+package structured
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLocatedField struct is the expected label, location and value of a
+// Field, along with whether the value appears verbatim in the contents.
+type testLocatedField struct {
+	label    string
+	location string
+	value    string
+	verbatim bool
+}
+
+// TestParse() unit test function tests that Parse() returns the values of
+// each supported format with their labels and locations, and that the Offset
+// of each Field is the position of its value within the contents.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		expected []testLocatedField
+		format   string
+	}{
+		{
+			name:     "csv",
+			file:     "patients.csv",
+			contents: "\ufeffname,dob,\n\"Smith, John\",1980-01-02,x\n\nJane Doe,,\"say \"\"hi\"\"\"\n",
+			expected: []testLocatedField{
+				{label: "name", location: "row 2, column 1", value: "Smith, John", verbatim: true},
+				{label: "dob", location: "row 2, column 2", value: "1980-01-02", verbatim: true},
+				{label: "column 3", location: "row 2, column 3", value: "x", verbatim: true},
+				{label: "name", location: "row 3, column 1", value: "Jane Doe", verbatim: true},
+				{label: "column 3", location: "row 3, column 3", value: `say "hi"`},
+			},
+			format: FormatCSV,
+		},
+		{
+			name:     "tsv",
+			file:     "patients.TSV",
+			contents: "name\tphone\nJohn Smith\t555-0100\n",
+			expected: []testLocatedField{
+				{label: "name", location: "row 2, column 1", value: "John Smith", verbatim: true},
+				{label: "phone", location: "row 2, column 2", value: "555-0100", verbatim: true},
+			},
+			format: FormatCSV,
+		},
+		{
+			name:     "json",
+			file:     "patient.json",
+			contents: `{"patient": {"dob": "1980-01-02", "active": true, "a/b": null, "mrn": 12345, "notes": ["seen by Dr. \"Who\"", "ok"]}, "": ""}`,
+			expected: []testLocatedField{
+				{label: "patient.dob", location: "/patient/dob", value: "1980-01-02", verbatim: true},
+				{label: "patient.mrn", location: "/patient/mrn", value: "12345", verbatim: true},
+				{label: "patient.notes[0]", location: "/patient/notes/0", value: `seen by Dr. "Who"`},
+				{label: "patient.notes[1]", location: "/patient/notes/1", value: "ok", verbatim: true},
+			},
+			format: FormatJSON,
+		},
+		{
+			name:     "json root value",
+			file:     "name.json",
+			contents: " \"John Smith\" ",
+			expected: []testLocatedField{
+				{label: RootLabel, location: "", value: "John Smith", verbatim: true},
+			},
+			format: FormatJSON,
+		},
+		{
+			name:     "xml",
+			file:     "patients.xml",
+			contents: "<?xml version=\"1.0\"?>\n<patients xmlns=\"urn:x\">\n  <patient id='p1'>\n    <name>John Smith</name>\n  </patient>\n  <patient id=\"p2\">\n    <name>Jane &amp; Doe</name>\n    <note><![CDATA[born 1980]]></note>\n  </patient>\n</patients>\n",
+			expected: []testLocatedField{
+				{label: "patients.patient.id", location: "/patients[1]/patient[1]/@id", value: "p1", verbatim: true},
+				{label: "patients.patient.name", location: "/patients[1]/patient[1]/name[1]/text()", value: "John Smith", verbatim: true},
+				{label: "patients.patient.id", location: "/patients[1]/patient[2]/@id", value: "p2", verbatim: true},
+				{label: "patients.patient.name", location: "/patients[1]/patient[2]/name[1]/text()", value: "Jane & Doe"},
+				{label: "patients.patient.note", location: "/patients[1]/patient[2]/note[1]/text()", value: "born 1980", verbatim: true},
+			},
+			format: FormatXML,
+		},
+		{
+			name:     "yaml",
+			file:     "patient.yml",
+			contents: "patient:\n  name: \"John Smith\"\n  active: yes\n  dob: 1980-01-02\n  phones:\n    - '555-0100'\n    - ~\n  bio: |\n    line one\n---\nnote: second document\n",
+			expected: []testLocatedField{
+				{label: "patient.name", location: "/patient/name", value: "John Smith", verbatim: true},
+				{label: "patient.active", location: "/patient/active", value: "yes", verbatim: true},
+				{label: "patient.dob", location: "/patient/dob", value: "1980-01-02", verbatim: true},
+				{label: "patient.phones[0]", location: "/patient/phones/0", value: "555-0100", verbatim: true},
+				{label: "patient.bio", location: "/patient/bio", value: "line one\n"},
+				{label: "note", location: "/note", value: "second document", verbatim: true},
+			},
+			format: FormatYAML,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			format, fields, err := Parse(test.file, []byte(test.contents))
+			assert.NoError(t, err)
+			assert.Equal(t, test.format, format)
+			if !assert.Len(t, fields, len(test.expected)) {
+				return
+			}
+			for i, expected := range test.expected {
+				field := fields[i]
+				assert.Equal(t, expected.label, field.Label)
+				assert.Equal(t, expected.location, field.Location)
+				assert.Equal(t, expected.value, field.Value)
+				if !expected.verbatim {
+					assert.Equal(t, -1, field.Offset, expected.location)
+					assert.Equal(t, 0, field.Length, expected.location)
+					continue
+				}
+				if assert.GreaterOrEqual(t, field.Offset, 0, expected.location) {
+					assert.Equal(t, expected.value, test.contents[field.Offset:field.Offset+field.Length])
+				}
+			}
+		})
+	}
+}
+
+// TestParse_errors() unit test function tests that Parse() returns an error
+// for unsupported formats and for data that cannot be parsed.
+func TestParse_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		expected error
+	}{
+		{name: "unsupported", file: "notes.txt", contents: "John Smith", expected: ErrUnsupportedFormat},
+		{name: "json invalid", file: "a.json", contents: `{"a": `},
+		{name: "json trailing", file: "a.json", contents: `{"a": "b"} {"c": "d"}`, expected: ErrTrailingData},
+		{name: "xml invalid", file: "a.xml", contents: "<a><b></a>"},
+		{name: "yaml invalid", file: "a.yaml", contents: "a: [b"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, fields, err := Parse(test.file, []byte(test.contents))
+			assert.Error(t, err)
+			assert.Nil(t, fields)
+			if test.expected != nil {
+				assert.ErrorIs(t, err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlElement struct is an open element of XML data.
+type xmlElement struct {
+	// children is the number of child elements of each name, which is used
+	// for the position of the next child element of the name
+	children map[string]int
+	label    string
+	path     string
+}
+
+// parseXML() function returns a Field for each (non-empty) attribute value
+// and text of the elements of XML data, labelled by the names of the
+// elements and located by XPath, where every element has its position among
+// the sibling elements of the same name (e.g. "/patients/patient[2]").
+func parseXML(contents []byte) ([]Field, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(contents))
+	fields := make([]Field, 0)
+	stack := []*xmlElement{{children: make(map[string]int)}}
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) > MaxDepth {
+				return nil, ErrMaxDepthExceeded
+			}
+			name := t.Name.Local
+			parent.children[name]++
+			element := &xmlElement{
+				children: make(map[string]int),
+				label:    name,
+				path:     parent.path + "/" + name + "[" + strconv.Itoa(parent.children[name]) + "]",
+			}
+			if parent.label != "" {
+				element.label = parent.label + "." + name
+			}
+			stack = append(stack, element)
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || strings.TrimSpace(attr.Value) == "" {
+					continue
+				}
+				fields = append(fields, newField(
+					contents,
+					xmlAttrOffset(contents, start, end, attr),
+					element.label+"."+attr.Name.Local,
+					element.path+"/@"+attr.Name.Local,
+					attr.Value,
+				))
+			}
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" || len(stack) == 1 {
+				continue
+			}
+			// the text appears verbatim unless it has entities, in which
+			// case it is not found within the raw token
+			offset := trimmedOffset(start, string(t))
+			if string(contents[start:end]) != string(t) {
+				offset = indexFrom(contents[:end], start, text)
+			}
+			fields = append(fields, newField(contents, offset, parent.label, parent.path+"/text()", text))
+		}
+	}
+
+	return fields, nil
+}
+
+// xmlAttrOffset() function returns the byte position of the value of the
+// attribute within the start tag found between start and end, or -1 if the
+// value does not appear verbatim after the name of the attribute.
+func xmlAttrOffset(contents []byte, start, end int, attr xml.Attr) int {
+	tag := contents[:end]
+	name := indexFrom(tag, start, attr.Name.Local)
+	if name < 0 {
+		return -1
+	}
+	for _, quote := range []string{`"`, `'`} {
+		if i := indexFrom(tag, name, quote+attr.Value+quote); i >= 0 {
+			return i + 1
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,89 @@
+package structured
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlParser struct walks the nodes of the documents of YAML data.
+type yamlParser struct {
+	contents []byte
+	fields   []Field
+	offsets  []int
+}
+
+// parseYAML() function returns a Field for each (non-null and non-boolean)
+// scalar value of the documents of YAML data, labelled by its key path.
+// Aliases are skipped, such that the values of an anchor are only added
+// once.
+func parseYAML(contents []byte) ([]Field, error) {
+	parser := &yamlParser{
+		contents: contents,
+		fields:   make([]Field, 0),
+		offsets:  lineOffsets(contents),
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.parseNode(&document, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return parser.fields, nil
+}
+
+// parseNode() method adds the fields of the node, which is found at the
+// provided path.
+func (p *yamlParser) parseNode(node *yaml.Node, path []pathSegment) error {
+	if len(path) > MaxDepth {
+		return ErrMaxDepthExceeded
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := p.parseNode(child, path); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if err := p.parseNode(node.Content[i+1], appendSegment(path, pathSegment{index: -1, key: key})); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := p.parseNode(child, appendSegment(path, pathSegment{index: i})); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!bool", "!!null":
+			return nil
+		}
+		if strings.TrimSpace(node.Value) == "" {
+			return nil
+		}
+		start := positionOffset(p.contents, p.offsets, node.Line, node.Column)
+		if start >= 0 && (node.Style == yaml.DoubleQuotedStyle || node.Style == yaml.SingleQuotedStyle) {
+			start++
+		}
+		p.fields = append(p.fields, newField(p.contents, start, pathLabel(path), pathPointer(path), node.Value))
+	}
+
+	return nil
+}
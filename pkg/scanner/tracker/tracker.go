@@ -228,9 +228,17 @@ func (st *KeyTracker) GetKeysData() map[string]KeyData {
 	// unlock the tracker after the function returns
 	defer st.mu.RUnlock()
 
-	// make a copy of the map to return
+	// make a copy of the map to return, including the map of children of
+	// each key, which would otherwise be shared with the tracker
 	out := make(map[string]KeyData, 0)
 	for key, key_data := range st.keys {
+		if key_data.Children != nil {
+			children := make(map[string]bool, len(key_data.Children))
+			for child_key, is_complete := range key_data.Children {
+				children[child_key] = is_complete
+			}
+			key_data.Children = children
+		}
 		out[key] = key_data
 	}
 
@@ -255,6 +263,36 @@ func (st *KeyTracker) GetKeysDataForCode(code int) (map[string]KeyData, error) {
 	return out, nil
 }
 
+// Import() method adds the provided keys and their KeyData to the KeyTracker,
+// such that the keys are skipped when they are seen again in a resumed scan.
+// Only keys in a final state (KeyCodeComplete or KeyCodeIgnore) that are not
+// already known to the KeyTracker are imported. Returns the number of keys
+// that were imported.
+func (kt *KeyTracker) Import(keys map[string]KeyData) (imported int) {
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+
+	for key, key_data := range keys {
+		if key == "" {
+			continue
+		}
+		if key_data.Code != KeyCodeComplete && key_data.Code != KeyCodeIgnore {
+			continue
+		}
+		if _, exists := kt.keys[key]; exists {
+			continue
+		}
+		if key_data.Children == nil {
+			key_data.Children = make(map[string]bool)
+		}
+		key_data.State = KeyCodeToState(key_data.Code)
+		kt.keys[key] = key_data
+		imported++
+	}
+
+	return
+}
+
 func (st *KeyTracker) PrintCodes() []int {
 	codes := make([]int, 0)
 	for key, key_data := range st.GetKeysData() {
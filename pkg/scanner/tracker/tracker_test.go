@@ -952,6 +952,51 @@ func TestKeyTracker_GetKeysData(t *testing.T) {
 	assert.Equal(t, tracker.keys, keysData)
 }
 
+// TestKeyTracker_Import() unit test function tests the Import() method of
+// the KeyTracker type.
+func TestKeyTracker_Import(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	tracker, err := NewKeyTracker(ScanObjectTypeFile, &logger)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = tracker.Update("existing", KeyCodePending, test_message_pending, []string{"child"})
+	assert.NoError(t, err)
+
+	imported := tracker.Import(map[string]KeyData{
+		"":         {Code: KeyCodeComplete},
+		"complete": {Code: KeyCodeComplete, Children: map[string]bool{"child": true}},
+		"error":    {Code: KeyCodeError, Message: test_message_error},
+		"existing": {Code: KeyCodeComplete},
+		"ignore":   {Code: KeyCodeIgnore, Message: test_message_ignore},
+		"init":     {Code: KeyCodeInit},
+		"pending":  {Code: KeyCodePending},
+	})
+	assert.Equal(t, 2, imported)
+
+	complete, exists := tracker.Get("complete")
+	assert.True(t, exists)
+	assert.Equal(t, KeyStateComplete, complete.State)
+	assert.Equal(t, map[string]bool{"child": true}, complete.Children)
+	ignore, exists := tracker.Get("ignore")
+	assert.True(t, exists)
+	assert.Equal(t, KeyStateIgnore, ignore.State)
+	assert.NotNil(t, ignore.Children)
+	// keys already known to the tracker should not be overwritten
+	existing, _ := tracker.Get("existing")
+	assert.Equal(t, KeyCodePending, existing.Code)
+	for _, key := range []string{"error", "init", "pending"} {
+		_, exists = tracker.Get(key)
+		assert.False(t, exists, key)
+	}
+	// keys imported as complete should be skipped by subsequent updates
+	code, err := tracker.Update("complete", KeyCodeInit, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyCodeComplete, code)
+}
+
 // TestKeyTracker_PrintCodes unit test function tests the PrintCodes() method
 // of the KeyTracker type.
 func TestKeyTracker_PrintCodes(t *testing.T) {
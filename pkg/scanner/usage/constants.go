@@ -0,0 +1,11 @@
+package usage
+
+// CharactersPerTextRecord is the number of characters in each text record
+// billed by the Azure AI Language service.
+const CharactersPerTextRecord int = 1000
+
+const MetricBatches string = "azure_ai.usage.batches"
+const MetricCharacters string = "azure_ai.usage.characters"
+const MetricDocuments string = "azure_ai.usage.documents"
+const MetricEstimatedCost string = "azure_ai.usage.estimated_cost"
+const MetricTransactions string = "azure_ai.usage.transactions"
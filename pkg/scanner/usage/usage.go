@@ -0,0 +1,154 @@
+package usage
+
+import (
+	"sort"
+	"sync"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Usage struct contains the counts used to bill requests sent to the Azure
+// AI Language service, along with the cost estimated from those counts.
+type Usage struct {
+	// Batches is the number of requests sent to the service.
+	Batches int64 `json:"batches"`
+	// Characters is the number of characters in the documents sent to the
+	// service for PII entity recognition.
+	Characters int64 `json:"characters"`
+	// Documents is the number of documents processed by the service.
+	Documents int64 `json:"documents"`
+	// EstimatedCost is the cost of the Transactions at the price per 1000
+	// text records of the Tracker that returned the Usage.
+	EstimatedCost float64 `json:"estimated_cost"`
+	// Transactions is the number of text records billed by the service,
+	// including any text records billed for language detection.
+	Transactions int64 `json:"transactions"`
+}
+
+// add() method adds the counts of the other Usage to the Usage.
+func (u *Usage) add(other Usage) {
+	u.Batches += other.Batches
+	u.Characters += other.Characters
+	u.Documents += other.Documents
+	u.Transactions += other.Transactions
+}
+
+// EstimateTransactions() function returns the number of text records billed
+// for a document with the provided number of characters, where every
+// document is billed for at least one text record.
+func EstimateTransactions(characters int) int {
+	if characters <= 0 {
+		return 1
+	}
+
+	return (characters + CharactersPerTextRecord - 1) / CharactersPerTextRecord
+}
+
+// Tracker struct adds up the Usage of the Azure AI Language service for each
+// repository and in total, and publishes the total Usage as metrics. Safe
+// for concurrent use.
+type Tracker struct {
+	mutex        *sync.Mutex
+	price_per_1k float64
+	registry     metrics.Registry
+	repositories map[string]*Usage
+	total        Usage
+}
+
+// NewTracker() function returns a new Tracker that estimates costs using the
+// provided price per 1000 text records and that publishes metrics to the
+// provided registry (or to no registry if nil).
+func NewTracker(price_per_1k float64, registry metrics.Registry) *Tracker {
+	return &Tracker{
+		mutex:        &sync.Mutex{},
+		price_per_1k: price_per_1k,
+		registry:     registry,
+		repositories: make(map[string]*Usage),
+	}
+}
+
+// AddBatch() method adds the Usage of a single request sent to the service,
+// where the request may contain documents from more than one repository.
+// The batch is counted once in total and once for each repository.
+func (t *Tracker) AddBatch(usage_by_repository map[string]Usage) {
+	if len(usage_by_repository) == 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	batch := Usage{Batches: 1}
+	for repository_id, usage := range usage_by_repository {
+		usage.Batches = 1
+		repository_usage, ok := t.repositories[repository_id]
+		if !ok {
+			repository_usage = &Usage{}
+			t.repositories[repository_id] = repository_usage
+		}
+		repository_usage.add(usage)
+
+		batch.Characters += usage.Characters
+		batch.Documents += usage.Documents
+		batch.Transactions += usage.Transactions
+	}
+	t.total.add(batch)
+
+	if t.registry != nil {
+		metrics.GetOrRegisterCounter(MetricBatches, t.registry).Inc(batch.Batches)
+		metrics.GetOrRegisterCounter(MetricCharacters, t.registry).Inc(batch.Characters)
+		metrics.GetOrRegisterCounter(MetricDocuments, t.registry).Inc(batch.Documents)
+		metrics.GetOrRegisterCounter(MetricTransactions, t.registry).Inc(batch.Transactions)
+		metrics.GetOrRegisterGaugeFloat64(MetricEstimatedCost, t.registry).Update(t.estimateCost(t.total))
+	}
+}
+
+// Get() method returns the Usage for the repository with the provided ID.
+func (t *Tracker) Get(repository_id string) Usage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var usage Usage
+	if repository_usage, ok := t.repositories[repository_id]; ok {
+		usage = *repository_usage
+	}
+	usage.EstimatedCost = t.estimateCost(usage)
+
+	return usage
+}
+
+// GetPricePer1k() method returns the price per 1000 text records used to
+// estimate costs.
+func (t *Tracker) GetPricePer1k() float64 {
+	return t.price_per_1k
+}
+
+// Repositories() method returns the sorted IDs of the repositories with Usage.
+func (t *Tracker) Repositories() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ids := make([]string, 0, len(t.repositories))
+	for id := range t.repositories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Total() method returns the total Usage across all repositories.
+func (t *Tracker) Total() Usage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	usage := t.total
+	usage.EstimatedCost = t.estimateCost(usage)
+
+	return usage
+}
+
+// estimateCost() method returns the cost of the transactions of the Usage.
+func (t *Tracker) estimateCost(usage Usage) float64 {
+	return float64(usage.Transactions) * t.price_per_1k / 1000
+}
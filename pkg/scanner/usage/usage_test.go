@@ -0,0 +1,69 @@
+package usage
+
+import (
+	"testing"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEstimateTransactions() unit test function tests the
+// EstimateTransactions() function.
+func TestEstimateTransactions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		characters int
+		expected   int
+	}{
+		{characters: 0, expected: 1},
+		{characters: 1, expected: 1},
+		{characters: 1000, expected: 1},
+		{characters: 1001, expected: 2},
+		{characters: 5000, expected: 5},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, EstimateTransactions(test.characters))
+	}
+}
+
+// TestTracker() unit test function tests that the Tracker adds up Usage for
+// each repository and in total, and publishes metrics to the registry.
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	tracker := NewTracker(1.5, registry)
+
+	tracker.AddBatch(map[string]Usage{
+		"repo_a": {Characters: 1500, Documents: 2, Transactions: 3},
+		"repo_b": {Characters: 500, Documents: 1, Transactions: 1},
+	})
+	tracker.AddBatch(map[string]Usage{
+		"repo_a": {Characters: 4000, Documents: 1, Transactions: 4},
+	})
+	// empty batches are not counted
+	tracker.AddBatch(nil)
+
+	assert.Equal(t, []string{"repo_a", "repo_b"}, tracker.Repositories())
+	assert.Equal(t, Usage{
+		Batches:       2,
+		Characters:    5500,
+		Documents:     3,
+		EstimatedCost: 7 * 1.5 / 1000,
+		Transactions:  7,
+	}, tracker.Get("repo_a"))
+	assert.Equal(t, Usage{}, tracker.Get("repo_c"))
+
+	total := tracker.Total()
+	assert.Equal(t, int64(2), total.Batches)
+	assert.Equal(t, int64(6000), total.Characters)
+	assert.Equal(t, int64(4), total.Documents)
+	assert.Equal(t, int64(8), total.Transactions)
+	assert.InDelta(t, 0.012, total.EstimatedCost, 1e-9)
+
+	assert.Equal(t, int64(2), metrics.GetOrRegisterCounter(MetricBatches, registry).Count())
+	assert.Equal(t, int64(8), metrics.GetOrRegisterCounter(MetricTransactions, registry).Count())
+	assert.InDelta(t, 0.012, metrics.GetOrRegisterGaugeFloat64(MetricEstimatedCost, registry).Value(), 1e-9)
+}
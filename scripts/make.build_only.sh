@@ -3,9 +3,14 @@
 
 _script_dir=`cd "$( dirname "${BASH_SOURCE[0]}" )" &> /dev/null && pwd`
 _parent_dir=${_script_dir}/..
+_cfg_pkg=github.com/has-ghas/no-phi-ai/pkg/cfg
+_build_commit=`git -C ${_parent_dir} rev-parse HEAD 2> /dev/null || echo unknown`
+_build_time=`date -u +%Y-%m-%dT%H:%M:%SZ`
 
 cd ${_parent_dir}/pkg/ \
-	&& go build -buildmode="pie" -buildvcs=false -o ${_parent_dir}/build/no-phi-ai \
+	&& go build -buildmode="pie" -buildvcs=false \
+		-ldflags "-X ${_cfg_pkg}.BuildCommit=${_build_commit} -X ${_cfg_pkg}.BuildTime=${_build_time}" \
+		-o ${_parent_dir}/build/no-phi-ai \
 	&& echo "SUCCESS : no-phi-ai : build_only" \
 	|| (echo "ERROR : no-phi-ai : build_only" && exit 70)
 